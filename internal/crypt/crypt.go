@@ -0,0 +1,263 @@
+// Package crypt implements the PDF encryption algorithms
+// (see ISO 32000-2:2020, 7.6).
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrInvalidPassword       = errors.New("invalid password")
+	ErrUnsupportedEncryption = errors.New("unsupported encryption")
+	ErrInvalidCiphertext     = errors.New("invalid ciphertext")
+)
+
+// Method is the method a crypt filter decrypts the data with
+// (see ISO 32000-2:2020, Table 25).
+type Method uint8
+
+const (
+	// MethodNone means the data is not encrypted,
+	// i.e. the /Identity crypt filter.
+	MethodNone Method = iota
+	// MethodRC4 is the RC4 cipher, the /V2 crypt filter method.
+	MethodRC4
+	// MethodAESV2 is the AES-128 cipher in the CBC mode.
+	MethodAESV2
+	// MethodAESV3 is the AES-256 cipher in the CBC mode.
+	MethodAESV3
+)
+
+func (m Method) String() string {
+	switch m {
+	case MethodNone:
+		return "None"
+	case MethodRC4:
+		return "V2"
+	case MethodAESV2:
+		return "AESV2"
+	case MethodAESV3:
+		return "AESV3"
+	default:
+		return fmt.Sprintf("Method(%d)", uint8(m))
+	}
+}
+
+// ObjectKey returns the key the strings and the streams of the
+// object are encrypted with (see ISO 32000-2:2020, 7.6.2,
+// Algorithm 1). The file key is used as is by AESV3.
+func ObjectKey(method Method, key []byte, objNo uint32, gen uint16) []byte {
+	const maxKeyLen = 16
+
+	if method == MethodAESV3 || method == MethodNone {
+		return key
+	}
+
+	hash := md5.New()
+	hash.Write(key)
+	hash.Write([]byte{byte(objNo), byte(objNo >> 8), byte(objNo >> 16), byte(gen), byte(gen >> 8)})
+
+	if method == MethodAESV2 {
+		hash.Write([]byte("sAlT"))
+	}
+
+	n := len(key) + 5
+	if n > maxKeyLen {
+		n = maxKeyLen
+	}
+
+	return hash.Sum(nil)[:n]
+}
+
+// Decrypt decrypts the data with the object key.
+func Decrypt(method Method, key, data []byte) ([]byte, error) {
+	if method == MethodNone {
+		return data, nil
+	}
+
+	// An encrypted empty string may lack even the IV.
+	if len(data) == 0 {
+		return data, nil
+	}
+
+	r, err := NewDecryptReader(method, key, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+// Encrypt encrypts the data with the object key. The AES
+// data is padded and preceded by a random IV.
+func Encrypt(method Method, key, data []byte) ([]byte, error) {
+	switch method {
+	case MethodNone:
+		return data, nil
+	case MethodRC4:
+		c, err := rc4.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt: %w", err)
+		}
+
+		encrypted := make([]byte, len(data))
+		c.XORKeyStream(encrypted, data)
+
+		return encrypted, nil
+	case MethodAESV2, MethodAESV3:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt: %w", err)
+		}
+
+		padding := aes.BlockSize - len(data)%aes.BlockSize
+
+		encrypted := make([]byte, aes.BlockSize+len(data)+padding)
+		if _, err = rand.Read(encrypted[:aes.BlockSize]); err != nil {
+			return nil, fmt.Errorf("encrypt: %w", err)
+		}
+
+		plain := encrypted[aes.BlockSize:]
+		copy(plain, data)
+		copy(plain[len(data):], bytes.Repeat([]byte{byte(padding)}, padding))
+
+		cipher.NewCBCEncrypter(block, encrypted[:aes.BlockSize]).CryptBlocks(plain, plain)
+
+		return encrypted, nil
+	default:
+		return nil, fmt.Errorf("encrypt: %w: method %s", ErrUnsupportedEncryption, method)
+	}
+}
+
+// EncryptedLength returns the length of the
+// data of the length n once encrypted.
+func EncryptedLength(method Method, n int) int {
+	if method != MethodAESV2 && method != MethodAESV3 {
+		return n
+	}
+
+	return aes.BlockSize + n + aes.BlockSize - n%aes.BlockSize
+}
+
+// NewDecryptReader returns a reader that decrypts r with the
+// object key. The AES padding is removed at the end of r.
+func NewDecryptReader(method Method, key []byte, r io.Reader) (io.Reader, error) {
+	switch method {
+	case MethodNone:
+		return r, nil
+	case MethodRC4:
+		c, err := rc4.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("new rc4 reader: %w", err)
+		}
+
+		return &cipher.StreamReader{S: c, R: r}, nil
+	case MethodAESV2, MethodAESV3:
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("new aes reader: %w", err)
+		}
+
+		return &aesReader{r: r, block: block}, nil
+	default:
+		return nil, fmt.Errorf("%w: method %s", ErrUnsupportedEncryption, method)
+	}
+}
+
+// aesReader decrypts the AES-CBC data preceded by the 16-byte IV.
+// The last block is held back until the end of the input, so its
+// padding can be removed.
+type aesReader struct {
+	r     io.Reader
+	block cipher.Block
+	mode  cipher.BlockMode
+	// in is the input not decrypted yet.
+	in []byte
+	// out is the decrypted data ready to be read.
+	out []byte
+	// tail is the last decrypted block.
+	tail []byte
+	err  error
+}
+
+func (ar *aesReader) Read(p []byte) (int, error) {
+	for len(ar.out) == 0 {
+		if ar.err != nil {
+			return 0, ar.err
+		}
+
+		ar.fill()
+	}
+
+	n := copy(p, ar.out)
+	ar.out = ar.out[n:]
+
+	return n, nil
+}
+
+func (ar *aesReader) fill() {
+	var chunk [4096]byte
+
+	n, err := ar.r.Read(chunk[:])
+	ar.in = append(ar.in, chunk[:n]...)
+
+	if ar.mode == nil && len(ar.in) >= aes.BlockSize {
+		ar.mode = cipher.NewCBCDecrypter(ar.block, ar.in[:aes.BlockSize])
+		ar.in = ar.in[aes.BlockSize:]
+	}
+
+	if full := len(ar.in) - len(ar.in)%aes.BlockSize; ar.mode != nil && full > 0 {
+		data := make([]byte, len(ar.tail)+full)
+		copy(data, ar.tail)
+		ar.mode.CryptBlocks(data[len(ar.tail):], ar.in[:full])
+		ar.in = append(ar.in[:0], ar.in[full:]...)
+
+		ar.out = append(ar.out, data[:len(data)-aes.BlockSize]...)
+		ar.tail = data[len(data)-aes.BlockSize:]
+	}
+
+	switch {
+	case errors.Is(err, io.EOF):
+		if ar.mode == nil && len(ar.in) > 0 {
+			ar.err = fmt.Errorf("%w: missing AES initialization vector", ErrInvalidCiphertext)
+
+			return
+		}
+
+		// An incomplete final block is dropped.
+		ar.out = append(ar.out, unpad(ar.tail)...)
+		ar.tail = nil
+		ar.err = io.EOF
+	case err != nil:
+		ar.err = err
+	}
+}
+
+// unpad removes the PKCS#5 padding. A block
+// with invalid padding is returned as is.
+func unpad(block []byte) []byte {
+	if len(block) == 0 {
+		return block
+	}
+
+	n := int(block[len(block)-1])
+	if n == 0 || n > len(block) {
+		return block
+	}
+
+	for _, ch := range block[len(block)-n:] {
+		if int(ch) != n {
+			return block
+		}
+	}
+
+	return block[:len(block)-n]
+}
@@ -0,0 +1,110 @@
+package crypt_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/crypt"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method crypt.Method
+		key    []byte
+		data   []byte
+	}{
+		{name: "identity", method: crypt.MethodNone, data: []byte(content)},
+		{name: "RC4 40", method: crypt.MethodRC4, key: bytes.Repeat([]byte{1}, 5), data: []byte(content)},
+		{name: "RC4 128", method: crypt.MethodRC4, key: bytes.Repeat([]byte{2}, 16), data: []byte(content)},
+		{name: "AESV2", method: crypt.MethodAESV2, key: bytes.Repeat([]byte{3}, 16), data: []byte(content)},
+		{name: "AESV3", method: crypt.MethodAESV3, key: bytes.Repeat([]byte{4}, 32), data: []byte(content)},
+		{name: "AESV2 block size", method: crypt.MethodAESV2, key: bytes.Repeat([]byte{5}, 16), data: []byte(title + title + "012345")},
+		{name: "AESV3 empty", method: crypt.MethodAESV3, key: bytes.Repeat([]byte{6}, 32), data: []byte{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			encrypted, err := crypt.Encrypt(tt.method, tt.key, tt.data)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Len(t, encrypted, crypt.EncryptedLength(tt.method, len(tt.data)))
+
+			r, err := crypt.NewDecryptReader(tt.method, tt.key, iotest.HalfReader(bytes.NewReader(encrypted)))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			decrypted, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.data, decrypted)
+		})
+	}
+}
+
+func TestStandardSecuritySetup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		r               int
+		length          int
+		userPassword    string
+		ownerPassword   string
+		encryptMetadata bool
+	}{
+		{name: "R2", r: 2, length: 5, userPassword: "user", ownerPassword: ownerPassword, encryptMetadata: true},
+		{name: "R3", r: 3, length: 16, userPassword: "user", ownerPassword: ownerPassword, encryptMetadata: true},
+		{name: "R4", r: 4, length: 16, userPassword: "", ownerPassword: ownerPassword},
+		{name: "R6", r: 6, length: 32, userPassword: "user", ownerPassword: ownerPassword, encryptMetadata: true},
+		{name: "R6 unicode", r: 6, length: 32, userPassword: "pässwörd", ownerPassword: ownerPassword},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sec := crypt.StandardSecurity{
+				R:               tt.r,
+				Length:          tt.length,
+				P:               -3904,
+				EncryptMetadata: tt.encryptMetadata,
+				ID:              []byte("0123456789abcdef"),
+			}
+
+			key, err := sec.Setup(tt.userPassword, tt.ownerPassword)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Len(t, key, tt.length)
+
+			userKey, isOwner, err := sec.Authenticate(tt.userPassword)
+			assert.NoError(t, err)
+			assert.False(t, isOwner)
+			assert.Equal(t, key, userKey)
+
+			ownerKey, isOwner, err := sec.Authenticate(tt.ownerPassword)
+			assert.NoError(t, err)
+			assert.True(t, isOwner)
+			assert.Equal(t, key, ownerKey)
+
+			_, _, err = sec.Authenticate("wrong")
+			assert.ErrorIs(t, err, crypt.ErrInvalidPassword)
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrRecipientNotFound = errors.New("recipient not found")
+
+// nolint:gochecknoglobals
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidRSAEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidDESEDE3CBC    = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAES128CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// The PKCS#7 (CMS) structures of RFC 5652 used by
+// the public-key security handler.
+type (
+	// contentInfo is the content type and the explicitly
+	// tagged content, which is not unwrapped for raw values.
+	contentInfo struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}
+
+	envelopedData struct {
+		Version              int
+		OriginatorInfo       asn1.RawValue   `asn1:"optional,tag:0"`
+		RecipientInfos       []asn1.RawValue `asn1:"set"`
+		EncryptedContentInfo encryptedContentInfo
+	}
+
+	keyTransRecipientInfo struct {
+		Version                int
+		RecipientIdentifier    asn1.RawValue
+		KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+		EncryptedKey           []byte
+	}
+
+	issuerAndSerialNumber struct {
+		Issuer       asn1.RawValue
+		SerialNumber *big.Int
+	}
+
+	encryptedContentInfo struct {
+		ContentType                asn1.ObjectIdentifier
+		ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+		EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+	}
+)
+
+// openEnvelope decrypts the content of the DER-encoded enveloped
+// data with the private key of the recipient certificate.
+func openEnvelope(der []byte, cert *x509.Certificate, key crypto.Decrypter) ([]byte, error) {
+	var info contentInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("open envelope: %w", err)
+	}
+
+	if !info.ContentType.Equal(oidEnvelopedData) {
+		return nil, fmt.Errorf("open envelope: %w: content type %s", ErrUnsupportedEncryption, info.ContentType)
+	}
+
+	if info.Content.Class != asn1.ClassContextSpecific || info.Content.Tag != 0 {
+		return nil, fmt.Errorf("open envelope: %w: no content", ErrInvalidCiphertext)
+	}
+
+	var env envelopedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &env); err != nil {
+		return nil, fmt.Errorf("open envelope: %w", err)
+	}
+
+	recipient, err := findRecipient(env.RecipientInfos, cert)
+	if err != nil {
+		return nil, fmt.Errorf("open envelope: %w", err)
+	}
+
+	if !recipient.KeyEncryptionAlgorithm.Algorithm.Equal(oidRSAEncryption) {
+		return nil, fmt.Errorf("open envelope: %w: key encryption %s",
+			ErrUnsupportedEncryption, recipient.KeyEncryptionAlgorithm.Algorithm)
+	}
+
+	contentKey, err := key.Decrypt(rand.Reader, recipient.EncryptedKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open envelope: %w", err)
+	}
+
+	content, err := decryptContent(&env.EncryptedContentInfo, contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("open envelope: %w", err)
+	}
+
+	return content, nil
+}
+
+// findRecipient returns the key transport recipient info of the
+// certificate, identified by either the issuer and the serial
+// number or the subject key identifier.
+func findRecipient(infos []asn1.RawValue, cert *x509.Certificate) (*keyTransRecipientInfo, error) {
+	for _, raw := range infos {
+		// The other kinds of the recipient info are tagged.
+		if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagSequence {
+			continue
+		}
+
+		var info keyTransRecipientInfo
+		if _, err := asn1.Unmarshal(raw.FullBytes, &info); err != nil {
+			return nil, err
+		}
+
+		rid := info.RecipientIdentifier
+
+		switch {
+		case rid.Class == asn1.ClassUniversal && rid.Tag == asn1.TagSequence:
+			var ias issuerAndSerialNumber
+			if _, err := asn1.Unmarshal(rid.FullBytes, &ias); err != nil {
+				return nil, err
+			}
+
+			if bytes.Equal(ias.Issuer.FullBytes, cert.RawIssuer) && ias.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return &info, nil
+			}
+		case rid.Class == asn1.ClassContextSpecific && rid.Tag == 0:
+			if len(cert.SubjectKeyId) > 0 && bytes.Equal(rid.Bytes, cert.SubjectKeyId) {
+				return &info, nil
+			}
+		}
+	}
+
+	return nil, ErrRecipientNotFound
+}
+
+// decryptContent decrypts the CBC-encrypted content.
+func decryptContent(info *encryptedContentInfo, key []byte) ([]byte, error) {
+	block, err := newContentCipher(info.ContentEncryptionAlgorithm.Algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var iv []byte
+	if _, err = asn1.Unmarshal(info.ContentEncryptionAlgorithm.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("content encryption IV: %w", err)
+	}
+
+	data := info.EncryptedContent.Bytes
+
+	// The constructed content is the sequence of octet strings.
+	if info.EncryptedContent.IsCompound {
+		var chunks []byte
+
+		for rest := data; len(rest) > 0; {
+			var chunk []byte
+			if rest, err = asn1.Unmarshal(rest, &chunk); err != nil {
+				return nil, fmt.Errorf("encrypted content: %w", err)
+			}
+
+			chunks = append(chunks, chunk...)
+		}
+
+		data = chunks
+	}
+
+	if len(iv) != block.BlockSize() || len(data) == 0 || len(data)%block.BlockSize() != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	return unpad(plain), nil
+}
+
+func newContentCipher(algorithm asn1.ObjectIdentifier, key []byte) (cipher.Block, error) {
+	var keyLen int
+
+	switch {
+	case algorithm.Equal(oidDESEDE3CBC):
+		return des.NewTripleDESCipher(key)
+	case algorithm.Equal(oidAES128CBC):
+		keyLen = 16
+	case algorithm.Equal(oidAES192CBC):
+		keyLen = 24
+	case algorithm.Equal(oidAES256CBC):
+		keyLen = 32
+	default:
+		return nil, fmt.Errorf("%w: content encryption %s", ErrUnsupportedEncryption, algorithm)
+	}
+
+	if len(key) != keyLen {
+		return nil, fmt.Errorf("%w: content key length %d", ErrInvalidCiphertext, len(key))
+	}
+
+	return aes.NewCipher(key)
+}
+
+// sealEnvelope encrypts the content with AES-256 for the RSA
+// recipient certificates and returns the DER-encoded enveloped data.
+func sealEnvelope(content []byte, recipients []*x509.Certificate) ([]byte, error) {
+	const contentKeyLen = 32
+
+	contentKey := make([]byte, contentKeyLen)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, fmt.Errorf("seal envelope: %w", err)
+	}
+
+	encrypted, err := Encrypt(MethodAESV3, contentKey, content)
+	if err != nil {
+		return nil, fmt.Errorf("seal envelope: %w", err)
+	}
+
+	iv, err := asn1.Marshal(encrypted[:aes.BlockSize])
+	if err != nil {
+		return nil, fmt.Errorf("seal envelope: %w", err)
+	}
+
+	env := envelopedData{
+		RecipientInfos: make([]asn1.RawValue, 0, len(recipients)),
+		EncryptedContentInfo: encryptedContentInfo{
+			ContentType: oidData,
+			ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+				Algorithm:  oidAES256CBC,
+				Parameters: asn1.RawValue{FullBytes: iv},
+			},
+			EncryptedContent: asn1.RawValue{
+				Class: asn1.ClassContextSpecific,
+				Tag:   0,
+				Bytes: encrypted[aes.BlockSize:],
+			},
+		},
+	}
+
+	for _, cert := range recipients {
+		info, err := newRecipientInfo(cert, contentKey)
+		if err != nil {
+			return nil, fmt.Errorf("seal envelope: %w", err)
+		}
+
+		env.RecipientInfos = append(env.RecipientInfos, asn1.RawValue{FullBytes: info})
+	}
+
+	envDER, err := asn1.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("seal envelope: %w", err)
+	}
+
+	der, err := asn1.Marshal(contentInfo{
+		ContentType: oidEnvelopedData,
+		Content: asn1.RawValue{
+			Class:      asn1.ClassContextSpecific,
+			Tag:        0,
+			IsCompound: true,
+			Bytes:      envDER,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("seal envelope: %w", err)
+	}
+
+	return der, nil
+}
+
+// newRecipientInfo encrypts the content key for the recipient and
+// returns the DER-encoded key transport recipient info.
+func newRecipientInfo(cert *x509.Certificate, contentKey []byte) ([]byte, error) {
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s public key", ErrUnsupportedEncryption, cert.PublicKeyAlgorithm)
+	}
+
+	encryptedKey, err := rsa.EncryptPKCS1v15(rand.Reader, pub, contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	rid, err := asn1.Marshal(issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+		SerialNumber: cert.SerialNumber,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(keyTransRecipientInfo{
+		RecipientIdentifier: asn1.RawValue{FullBytes: rid},
+		KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidRSAEncryption,
+			Parameters: asn1.NullRawValue,
+		},
+		EncryptedKey: encryptedKey,
+	})
+}
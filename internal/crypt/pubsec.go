@@ -0,0 +1,117 @@
+package crypt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha1" // nolint:gosec
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// PublicKeySecurity is the public-key security handler, i.e. the
+// /Adobe.PubSec filter (see ISO 32000-2:2020, 7.6.5). The file key
+// is enveloped for each recipient certificate.
+type PublicKeySecurity struct {
+	// Recipients are the DER-encoded PKCS#7 enveloped data objects.
+	Recipients [][]byte
+	// Length is the file key length in bytes.
+	Length int
+	// P is the access permissions of the recipient.
+	P int32
+	// Method is the method of the default crypt filter. The
+	// file key of MethodAESV3 is computed with SHA-256.
+	Method          Method
+	EncryptMetadata bool
+}
+
+const (
+	seedLen = 20
+	// envelopeLen is the length of the seed followed by P.
+	envelopeLen = seedLen + 4
+)
+
+// Authenticate opens the enveloped data of the recipient certificate with
+// its private key, sets P and returns the file key (see Algorithm 1).
+func (sec *PublicKeySecurity) Authenticate(cert *x509.Certificate, key crypto.Decrypter) ([]byte, error) {
+	if cert == nil || key == nil {
+		return nil, fmt.Errorf("authenticate: %w", ErrRecipientNotFound)
+	}
+
+	for _, recipient := range sec.Recipients {
+		content, err := openEnvelope(recipient, cert, key)
+		if errors.Is(err, ErrRecipientNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("authenticate: %w", err)
+		}
+
+		if len(content) < envelopeLen {
+			return nil, fmt.Errorf("authenticate: %w: enveloped data length %d", ErrInvalidCiphertext, len(content))
+		}
+
+		sec.P = int32(binary.BigEndian.Uint32(content[seedLen:envelopeLen]))
+
+		return sec.fileKey(content[:seedLen])
+	}
+
+	return nil, fmt.Errorf("authenticate: %w", ErrRecipientNotFound)
+}
+
+// Setup envelopes a random seed and P for the recipients,
+// sets Recipients and returns the new file key.
+func (sec *PublicKeySecurity) Setup(recipients []*x509.Certificate) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("setup: %w: no recipients", ErrRecipientNotFound)
+	}
+
+	content := make([]byte, envelopeLen)
+	if _, err := rand.Read(content[:seedLen]); err != nil {
+		return nil, fmt.Errorf("setup: %w", err)
+	}
+
+	binary.BigEndian.PutUint32(content[seedLen:], uint32(sec.P))
+
+	envelope, err := sealEnvelope(content, recipients)
+	if err != nil {
+		return nil, fmt.Errorf("setup: %w", err)
+	}
+
+	sec.Recipients = [][]byte{envelope}
+
+	return sec.fileKey(content[:seedLen])
+}
+
+// fileKey digests the seed, all the recipients and, if the metadata is
+// left unencrypted, 4 bytes of 0xFF. The file key is the digest prefix.
+func (sec *PublicKeySecurity) fileKey(seed []byte) ([]byte, error) {
+	const minKeyLen = 5
+
+	var h hash.Hash
+	if sec.Method == MethodAESV3 {
+		h = sha256.New()
+	} else {
+		h = sha1.New() // nolint:gosec
+	}
+
+	if sec.Length < minKeyLen || sec.Length > h.Size() {
+		return nil, fmt.Errorf("%w: key length %d", ErrUnsupportedEncryption, sec.Length)
+	}
+
+	h.Write(seed)
+
+	for _, recipient := range sec.Recipients {
+		h.Write(recipient)
+	}
+
+	if !sec.EncryptMetadata {
+		h.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	}
+
+	return h.Sum(nil)[:sec.Length], nil
+}
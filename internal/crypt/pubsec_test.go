@@ -0,0 +1,153 @@
+package crypt_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/crypt"
+)
+
+type recipient struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+func newRecipient(t *testing.T, serial int64) recipient {
+	t.Helper()
+
+	const keyBits = 2048
+
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "recipient"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return recipient{cert: cert, key: key}
+}
+
+func TestPublicKeySecurity(t *testing.T) {
+	t.Parallel()
+
+	alice, bob, eve := newRecipient(t, 1), newRecipient(t, 2), newRecipient(t, 3)
+
+	tests := []struct {
+		name            string
+		method          crypt.Method
+		length          int
+		encryptMetadata bool
+	}{
+		{name: "RC4 40", method: crypt.MethodRC4, length: 5, encryptMetadata: true},
+		{name: "RC4 128", method: crypt.MethodRC4, length: 16, encryptMetadata: true},
+		{name: "AESV2", method: crypt.MethodAESV2, length: 16, encryptMetadata: true},
+		{name: "AESV2 plain metadata", method: crypt.MethodAESV2, length: 16},
+		{name: "AESV3", method: crypt.MethodAESV3, length: 32, encryptMetadata: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			const p = -3904
+
+			sec := crypt.PublicKeySecurity{
+				Length:          tt.length,
+				P:               p,
+				Method:          tt.method,
+				EncryptMetadata: tt.encryptMetadata,
+			}
+
+			key, err := sec.Setup([]*x509.Certificate{alice.cert, bob.cert})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Len(t, key, tt.length)
+
+			for _, r := range []recipient{alice, bob} {
+				opened := crypt.PublicKeySecurity{
+					Recipients:      sec.Recipients,
+					Length:          tt.length,
+					Method:          tt.method,
+					EncryptMetadata: tt.encryptMetadata,
+				}
+
+				fileKey, err := opened.Authenticate(r.cert, r.key)
+				assert.NoError(t, err)
+				assert.Equal(t, key, fileKey)
+				assert.Equal(t, int32(p), opened.P)
+			}
+
+			_, err = sec.Authenticate(eve.cert, eve.key)
+			assert.ErrorIs(t, err, crypt.ErrRecipientNotFound)
+
+			// The metadata flag changes the file key.
+			sec.EncryptMetadata = !sec.EncryptMetadata
+
+			fileKey, err := sec.Authenticate(alice.cert, alice.key)
+			assert.NoError(t, err)
+			assert.NotEqual(t, key, fileKey)
+		})
+	}
+}
+
+func TestPublicKeySecurityRecipients(t *testing.T) {
+	t.Parallel()
+
+	alice, bob := newRecipient(t, 1), newRecipient(t, 2)
+
+	forAlice := crypt.PublicKeySecurity{Length: 16, P: -4, Method: crypt.MethodAESV2}
+	if _, err := forAlice.Setup([]*x509.Certificate{alice.cert}); err != nil {
+		t.Fatal(err)
+	}
+
+	forBob := crypt.PublicKeySecurity{Length: 16, P: -3904, Method: crypt.MethodAESV2}
+	if _, err := forBob.Setup([]*x509.Certificate{bob.cert}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each recipient has own permissions, but the file key is the same
+	// as long as the seed is, so only P of the recipient is checked.
+	sec := crypt.PublicKeySecurity{
+		Recipients: [][]byte{forAlice.Recipients[0], forBob.Recipients[0]},
+		Length:     16,
+		Method:     crypt.MethodAESV2,
+	}
+
+	_, err := sec.Authenticate(bob.cert, bob.key)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-3904), sec.P)
+
+	_, err = sec.Authenticate(alice.cert, alice.key)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(-4), sec.P)
+
+	_, err = (&crypt.PublicKeySecurity{Length: 16}).Setup(nil)
+	assert.ErrorIs(t, err, crypt.ErrRecipientNotFound)
+}
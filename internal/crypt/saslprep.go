@@ -0,0 +1,147 @@
+package crypt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+)
+
+var ErrProhibitedChar = errors.New("prohibited character")
+
+// SASLprep prepares the password as defined by RFC 4013. The
+// unassigned code points are allowed, as they are in queries.
+func SASLprep(password string) (string, error) {
+	mapped := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(mappedToNothing, r):
+			return -1
+		case unicode.Is(nonASCIISpace, r):
+			return ' '
+		default:
+			return r
+		}
+	}, password)
+
+	prepared := norm.NFKC.String(mapped)
+
+	for _, r := range prepared {
+		if unicode.Is(prohibited, r) || unicode.Is(nonASCIISpace, r) {
+			return "", fmt.Errorf("saslprep: %w: %U", ErrProhibitedChar, r)
+		}
+	}
+
+	if err := checkBidi(prepared); err != nil {
+		return "", fmt.Errorf("saslprep: %w", err)
+	}
+
+	return prepared, nil
+}
+
+// checkBidi checks the bidirectional characters (see RFC 3454, 6):
+// a string with any RandALCat character must not contain LCat
+// characters and must start and end with RandALCat characters.
+func checkBidi(s string) error {
+	hasRandAL, hasL := false, false
+
+	runes := []rune(s)
+	for _, r := range runes {
+		switch bidiClass(r) {
+		case bidi.R, bidi.AL:
+			hasRandAL = true
+		case bidi.L:
+			hasL = true
+		}
+	}
+
+	if !hasRandAL {
+		return nil
+	}
+
+	isRandAL := func(r rune) bool {
+		class := bidiClass(r)
+
+		return class == bidi.R || class == bidi.AL
+	}
+
+	if hasL || !isRandAL(runes[0]) || !isRandAL(runes[len(runes)-1]) {
+		return fmt.Errorf("%w: invalid bidirectional text", ErrProhibitedChar)
+	}
+
+	return nil
+}
+
+func bidiClass(r rune) bidi.Class {
+	props, _ := bidi.LookupRune(r)
+
+	return props.Class()
+}
+
+// mappedToNothing is RFC 3454, table B.1.
+var mappedToNothing = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00AD, Hi: 0x00AD, Stride: 1},
+		{Lo: 0x034F, Hi: 0x034F, Stride: 1},
+		{Lo: 0x1806, Hi: 0x1806, Stride: 1},
+		{Lo: 0x180B, Hi: 0x180D, Stride: 1},
+		{Lo: 0x200B, Hi: 0x200D, Stride: 1},
+		{Lo: 0x2060, Hi: 0x2060, Stride: 1},
+		{Lo: 0xFE00, Hi: 0xFE0F, Stride: 1},
+		{Lo: 0xFEFF, Hi: 0xFEFF, Stride: 1},
+	},
+}
+
+// nonASCIISpace is RFC 3454, table C.1.2.
+var nonASCIISpace = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00A0, Hi: 0x00A0, Stride: 1},
+		{Lo: 0x1680, Hi: 0x1680, Stride: 1},
+		{Lo: 0x2000, Hi: 0x200B, Stride: 1},
+		{Lo: 0x202F, Hi: 0x202F, Stride: 1},
+		{Lo: 0x205F, Hi: 0x205F, Stride: 1},
+		{Lo: 0x3000, Hi: 0x3000, Stride: 1},
+	},
+}
+
+// prohibited is RFC 3454, tables C.2.1 to C.9.
+var prohibited = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x0000, Hi: 0x001F, Stride: 1},
+		{Lo: 0x007F, Hi: 0x009F, Stride: 1},
+		{Lo: 0x0340, Hi: 0x0341, Stride: 1},
+		{Lo: 0x06DD, Hi: 0x06DD, Stride: 1},
+		{Lo: 0x070F, Hi: 0x070F, Stride: 1},
+		{Lo: 0x180E, Hi: 0x180E, Stride: 1},
+		{Lo: 0x200C, Hi: 0x200F, Stride: 1},
+		{Lo: 0x2028, Hi: 0x202E, Stride: 1},
+		{Lo: 0x2060, Hi: 0x2063, Stride: 1},
+		{Lo: 0x206A, Hi: 0x206F, Stride: 1},
+		{Lo: 0x2FF0, Hi: 0x2FFB, Stride: 1},
+		{Lo: 0xD800, Hi: 0xF8FF, Stride: 1},
+		{Lo: 0xFDD0, Hi: 0xFDEF, Stride: 1},
+		{Lo: 0xFEFF, Hi: 0xFEFF, Stride: 1},
+		{Lo: 0xFFF9, Hi: 0xFFFF, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1D173, Hi: 0x1D17A, Stride: 1},
+		{Lo: 0x1FFFE, Hi: 0x1FFFF, Stride: 1},
+		{Lo: 0x2FFFE, Hi: 0x2FFFF, Stride: 1},
+		{Lo: 0x3FFFE, Hi: 0x3FFFF, Stride: 1},
+		{Lo: 0x4FFFE, Hi: 0x4FFFF, Stride: 1},
+		{Lo: 0x5FFFE, Hi: 0x5FFFF, Stride: 1},
+		{Lo: 0x6FFFE, Hi: 0x6FFFF, Stride: 1},
+		{Lo: 0x7FFFE, Hi: 0x7FFFF, Stride: 1},
+		{Lo: 0x8FFFE, Hi: 0x8FFFF, Stride: 1},
+		{Lo: 0x9FFFE, Hi: 0x9FFFF, Stride: 1},
+		{Lo: 0xAFFFE, Hi: 0xAFFFF, Stride: 1},
+		{Lo: 0xBFFFE, Hi: 0xBFFFF, Stride: 1},
+		{Lo: 0xCFFFE, Hi: 0xCFFFF, Stride: 1},
+		{Lo: 0xDFFFE, Hi: 0xDFFFF, Stride: 1},
+		{Lo: 0xE0001, Hi: 0xE0001, Stride: 1},
+		{Lo: 0xE0020, Hi: 0xE007F, Stride: 1},
+		{Lo: 0xEFFFE, Hi: 0x10FFFF, Stride: 1},
+	},
+}
@@ -0,0 +1,49 @@
+package crypt_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/crypt"
+)
+
+func TestSASLprep(t *testing.T) {
+	t.Parallel()
+
+	// The examples of RFC 4013, section 3.
+	tests := []struct {
+		name     string
+		password string
+		want     string
+		wantErr  error
+	}{
+		{name: "soft hyphen", password: "I\u00ADX", want: "IX"},
+		{name: "no transformation", password: "user", want: "user"},
+		{name: "case preserved", password: "USER", want: "USER"},
+		{name: "ISO 8859-1 a", password: "ª", want: "a"},
+		{name: "roman numeral IX", password: "Ⅸ", want: "IX"},
+		{name: "non-ASCII space", password: "a\u00A0b", want: "a b"},
+		{name: "prohibited character", password: "\u0007", wantErr: crypt.ErrProhibitedChar},
+		{name: "bidirectional check", password: "ا1", wantErr: crypt.ErrProhibitedChar},
+		{name: "RandALCat only", password: "اب", want: "اب"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := crypt.SASLprep(tt.password)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
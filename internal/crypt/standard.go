@@ -0,0 +1,504 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf8"
+)
+
+// passwordPadding pads the passwords of the revisions 2 to 4
+// (see ISO 32000-2:2020, 7.6.4.3.2, Algorithm 2, step a).
+var passwordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41,
+	0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80,
+	0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+const (
+	paddedPasswordLen = 32
+	// maxPasswordLen is the maximum length of
+	// the UTF-8 password of the revision 6.
+	maxPasswordLen = 127
+	hashLen        = 32
+	saltLen        = 8
+	// rc4Iterations is the number of the RC4 passes
+	// of the revision 3 and later.
+	rc4Iterations = 20
+	md5Iterations = 50
+)
+
+// StandardSecurity holds the entries of the encryption dictionary
+// of the standard security handler (see ISO 32000-2:2020, Table 21).
+type StandardSecurity struct {
+	// R is the revision of the handler: 2, 3, 4, 5 or 6.
+	R int
+	// Length is the file key length in bytes.
+	// It is ignored by the revisions 5 and 6.
+	Length int
+	O      []byte
+	U      []byte
+	OE     []byte
+	UE     []byte
+	// Perms is the encrypted copy of P of the revision 6.
+	Perms []byte
+	P     int32
+	// EncryptMetadata is false if the metadata
+	// streams are not encrypted.
+	EncryptMetadata bool
+	// ID is the first element of the /ID array of the trailer.
+	ID []byte
+}
+
+// Authenticate returns the file key if the password is either the
+// user or the owner password. The owner flag is set for the latter.
+func (sec *StandardSecurity) Authenticate(password string) (key []byte, owner bool, err error) {
+	switch sec.R {
+	case 2, 3, 4:
+		pw := encodeLegacyPassword(password)
+
+		if key = sec.authenticateUser(pw); key != nil {
+			return key, false, nil
+		}
+
+		if key = sec.authenticateOwner(pw); key != nil {
+			return key, true, nil
+		}
+	case 5, 6:
+		pw, err := saslPassword(password)
+		if err != nil {
+			return nil, false, fmt.Errorf("authenticate: %w: %w", ErrInvalidPassword, err)
+		}
+
+		if key, err = sec.authenticateAES256(pw, false); key != nil || err != nil {
+			return key, false, err
+		}
+
+		if key, err = sec.authenticateAES256(pw, true); key != nil || err != nil {
+			return key, true, err
+		}
+	default:
+		return nil, false, fmt.Errorf("authenticate: %w: revision %d", ErrUnsupportedEncryption, sec.R)
+	}
+
+	return nil, false, fmt.Errorf("authenticate: %w", ErrInvalidPassword)
+}
+
+// Setup computes the /O and /U values of the passwords and returns
+// the file key, R, Length, P, EncryptMetadata and ID must be set.
+// The /OE, /UE and /Perms values of the revision 6 are computed
+// as well. If the owner password is empty, the user one is used.
+func (sec *StandardSecurity) Setup(userPassword, ownerPassword string) (key []byte, err error) {
+	if ownerPassword == "" {
+		ownerPassword = userPassword
+	}
+
+	switch sec.R {
+	case 2, 3, 4:
+		user, owner := encodeLegacyPassword(userPassword), encodeLegacyPassword(ownerPassword)
+
+		sec.O = sec.computeO(user, owner)
+		key = sec.fileKey(user)
+		sec.U = sec.computeU(key)
+
+		return key, nil
+	case 6:
+		user, err := saslPassword(userPassword)
+		if err != nil {
+			return nil, fmt.Errorf("setup: user password: %w", err)
+		}
+
+		owner, err := saslPassword(ownerPassword)
+		if err != nil {
+			return nil, fmt.Errorf("setup: owner password: %w", err)
+		}
+
+		key = make([]byte, hashLen)
+		if _, err = rand.Read(key); err != nil {
+			return nil, fmt.Errorf("setup: %w", err)
+		}
+
+		if sec.U, sec.UE, err = sec.computeAES256Entry(user, key, nil); err != nil {
+			return nil, fmt.Errorf("setup: %w", err)
+		}
+
+		if sec.O, sec.OE, err = sec.computeAES256Entry(owner, key, sec.U); err != nil {
+			return nil, fmt.Errorf("setup: %w", err)
+		}
+
+		if sec.Perms, err = sec.computePerms(key); err != nil {
+			return nil, fmt.Errorf("setup: %w", err)
+		}
+
+		return key, nil
+	default:
+		return nil, fmt.Errorf("setup: %w: revision %d", ErrUnsupportedEncryption, sec.R)
+	}
+}
+
+// computeO computes the /O value of the revisions 2 to 4
+// (see ISO 32000-2:2020, 7.6.4.4, Algorithm 3).
+func (sec *StandardSecurity) computeO(userPassword, ownerPassword []byte) []byte {
+	o := padPassword(userPassword)
+
+	if key := sec.ownerKey(ownerPassword); sec.R == 2 {
+		rc4XOR(key, o, o)
+	} else {
+		rc4Passes(key, o, false)
+	}
+
+	return o
+}
+
+// computeAES256Entry computes either the /U and /UE values or the /O
+// and /OE values of the revision 6 (see ISO 32000-2:2020, 7.6.4.4,
+// Algorithms 8 and 9). The /U value is used for the latter.
+func (sec *StandardSecurity) computeAES256Entry(password, key, userEntry []byte) (entry, encryptedKey []byte, err error) {
+	salts := make([]byte, 2*saltLen)
+	if _, err = rand.Read(salts); err != nil {
+		return nil, nil, err
+	}
+
+	validationSalt, keySalt := salts[:saltLen], salts[saltLen:]
+
+	entry = append(sec.hash(password, validationSalt, userEntry), salts...)
+
+	block, err := aes.NewCipher(sec.hash(password, keySalt, userEntry))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey = make([]byte, len(key))
+	cipher.NewCBCEncrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(encryptedKey, key)
+
+	return entry, encryptedKey, nil
+}
+
+// computePerms computes the /Perms value of the revision 6
+// (see ISO 32000-2:2020, 7.6.4.4, Algorithm 10).
+func (sec *StandardSecurity) computePerms(key []byte) ([]byte, error) {
+	perms := make([]byte, aes.BlockSize)
+
+	binary.LittleEndian.PutUint32(perms, uint32(sec.P))
+	copy(perms[4:8], []byte{0xFF, 0xFF, 0xFF, 0xFF})
+
+	perms[8] = 'F'
+	if sec.EncryptMetadata {
+		perms[8] = 'T'
+	}
+
+	copy(perms[9:12], "adb")
+
+	if _, err := rand.Read(perms[12:]); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	block.Encrypt(perms, perms)
+
+	return perms, nil
+}
+
+// keyLength returns the file key length of the revisions 2 to 4.
+func (sec *StandardSecurity) keyLength() int {
+	const (
+		minKeyLen = 5
+		maxKeyLen = 16
+	)
+
+	if sec.R == 2 || sec.Length < minKeyLen {
+		return minKeyLen
+	}
+
+	if sec.Length > maxKeyLen {
+		return maxKeyLen
+	}
+
+	return sec.Length
+}
+
+// fileKey computes the file key from the padded user password
+// (see ISO 32000-2:2020, 7.6.4.3.2, Algorithm 2).
+func (sec *StandardSecurity) fileKey(password []byte) []byte {
+	n := sec.keyLength()
+
+	hash := md5.New()
+	hash.Write(padPassword(password))
+	hash.Write(sec.O)
+
+	var p [4]byte
+	binary.LittleEndian.PutUint32(p[:], uint32(sec.P))
+	hash.Write(p[:])
+	hash.Write(sec.ID)
+
+	if sec.R >= 4 && !sec.EncryptMetadata {
+		hash.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	}
+
+	key := hash.Sum(nil)
+
+	if sec.R >= 3 {
+		for i := 0; i < md5Iterations; i++ {
+			sum := md5.Sum(key[:n])
+			key = sum[:]
+		}
+	}
+
+	return key[:n]
+}
+
+// computeU computes the /U value of the revisions 2 to 4
+// (see ISO 32000-2:2020, 7.6.4.4, Algorithms 4 and 5).
+func (sec *StandardSecurity) computeU(key []byte) []byte {
+	if sec.R == 2 {
+		u := make([]byte, paddedPasswordLen)
+		rc4XOR(key, u, passwordPadding)
+
+		return u
+	}
+
+	hash := md5.New()
+	hash.Write(passwordPadding)
+	hash.Write(sec.ID)
+
+	u := hash.Sum(nil)
+	rc4Passes(key, u, false)
+
+	// The rest of /U is arbitrary.
+	return append(u, make([]byte, len(u))...)
+}
+
+// authenticateUser returns the file key if the password is the user
+// password (see ISO 32000-2:2020, 7.6.4.4, Algorithm 6).
+func (sec *StandardSecurity) authenticateUser(password []byte) []byte {
+	const checkedLen = 16
+
+	key := sec.fileKey(password)
+	u := sec.computeU(key)
+
+	n := checkedLen
+	if sec.R == 2 {
+		n = paddedPasswordLen
+	}
+
+	if len(sec.U) < n || !bytes.Equal(u[:n], sec.U[:n]) {
+		return nil
+	}
+
+	return key
+}
+
+// authenticateOwner returns the file key if the password is the owner
+// password (see ISO 32000-2:2020, 7.6.4.4, Algorithm 7).
+func (sec *StandardSecurity) authenticateOwner(password []byte) []byte {
+	if len(sec.O) < paddedPasswordLen {
+		return nil
+	}
+
+	// The user password is encrypted with the owner key.
+	userPassword := make([]byte, paddedPasswordLen)
+	copy(userPassword, sec.O)
+
+	if key := sec.ownerKey(password); sec.R == 2 {
+		rc4XOR(key, userPassword, userPassword)
+	} else {
+		rc4Passes(key, userPassword, true)
+	}
+
+	return sec.authenticateUser(userPassword)
+}
+
+// ownerKey computes the RC4 key of the /O value (see
+// ISO 32000-2:2020, 7.6.4.4, Algorithm 3, steps a to d).
+func (sec *StandardSecurity) ownerKey(password []byte) []byte {
+	n := sec.keyLength()
+
+	key := md5.Sum(padPassword(password))
+	if sec.R >= 3 {
+		for i := 0; i < md5Iterations; i++ {
+			key = md5.Sum(key[:])
+		}
+	}
+
+	return key[:n]
+}
+
+// authenticateAES256 returns the file key if the password is the user
+// or the owner password (see ISO 32000-2:2020, 7.6.4.4, Algorithms 11
+// and 12). The file key is decrypted from /UE or /OE.
+func (sec *StandardSecurity) authenticateAES256(password []byte, owner bool) ([]byte, error) {
+	const entryLen = hashLen + 2*saltLen
+
+	entry, encryptedKey, userEntry := sec.U, sec.UE, []byte(nil)
+	if owner {
+		entry, encryptedKey = sec.O, sec.OE
+
+		if len(sec.U) < entryLen {
+			return nil, fmt.Errorf("%w: /U is too short", ErrInvalidPassword)
+		}
+
+		userEntry = sec.U[:entryLen]
+	}
+
+	if len(entry) < entryLen {
+		return nil, nil
+	}
+
+	validationSalt := entry[hashLen : hashLen+saltLen]
+	keySalt := entry[hashLen+saltLen : entryLen]
+
+	if !bytes.Equal(sec.hash(password, validationSalt, userEntry), entry[:hashLen]) {
+		return nil, nil
+	}
+
+	if len(encryptedKey) != hashLen {
+		return nil, fmt.Errorf("%w: invalid encrypted file key length %d", ErrInvalidCiphertext, len(encryptedKey))
+	}
+
+	block, err := aes.NewCipher(sec.hash(password, keySalt, userEntry))
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, hashLen)
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(key, encryptedKey)
+
+	return key, nil
+}
+
+// hash computes the password hash of the revisions 5 and 6
+// (see ISO 32000-2:2020, 7.6.4.3.4, Algorithm 2.B).
+func (sec *StandardSecurity) hash(password, salt, userEntry []byte) []byte {
+	const (
+		minRounds = 64
+		repeat    = 64
+		keyLen    = 16
+		modulus   = 3
+	)
+
+	sum := sha256.New()
+	sum.Write(password)
+	sum.Write(salt)
+	sum.Write(userEntry)
+
+	k := sum.Sum(nil)
+	if sec.R < 6 {
+		return k
+	}
+
+	for round := 0; ; round++ {
+		seq := make([]byte, 0, len(password)+len(k)+len(userEntry))
+		seq = append(append(append(seq, password...), k...), userEntry...)
+		k1 := bytes.Repeat(seq, repeat)
+
+		block, _ := aes.NewCipher(k[:keyLen])
+		e := make([]byte, len(k1))
+		cipher.NewCBCEncrypter(block, k[keyLen:2*keyLen]).CryptBlocks(e, k1)
+
+		// The first 16 bytes as a big-endian number modulo 3
+		// equal the sum of the bytes modulo 3.
+		mod := 0
+		for _, ch := range e[:keyLen] {
+			mod += int(ch)
+		}
+
+		switch mod % modulus {
+		case 0:
+			sum := sha256.Sum256(e)
+			k = sum[:]
+		case 1:
+			sum := sha512.Sum384(e)
+			k = sum[:]
+		default:
+			sum := sha512.Sum512(e)
+			k = sum[:]
+		}
+
+		if round+1 >= minRounds && int(e[len(e)-1]) <= round+1-32 {
+			break
+		}
+	}
+
+	return k[:hashLen]
+}
+
+// padPassword pads or truncates the password to 32 bytes.
+func padPassword(password []byte) []byte {
+	padded := make([]byte, paddedPasswordLen)
+	n := copy(padded, password)
+	copy(padded[n:], passwordPadding)
+
+	return padded
+}
+
+// encodeLegacyPassword encodes the password of the revisions 2 to 4
+// as PDFDocEncoding. Only the characters PDFDocEncoding shares with
+// Latin-1 are supported, other passwords are used as UTF-8 bytes.
+func encodeLegacyPassword(password string) []byte {
+	const maxLatin1 = 0xFF
+
+	encoded := make([]byte, 0, len(password))
+
+	for _, r := range password {
+		if r > maxLatin1 || r == utf8.RuneError {
+			return []byte(password)
+		}
+
+		encoded = append(encoded, byte(r))
+	}
+
+	return encoded
+}
+
+// saslPassword prepares the password of the
+// revisions 5 and 6 (see ISO 32000-2:2020, 7.6.4.3.3).
+func saslPassword(password string) ([]byte, error) {
+	prepared, err := SASLprep(password)
+	if err != nil {
+		return nil, err
+	}
+
+	pw := []byte(prepared)
+	if len(pw) > maxPasswordLen {
+		pw = pw[:maxPasswordLen]
+	}
+
+	return pw, nil
+}
+
+// rc4XOR encrypts or decrypts src into dst with RC4.
+func rc4XOR(key, dst, src []byte) {
+	c, _ := rc4.NewCipher(key)
+	c.XORKeyStream(dst, src)
+}
+
+// rc4Passes encrypts the data in place 20 times with the key XORed
+// with the pass number, or decrypts it if reverse is set.
+func rc4Passes(key, data []byte, reverse bool) {
+	passKey := make([]byte, len(key))
+
+	for i := 0; i < rc4Iterations; i++ {
+		pass := i
+		if reverse {
+			pass = rc4Iterations - 1 - i
+		}
+
+		for j := range key {
+			passKey[j] = key[j] ^ byte(pass)
+		}
+
+		rc4XOR(passKey, data, data)
+	}
+}
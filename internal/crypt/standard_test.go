@@ -0,0 +1,285 @@
+package crypt_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/crypt"
+)
+
+const (
+	ownerPassword = "owner"
+	title         = "Hello"
+	content       = "BT /F1 12 Tf 10 10 Td (Hello, World) Tj ET"
+	// The /Info dictionary with the /Title is object 5
+	// and the content stream is object 4.
+	titleObjNo  = 5
+	streamObjNo = 4
+)
+
+func fromHex(s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+// The test files have been encrypted by pdfcpu. Its strings
+// of the revision 6 are encrypted with a wrong key, so the
+// title of these is encrypted by the test, see TestDecrypt.
+//
+// nolint:gochecknoglobals
+var standardTests = []struct {
+	name     string
+	method   crypt.Method
+	password string
+	security crypt.StandardSecurity
+	title    []byte
+	stream   []byte
+}{
+	{
+		name:     "rc4-40",
+		method:   crypt.MethodRC4,
+		password: "user",
+		security: crypt.StandardSecurity{
+			R:               2,
+			Length:          5,
+			O:               fromHex("94e8094419662a774442fb072e3d9f19e9d130ec09a4d0061e78fe920f7ab62f"),
+			U:               fromHex("d470396e0718bdaa90c41d2623ea5a07444e9875ea2cefd03cc15b2b469fef9d"),
+			P:               -3901,
+			EncryptMetadata: true,
+			ID:              fromHex("c04da65b4ad23805cc69cc77f886797d"),
+		},
+		title:  fromHex("1509dc062a"),
+		stream: fromHex("94c1a6f50a51ebc4c1199bbd730c87509c11566e7b5cfbe28e71a15ebdfbaa5c6136c9d0fbbf14fdcb44"),
+	},
+	{
+		name:     "rc4-128",
+		method:   crypt.MethodRC4,
+		password: "user",
+		security: crypt.StandardSecurity{
+			R:               4,
+			Length:          16,
+			O:               fromHex("0ba3835f88f90388e74e54584125ce142be0de24c6b0d37746e075b891756671"),
+			U:               fromHex("109f4798ff5e450129f5f963c2ded1f600000000000000000000000000000000"),
+			P:               -3901,
+			EncryptMetadata: true,
+			ID:              fromHex("1a2566f9ed938e763ab3f43b87869929"),
+		},
+		title:  fromHex("318af43619"),
+		stream: fromHex("e9ea0f02552e2fb379c3d793c4010ca21946537fe066c28ae4487ad509402283b66ca635420cd3977ea3"),
+	},
+	{
+		name:     "rc4-128-empty",
+		method:   crypt.MethodRC4,
+		password: "",
+		security: crypt.StandardSecurity{
+			R:               4,
+			Length:          16,
+			O:               fromHex("566fa873ee33c797cd3b904fdadf814afa34df9a38f6ed41b984e2c6da2aa6f5"),
+			U:               fromHex("6a4a2bbaf46c5dc494607a44a7bc342000000000000000000000000000000000"),
+			P:               -3901,
+			EncryptMetadata: true,
+			ID:              fromHex("bea48e5343ab49fd6e24ffed4976e976"),
+		},
+		title:  fromHex("cf26d623f2"),
+		stream: fromHex("08d77b3d2b109f5b250d884cc85c0ef448c174c2825c07a692344d38b87281307eebda2902eb714b4bb4"),
+	},
+	{
+		name:     "aes-128",
+		method:   crypt.MethodAESV2,
+		password: "user",
+		security: crypt.StandardSecurity{
+			R:               4,
+			Length:          16,
+			O:               fromHex("0ba3835f88f90388e74e54584125ce142be0de24c6b0d37746e075b891756671"),
+			U:               fromHex("151703a54d0397f68adcf25cabc9ef2800000000000000000000000000000000"),
+			P:               -3901,
+			EncryptMetadata: true,
+			ID:              fromHex("633a91a2acfb350a1115d8203da0b626"),
+		},
+		title:  fromHex("8531482294cd791fb22c57512c9ff28857ae0a263edd2707fdf1e82e3e3d14f3"),
+		stream: fromHex("10fd65a77c1c14cd1efd39dfe7e7d812b455e38a6c86663c554731459c3b8feeb470368330400d579151abad51858e345e894158d5b0f2411fad662fb4f1a1a8"),
+	},
+	{
+		name:     "aes-256",
+		method:   crypt.MethodAESV3,
+		password: "user",
+		security: crypt.StandardSecurity{
+			R:               5,
+			Length:          32,
+			O:               fromHex("d7dc1b2fee1c82bfdfa2517bd4fd5124fe138193cef2ceb2b7de65417ac4e8f15a1049c47b051d6ad239e101aa918d26"),
+			U:               fromHex("4507e16235a7130a7089c6924b1015a01da9ca74f67cf97f5d0e9d488d6fdd5ba0caa998c176c0d56e402554fb51aed8"),
+			OE:              fromHex("c0c546a1390f822fd6f3042d35737e4175ef6f0c137457e19dabc0e3b0537a76"),
+			UE:              fromHex("309cd1d0064d3f5246b23636acf49d93200eaebe008ea964d507d3b3a8c5787c"),
+			P:               -3901,
+			EncryptMetadata: true,
+			ID:              fromHex("b7bd5612fc71fd246e30104734296338"),
+		},
+		title:  fromHex("1b5202df0417eb611371654f9a781182b6cb991d090e7fdb22d9e50bea5457af"),
+		stream: fromHex("dbc28b34dda236773397d21db1611a0692da03d1b6f1c2db5932c1399b5fa7819158c67fd6e09c982019522b63ef5a02760ce24c30e49ae057787791778367c5"),
+	},
+	{
+		name:     "aes-256-empty",
+		method:   crypt.MethodAESV3,
+		password: "",
+		security: crypt.StandardSecurity{
+			R:               5,
+			Length:          32,
+			O:               fromHex("b78a0ecffdf0ac58edecae988ad48b08507f461281974e455df9a8e525134c05e151ae14ee858704025d8656e36c5f9e"),
+			U:               fromHex("bb98628d74dfffa9470d3ed5a3fa44640f5c45c998df1ce496c696dadb4d54e5dfd9ef7ea5d6fcaa44f7f0cf8c91f41b"),
+			OE:              fromHex("4aeba739719558f618a26b12ce1d70a2f7c291e626c8d07edbae4b7ff2167f86"),
+			UE:              fromHex("ec8d24e27eff6467d607382c4c0eb6996e8b34174821f0ba72fff16ae00b935a"),
+			P:               -3901,
+			EncryptMetadata: true,
+			ID:              fromHex("80dbcf513c8c6edad5d6551a92ffe867"),
+		},
+		title:  fromHex("3d08a6059f300e042c9059ae8d20f4245a9e4b673da5f57680dfa3f155eeb853"),
+		stream: fromHex("4f885935085185bbb92e406bbd676d2f99937c4bfee49c91338fa6d8bc89780e4eaae433025eece087314060a36b39eb726643fc22b5e7b028951ee4475fa866"),
+	},
+	{
+		name:     "r6-aes-256",
+		method:   crypt.MethodAESV3,
+		password: "user",
+		security: crypt.StandardSecurity{
+			R:               6,
+			Length:          32,
+			O:               fromHex("a26b22d566b40bff402724f80feeae6bcd471fb5ad9d51f63d29b7aa0ffc16d1c82b5b5c16dfb411ddaf314efa9aebeb"),
+			U:               fromHex("057ca0b2434a07940ec799d13314753dd2fdf1b7be3edf7413760bd2815d8b12aa648f4284b9b86f240cbf5de11c38ea"),
+			OE:              fromHex("6ff9b319af6e31edb11f7c302ca43422ddacd8893e167ee3efaa9bb03c86f7ee"),
+			UE:              fromHex("35c1fa1b0fda9d57a19acb65e370d4cd14f6e0daa649d13a8cee39eebdd1d9a4"),
+			P:               -3901,
+			EncryptMetadata: true,
+			ID:              fromHex("fba448e277f6a6fda5174b2a3dd9c92e"),
+		},
+		stream: fromHex("f6e9cb15a75ead43d1ced687c8ef2a21eec66c84aa4c9a77b9c306b04552ea2a1e8cc21e1c190a9635d00a22073ec54726d414a0ba8f88d61e1debc858d89509"),
+	},
+	{
+		name:     "r6-unicode",
+		method:   crypt.MethodAESV3,
+		password: "pässwörd",
+		security: crypt.StandardSecurity{
+			R:               6,
+			Length:          32,
+			O:               fromHex("0bc0590fa60e2ebedd4fb097def1b33424da422b54da63afd196fc1d1304d9bdd90473cfdb3ce501b90c46d338c9ef51"),
+			U:               fromHex("4d3c2764b9af820b70ff6642b4d4d7fafe8b8771d1499a21a0c0062ecf5f2309491336881956aadfaf6d6b70b9b52675"),
+			OE:              fromHex("e5be28fd93d7397d834c123a1e723f2818165daf3e60bb6cc767136dd610d68a"),
+			UE:              fromHex("65500caa976c41f25d67cc032a1368c24b6ac41d04c526b192b671f80ca23c69"),
+			P:               -3901,
+			EncryptMetadata: true,
+			ID:              fromHex("fd9d186a4db52b41573cd628535958be"),
+		},
+		stream: fromHex("f97d7498cd7e313e1d78c9154dab86070ae1b764204dd091264715b2360d21e8f6c8a0cefcb066fa0b890895195042ed934b9b02066f43933df0e9ab90898fdb"),
+	},
+}
+
+func TestStandardSecurityAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range standardTests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			userKey, isOwner, err := tt.security.Authenticate(tt.password)
+			assert.NoError(t, err)
+			assert.False(t, isOwner)
+			assert.NotEmpty(t, userKey)
+
+			ownerKey, isOwner, err := tt.security.Authenticate(ownerPassword)
+			assert.NoError(t, err)
+			assert.True(t, isOwner)
+			assert.Equal(t, userKey, ownerKey)
+
+			_, _, err = tt.security.Authenticate("wrong")
+			assert.ErrorIs(t, err, crypt.ErrInvalidPassword)
+		})
+	}
+}
+
+func TestDecrypt(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range standardTests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			key, _, err := tt.security.Authenticate(tt.password)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			titleKey := crypt.ObjectKey(tt.method, key, titleObjNo, 0)
+
+			encrypted := tt.title
+			if encrypted == nil {
+				encrypted, err = crypt.Encrypt(tt.method, titleKey, []byte(title))
+				require.NoError(t, err)
+				require.NotEqual(t, title, string(encrypted))
+			}
+
+			data, err := crypt.Decrypt(tt.method, titleKey, encrypted)
+			assert.NoError(t, err)
+			assert.Equal(t, title, string(data))
+
+			r, err := crypt.NewDecryptReader(tt.method, crypt.ObjectKey(tt.method, key, streamObjNo, 0),
+				iotest.OneByteReader(bytes.NewReader(tt.stream)))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			data, err = io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, content, string(data))
+		})
+	}
+}
+
+func TestDecryptIdentity(t *testing.T) {
+	t.Parallel()
+
+	data, err := crypt.Decrypt(crypt.MethodNone, nil, []byte(content))
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestDecryptMissingIV(t *testing.T) {
+	t.Parallel()
+
+	_, err := crypt.Decrypt(crypt.MethodAESV2, make([]byte, 16), []byte("short"))
+	assert.ErrorIs(t, err, crypt.ErrInvalidCiphertext)
+}
+
+func TestStandardSecurityAuthenticateUnsupported(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := (&crypt.StandardSecurity{R: 7}).Authenticate("")
+	assert.ErrorIs(t, err, crypt.ErrUnsupportedEncryption)
+}
+
+func TestStandardSecurityAuthenticateNormalized(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range standardTests {
+		if tt.name != "r6-unicode" {
+			continue
+		}
+
+		// The decomposed password is normalized by SASLprep.
+		key, isOwner, err := tt.security.Authenticate("pa\u0308sswo\u0308rd")
+		assert.NoError(t, err)
+		assert.False(t, isOwner)
+		assert.NotEmpty(t, key)
+	}
+}
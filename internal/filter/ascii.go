@@ -0,0 +1,262 @@
+package filter
+
+import (
+	"bufio"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// isWhitespace reports the PDF white-space characters
+// (see ISO 32000-2:2020, Table 1).
+func isWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	default:
+		return false
+	}
+}
+
+type asciiHexDecoder struct {
+	r *bufio.Reader
+	// high is the first digit of a pair, if any.
+	high    byte
+	hasHigh bool
+	err     error
+}
+
+func newASCIIHexDecoder(r io.Reader, _ Params) (io.Reader, error) {
+	return &asciiHexDecoder{r: bufio.NewReader(r)}, nil
+}
+
+func (dec *asciiHexDecoder) Read(p []byte) (n int, err error) {
+	for n < len(p) && dec.err == nil {
+		c, err := dec.r.ReadByte()
+
+		switch {
+		case err != nil:
+			// The missing EOD marker is tolerated.
+			dec.err = err
+		case c == '>':
+			dec.err = io.EOF
+		case isWhitespace(c):
+			continue
+		default:
+			v, ok := unhex(c)
+			if !ok {
+				dec.err = fmt.Errorf("%w: ASCIIHex character %q", ErrCorruptData, c)
+
+				continue
+			}
+
+			if dec.hasHigh {
+				p[n] = dec.high<<4 | v
+				n++
+			} else {
+				dec.high = v
+			}
+
+			dec.hasHigh = !dec.hasHigh
+
+			continue
+		}
+
+		// The missing last digit is assumed to be 0.
+		if dec.hasHigh {
+			p[n] = dec.high << 4
+			n++
+			dec.hasHigh = false
+		}
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+
+	return 0, dec.err
+}
+
+func unhex(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
+
+type asciiHexEncoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+func newASCIIHexEncoder(w io.Writer, _ Params) (io.WriteCloser, error) {
+	return &asciiHexEncoder{w: w}, nil
+}
+
+func (enc *asciiHexEncoder) Write(p []byte) (int, error) {
+	if cap(enc.buf) < hex.EncodedLen(len(p)) {
+		enc.buf = make([]byte, hex.EncodedLen(len(p)))
+	}
+
+	enc.buf = enc.buf[:hex.Encode(enc.buf[:cap(enc.buf)], p)]
+
+	if _, err := enc.w.Write(enc.buf); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (enc *asciiHexEncoder) Close() error {
+	_, err := enc.w.Write([]byte{'>'})
+
+	return err
+}
+
+type ascii85Decoder struct {
+	r *bufio.Reader
+	// group is the incomplete group of digits.
+	group [5]byte
+	n     int
+	// out is the decoded data not yet read.
+	out     [4]byte
+	pending []byte
+	err     error
+}
+
+func newASCII85Decoder(r io.Reader, _ Params) (io.Reader, error) {
+	br := bufio.NewReader(r)
+
+	// Skip the optional "<~" prefix.
+	if prefix, err := br.Peek(len("<~")); err == nil && string(prefix) == "<~" {
+		_, _ = br.Discard(len(prefix))
+	}
+
+	return &ascii85Decoder{r: br}, nil
+}
+
+func (dec *ascii85Decoder) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if len(dec.pending) > 0 {
+			copied := copy(p[n:], dec.pending)
+			dec.pending = dec.pending[copied:]
+			n += copied
+
+			continue
+		}
+
+		if dec.err != nil {
+			break
+		}
+
+		dec.err = dec.decodeGroup()
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+
+	return 0, dec.err
+}
+
+// decodeGroup decodes the next group of 5 digits or the last
+// incomplete one into pending.
+func (dec *ascii85Decoder) decodeGroup() error {
+	const groupLen = 5
+
+	for dec.n < groupLen {
+		c, err := dec.r.ReadByte()
+
+		switch {
+		case err != nil || c == '~':
+			// The missing EOD marker is tolerated.
+			if err == nil || errors.Is(err, io.EOF) {
+				return dec.flush()
+			}
+
+			return err
+		case isWhitespace(c):
+		case c == 'z' && dec.n == 0:
+			dec.out = [4]byte{}
+			dec.pending = dec.out[:]
+
+			return nil
+		case c < '!' || c > 'u':
+			return fmt.Errorf("%w: ASCII85 character %q", ErrCorruptData, c)
+		default:
+			dec.group[dec.n] = c
+			dec.n++
+		}
+	}
+
+	return dec.decode(groupLen)
+}
+
+// flush decodes the last incomplete group, which is padded with 'u'.
+func (dec *ascii85Decoder) flush() error {
+	switch n := dec.n; n {
+	case 0:
+		return io.EOF
+	case 1:
+		return fmt.Errorf("%w: ASCII85 final group of one digit", ErrCorruptData)
+	default:
+		for i := n; i < len(dec.group); i++ {
+			dec.group[i] = 'u'
+		}
+
+		if err := dec.decode(n); err != nil {
+			return err
+		}
+
+		return io.EOF
+	}
+}
+
+// decode decodes the group of n digits.
+func (dec *ascii85Decoder) decode(n int) error {
+	const base = 85
+
+	var v uint64
+	for _, c := range dec.group {
+		v = v*base + uint64(c-'!')
+	}
+
+	if v > 0xFFFFFFFF {
+		return fmt.Errorf("%w: ASCII85 group overflow", ErrCorruptData)
+	}
+
+	dec.out = [4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	dec.pending = dec.out[:n-1]
+	dec.n = 0
+
+	return nil
+}
+
+type ascii85Encoder struct {
+	w   io.Writer
+	enc io.WriteCloser
+}
+
+func newASCII85Encoder(w io.Writer, _ Params) (io.WriteCloser, error) {
+	return &ascii85Encoder{w: w, enc: ascii85.NewEncoder(w)}, nil
+}
+
+func (enc *ascii85Encoder) Write(p []byte) (int, error) { return enc.enc.Write(p) }
+
+func (enc *ascii85Encoder) Close() error {
+	if err := enc.enc.Close(); err != nil {
+		return err
+	}
+
+	_, err := enc.w.Write([]byte("~>"))
+
+	return err
+}
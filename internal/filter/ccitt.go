@@ -0,0 +1,626 @@
+package filter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+)
+
+// ccittMaxColumns limits the row width, so a bogus /Columns
+// does not allocate an enormous row.
+const ccittMaxColumns = 1 << 20
+
+// ccittEOL is the end-of-line code 000000000001.
+const (
+	ccittEOL    = 1
+	ccittEOLLen = 12
+)
+
+// The 2D coding modes (see ITU-T T.6, Table 1). The vertical modes
+// are ordered by the offset of a1 from b1.
+const (
+	ccittModeVL3 = iota
+	ccittModeVL2
+	ccittModeVL1
+	ccittModeV0
+	ccittModeVR1
+	ccittModeVR2
+	ccittModeVR3
+	ccittModePass
+	ccittModeHorizontal
+	ccittModeExtension
+)
+
+type ccittCode struct {
+	bits  string
+	value int
+}
+
+// nolint:gochecknoglobals
+var ccittModeCodes = []ccittCode{
+	{"0000010", ccittModeVL3}, {"000010", ccittModeVL2}, {"010", ccittModeVL1},
+	{"1", ccittModeV0},
+	{"011", ccittModeVR1}, {"000011", ccittModeVR2}, {"0000011", ccittModeVR3},
+	{"0001", ccittModePass}, {"001", ccittModeHorizontal}, {"0000001", ccittModeExtension},
+}
+
+// The run length codes (see ITU-T T.4, Tables 2 and 3).
+//
+// nolint:gochecknoglobals
+var ccittWhiteCodes = []ccittCode{
+	{"00110101", 0}, {"000111", 1}, {"0111", 2}, {"1000", 3},
+	{"1011", 4}, {"1100", 5}, {"1110", 6}, {"1111", 7},
+	{"10011", 8}, {"10100", 9}, {"00111", 10}, {"01000", 11},
+	{"001000", 12}, {"000011", 13}, {"110100", 14}, {"110101", 15},
+	{"101010", 16}, {"101011", 17}, {"0100111", 18}, {"0001100", 19},
+	{"0001000", 20}, {"0010111", 21}, {"0000011", 22}, {"0000100", 23},
+	{"0101000", 24}, {"0101011", 25}, {"0010011", 26}, {"0100100", 27},
+	{"0011000", 28}, {"00000010", 29}, {"00000011", 30}, {"00011010", 31},
+	{"00011011", 32}, {"00010010", 33}, {"00010011", 34}, {"00010100", 35},
+	{"00010101", 36}, {"00010110", 37}, {"00010111", 38}, {"00101000", 39},
+	{"00101001", 40}, {"00101010", 41}, {"00101011", 42}, {"00101100", 43},
+	{"00101101", 44}, {"00000100", 45}, {"00000101", 46}, {"00001010", 47},
+	{"00001011", 48}, {"01010010", 49}, {"01010011", 50}, {"01010100", 51},
+	{"01010101", 52}, {"00100100", 53}, {"00100101", 54}, {"01011000", 55},
+	{"01011001", 56}, {"01011010", 57}, {"01011011", 58}, {"01001010", 59},
+	{"01001011", 60}, {"00110010", 61}, {"00110011", 62}, {"00110100", 63},
+	{"11011", 64}, {"10010", 128}, {"010111", 192}, {"0110111", 256},
+	{"00110110", 320}, {"00110111", 384}, {"01100100", 448}, {"01100101", 512},
+	{"01101000", 576}, {"01100111", 640}, {"011001100", 704}, {"011001101", 768},
+	{"011010010", 832}, {"011010011", 896}, {"011010100", 960}, {"011010101", 1024},
+	{"011010110", 1088}, {"011010111", 1152}, {"011011000", 1216}, {"011011001", 1280},
+	{"011011010", 1344}, {"011011011", 1408}, {"010011000", 1472}, {"010011001", 1536},
+	{"010011010", 1600}, {"011000", 1664}, {"010011011", 1728},
+}
+
+// nolint:gochecknoglobals
+var ccittBlackCodes = []ccittCode{
+	{"0000110111", 0}, {"010", 1}, {"11", 2}, {"10", 3},
+	{"011", 4}, {"0011", 5}, {"0010", 6}, {"00011", 7},
+	{"000101", 8}, {"000100", 9}, {"0000100", 10}, {"0000101", 11},
+	{"0000111", 12}, {"00000100", 13}, {"00000111", 14}, {"000011000", 15},
+	{"0000010111", 16}, {"0000011000", 17}, {"0000001000", 18}, {"00001100111", 19},
+	{"00001101000", 20}, {"00001101100", 21}, {"00000110111", 22}, {"00000101000", 23},
+	{"00000010111", 24}, {"00000011000", 25}, {"000011001010", 26}, {"000011001011", 27},
+	{"000011001100", 28}, {"000011001101", 29}, {"000001101000", 30}, {"000001101001", 31},
+	{"000001101010", 32}, {"000001101011", 33}, {"000011010010", 34}, {"000011010011", 35},
+	{"000011010100", 36}, {"000011010101", 37}, {"000011010110", 38}, {"000011010111", 39},
+	{"000001101100", 40}, {"000001101101", 41}, {"000011011010", 42}, {"000011011011", 43},
+	{"000001010100", 44}, {"000001010101", 45}, {"000001010110", 46}, {"000001010111", 47},
+	{"000001100100", 48}, {"000001100101", 49}, {"000001010010", 50}, {"000001010011", 51},
+	{"000000100100", 52}, {"000000110111", 53}, {"000000111000", 54}, {"000000100111", 55},
+	{"000000101000", 56}, {"000001011000", 57}, {"000001011001", 58}, {"000000101011", 59},
+	{"000000101100", 60}, {"000001011010", 61}, {"000001100110", 62}, {"000001100111", 63},
+	{"0000001111", 64}, {"000011001000", 128}, {"000011001001", 192}, {"000001011011", 256},
+	{"000000110011", 320}, {"000000110100", 384}, {"000000110101", 448}, {"0000001101100", 512},
+	{"0000001101101", 576}, {"0000001001010", 640}, {"0000001001011", 704}, {"0000001001100", 768},
+	{"0000001001101", 832}, {"0000001110010", 896}, {"0000001110011", 960}, {"0000001110100", 1024},
+	{"0000001110101", 1088}, {"0000001110110", 1152}, {"0000001110111", 1216}, {"0000001010010", 1280},
+	{"0000001010011", 1344}, {"0000001010100", 1408}, {"0000001010101", 1472}, {"0000001011010", 1536},
+	{"0000001011011", 1600}, {"0000001100100", 1664}, {"0000001100101", 1728},
+}
+
+// ccittExtendedCodes are the makeup codes shared by
+// both colors (see ITU-T T.4, Table 3a).
+//
+// nolint:gochecknoglobals
+var ccittExtendedCodes = []ccittCode{
+	{"00000001000", 1792}, {"00000001100", 1856}, {"00000001101", 1920},
+	{"000000010010", 1984}, {"000000010011", 2048}, {"000000010100", 2112},
+	{"000000010101", 2176}, {"000000010110", 2240}, {"000000010111", 2304},
+	{"000000011100", 2368}, {"000000011101", 2432}, {"000000011110", 2496},
+	{"000000011111", 2560},
+}
+
+// ccittMaxCodeLen is the length of the longest code.
+const ccittMaxCodeLen = 13
+
+// ccittTable maps the codes of each length to their values, -1
+// means there is no such code.
+type ccittTable [ccittMaxCodeLen + 1][]int16
+
+func newCCITTTable(codes ...[]ccittCode) *ccittTable {
+	var table ccittTable
+
+	for n := range table {
+		table[n] = make([]int16, 1<<n)
+		for i := range table[n] {
+			table[n][i] = -1
+		}
+	}
+
+	for _, list := range codes {
+		for _, code := range list {
+			var bits int
+			for _, c := range code.bits {
+				bits = bits<<1 | int(c-'0')
+			}
+
+			table[len(code.bits)][bits] = int16(code.value)
+		}
+	}
+
+	return &table
+}
+
+// nolint:gochecknoglobals
+var (
+	ccittModeTable  = newCCITTTable(ccittModeCodes)
+	ccittWhiteTable = newCCITTTable(ccittWhiteCodes, ccittExtendedCodes)
+	ccittBlackTable = newCCITTTable(ccittBlackCodes, ccittExtendedCodes)
+)
+
+// ccittBitReader reads the bits most significant first.
+type ccittBitReader struct {
+	r     io.ByteReader
+	bits  uint64
+	nBits uint
+	err   error
+}
+
+// fill reads the bytes until there are n bits or the data ends.
+func (br *ccittBitReader) fill(n uint) {
+	for br.nBits < n && br.err == nil {
+		c, err := br.r.ReadByte()
+		if err != nil {
+			br.err = err
+
+			return
+		}
+
+		br.bits = br.bits<<8 | uint64(c)
+		br.nBits += 8
+	}
+}
+
+// peek returns the next n bits, which are padded
+// with zero bits at the end of the data.
+func (br *ccittBitReader) peek(n uint) int {
+	br.fill(n)
+
+	if br.nBits < n {
+		return int(br.bits<<(n-br.nBits)) & (1<<n - 1)
+	}
+
+	return int(br.bits>>(br.nBits-n)) & (1<<n - 1)
+}
+
+func (br *ccittBitReader) skip(n uint) {
+	br.fill(n)
+
+	if n > br.nBits {
+		n = br.nBits
+	}
+
+	br.nBits -= n
+}
+
+func (br *ccittBitReader) readBit() (int, error) {
+	br.fill(1)
+
+	if br.nBits == 0 {
+		if errors.Is(br.err, io.EOF) {
+			return 0, io.ErrUnexpectedEOF
+		}
+
+		return 0, br.err
+	}
+
+	br.nBits--
+
+	return int(br.bits>>br.nBits) & 1, nil
+}
+
+// align skips the rest of the current byte.
+func (br *ccittBitReader) align() {
+	br.nBits -= br.nBits % 8
+}
+
+// end returns io.EOF or the read error at the end of the data.
+func (br *ccittBitReader) end() error {
+	br.fill(1)
+
+	if br.nBits > 0 {
+		return nil
+	}
+
+	return br.err
+}
+
+// readCode reads the code of the table.
+func (br *ccittBitReader) readCode(table *ccittTable) (int, error) {
+	code := 0
+
+	for n := 1; n <= ccittMaxCodeLen; n++ {
+		bit, err := br.readBit()
+		if err != nil {
+			return 0, err
+		}
+
+		code = code<<1 | bit
+
+		if v := table[n][code]; v >= 0 {
+			return int(v), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: CCITT code %013b", ErrCorruptData, code)
+}
+
+// ccittDecoder decodes the CCITT Group 3 and Group 4 fax data (see
+// ISO 32000-2:2020, 7.4.6) into the rows of 1 bit per pixel.
+type ccittDecoder struct {
+	br ccittBitReader
+
+	k           int
+	columns     int
+	rows        int
+	endOfLine   bool
+	byteAlign   bool
+	endOfBlock  bool
+	blackIs1    bool
+	maxDamaged  int
+	damagedRows int
+
+	row int
+	// ref and cur are the ends of the runs of the reference and the
+	// coding lines. The runs alternate starting with a white one.
+	ref []int
+	cur []int
+	// refPos is the index of the first run of the
+	// reference line that ends to the right of a0.
+	refPos int
+
+	line    []byte
+	pending []byte
+	err     error
+}
+
+func newCCITTFaxDecoder(r io.Reader, params Params) (io.Reader, error) {
+	dec := &ccittDecoder{
+		br:         ccittBitReader{r: bufio.NewReader(r)},
+		k:          int(paramInt(params, "K", 0)),
+		columns:    int(paramInt(params, "Columns", 1728)),
+		rows:       int(paramInt(params, "Rows", 0)),
+		endOfLine:  paramBool(params, "EndOfLine", false),
+		byteAlign:  paramBool(params, "EncodedByteAlign", false),
+		endOfBlock: paramBool(params, "EndOfBlock", true),
+		blackIs1:   paramBool(params, "BlackIs1", false),
+		maxDamaged: int(paramInt(params, "DamagedRowsBeforeError", 0)),
+	}
+
+	if dec.columns < 1 || dec.columns > ccittMaxColumns {
+		return nil, fmt.Errorf("%w: CCITT /Columns %d", ErrCorruptData, dec.columns)
+	}
+
+	if dec.rows < 0 {
+		return nil, fmt.Errorf("%w: CCITT /Rows %d", ErrCorruptData, dec.rows)
+	}
+
+	dec.line = make([]byte, (dec.columns+7)/8)
+
+	return dec, nil
+}
+
+func (dec *ccittDecoder) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if len(dec.pending) > 0 {
+			copied := copy(p[n:], dec.pending)
+			dec.pending = dec.pending[copied:]
+			n += copied
+
+			continue
+		}
+
+		if dec.err != nil {
+			break
+		}
+
+		dec.err = dec.decode()
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+
+	return 0, dec.err
+}
+
+// decode decodes the next row into pending.
+func (dec *ccittDecoder) decode() error {
+	// The /Rows are ignored if the data ends with the end-of-block.
+	if !dec.endOfBlock && dec.rows > 0 && dec.row >= dec.rows {
+		return io.EOF
+	}
+
+	twoD, err := dec.startRow()
+	if err != nil {
+		return err
+	}
+
+	dec.cur = dec.cur[:0]
+
+	if twoD {
+		err = dec.decode2D()
+	} else {
+		err = dec.decode1D()
+	}
+
+	switch {
+	case err == nil:
+		dec.damagedRows = 0
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// The truncated data ends with the partial row.
+		if len(dec.cur) == 0 {
+			return io.EOF
+		}
+
+		err = io.EOF
+	case errors.Is(err, ErrCorruptData) && dec.canSkipDamaged():
+		log.Printf("CCITT row %d is damaged: %v", dec.row, err)
+
+		dec.skipDamaged()
+		err = nil
+	default:
+		return err
+	}
+
+	dec.row++
+	dec.pack()
+	dec.ref, dec.cur = dec.cur, dec.ref
+	dec.pending = dec.line
+
+	return err
+}
+
+// startRow skips the fill bits and the EOL codes before the row.
+// It returns io.EOF at the end of the data or the end-of-block,
+// which is EOFB (two EOL codes) or RTC (six EOL codes).
+func (dec *ccittDecoder) startRow() (twoD bool, err error) {
+	// The 2D rows of Group 4 have no EOL codes.
+	if dec.byteAlign && dec.k < 0 {
+		dec.br.align()
+	}
+
+	eols := 0
+
+	for done := false; !done; {
+		switch dec.br.peek(ccittEOLLen) {
+		case 0:
+			// The fill bits before EOL.
+			if err := dec.br.end(); err != nil {
+				return false, err
+			}
+
+			dec.br.skip(1)
+		case ccittEOL:
+			dec.br.skip(ccittEOLLen)
+
+			// The fill bits either precede EOL, so it ends on the
+			// byte boundary, or follow it, so the row begins there.
+			// The EOL codes of RTC may be unaligned.
+			if dec.byteAlign && dec.br.peek(ccittEOLLen) != ccittEOL {
+				dec.br.align()
+			}
+
+			if eols++; eols > 1 && dec.endOfBlock {
+				return false, io.EOF
+			}
+
+			// The 1D/2D tag bit follows each EOL of RTC.
+			if dec.k > 0 && dec.br.peek(ccittEOLLen+1)&(1<<ccittEOLLen-1) == ccittEOL {
+				dec.br.skip(1)
+			}
+		default:
+			done = true
+		}
+	}
+
+	if dec.byteAlign && dec.k >= 0 && eols == 0 {
+		dec.br.align()
+	}
+
+	switch {
+	case dec.k < 0:
+		return true, nil
+	case dec.k == 0:
+		return false, nil
+	default:
+		// The tag bit is 1 for 1D and 0 for 2D coding.
+		tag, err := dec.br.readBit()
+		if err != nil {
+			return false, io.EOF
+		}
+
+		return tag == 0, nil
+	}
+}
+
+// canSkipDamaged reports whether the damaged row is tolerated.
+// It is located by the EOL following it, so EndOfLine is required.
+func (dec *ccittDecoder) canSkipDamaged() bool {
+	return dec.endOfLine && dec.k >= 0 && dec.damagedRows < dec.maxDamaged
+}
+
+// skipDamaged skips the data up to the next EOL. The damaged row is
+// replaced by the previous row, unless it was damaged too, then the
+// white row is used.
+func (dec *ccittDecoder) skipDamaged() {
+	for dec.br.peek(ccittEOLLen) != ccittEOL && dec.br.end() == nil {
+		dec.br.skip(1)
+	}
+
+	dec.cur = dec.cur[:0]
+	if dec.damagedRows == 0 {
+		dec.cur = append(dec.cur, dec.ref...)
+	}
+
+	dec.damagedRows++
+}
+
+// addRun ends the run of the color at a1. The empty runs are merged
+// into the previous ones, so the runs keep alternating.
+func (dec *ccittDecoder) addRun(a1 int, black bool) {
+	if a1 > dec.columns {
+		a1 = dec.columns
+	}
+
+	n := len(dec.cur)
+
+	switch {
+	case n > 0 && a1 <= dec.cur[n-1], n == 0 && a1 <= 0:
+	case (n%2 == 1) == black:
+		dec.cur = append(dec.cur, a1)
+	case n == 0:
+		// The row starts with the black run.
+		dec.cur = append(dec.cur, 0, a1)
+	default:
+		dec.cur[n-1] = a1
+	}
+}
+
+// readRun reads the makeup codes and the terminating code of a run.
+func (dec *ccittDecoder) readRun(black bool) (int, error) {
+	table := ccittWhiteTable
+	if black {
+		table = ccittBlackTable
+	}
+
+	const maxTerminating = 63
+
+	run := 0
+
+	for {
+		v, err := dec.br.readCode(table)
+		if err != nil {
+			return 0, err
+		}
+
+		if run += v; run > dec.columns {
+			run = dec.columns
+		}
+
+		if v <= maxTerminating {
+			return run, nil
+		}
+	}
+}
+
+// decode1D decodes the row of the Modified Huffman coding.
+func (dec *ccittDecoder) decode1D() error {
+	for a0, black := 0, false; a0 < dec.columns; black = !black {
+		run, err := dec.readRun(black)
+		if err != nil {
+			return err
+		}
+
+		a0 += run
+		dec.addRun(a0, black)
+	}
+
+	return nil
+}
+
+// decode2D decodes the row of the two-dimensional coding, which
+// refers to the changing elements of the previous row.
+func (dec *ccittDecoder) decode2D() error {
+	dec.refPos = 0
+
+	for a0, black := -1, false; a0 < dec.columns; {
+		mode, err := dec.br.readCode(ccittModeTable)
+		if err != nil {
+			return err
+		}
+
+		b1, b2 := dec.findB(a0, black)
+
+		switch mode {
+		case ccittModePass:
+			dec.addRun(b2, black)
+			a0 = b2
+		case ccittModeHorizontal:
+			if a0 < 0 {
+				a0 = 0
+			}
+
+			run1, err := dec.readRun(black)
+			if err != nil {
+				return err
+			}
+
+			run2, err := dec.readRun(!black)
+			if err != nil {
+				return err
+			}
+
+			dec.addRun(a0+run1, black)
+			dec.addRun(a0+run1+run2, !black)
+			a0 += run1 + run2
+		case ccittModeExtension:
+			return fmt.Errorf("%w: CCITT uncompressed mode", ErrUnsupportedFilter)
+		default:
+			a1 := b1 + mode - ccittModeV0
+			if a1 < 0 || a1 < a0 || a1 > dec.columns {
+				return fmt.Errorf("%w: CCITT vertical mode at %d", ErrCorruptData, a1)
+			}
+
+			dec.addRun(a1, black)
+			a0, black = a1, !black
+		}
+	}
+
+	return nil
+}
+
+// findB returns b1, the first changing element of the reference line
+// to the right of a0 and of the opposite color, and b2, the next one.
+func (dec *ccittDecoder) findB(a0 int, black bool) (b1, b2 int) {
+	for dec.refPos < len(dec.ref) && dec.ref[dec.refPos] <= a0 {
+		dec.refPos++
+	}
+
+	// The even runs are white, so they end at the change to black.
+	i := dec.refPos
+	if (i%2 == 1) != black {
+		i++
+	}
+
+	b1, b2 = dec.columns, dec.columns
+
+	if i < len(dec.ref) {
+		b1 = dec.ref[i]
+	}
+
+	if i+1 < len(dec.ref) {
+		b2 = dec.ref[i+1]
+	}
+
+	return b1, b2
+}
+
+// pack writes the runs of the coding line into the row of bits.
+func (dec *ccittDecoder) pack() {
+	var white, black byte = 0xFF, 0
+
+	if dec.blackIs1 {
+		white, black = black, white
+	}
+
+	for i := range dec.line {
+		dec.line[i] = white
+	}
+
+	// The bits after the last column are zero.
+	if pad := dec.columns % 8; pad != 0 {
+		dec.line[len(dec.line)-1] &= 0xFF << (8 - pad)
+	}
+
+	for i := 1; i < len(dec.cur); i += 2 {
+		for x := dec.cur[i-1]; x < dec.cur[i]; x++ {
+			mask := byte(0x80) >> (x % 8)
+			dec.line[x/8] = dec.line[x/8]&^mask | black&mask
+		}
+	}
+}
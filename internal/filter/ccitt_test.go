@@ -0,0 +1,157 @@
+package filter_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/filter"
+)
+
+// packBits packs the string of '0' and '1' into the bytes, the last
+// byte is padded with zero bits. The spaces are ignored.
+func packBits(t *testing.T, bits string) []byte {
+	t.Helper()
+
+	bits = strings.ReplaceAll(bits, " ", "")
+	data := make([]byte, (len(bits)+7)/8)
+
+	for i, c := range bits {
+		switch c {
+		case '0':
+		case '1':
+			data[i/8] |= 0x80 >> (i % 8)
+		default:
+			t.Fatalf("invalid bit %q", c)
+		}
+	}
+
+	return data
+}
+
+func TestCCITTFaxDecode(t *testing.T) {
+	t.Parallel()
+
+	const (
+		eol  = "000000000001 "
+		eofb = eol + eol
+		rtc  = eol + eol + eol + eol + eol + eol
+		// The rows are WWBBBWWW and WWWBBBWW.
+		rows1D = "0111 10 1000 " + "1000 10 0111 "
+	)
+
+	tests := []struct {
+		name    string
+		params  params
+		bits    string
+		want    []byte
+		wantErr error
+	}{
+		{name: "K=0", params: params{"Columns": 8}, bits: rows1D, want: []byte{0xC7, 0xE3}},
+		{
+			name:   "K=0 BlackIs1",
+			params: params{"Columns": 8, "BlackIs1": 1},
+			bits:   rows1D,
+			want:   []byte{0x38, 0x1C},
+		},
+		{
+			name:   "K=0 RTC",
+			params: params{"Columns": 8, "EndOfLine": 1},
+			bits:   eol + "0111 10 1000 " + eol + "1000 10 0111 " + rtc + "1111",
+			want:   []byte{0xC7, 0xE3},
+		},
+		{
+			name:   "K=0 makeup codes",
+			params: params{"Columns": 100},
+			// The white run of 64+6 pixels and the black run of 30 pixels.
+			bits: "11011 1110 000001101000",
+			want: []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFC, 0, 0, 0, 0},
+		},
+		{
+			name:   "K<0",
+			params: params{"K": -1, "Columns": 8},
+			// Horizontal, V0 | V0, V0, V0 | VR1, VR1, V0.
+			bits: "001 0111 10 1 " + "1 1 1 " + "011 011 1 " + eofb + "1111",
+			want: []byte{0xC7, 0xC7, 0xE3},
+		},
+		{
+			name:   "K<0 pass",
+			params: params{"K": -1, "Columns": 8},
+			// Horizontal, V0 | Pass, VL2, V0.
+			bits: "001 0111 10 1 " + "0001 000010 1",
+			want: []byte{0xC7, 0xFC},
+		},
+		{
+			name:   "K<0 EncodedByteAlign",
+			params: params{"K": -1, "Columns": 8, "EncodedByteAlign": 1},
+			bits:   "00101111 01000000 " + "11100000 " + eofb,
+			want:   []byte{0xC7, 0xC7},
+		},
+		{
+			name:   "K>0",
+			params: params{"K": 2, "Columns": 8},
+			bits:   eol + "1 0111 10 1000 " + eol + "0 011 011 1 " + strings.Repeat(eol+"1 ", 6),
+			want:   []byte{0xC7, 0xE3},
+		},
+		{
+			name:   "Rows",
+			params: params{"Columns": 8, "Rows": 1, "EndOfBlock": 0},
+			bits:   rows1D,
+			want:   []byte{0xC7},
+		},
+		{
+			name:   "EndOfBlock overrides Rows",
+			params: params{"Columns": 8, "Rows": 1},
+			bits:   rows1D,
+			want:   []byte{0xC7, 0xE3},
+		},
+		{
+			name:   "truncated",
+			params: params{"Columns": 8},
+			bits:   "0111 10",
+			want:   []byte{0xC7},
+		},
+		{
+			name:   "DamagedRowsBeforeError",
+			params: params{"Columns": 8, "EndOfLine": 1, "DamagedRowsBeforeError": 1},
+			bits:   eol + "0111 10 1000 " + eol + "0000000011111 " + eol + "1000 10 0111",
+			want:   []byte{0xC7, 0xC7, 0xE3},
+		},
+		{
+			name:    "damaged row",
+			params:  params{"Columns": 8, "EndOfLine": 1},
+			bits:    eol + "0111 10 1000 " + eol + "0000000011111 " + eol + "1000 10 0111",
+			wantErr: filter.ErrCorruptData,
+		},
+		{name: "invalid Columns", params: params{"Columns": 0}, wantErr: filter.ErrCorruptData},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := filter.NewReader(bytes.NewReader(packBits(t, tt.bits)), filter.TypeCCITTFaxDecode, tt.params)
+			if err == nil {
+				var got []byte
+
+				got, err = io.ReadAll(r)
+				if tt.wantErr == nil {
+					assert.Equal(t, tt.want, got)
+				}
+			}
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+var (
+	ErrUnsupportedFilter = errors.New("unsupported filter")
+	ErrCorruptData       = errors.New("corrupt filter data")
+)
+
+// Type is the stream filter (see ISO 32000-2:2020, 7.4).
+type Type uint8
+
+const (
+	TypeNone Type = iota
+	TypeASCIIHexDecode
+	TypeASCII85Decode
+	TypeLZWDecode
+	TypeFlateDecode
+	TypeRunLengthDecode
+	TypeCCITTFaxDecode
+	TypeJBIG2Decode
+	TypeDCTDecode
+	TypeJPXDecode
+	TypeCrypt
+)
+
+// nolint:gochecknoglobals
+var typeNames = [...]string{
+	TypeNone:            "",
+	TypeASCIIHexDecode:  "ASCIIHexDecode",
+	TypeASCII85Decode:   "ASCII85Decode",
+	TypeLZWDecode:       "LZWDecode",
+	TypeFlateDecode:     "FlateDecode",
+	TypeRunLengthDecode: "RunLengthDecode",
+	TypeCCITTFaxDecode:  "CCITTFaxDecode",
+	TypeJBIG2Decode:     "JBIG2Decode",
+	TypeDCTDecode:       "DCTDecode",
+	TypeJPXDecode:       "JPXDecode",
+	TypeCrypt:           "Crypt",
+}
+
+// abbreviations are the filter names of the inline images
+// (see ISO 32000-2:2020, Table 92).
+//
+// nolint:gochecknoglobals
+var abbreviations = map[string]Type{
+	"AHx": TypeASCIIHexDecode,
+	"A85": TypeASCII85Decode,
+	"LZW": TypeLZWDecode,
+	"Fl":  TypeFlateDecode,
+	"RL":  TypeRunLengthDecode,
+	"CCF": TypeCCITTFaxDecode,
+	"DCT": TypeDCTDecode,
+}
+
+// ParseType returns the filter of the /Filter name,
+// which may be abbreviated as in the inline images.
+func ParseType(name string) (Type, error) {
+	for t, typeName := range typeNames {
+		if t != int(TypeNone) && typeName == name {
+			return Type(t), nil
+		}
+	}
+
+	if t, ok := abbreviations[name]; ok {
+		return t, nil
+	}
+
+	return TypeNone, fmt.Errorf("%w: /%s", ErrUnsupportedFilter, name)
+}
+
+// String returns the /Filter name.
+func (t Type) String() string {
+	if int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+
+	return fmt.Sprintf("Type(%d)", uint8(t))
+}
+
+// IsMedia returns true for the image compression filters. Their data
+// may be passed through undecoded, see NewUnwrapReader.
+func (t Type) IsMedia() bool {
+	return t == TypeDCTDecode || t == TypeJPXDecode || t == TypeJBIG2Decode
+}
+
+// Params are the filter parameters, i.e. the /DecodeParms dictionary
+// of the filter. The nil Params means all the default values.
+type Params interface {
+	// Int returns the integer value of the key or defval.
+	Int(key string, defval int64) int64
+	// Bool returns the boolean value of the key or defval.
+	Bool(key string, defval bool) bool
+}
+
+func paramInt(params Params, key string, defval int64) int64 {
+	if params == nil {
+		return defval
+	}
+
+	return params.Int(key, defval)
+}
+
+func paramBool(params Params, key string, defval bool) bool {
+	if params == nil {
+		return defval
+	}
+
+	return params.Bool(key, defval)
+}
+
+// StreamParams are the filter parameters with the streams, e.g.
+// /JBIG2Globals of the JBIG2 filter.
+type StreamParams interface {
+	Params
+	// Stream returns the decoded data of the stream of the key or nil.
+	Stream(key string) []byte
+}
+
+func paramStream(params Params, key string) []byte {
+	if sp, ok := params.(StreamParams); ok {
+		return sp.Stream(key)
+	}
+
+	return nil
+}
+
+// Decoder creates the reader that decodes the data read from r.
+type Decoder func(r io.Reader, params Params) (io.Reader, error)
+
+// Encoder creates the writer that encodes the data written to it into
+// w. Close flushes the encoded data, but it does not close w.
+type Encoder func(w io.Writer, params Params) (io.WriteCloser, error)
+
+type registry struct {
+	mu       sync.RWMutex
+	decoders map[Type]Decoder
+	encoders map[Type]Encoder
+}
+
+// filters is the registry of the filters. There are no decoders of
+// the DCT and JPX filters, their data is passed through as is. The
+// CCITT fax and JBIG2 filters can only be decoded. The Crypt filter
+// is applied by the security handler, so it is the identity filter
+// here.
+//
+// nolint:gochecknoglobals
+var filters = &registry{
+	decoders: map[Type]Decoder{
+		TypeASCIIHexDecode:  newASCIIHexDecoder,
+		TypeASCII85Decode:   newASCII85Decoder,
+		TypeLZWDecode:       newLZWDecoder,
+		TypeFlateDecode:     newFlateDecoder,
+		TypeRunLengthDecode: newRunLengthDecoder,
+		TypeCCITTFaxDecode:  newCCITTFaxDecoder,
+		TypeJBIG2Decode:     newJBIG2Decoder,
+		TypeCrypt:           newIdentityDecoder,
+	},
+	encoders: map[Type]Encoder{
+		TypeASCIIHexDecode:  newASCIIHexEncoder,
+		TypeASCII85Decode:   newASCII85Encoder,
+		TypeLZWDecode:       newLZWEncoder,
+		TypeFlateDecode:     newFlateEncoder,
+		TypeRunLengthDecode: newRunLengthEncoder,
+		TypeCrypt:           newIdentityEncoder,
+	},
+}
+
+// Register sets the decoder and the encoder of the filter,
+// either may be nil to keep the registered one.
+func Register(t Type, dec Decoder, enc Encoder) {
+	filters.mu.Lock()
+	defer filters.mu.Unlock()
+
+	if dec != nil {
+		filters.decoders[t] = dec
+	}
+
+	if enc != nil {
+		filters.encoders[t] = enc
+	}
+}
+
+// NewReader returns the reader that decodes the data of the filter t.
+func NewReader(r io.Reader, t Type, params Params) (io.Reader, error) {
+	filters.mu.RLock()
+	dec, ok := filters.decoders[t]
+	filters.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("new reader: %w: %s", ErrUnsupportedFilter, t)
+	}
+
+	dr, err := dec(r, params)
+	if err != nil {
+		return nil, fmt.Errorf("new %s reader: %w", t, err)
+	}
+
+	return dr, nil
+}
+
+// NewWriter returns the writer that encodes the data with the filter t.
+func NewWriter(w io.Writer, t Type, params Params) (io.WriteCloser, error) {
+	filters.mu.RLock()
+	enc, ok := filters.encoders[t]
+	filters.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("new writer: %w: %s", ErrUnsupportedFilter, t)
+	}
+
+	ew, err := enc(w, params)
+	if err != nil {
+		return nil, fmt.Errorf("new %s writer: %w", t, err)
+	}
+
+	return ew, nil
+}
+
+// NewChainReader returns the reader that decodes the data of the
+// chained filters, the first filter is applied to r first. The
+// params are matched to the filters by index and may be shorter.
+func NewChainReader(r io.Reader, types []Type, params []Params) (io.Reader, error) {
+	for i, t := range types {
+		var err error
+
+		if r, err = NewReader(r, t, paramsAt(params, i)); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// NewUnwrapReader decodes the chained filters up to the first media
+// filter. It returns the media filters left, whose data is passed
+// through undecoded, e.g. the JPEG image of the DCT filter.
+func NewUnwrapReader(r io.Reader, types []Type, params []Params) (io.Reader, []Type, error) {
+	n := len(types)
+
+	for i, t := range types {
+		if t.IsMedia() {
+			n = i
+
+			break
+		}
+	}
+
+	r, err := NewChainReader(r, types[:n], params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r, types[n:], nil
+}
+
+type chainWriter struct {
+	// writers are the encoders of the filters in
+	// order, the data is written to the last one.
+	writers []io.WriteCloser
+}
+
+// NewChainWriter returns the writer that encodes the data, so it is
+// decoded by NewChainReader with the same filters. Close flushes all
+// the encoders, but it does not close w.
+func NewChainWriter(w io.Writer, types []Type, params []Params) (io.WriteCloser, error) {
+	if len(types) == 0 {
+		return nopWriteCloser{w}, nil
+	}
+
+	cw := &chainWriter{writers: make([]io.WriteCloser, 0, len(types))}
+
+	for i, t := range types {
+		ew, err := NewWriter(w, t, paramsAt(params, i))
+		if err != nil {
+			return nil, err
+		}
+
+		cw.writers = append(cw.writers, ew)
+		w = ew
+	}
+
+	return cw, nil
+}
+
+func (cw *chainWriter) Write(p []byte) (int, error) {
+	return cw.writers[len(cw.writers)-1].Write(p)
+}
+
+func (cw *chainWriter) Close() error {
+	for i := len(cw.writers) - 1; i >= 0; i-- {
+		if err := cw.writers[i].Close(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func paramsAt(params []Params, i int) Params {
+	if i < len(params) {
+		return params[i]
+	}
+
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func newIdentityDecoder(r io.Reader, _ Params) (io.Reader, error) { return r, nil }
+
+func newIdentityEncoder(w io.Writer, _ Params) (io.WriteCloser, error) {
+	return nopWriteCloser{w}, nil
+}
@@ -0,0 +1,247 @@
+package filter_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/filter"
+)
+
+type params map[string]int64
+
+func (p params) Int(key string, defval int64) int64 {
+	if v, ok := p[key]; ok {
+		return v
+	}
+
+	return defval
+}
+
+func (p params) Bool(key string, defval bool) bool {
+	if v, ok := p[key]; ok {
+		return v != 0
+	}
+
+	return defval
+}
+
+func testData(t *testing.T) map[string][]byte {
+	t.Helper()
+
+	const size = 200_000
+
+	rnd := rand.New(rand.NewSource(1)) // nolint:gosec
+
+	random := make([]byte, size)
+	rnd.Read(random)
+
+	// The text-like data with the repeated words and runs.
+	words := []string{"stream", "endstream", "obj", "0 0 612 792", "BT", "ET", "\x00\x00\x00\x00\x00"}
+
+	var text bytes.Buffer
+	for text.Len() < size {
+		text.WriteString(words[rnd.Intn(len(words))])
+		text.WriteByte(byte(rnd.Intn(4)))
+	}
+
+	return map[string][]byte{
+		"empty":  {},
+		"byte":   {'x'},
+		"text":   text.Bytes(),
+		"random": random,
+		"runs":   bytes.Repeat([]byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaab"), 100), // nolint:lll
+	}
+}
+
+func TestFilterRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		filters []filter.Type
+		params  []filter.Params
+	}{
+		{name: "ASCIIHex", filters: []filter.Type{filter.TypeASCIIHexDecode}},
+		{name: "ASCII85", filters: []filter.Type{filter.TypeASCII85Decode}},
+		{name: "LZW", filters: []filter.Type{filter.TypeLZWDecode}},
+		{name: "LZW no early change", filters: []filter.Type{filter.TypeLZWDecode}, params: []filter.Params{params{"EarlyChange": 0}}},
+		{name: "Flate", filters: []filter.Type{filter.TypeFlateDecode}},
+		{
+			name:    "Flate PNG predictor",
+			filters: []filter.Type{filter.TypeFlateDecode},
+			params:  []filter.Params{params{"Predictor": 15, "Colors": 3, "Columns": 10}},
+		},
+		{
+			name:    "LZW TIFF predictor",
+			filters: []filter.Type{filter.TypeLZWDecode},
+			params:  []filter.Params{params{"Predictor": 2, "BitsPerComponent": 4, "Columns": 9}},
+		},
+		{name: "RunLength", filters: []filter.Type{filter.TypeRunLengthDecode}},
+		{name: "Crypt", filters: []filter.Type{filter.TypeCrypt}},
+		{name: "no filters"},
+		{
+			name:    "chain",
+			filters: []filter.Type{filter.TypeASCII85Decode, filter.TypeFlateDecode, filter.TypeRunLengthDecode},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		for dataName, data := range testData(t) {
+			data := data
+
+			t.Run(tt.name+"/"+dataName, func(t *testing.T) {
+				t.Parallel()
+
+				var encoded bytes.Buffer
+
+				w, err := filter.NewChainWriter(&encoded, tt.filters, tt.params)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				// Small writes split the runs and the LZW strings.
+				for rest := data; len(rest) > 0; {
+					n := 1 + len(rest)%97
+					if n > len(rest) {
+						n = len(rest)
+					}
+
+					_, err = w.Write(rest[:n])
+					assert.NoError(t, err)
+
+					rest = rest[n:]
+				}
+
+				assert.NoError(t, w.Close())
+
+				r, err := filter.NewChainReader(iotest.OneByteReader(&encoded), tt.filters, tt.params)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				decoded, err := io.ReadAll(r)
+				assert.NoError(t, err)
+				assert.Equal(t, data, decoded)
+			})
+		}
+	}
+}
+
+func TestFilterDecode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		filter  filter.Type
+		params  filter.Params
+		encoded string
+		want    string
+		wantErr error
+	}{
+		{name: "ASCIIHex", filter: filter.TypeASCIIHexDecode, encoded: "48 65\n6c6C 6f>garbage", want: "Hello"},
+		{name: "ASCIIHex odd digits", filter: filter.TypeASCIIHexDecode, encoded: "4865 7>", want: "Hep"},
+		{name: "ASCIIHex no EOD", filter: filter.TypeASCIIHexDecode, encoded: "4865", want: "He"},
+		{name: "ASCIIHex invalid", filter: filter.TypeASCIIHexDecode, encoded: "48x5>", wantErr: filter.ErrCorruptData},
+		{name: "ASCII85", filter: filter.TypeASCII85Decode, encoded: "87cURD_*#4\nDfTZ)+T~>", want: "Hello, World!"},
+		{name: "ASCII85 prefix", filter: filter.TypeASCII85Decode, encoded: "<~87cURD_*#4DfTZ)+T~>", want: "Hello, World!"},
+		{name: "ASCII85 zero", filter: filter.TypeASCII85Decode, encoded: "z!!~>", want: "\x00\x00\x00\x00\x00"},
+		{name: "ASCII85 invalid", filter: filter.TypeASCII85Decode, encoded: "87cU{~>", wantErr: filter.ErrCorruptData},
+		{
+			// The example of ISO 32000-2:2020, 7.4.4.2.
+			name:    "LZW",
+			filter:  filter.TypeLZWDecode,
+			encoded: "\x80\x0B\x60\x50\x22\x0C\x0C\x85\x01",
+			want:    "-----A---B",
+		},
+		{name: "LZW invalid code", filter: filter.TypeLZWDecode, encoded: "\x80\x7F\xF0", wantErr: filter.ErrCorruptData},
+		{name: "RunLength", filter: filter.TypeRunLengthDecode, encoded: "\x02abc\xFDx\x00d\x80ignored", want: "abcxxxxd"},
+		{name: "RunLength no EOD", filter: filter.TypeRunLengthDecode, encoded: "\xFEz", want: "zzz"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := filter.NewReader(bytes.NewBufferString(tt.encoded), tt.filter, tt.params)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			got, err := io.ReadAll(r)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestFilterUnsupported(t *testing.T) {
+	t.Parallel()
+
+	for _, typ := range []filter.Type{filter.TypeDCTDecode, filter.TypeJPXDecode, filter.Type(100)} {
+		_, err := filter.NewReader(bytes.NewReader(nil), typ, nil)
+		assert.ErrorIs(t, err, filter.ErrUnsupportedFilter, typ)
+
+		_, err = filter.NewWriter(io.Discard, typ, nil)
+		assert.ErrorIs(t, err, filter.ErrUnsupportedFilter, typ)
+	}
+
+	_, err := filter.ParseType("UnknownDecode")
+	assert.ErrorIs(t, err, filter.ErrUnsupportedFilter)
+}
+
+func TestParseType(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"FlateDecode", "Fl"} {
+		typ, err := filter.ParseType(name)
+		assert.NoError(t, err)
+		assert.Equal(t, filter.TypeFlateDecode, typ)
+	}
+
+	assert.Equal(t, "JBIG2Decode", filter.TypeJBIG2Decode.String())
+}
+
+func TestNewUnwrapReader(t *testing.T) {
+	t.Parallel()
+
+	jpeg := []byte("\xFF\xD8\xFF\xE0 not really a JPEG \xFF\xD9")
+	filters := []filter.Type{filter.TypeASCIIHexDecode, filter.TypeDCTDecode}
+
+	var encoded bytes.Buffer
+
+	w, err := filter.NewWriter(&encoded, filter.TypeASCIIHexDecode, nil)
+	assert.NoError(t, err)
+
+	_, err = w.Write(jpeg)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	r, media, err := filter.NewUnwrapReader(&encoded, filters, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []filter.Type{filter.TypeDCTDecode}, media)
+
+	got, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, jpeg, got)
+
+	_, err = filter.NewChainReader(bytes.NewReader(nil), filters, nil)
+	assert.ErrorIs(t, err, filter.ErrUnsupportedFilter)
+}
@@ -0,0 +1,20 @@
+package filter
+
+import (
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+func newFlateDecoder(r io.Reader, params Params) (io.Reader, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptData, err)
+	}
+
+	return newPredictorDecoder(zr, params)
+}
+
+func newFlateEncoder(w io.Writer, params Params) (io.WriteCloser, error) {
+	return newPredictorEncoder(zlib.NewWriter(w), params)
+}
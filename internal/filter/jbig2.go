@@ -0,0 +1,797 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+)
+
+// The JBIG2 segment types (see ITU-T T.88, 7.3).
+const (
+	jbig2SymbolDictionary                  = 0
+	jbig2IntermediateTextRegion            = 4
+	jbig2ImmediateTextRegion               = 6
+	jbig2ImmediateLosslessTextRegion       = 7
+	jbig2PatternDictionary                 = 16
+	jbig2IntermediateHalftoneRegion        = 20
+	jbig2ImmediateHalftoneRegion           = 22
+	jbig2ImmediateLosslessHalftoneRegion   = 23
+	jbig2IntermediateGenericRegion         = 36
+	jbig2ImmediateGenericRegion            = 38
+	jbig2ImmediateLosslessGenericRegion    = 39
+	jbig2IntermediateRefinementRegion      = 40
+	jbig2ImmediateRefinementRegion         = 42
+	jbig2ImmediateLosslessRefinementRegion = 43
+	jbig2PageInformation                   = 48
+	jbig2EndOfPage                         = 49
+	jbig2EndOfStripe                       = 50
+	jbig2EndOfFile                         = 51
+	jbig2Profiles                          = 52
+	jbig2Tables                            = 53
+	jbig2Extension                         = 62
+)
+
+// The combination operators of the regions (see ITU-T T.88, 7.4.1.5).
+const (
+	jbig2OpOr = iota
+	jbig2OpAnd
+	jbig2OpXor
+	jbig2OpXnor
+	jbig2OpReplace
+)
+
+const (
+	// jbig2FileID starts the JBIG2 file, the embedded
+	// streams have no file header (see ITU-T T.88, D.4.1).
+	jbig2FileID = "\x97JB2\r\n\x1A\n"
+	// jbig2UnknownLength is the data length of the immediate generic
+	// region, which is found by its end marker (see ITU-T T.88, 7.2.7).
+	jbig2UnknownLength = 0xFFFFFFFF
+	// jbig2UnknownHeight is the height of the striped page, which
+	// grows with the stripes (see ITU-T T.88, 7.4.8.2).
+	jbig2UnknownHeight = 0xFFFFFFFF
+	// jbig2MaxPixels limits the bitmap size, so the
+	// corrupt data does not allocate too much memory.
+	jbig2MaxPixels = 1 << 28
+)
+
+// jbig2Bitmap is the bilevel image of the region or the symbol, it has
+// a byte per pixel, which is 1 for the black pixels.
+type jbig2Bitmap struct {
+	width  int
+	height int
+	pix    []byte
+}
+
+func newJBIG2Bitmap(width, height int) (*jbig2Bitmap, error) {
+	if width < 0 || height < 0 || (height > 0 && width > jbig2MaxPixels/height) {
+		return nil, fmt.Errorf("%w: JBIG2 bitmap %dx%d", ErrCorruptData, width, height)
+	}
+
+	return &jbig2Bitmap{width: width, height: height, pix: make([]byte, width*height)}, nil
+}
+
+// at returns the pixel, the pixels outside of the bitmap are white.
+func (bm *jbig2Bitmap) at(x, y int) byte {
+	if x < 0 || y < 0 || x >= bm.width || y >= bm.height {
+		return 0
+	}
+
+	return bm.pix[y*bm.width+x]
+}
+
+func (bm *jbig2Bitmap) fill(v byte) {
+	for i := range bm.pix {
+		bm.pix[i] = v
+	}
+}
+
+// grow extends the bitmap down to the height with the pixels v.
+func (bm *jbig2Bitmap) grow(height int, v byte) error {
+	if height <= bm.height {
+		return nil
+	}
+
+	if bm.width > 0 && height > jbig2MaxPixels/bm.width {
+		return fmt.Errorf("%w: JBIG2 bitmap %dx%d", ErrCorruptData, bm.width, height)
+	}
+
+	n := len(bm.pix)
+	bm.pix = append(bm.pix, make([]byte, (height-bm.height)*bm.width)...)
+	bm.height = height
+
+	for i := n; i < len(bm.pix); i++ {
+		bm.pix[i] = v
+	}
+
+	return nil
+}
+
+// sub returns the copy of the area of the bitmap.
+func (bm *jbig2Bitmap) sub(x, y, width, height int) (*jbig2Bitmap, error) {
+	sub, err := newJBIG2Bitmap(width, height)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.compose(bm, -x, -y, jbig2OpReplace)
+
+	return sub, nil
+}
+
+// compose combines the bitmap src placed at x, y into the bitmap.
+func (bm *jbig2Bitmap) compose(src *jbig2Bitmap, x, y, op int) {
+	x0, x1 := x, x+src.width
+	if x0 < 0 {
+		x0 = 0
+	}
+
+	if x1 > bm.width {
+		x1 = bm.width
+	}
+
+	for sy := 0; sy < src.height; sy++ {
+		dy := y + sy
+		if dy < 0 || dy >= bm.height || x0 >= x1 {
+			continue
+		}
+
+		dst := bm.pix[dy*bm.width+x0 : dy*bm.width+x1]
+		row := src.pix[sy*src.width+x0-x : sy*src.width+x1-x]
+
+		for i, s := range row {
+			switch op {
+			case jbig2OpOr:
+				dst[i] |= s
+			case jbig2OpAnd:
+				dst[i] &= s
+			case jbig2OpXor:
+				dst[i] ^= s
+			case jbig2OpXnor:
+				dst[i] = 1 ^ dst[i] ^ s
+			default:
+				dst[i] = s
+			}
+		}
+	}
+}
+
+// pack returns the rows of 1 bit per pixel. The PDF images have 0 for
+// the black pixels, so the pixels are inverted.
+func (bm *jbig2Bitmap) pack() []byte {
+	stride := (bm.width + 7) / 8
+	data := make([]byte, stride*bm.height)
+
+	for y := 0; y < bm.height; y++ {
+		row := bm.pix[y*bm.width : (y+1)*bm.width]
+
+		for x, v := range row {
+			if v == 0 {
+				data[y*stride+x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+
+	return data
+}
+
+// jbig2Reader reads the big-endian integers of the segment data. The
+// read error is sticky, the values read after it are zero.
+type jbig2Reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (rd *jbig2Reader) bytes(n int) []byte {
+	if rd.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > len(rd.data)-rd.pos {
+		rd.err = fmt.Errorf("%w: JBIG2 data is truncated", ErrCorruptData)
+
+		return nil
+	}
+
+	rd.pos += n
+
+	return rd.data[rd.pos-n : rd.pos]
+}
+
+func (rd *jbig2Reader) u8() int {
+	if b := rd.bytes(1); b != nil {
+		return int(b[0])
+	}
+
+	return 0
+}
+
+func (rd *jbig2Reader) u16() int {
+	if b := rd.bytes(2); b != nil {
+		return int(binary.BigEndian.Uint16(b))
+	}
+
+	return 0
+}
+
+func (rd *jbig2Reader) u32() uint32 {
+	if b := rd.bytes(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+
+	return 0
+}
+
+func (rd *jbig2Reader) i32() int {
+	return int(int32(rd.u32()))
+}
+
+// points reads the coordinates of the adaptive template pixels.
+func (rd *jbig2Reader) points(n int) []jbig2Point {
+	points := make([]jbig2Point, n)
+
+	for i := range points {
+		points[i].x = int(int8(rd.u8()))
+		points[i].y = int(int8(rd.u8()))
+	}
+
+	return points
+}
+
+// jbig2RegionInfo is the region segment information field
+// (see ITU-T T.88, 7.4.1).
+type jbig2RegionInfo struct {
+	width  int
+	height int
+	x      int
+	y      int
+	op     int
+}
+
+func (rd *jbig2Reader) regionInfo() jbig2RegionInfo {
+	info := jbig2RegionInfo{
+		width:  int(rd.u32()),
+		height: int(rd.u32()),
+		x:      int(rd.u32()),
+		y:      int(rd.u32()),
+	}
+
+	info.op = rd.u8() & 7
+
+	return info
+}
+
+// jbig2Segment is the segment (see ITU-T T.88, 7.2) with the results
+// used by the segments referring to it.
+type jbig2Segment struct {
+	number uint32
+	typ    int
+	refs   []uint32
+	data   []byte
+
+	symbols  []*jbig2Bitmap
+	patterns []*jbig2Bitmap
+	table    *jbig2HuffmanTable
+	// region is the bitmap of the intermediate region.
+	region *jbig2Bitmap
+	// gb and gr are the retained contexts of the symbol dictionary.
+	gb []byte
+	gr []byte
+}
+
+// jbig2Decoder decodes the JBIG2 page (see ISO 32000-2:2020, 7.4.7)
+// into the rows of 1 bit per pixel. The segments of /JBIG2Globals
+// precede the segments of the stream.
+type jbig2Decoder struct {
+	r       io.Reader
+	globals []byte
+
+	segments map[uint32]*jbig2Segment
+	page     *jbig2Bitmap
+	// striped is true if the page height is unknown.
+	striped   bool
+	defPixel  byte
+	endOfPage bool
+	endOfFile bool
+	decoded   bool
+	pending   []byte
+	err       error
+}
+
+func newJBIG2Decoder(r io.Reader, params Params) (io.Reader, error) {
+	return &jbig2Decoder{
+		r:        r,
+		globals:  paramStream(params, "JBIG2Globals"),
+		segments: make(map[uint32]*jbig2Segment),
+	}, nil
+}
+
+func (dec *jbig2Decoder) Read(p []byte) (int, error) {
+	if !dec.decoded {
+		dec.decoded = true
+		dec.err = dec.decode()
+	}
+
+	if len(dec.pending) == 0 {
+		if dec.err != nil {
+			return 0, dec.err
+		}
+
+		return 0, io.EOF
+	}
+
+	n := copy(p, dec.pending)
+	dec.pending = dec.pending[n:]
+
+	return n, nil
+}
+
+// decode decodes the whole page, the regions may be anywhere on it.
+func (dec *jbig2Decoder) decode() error {
+	data, err := io.ReadAll(dec.r)
+	if err != nil {
+		return err
+	}
+
+	if err := dec.decodeSegments(dec.globals); err != nil {
+		return fmt.Errorf("JBIG2 globals: %w", err)
+	}
+
+	if err := dec.decodeSegments(data); err != nil {
+		return err
+	}
+
+	if dec.page == nil {
+		return fmt.Errorf("%w: JBIG2 page information is missing", ErrCorruptData)
+	}
+
+	dec.pending = dec.page.pack()
+
+	return nil
+}
+
+// decodeSegments decodes the segments up to the end of the page.
+func (dec *jbig2Decoder) decodeSegments(data []byte) error {
+	rd := &jbig2Reader{data: data}
+
+	segments, err := dec.readSegments(rd)
+	if err != nil {
+		return err
+	}
+
+	for _, seg := range segments {
+		if dec.endOfPage || dec.endOfFile {
+			break
+		}
+
+		if err := dec.decodeSegment(seg); err != nil {
+			return fmt.Errorf("JBIG2 segment %d: %w", seg.number, err)
+		}
+
+		dec.segments[seg.number] = seg
+	}
+
+	return nil
+}
+
+// readSegments reads the segments of the embedded stream or the JBIG2
+// file. The random-access file has all the segment headers first, then
+// the data of the segments in the same order (see ITU-T T.88, 7.1).
+func (dec *jbig2Decoder) readSegments(rd *jbig2Reader) ([]*jbig2Segment, error) {
+	sequential := true
+
+	if bytes.HasPrefix(rd.data, []byte(jbig2FileID)) {
+		rd.pos = len(jbig2FileID)
+
+		const (
+			flagSequential   = 1
+			flagUnknownPages = 2
+		)
+
+		flags := rd.u8()
+		if flags&flagUnknownPages == 0 {
+			rd.u32()
+		}
+
+		sequential = flags&flagSequential != 0
+	}
+
+	var (
+		segments []*jbig2Segment
+		lengths  []uint32
+	)
+
+	for rd.pos < len(rd.data) {
+		seg, length := dec.readSegmentHeader(rd)
+		if rd.err != nil {
+			if len(segments) > 0 {
+				// The trailing bytes after the last segment are ignored.
+				log.Printf("JBIG2 data after segment %d: %v", segments[len(segments)-1].number, rd.err)
+
+				break
+			}
+
+			return nil, rd.err
+		}
+
+		if sequential {
+			if seg.data, rd.err = dec.readSegmentData(rd, seg, length); rd.err != nil {
+				return nil, rd.err
+			}
+		}
+
+		segments = append(segments, seg)
+		lengths = append(lengths, length)
+
+		if seg.typ == jbig2EndOfFile {
+			break
+		}
+	}
+
+	if !sequential {
+		for i, seg := range segments {
+			if seg.data = rd.bytes(int(lengths[i])); rd.err != nil {
+				return nil, rd.err
+			}
+		}
+	}
+
+	return segments, nil
+}
+
+// readSegmentHeader reads the segment header (see ITU-T T.88, 7.2),
+// it returns the segment and its data length.
+func (dec *jbig2Decoder) readSegmentHeader(rd *jbig2Reader) (*jbig2Segment, uint32) {
+	const (
+		flagPageAssociationSize = 0x40
+		maxShortRefs            = 4
+		longRefs                = 7
+	)
+
+	seg := &jbig2Segment{number: rd.u32()}
+	flags := rd.u8()
+	seg.typ = flags & 0x3F
+
+	count := rd.u8()
+	nRefs := count >> 5
+
+	switch {
+	case nRefs == longRefs:
+		rd.pos--
+		nRefs = int(rd.u32() & 0x1FFFFFFF)
+		// The retention flags of the segment and of the referred ones.
+		rd.bytes((nRefs + 8) / 8)
+	case nRefs > maxShortRefs:
+		rd.err = fmt.Errorf("%w: JBIG2 segment %d refers to %d segments", ErrCorruptData, seg.number, nRefs)
+
+		return seg, 0
+	}
+
+	if nRefs > len(rd.data)-rd.pos {
+		rd.err = fmt.Errorf("%w: JBIG2 data is truncated", ErrCorruptData)
+
+		return seg, 0
+	}
+
+	seg.refs = make([]uint32, nRefs)
+
+	for i := range seg.refs {
+		switch {
+		case seg.number <= 256:
+			seg.refs[i] = uint32(rd.u8())
+		case seg.number <= 65536:
+			seg.refs[i] = uint32(rd.u16())
+		default:
+			seg.refs[i] = rd.u32()
+		}
+	}
+
+	// The page association is not used, the stream has a single page.
+	if flags&flagPageAssociationSize != 0 {
+		rd.u32()
+	} else {
+		rd.u8()
+	}
+
+	return seg, rd.u32()
+}
+
+// readSegmentData reads the segment data. The length of the immediate
+// generic region may be unknown, then its data ends with the marker
+// followed by the row count.
+func (dec *jbig2Decoder) readSegmentData(rd *jbig2Reader, seg *jbig2Segment, length uint32) ([]byte, error) {
+	if length != jbig2UnknownLength {
+		data := rd.bytes(int(length))
+
+		return data, rd.err
+	}
+
+	if seg.typ != jbig2ImmediateGenericRegion {
+		return nil, fmt.Errorf("%w: JBIG2 segment %d has unknown length", ErrCorruptData, seg.number)
+	}
+
+	const (
+		headerLen = 18
+		flagMMR   = 1
+		rowsLen   = 4
+	)
+
+	marker := []byte{0xFF, 0xAC}
+	if start := rd.pos + headerLen - 1; start < len(rd.data) && rd.data[start]&flagMMR != 0 {
+		marker = []byte{0x00, 0x00}
+	}
+
+	if start := rd.pos + headerLen; start < len(rd.data) {
+		if end := bytes.Index(rd.data[start:], marker); end >= 0 && start+end+len(marker)+rowsLen <= len(rd.data) {
+			data := rd.bytes(end + headerLen + len(marker) + rowsLen)
+
+			return data, rd.err
+		}
+	}
+
+	return nil, fmt.Errorf("%w: JBIG2 segment %d has no end marker", ErrCorruptData, seg.number)
+}
+
+func (dec *jbig2Decoder) decodeSegment(seg *jbig2Segment) error {
+	switch seg.typ {
+	case jbig2SymbolDictionary:
+		return dec.decodeSymbolDictionary(seg)
+	case jbig2PatternDictionary:
+		return dec.decodePatternDictionary(seg)
+	case jbig2Tables:
+		table, err := parseJBIG2HuffmanTable(seg.data)
+		seg.table = table
+
+		return err
+	case jbig2PageInformation:
+		return dec.decodePageInformation(seg)
+	case jbig2EndOfPage:
+		dec.endOfPage = true
+	case jbig2EndOfFile:
+		dec.endOfFile = true
+	case jbig2EndOfStripe:
+		return dec.decodeEndOfStripe(seg)
+	case jbig2Profiles, jbig2Extension:
+	default:
+		return dec.decodeRegion(seg)
+	}
+
+	return nil
+}
+
+func (dec *jbig2Decoder) decodeRegion(seg *jbig2Segment) error {
+	var (
+		info jbig2RegionInfo
+		bm   *jbig2Bitmap
+		err  error
+	)
+
+	switch seg.typ {
+	case jbig2IntermediateTextRegion, jbig2ImmediateTextRegion, jbig2ImmediateLosslessTextRegion:
+		info, bm, err = dec.decodeTextRegion(seg)
+	case jbig2IntermediateHalftoneRegion, jbig2ImmediateHalftoneRegion, jbig2ImmediateLosslessHalftoneRegion:
+		info, bm, err = dec.decodeHalftoneRegion(seg)
+	case jbig2IntermediateGenericRegion, jbig2ImmediateGenericRegion, jbig2ImmediateLosslessGenericRegion:
+		info, bm, err = dec.decodeGenericRegion(seg)
+	case jbig2IntermediateRefinementRegion, jbig2ImmediateRefinementRegion, jbig2ImmediateLosslessRefinementRegion:
+		info, bm, err = dec.decodeRefinementRegion(seg)
+	default:
+		log.Printf("JBIG2 segment %d of type %d is ignored", seg.number, seg.typ)
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	switch seg.typ {
+	case jbig2IntermediateTextRegion, jbig2IntermediateHalftoneRegion,
+		jbig2IntermediateGenericRegion, jbig2IntermediateRefinementRegion:
+		seg.region = bm
+
+		return nil
+	}
+
+	return dec.composePage(info, bm)
+}
+
+// composePage combines the region into the page.
+func (dec *jbig2Decoder) composePage(info jbig2RegionInfo, bm *jbig2Bitmap) error {
+	if dec.page == nil {
+		return fmt.Errorf("%w: JBIG2 page information is missing", ErrCorruptData)
+	}
+
+	if dec.striped {
+		if err := dec.page.grow(info.y+bm.height, dec.defPixel); err != nil {
+			return err
+		}
+	}
+
+	dec.page.compose(bm, info.x, info.y, info.op)
+
+	return nil
+}
+
+// decodePageInformation creates the page (see ITU-T T.88, 7.4.8).
+func (dec *jbig2Decoder) decodePageInformation(seg *jbig2Segment) error {
+	if dec.page != nil {
+		log.Printf("JBIG2 segment %d: the page is decoded, other pages are ignored", seg.number)
+
+		dec.endOfPage = true
+
+		return nil
+	}
+
+	const flagDefaultPixel = 4
+
+	rd := &jbig2Reader{data: seg.data}
+	width := rd.u32()
+	height := rd.u32()
+	// The resolution is not used.
+	rd.bytes(8)
+	flags := rd.u8()
+	rd.u16()
+
+	if rd.err != nil {
+		return rd.err
+	}
+
+	if flags&flagDefaultPixel != 0 {
+		dec.defPixel = 1
+	}
+
+	if height == jbig2UnknownHeight {
+		dec.striped, height = true, 0
+	}
+
+	page, err := newJBIG2Bitmap(int(width), int(height))
+	if err != nil {
+		return err
+	}
+
+	page.fill(dec.defPixel)
+	dec.page = page
+
+	return nil
+}
+
+// decodeEndOfStripe extends the striped page to the end of the stripe.
+func (dec *jbig2Decoder) decodeEndOfStripe(seg *jbig2Segment) error {
+	rd := &jbig2Reader{data: seg.data}
+	end := rd.u32()
+
+	switch {
+	case rd.err != nil:
+		return rd.err
+	case dec.page == nil:
+		return fmt.Errorf("%w: JBIG2 page information is missing", ErrCorruptData)
+	case dec.striped:
+		return dec.page.grow(int(end)+1, dec.defPixel)
+	}
+
+	return nil
+}
+
+// referred returns the segments referred by the segment.
+func (dec *jbig2Decoder) referred(seg *jbig2Segment) ([]*jbig2Segment, error) {
+	refs := make([]*jbig2Segment, 0, len(seg.refs))
+
+	for _, number := range seg.refs {
+		ref, ok := dec.segments[number]
+		if !ok {
+			return nil, fmt.Errorf("%w: JBIG2 segment %d is missing", ErrCorruptData, number)
+		}
+
+		refs = append(refs, ref)
+	}
+
+	return refs, nil
+}
+
+// decodeGenericRegion decodes the generic region segment
+// (see ITU-T T.88, 7.4.6).
+func (dec *jbig2Decoder) decodeGenericRegion(seg *jbig2Segment) (jbig2RegionInfo, *jbig2Bitmap, error) {
+	const (
+		flagMMR         = 1
+		flagTPGDON      = 8
+		flagExtTemplate = 0x10
+	)
+
+	rd := &jbig2Reader{data: seg.data}
+	info := rd.regionInfo()
+	flags := rd.u8()
+
+	p := &jbig2GenericParams{
+		width:    info.width,
+		height:   info.height,
+		template: flags >> 1 & 3,
+		tpgdon:   flags&flagTPGDON != 0,
+	}
+
+	if flags&flagExtTemplate != 0 {
+		return info, nil, fmt.Errorf("%w: JBIG2 extended template", ErrUnsupportedFilter)
+	}
+
+	if flags&flagMMR == 0 {
+		n := 1
+		if p.template == 0 {
+			n = 4
+		}
+
+		p.at = rd.points(n)
+	}
+
+	if rd.err != nil {
+		return info, nil, rd.err
+	}
+
+	data := seg.data[rd.pos:]
+
+	if flags&flagMMR != 0 {
+		bm, err := newJBIG2Bitmap(info.width, info.height)
+		if err != nil {
+			return info, nil, err
+		}
+
+		return info, bm, newMMRDecoder(data).decodeMMR(bm)
+	}
+
+	bm, err := newJBIG2ArithDecoder(data).decodeGeneric(p)
+
+	return info, bm, err
+}
+
+// decodeRefinementRegion decodes the generic refinement region
+// segment (see ITU-T T.88, 7.4.7). It refines the intermediate region
+// it refers to or else the area of the page.
+func (dec *jbig2Decoder) decodeRefinementRegion(seg *jbig2Segment) (jbig2RegionInfo, *jbig2Bitmap, error) {
+	const flagTPGRON = 2
+
+	rd := &jbig2Reader{data: seg.data}
+	info := rd.regionInfo()
+	flags := rd.u8()
+
+	p := &jbig2RefinementParams{
+		width:    info.width,
+		height:   info.height,
+		template: flags & 1,
+		tpgron:   flags&flagTPGRON != 0,
+	}
+
+	if p.template == 0 {
+		p.at = rd.points(2)
+	}
+
+	if rd.err != nil {
+		return info, nil, rd.err
+	}
+
+	refs, err := dec.referred(seg)
+	if err != nil {
+		return info, nil, err
+	}
+
+	for _, ref := range refs {
+		if ref.region != nil {
+			p.ref = ref.region
+		}
+	}
+
+	if p.ref == nil {
+		if dec.page == nil {
+			return info, nil, fmt.Errorf("%w: JBIG2 page information is missing", ErrCorruptData)
+		}
+
+		if p.ref, err = dec.page.sub(info.x, info.y, info.width, info.height); err != nil {
+			return info, nil, err
+		}
+	}
+
+	bm, err := newJBIG2ArithDecoder(seg.data[rd.pos:]).decodeRefinement(p)
+
+	return info, bm, err
+}
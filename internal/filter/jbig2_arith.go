@@ -0,0 +1,531 @@
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// mqState is the probability estimation state of the MQ decoder.
+type mqState struct {
+	qe   uint32
+	nmps uint8
+	nlps uint8
+	// switchMPS is true if the LPS switches the MPS.
+	switchMPS bool
+}
+
+// mqStates is the Qe value and the probability estimation table
+// (see ITU-T T.88, Table E.1).
+//
+// nolint:gochecknoglobals
+var mqStates = [...]mqState{
+	{0x5601, 1, 1, true}, {0x3401, 2, 6, false}, {0x1801, 3, 9, false},
+	{0x0AC1, 4, 12, false}, {0x0521, 5, 29, false}, {0x0221, 38, 33, false},
+	{0x5601, 7, 6, true}, {0x5401, 8, 14, false}, {0x4801, 9, 14, false},
+	{0x3801, 10, 14, false}, {0x3001, 11, 17, false}, {0x2401, 12, 18, false},
+	{0x1C01, 13, 20, false}, {0x1601, 29, 21, false}, {0x5601, 15, 14, true},
+	{0x5401, 16, 14, false}, {0x5101, 17, 15, false}, {0x4801, 18, 16, false},
+	{0x3801, 19, 17, false}, {0x3401, 20, 18, false}, {0x3001, 21, 19, false},
+	{0x2801, 22, 19, false}, {0x2401, 23, 20, false}, {0x2201, 24, 21, false},
+	{0x1C01, 25, 22, false}, {0x1801, 26, 23, false}, {0x1601, 27, 24, false},
+	{0x1401, 28, 25, false}, {0x1201, 29, 26, false}, {0x1101, 30, 27, false},
+	{0x0AC1, 31, 28, false}, {0x09C1, 32, 29, false}, {0x08A1, 33, 30, false},
+	{0x0521, 34, 31, false}, {0x0441, 35, 32, false}, {0x02A1, 36, 33, false},
+	{0x0221, 37, 34, false}, {0x0141, 38, 35, false}, {0x0111, 39, 36, false},
+	{0x0085, 40, 37, false}, {0x0049, 41, 38, false}, {0x0025, 42, 39, false},
+	{0x0015, 43, 40, false}, {0x0009, 44, 41, false}, {0x0005, 45, 42, false},
+	{0x0001, 45, 43, false}, {0x5601, 46, 46, false},
+}
+
+// mqDecoder is the MQ arithmetic decoder (see ITU-T T.88, Annex E).
+// The context is a byte of the state index and the MPS bit.
+type mqDecoder struct {
+	data  []byte
+	pos   int
+	chigh uint32
+	clow  uint32
+	ct    int
+	a     uint32
+}
+
+func newMQDecoder(data []byte) *mqDecoder {
+	mq := &mqDecoder{data: data}
+
+	// INITDEC (see ITU-T T.88, E.3.5).
+	mq.chigh = mq.byteAt(0)
+	mq.byteIn()
+	mq.chigh = (mq.chigh<<7)&0xFFFF | (mq.clow>>9)&0x7F
+	mq.clow = (mq.clow << 7) & 0xFFFF
+	mq.ct -= 7
+	mq.a = 0x8000
+
+	return mq
+}
+
+// byteAt returns the data byte, the data is padded with 0xFF.
+func (mq *mqDecoder) byteAt(i int) uint32 {
+	if i < len(mq.data) {
+		return uint32(mq.data[i])
+	}
+
+	return 0xFF
+}
+
+// byteIn reads the next byte (see ITU-T T.88, E.3.4).
+func (mq *mqDecoder) byteIn() {
+	const markerMin = 0x8F
+
+	switch {
+	case mq.byteAt(mq.pos) != 0xFF:
+		mq.pos++
+		mq.clow += mq.byteAt(mq.pos) << 8
+		mq.ct = 8
+	case mq.byteAt(mq.pos+1) > markerMin:
+		// The marker code ends the data.
+		mq.clow += 0xFF00
+		mq.ct = 8
+	default:
+		mq.pos++
+		mq.clow += mq.byteAt(mq.pos) << 9
+		mq.ct = 7
+	}
+
+	if mq.clow > 0xFFFF {
+		mq.chigh += mq.clow >> 16
+		mq.clow &= 0xFFFF
+	}
+}
+
+// decode decodes the bit of the context cx[i] (see ITU-T T.88, E.3.2).
+func (mq *mqDecoder) decode(cx []byte, i int) int {
+	index, mps := int(cx[i]>>1), int(cx[i]&1)
+	state := &mqStates[index]
+	bit := mps
+
+	mq.a -= state.qe
+
+	if mq.chigh < state.qe {
+		// LPS_EXCHANGE
+		if mq.a < state.qe {
+			index = int(state.nmps)
+		} else {
+			bit = 1 ^ mps
+			index = int(state.nlps)
+		}
+
+		mq.a = state.qe
+	} else {
+		mq.chigh -= state.qe
+
+		if mq.a&0x8000 != 0 {
+			return mps
+		}
+
+		// MPS_EXCHANGE
+		if mq.a < state.qe {
+			bit = 1 ^ mps
+			index = int(state.nlps)
+		} else {
+			index = int(state.nmps)
+		}
+	}
+
+	if bit != mps && state.switchMPS {
+		mps = bit
+	}
+
+	// RENORMD
+	for mq.a&0x8000 == 0 {
+		if mq.ct == 0 {
+			mq.byteIn()
+		}
+
+		mq.a <<= 1
+		mq.chigh = (mq.chigh<<1)&0xFFFF | (mq.clow>>15)&1
+		mq.clow = (mq.clow << 1) & 0xFFFF
+		mq.ct--
+	}
+
+	cx[i] = byte(index<<1 | mps)
+
+	return bit
+}
+
+// jbig2IntContexts are the contexts of the integer decoding procedure.
+type jbig2IntContexts [512]byte
+
+// jbig2ArithDecoder is the arithmetic decoder with the contexts of the
+// decoding procedures of a segment. The Huffman coded segments use it
+// for the refinements, each coded as a separate chunk of data.
+type jbig2ArithDecoder struct {
+	mq *mqDecoder
+	gb []byte
+	gr []byte
+
+	iadh  jbig2IntContexts
+	iadw  jbig2IntContexts
+	iaex  jbig2IntContexts
+	iaai  jbig2IntContexts
+	iadt  jbig2IntContexts
+	iafs  jbig2IntContexts
+	iads  jbig2IntContexts
+	iait  jbig2IntContexts
+	iari  jbig2IntContexts
+	iardw jbig2IntContexts
+	iardh jbig2IntContexts
+	iardx jbig2IntContexts
+	iardy jbig2IntContexts
+	iaid  []byte
+}
+
+func newJBIG2ArithDecoder(data []byte) *jbig2ArithDecoder {
+	return &jbig2ArithDecoder{mq: newMQDecoder(data)}
+}
+
+// decodeInt decodes the integer (see ITU-T T.88, A.2),
+// ok is false for the out-of-band value.
+func (ad *jbig2ArithDecoder) decodeInt(cx *jbig2IntContexts) (v int, ok bool) {
+	prev := 1
+
+	readBits := func(n int) int {
+		v := 0
+
+		for i := 0; i < n; i++ {
+			bit := ad.mq.decode(cx[:], prev)
+
+			if prev < 256 {
+				prev = prev<<1 | bit
+			} else {
+				prev = (prev<<1|bit)&511 | 256
+			}
+
+			v = v<<1 | bit
+		}
+
+		return v
+	}
+
+	sign := readBits(1)
+
+	switch {
+	case readBits(1) == 0:
+		v = readBits(2)
+	case readBits(1) == 0:
+		v = readBits(4) + 4
+	case readBits(1) == 0:
+		v = readBits(6) + 20
+	case readBits(1) == 0:
+		v = readBits(8) + 84
+	case readBits(1) == 0:
+		v = readBits(12) + 340
+	default:
+		v = readBits(32) + 4436
+	}
+
+	if sign == 0 {
+		return v, true
+	}
+
+	return -v, v != 0
+}
+
+// decodeIAID decodes the symbol ID of codeLen bits (see ITU-T T.88, A.3).
+func (ad *jbig2ArithDecoder) decodeIAID(codeLen int) int {
+	if len(ad.iaid) != 1<<(codeLen+1) {
+		ad.iaid = make([]byte, 1<<(codeLen+1))
+	}
+
+	prev := 1
+	for i := 0; i < codeLen; i++ {
+		prev = prev<<1 | ad.mq.decode(ad.iaid, prev)
+	}
+
+	return prev - 1<<codeLen
+}
+
+// jbig2Point is the offset of the template pixel.
+type jbig2Point struct {
+	x int
+	y int
+}
+
+// jbig2GenericParams are the parameters of the generic region
+// decoding procedure (see ITU-T T.88, 6.2.2).
+type jbig2GenericParams struct {
+	width    int
+	height   int
+	template int
+	tpgdon   bool
+	// skip has the pixels that are not coded, if any.
+	skip *jbig2Bitmap
+	// at are the adaptive template pixels.
+	at []jbig2Point
+}
+
+// genericTemplate returns the template pixels, the first one is
+// the most significant bit of the context (see ITU-T T.88, 6.2.5.3).
+func genericTemplate(template int, at []jbig2Point) []jbig2Point {
+	switch template {
+	case 0:
+		return []jbig2Point{
+			at[3], {-1, -2}, {0, -2}, {1, -2}, at[2],
+			at[1], {-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1},
+			at[0], {-4, 0}, {-3, 0}, {-2, 0}, {-1, 0},
+		}
+	case 1:
+		return []jbig2Point{
+			{-1, -2}, {0, -2}, {1, -2}, {2, -2},
+			{-2, -1}, {-1, -1}, {0, -1}, {1, -1}, {2, -1},
+			at[0], {-3, 0}, {-2, 0}, {-1, 0},
+		}
+	case 2:
+		return []jbig2Point{
+			{-1, -2}, {0, -2}, {1, -2},
+			{-2, -1}, {-1, -1}, {0, -1}, {1, -1},
+			at[0], {-2, 0}, {-1, 0},
+		}
+	default:
+		return []jbig2Point{
+			{-3, -1}, {-2, -1}, {-1, -1}, {0, -1}, {1, -1},
+			at[0], {-4, 0}, {-3, 0}, {-2, 0}, {-1, 0},
+		}
+	}
+}
+
+// genericSLTP are the contexts of the typical prediction bit
+// of the templates (see ITU-T T.88, 6.2.5.7).
+//
+// nolint:gochecknoglobals
+var genericSLTP = [...]int{0x9B25, 0x0795, 0x00E5, 0x0195}
+
+// decodeGeneric decodes the arithmetic coded generic
+// region (see ITU-T T.88, 6.2.5).
+func (ad *jbig2ArithDecoder) decodeGeneric(p *jbig2GenericParams) (*jbig2Bitmap, error) {
+	bm, err := newJBIG2Bitmap(p.width, p.height)
+	if err != nil {
+		return nil, err
+	}
+
+	if ad.gb == nil {
+		ad.gb = make([]byte, 1<<16)
+	}
+
+	tmpl := genericTemplate(p.template, p.at)
+	offsets := make([]int, len(tmpl))
+
+	// The template is inside the bitmap for x in [left, right)
+	// and y in [top, bottom), the offsets are used there.
+	left, right, top, bottom := 0, bm.width, 0, bm.height
+
+	for i, pt := range tmpl {
+		offsets[i] = pt.y*bm.width + pt.x
+
+		if -pt.x > left {
+			left = -pt.x
+		}
+
+		if bm.width-pt.x < right {
+			right = bm.width - pt.x
+		}
+
+		if -pt.y > top {
+			top = -pt.y
+		}
+
+		if bm.height-pt.y < bottom {
+			bottom = bm.height - pt.y
+		}
+	}
+
+	ltp := 0
+
+	for y := 0; y < bm.height; y++ {
+		row := bm.pix[y*bm.width : (y+1)*bm.width]
+
+		if p.tpgdon {
+			if ltp ^= ad.mq.decode(ad.gb, genericSLTP[p.template]); ltp == 1 {
+				if y > 0 {
+					copy(row, bm.pix[(y-1)*bm.width:])
+				}
+
+				continue
+			}
+		}
+
+		for x := range row {
+			if p.skip != nil && p.skip.pix[y*bm.width+x] != 0 {
+				continue
+			}
+
+			cx := 0
+
+			if y >= top && y < bottom && x >= left && x < right {
+				i := y*bm.width + x
+				for _, off := range offsets {
+					cx = cx<<1 | int(bm.pix[i+off])
+				}
+			} else {
+				for _, pt := range tmpl {
+					cx = cx<<1 | int(bm.at(x+pt.x, y+pt.y))
+				}
+			}
+
+			row[x] = byte(ad.mq.decode(ad.gb, cx))
+		}
+	}
+
+	return bm, nil
+}
+
+// jbig2RefinementParams are the parameters of the generic refinement
+// region decoding procedure (see ITU-T T.88, 6.3.2).
+type jbig2RefinementParams struct {
+	width    int
+	height   int
+	template int
+	// ref is the reference bitmap placed at dx, dy.
+	ref    *jbig2Bitmap
+	dx     int
+	dy     int
+	tpgron bool
+	at     []jbig2Point
+}
+
+// refinementTemplate returns the template pixels of the refined and of
+// the reference bitmaps and the context of the typical prediction bit
+// (see ITU-T T.88, 6.3.5.3).
+func refinementTemplate(template int, at []jbig2Point) (coding, reference []jbig2Point, sltp int) {
+	if template == 0 {
+		coding = []jbig2Point{{0, -1}, {1, -1}, {-1, 0}, at[0]}
+		reference = []jbig2Point{{0, -1}, {1, -1}, {-1, 0}, {0, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}, at[1]}
+
+		return coding, reference, 0x0020
+	}
+
+	coding = []jbig2Point{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}}
+	reference = []jbig2Point{{0, -1}, {-1, 0}, {0, 0}, {1, 0}, {0, 1}, {1, 1}}
+
+	return coding, reference, 0x0008
+}
+
+// decodeRefinement decodes the generic refinement region
+// (see ITU-T T.88, 6.3.5).
+func (ad *jbig2ArithDecoder) decodeRefinement(p *jbig2RefinementParams) (*jbig2Bitmap, error) {
+	bm, err := newJBIG2Bitmap(p.width, p.height)
+	if err != nil {
+		return nil, err
+	}
+
+	if ad.gr == nil {
+		ad.gr = make([]byte, 1<<13)
+	}
+
+	coding, reference, sltp := refinementTemplate(p.template, p.at)
+	ltp := 0
+
+	for y := 0; y < bm.height; y++ {
+		if p.tpgron {
+			ltp ^= ad.mq.decode(ad.gr, sltp)
+		}
+
+		for x := 0; x < bm.width; x++ {
+			rx, ry := x-p.dx, y-p.dy
+
+			if ltp == 1 {
+				if v, ok := typicalPixel(p.ref, rx, ry); ok {
+					bm.pix[y*bm.width+x] = v
+
+					continue
+				}
+			}
+
+			cx := 0
+			for _, pt := range coding {
+				cx = cx<<1 | int(bm.at(x+pt.x, y+pt.y))
+			}
+
+			for _, pt := range reference {
+				cx = cx<<1 | int(p.ref.at(rx+pt.x, ry+pt.y))
+			}
+
+			bm.pix[y*bm.width+x] = byte(ad.mq.decode(ad.gr, cx))
+		}
+	}
+
+	return bm, nil
+}
+
+// typicalPixel returns the reference pixel, if its 3×3
+// neighbourhood has the same color (see ITU-T T.88, 6.3.5.6).
+func typicalPixel(ref *jbig2Bitmap, x, y int) (byte, bool) {
+	v := ref.at(x, y)
+
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if ref.at(x+dx, y+dy) != v {
+				return 0, false
+			}
+		}
+	}
+
+	return v, true
+}
+
+// newMMRDecoder returns the decoder of the MMR coded bitmaps
+// (see ITU-T T.88, 6.2.6), which are the Group 4 fax rows.
+func newMMRDecoder(data []byte) *ccittDecoder {
+	return &ccittDecoder{br: ccittBitReader{r: bytes.NewReader(data)}, k: -1, endOfBlock: true}
+}
+
+// decodeMMR decodes the rows of the bitmap. The rows missing at the
+// end of the data are white. The optional EOFB is skipped, so the next
+// bitmap starts on the byte boundary after it.
+func (dec *ccittDecoder) decodeMMR(bm *jbig2Bitmap) error {
+	if bm.width == 0 {
+		return nil
+	}
+
+	dec.columns = bm.width
+	dec.ref = dec.ref[:0]
+
+	for y := 0; y < bm.height; y++ {
+		if _, err := dec.startRow(); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return err
+		}
+
+		dec.cur = dec.cur[:0]
+
+		err := dec.decode2D()
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+
+		row := bm.pix[y*bm.width : (y+1)*bm.width]
+
+		// The odd runs are black.
+		for i := 1; i < len(dec.cur); i += 2 {
+			for x := dec.cur[i-1]; x < dec.cur[i]; x++ {
+				row[x] = 1
+			}
+		}
+
+		dec.ref, dec.cur = dec.cur, dec.ref
+
+		if err != nil {
+			break
+		}
+	}
+
+	if dec.br.peek(2*ccittEOLLen) == ccittEOL<<ccittEOLLen|ccittEOL {
+		dec.br.skip(2 * ccittEOLLen)
+	}
+
+	dec.br.align()
+
+	return nil
+}
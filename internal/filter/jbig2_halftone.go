@@ -0,0 +1,241 @@
+package filter
+
+import (
+	"fmt"
+)
+
+// decodePatternDictionary decodes the pattern dictionary segment
+// (see ITU-T T.88, 6.7 and 7.4.4).
+func (dec *jbig2Decoder) decodePatternDictionary(seg *jbig2Segment) error {
+	const flagMMR = 1
+
+	rd := &jbig2Reader{data: seg.data}
+	flags := rd.u8()
+	width := rd.u8()
+	height := rd.u8()
+	grayMax := rd.u32()
+
+	if rd.err != nil {
+		return rd.err
+	}
+
+	if width == 0 || height == 0 || grayMax >= jbig2MaxSymbols {
+		return fmt.Errorf("%w: JBIG2 %d patterns of %dx%d", ErrCorruptData, grayMax+1, width, height)
+	}
+
+	n := int(grayMax) + 1
+	data := seg.data[rd.pos:]
+
+	var (
+		bm  *jbig2Bitmap
+		err error
+	)
+
+	if flags&flagMMR != 0 {
+		if bm, err = newJBIG2Bitmap(n*width, height); err == nil {
+			err = newMMRDecoder(data).decodeMMR(bm)
+		}
+	} else {
+		bm, err = newJBIG2ArithDecoder(data).decodeGeneric(&jbig2GenericParams{
+			width:    n * width,
+			height:   height,
+			template: flags >> 1 & 3,
+			at:       []jbig2Point{{-width, 0}, {-3, -1}, {2, -2}, {-2, -2}},
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	seg.patterns = make([]*jbig2Bitmap, n)
+
+	for i := range seg.patterns {
+		if seg.patterns[i], err = bm.sub(i*width, 0, width, height); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeHalftoneRegion decodes the halftone region segment
+// (see ITU-T T.88, 6.6 and 7.4.5).
+func (dec *jbig2Decoder) decodeHalftoneRegion(seg *jbig2Segment) (jbig2RegionInfo, *jbig2Bitmap, error) {
+	const (
+		flagMMR      = 1
+		flagSkip     = 8
+		flagDefPixel = 0x80
+	)
+
+	rd := &jbig2Reader{data: seg.data}
+	info := rd.regionInfo()
+	flags := rd.u8()
+	g := &jbig2Grid{
+		width:  int(rd.u32()),
+		height: int(rd.u32()),
+		x:      rd.i32(),
+		y:      rd.i32(),
+		rx:     rd.u16(),
+		ry:     rd.u16(),
+	}
+
+	if rd.err != nil {
+		return info, nil, rd.err
+	}
+
+	refs, err := dec.referred(seg)
+	if err != nil {
+		return info, nil, err
+	}
+
+	var patterns []*jbig2Bitmap
+
+	for _, ref := range refs {
+		if ref.typ == jbig2PatternDictionary {
+			patterns = ref.patterns
+		}
+	}
+
+	if len(patterns) == 0 {
+		return info, nil, fmt.Errorf("%w: JBIG2 halftone region has no patterns", ErrCorruptData)
+	}
+
+	bm, err := newJBIG2Bitmap(info.width, info.height)
+	if err != nil {
+		return info, nil, err
+	}
+
+	if flags&flagDefPixel != 0 {
+		bm.fill(1)
+	}
+
+	var skip *jbig2Bitmap
+
+	if flags&flagSkip != 0 {
+		if skip, err = g.skip(bm, patterns[0]); err != nil {
+			return info, nil, err
+		}
+	}
+
+	gray, err := decodeJBIG2GrayScale(seg.data[rd.pos:], &jbig2GenericParams{
+		width:    g.width,
+		height:   g.height,
+		template: flags >> 1 & 3,
+		skip:     skip,
+	}, flags&flagMMR != 0, ceilLog2(len(patterns)))
+	if err != nil {
+		return info, nil, err
+	}
+
+	op := flags >> 4 & 7
+
+	for mg := 0; mg < g.height; mg++ {
+		for ng := 0; ng < g.width; ng++ {
+			v := gray[mg*g.width+ng]
+			if v >= len(patterns) {
+				v = len(patterns) - 1
+			}
+
+			x, y := g.at(mg, ng)
+			bm.compose(patterns[v], x, y, op)
+		}
+	}
+
+	return info, bm, nil
+}
+
+// jbig2Grid is the halftone grid, its origin and vector are in 1/256
+// of the pixel (see ITU-T T.88, 6.6.5.2).
+type jbig2Grid struct {
+	width  int
+	height int
+	x      int
+	y      int
+	rx     int
+	ry     int
+}
+
+// at returns the position of the grid cell.
+func (g *jbig2Grid) at(mg, ng int) (x, y int) {
+	return (g.x + mg*g.ry + ng*g.rx) >> 8, (g.y + mg*g.rx - ng*g.ry) >> 8
+}
+
+// skip returns the cells of the patterns placed outside
+// of the region (see ITU-T T.88, 6.6.5.1).
+func (g *jbig2Grid) skip(region, pattern *jbig2Bitmap) (*jbig2Bitmap, error) {
+	skip, err := newJBIG2Bitmap(g.width, g.height)
+	if err != nil {
+		return nil, err
+	}
+
+	for mg := 0; mg < g.height; mg++ {
+		for ng := 0; ng < g.width; ng++ {
+			x, y := g.at(mg, ng)
+			if x+pattern.width <= 0 || x >= region.width || y+pattern.height <= 0 || y >= region.height {
+				skip.pix[mg*g.width+ng] = 1
+			}
+		}
+	}
+
+	return skip, nil
+}
+
+// decodeJBIG2GrayScale decodes the gray-scale image of bpp bitplanes,
+// which are Gray coded (see ITU-T T.88, Annex C.5).
+func decodeJBIG2GrayScale(data []byte, p *jbig2GenericParams, mmr bool, bpp int) ([]int, error) {
+	// The pixels of the gray-scale image are the grid cells.
+	gray, err := newJBIG2Bitmap(p.width, p.height)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]int, len(gray.pix))
+
+	p.at = []jbig2Point{{3, -1}, {-3, -1}, {2, -2}, {-2, -2}}
+	if p.template >= 2 {
+		p.at[0].x = 2
+	}
+
+	var (
+		ad   *jbig2ArithDecoder
+		mmrd *ccittDecoder
+	)
+
+	if mmr {
+		mmrd = newMMRDecoder(data)
+	} else {
+		ad = newJBIG2ArithDecoder(data)
+	}
+
+	var prev *jbig2Bitmap
+
+	for j := bpp - 1; j >= 0; j-- {
+		var plane *jbig2Bitmap
+
+		if mmr {
+			if plane, err = newJBIG2Bitmap(p.width, p.height); err == nil {
+				err = mmrd.decodeMMR(plane)
+			}
+		} else {
+			plane, err = ad.decodeGeneric(p)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for i, v := range plane.pix {
+			if prev != nil {
+				v ^= prev.pix[i]
+				plane.pix[i] = v
+			}
+
+			values[i] |= int(v) << j
+		}
+
+		prev = plane
+	}
+
+	return values, nil
+}
@@ -0,0 +1,420 @@
+package filter
+
+import (
+	"fmt"
+)
+
+// jbig2BitReader reads the bits of the Huffman coded
+// data most significant first (see ITU-T T.88, 6.5.4.5).
+type jbig2BitReader struct {
+	data []byte
+	pos  int
+	bit  uint
+}
+
+func (br *jbig2BitReader) readBit() (int, error) {
+	if br.pos >= len(br.data) {
+		return 0, fmt.Errorf("%w: JBIG2 data is truncated", ErrCorruptData)
+	}
+
+	v := int(br.data[br.pos]>>(7-br.bit)) & 1
+
+	if br.bit++; br.bit == 8 {
+		br.bit = 0
+		br.pos++
+	}
+
+	return v, nil
+}
+
+// readBits reads the unsigned integer of n bits, n is up to 32.
+func (br *jbig2BitReader) readBits(n int) (int, error) {
+	v := 0
+
+	for i := 0; i < n; i++ {
+		bit, err := br.readBit()
+		if err != nil {
+			return 0, err
+		}
+
+		v = v<<1 | bit
+	}
+
+	return v, nil
+}
+
+// align skips the rest of the current byte.
+func (br *jbig2BitReader) align() {
+	if br.bit > 0 {
+		br.bit = 0
+		br.pos++
+	}
+}
+
+// bytes returns the next n bytes, the reader must be aligned.
+func (br *jbig2BitReader) bytes(n int) ([]byte, error) {
+	if n < 0 || n > len(br.data)-br.pos {
+		return nil, fmt.Errorf("%w: JBIG2 data is truncated", ErrCorruptData)
+	}
+
+	br.pos += n
+
+	return br.data[br.pos-n : br.pos], nil
+}
+
+// The kinds of the Huffman table lines (see ITU-T T.88, B.2).
+const (
+	jbig2LineNormal = iota
+	// jbig2LineLower is the lower range line, it codes
+	// the values below its range low down to -∞.
+	jbig2LineLower
+	// jbig2LineUpper is the upper range line, it codes
+	// the values from its range low up to +∞.
+	jbig2LineUpper
+	// jbig2LineOOB codes the out-of-band value.
+	jbig2LineOOB
+)
+
+// jbig2HuffmanLine is the line of the Huffman table, the code of the
+// value is its prefix followed by the offset of rangeLen bits.
+type jbig2HuffmanLine struct {
+	prefLen  int
+	rangeLen int
+	rangeLow int
+	kind     int
+}
+
+type jbig2HuffmanCode struct {
+	len  int
+	code int
+}
+
+// jbig2HuffmanTable is the Huffman table, the prefixes are assigned to
+// the lines in order (see ITU-T T.88, B.3).
+type jbig2HuffmanTable struct {
+	lines []jbig2HuffmanLine
+	// codes maps the prefixes to the line indexes.
+	codes map[jbig2HuffmanCode]int
+}
+
+// jbig2MaxPrefLen is the maximal length of the prefix.
+const jbig2MaxPrefLen = 32
+
+func newJBIG2HuffmanTable(lines []jbig2HuffmanLine) (*jbig2HuffmanTable, error) {
+	var count [jbig2MaxPrefLen + 1]int
+
+	for _, line := range lines {
+		if line.prefLen < 0 || line.prefLen > jbig2MaxPrefLen || line.rangeLen < 0 || line.rangeLen > 32 {
+			return nil, fmt.Errorf("%w: JBIG2 Huffman table line %v", ErrCorruptData, line)
+		}
+
+		count[line.prefLen]++
+	}
+
+	table := &jbig2HuffmanTable{lines: lines, codes: make(map[jbig2HuffmanCode]int, len(lines))}
+	count[0] = 0
+	first := 0
+
+	for n := 1; n <= jbig2MaxPrefLen; n++ {
+		first = (first + count[n-1]) << 1
+		code := first
+
+		for i, line := range lines {
+			if line.prefLen == n {
+				table.codes[jbig2HuffmanCode{n, code}] = i
+				code++
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// decode decodes the value, oob is true for the out-of-band value.
+func (t *jbig2HuffmanTable) decode(br *jbig2BitReader) (v int, oob bool, err error) {
+	code := 0
+
+	for n := 1; n <= jbig2MaxPrefLen; n++ {
+		bit, err := br.readBit()
+		if err != nil {
+			return 0, false, err
+		}
+
+		code = code<<1 | bit
+
+		i, ok := t.codes[jbig2HuffmanCode{n, code}]
+		if !ok {
+			continue
+		}
+
+		line := &t.lines[i]
+		if line.kind == jbig2LineOOB {
+			return 0, true, nil
+		}
+
+		offset, err := br.readBits(line.rangeLen)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if line.kind == jbig2LineLower {
+			return line.rangeLow - offset, false, nil
+		}
+
+		return line.rangeLow + offset, false, nil
+	}
+
+	return 0, false, fmt.Errorf("%w: JBIG2 Huffman code %b", ErrCorruptData, code)
+}
+
+// parseJBIG2HuffmanTable parses the table segment (see ITU-T T.88, B.2).
+func parseJBIG2HuffmanTable(data []byte) (*jbig2HuffmanTable, error) {
+	const flagOOB = 1
+
+	rd := &jbig2Reader{data: data}
+	flags := rd.u8()
+	low := rd.i32()
+	high := rd.i32()
+
+	if rd.err != nil {
+		return nil, rd.err
+	}
+
+	prefBits := flags>>1&7 + 1
+	rangeBits := flags>>4&7 + 1
+	br := &jbig2BitReader{data: data[rd.pos:]}
+
+	var lines []jbig2HuffmanLine
+
+	for cur := low; cur < high; {
+		prefLen, err := br.readBits(prefBits)
+		if err != nil {
+			return nil, err
+		}
+
+		rangeLen, err := br.readBits(rangeBits)
+		if err != nil {
+			return nil, err
+		}
+
+		if rangeLen > 32 {
+			return nil, fmt.Errorf("%w: JBIG2 Huffman range length %d", ErrCorruptData, rangeLen)
+		}
+
+		lines = append(lines, jbig2HuffmanLine{prefLen, rangeLen, cur, jbig2LineNormal})
+		cur += 1 << rangeLen
+	}
+
+	n := 2
+	if flags&flagOOB != 0 {
+		n++
+	}
+
+	prefLens := make([]int, n)
+
+	for i := range prefLens {
+		var err error
+
+		if prefLens[i], err = br.readBits(prefBits); err != nil {
+			return nil, err
+		}
+	}
+
+	lines = append(lines,
+		jbig2HuffmanLine{prefLens[0], 32, low - 1, jbig2LineLower},
+		jbig2HuffmanLine{prefLens[1], 32, high, jbig2LineUpper})
+
+	if flags&flagOOB != 0 {
+		lines = append(lines, jbig2HuffmanLine{prefLens[2], 0, 0, jbig2LineOOB})
+	}
+
+	return newJBIG2HuffmanTable(lines)
+}
+
+// jbig2StandardLines are the lines of the standard Huffman tables
+// B.1 to B.15 (see ITU-T T.88, B.5).
+//
+// nolint:gochecknoglobals
+var jbig2StandardLines = [...][]jbig2HuffmanLine{
+	1: {
+		{1, 4, 0, 0}, {2, 8, 16, 0}, {3, 16, 272, 0}, {3, 32, 65808, jbig2LineUpper},
+	},
+	2: {
+		{1, 0, 0, 0}, {2, 0, 1, 0}, {3, 0, 2, 0}, {4, 3, 3, 0}, {5, 6, 11, 0},
+		{6, 32, 75, jbig2LineUpper}, {6, 0, 0, jbig2LineOOB},
+	},
+	3: {
+		{8, 8, -256, 0}, {1, 0, 0, 0}, {2, 0, 1, 0}, {3, 0, 2, 0}, {4, 3, 3, 0}, {5, 6, 11, 0},
+		{8, 32, -257, jbig2LineLower}, {7, 32, 75, jbig2LineUpper}, {6, 0, 0, jbig2LineOOB},
+	},
+	4: {
+		{1, 0, 1, 0}, {2, 0, 2, 0}, {3, 0, 3, 0}, {4, 3, 4, 0}, {5, 6, 12, 0},
+		{5, 32, 76, jbig2LineUpper},
+	},
+	5: {
+		{7, 8, -255, 0}, {1, 0, 1, 0}, {2, 0, 2, 0}, {3, 0, 3, 0}, {4, 3, 4, 0}, {5, 6, 12, 0},
+		{7, 32, -256, jbig2LineLower}, {6, 32, 76, jbig2LineUpper},
+	},
+	6: {
+		{5, 10, -2048, 0}, {4, 9, -1024, 0}, {4, 8, -512, 0}, {4, 7, -256, 0}, {5, 6, -128, 0},
+		{5, 5, -64, 0}, {4, 5, -32, 0}, {2, 7, 0, 0}, {3, 7, 128, 0}, {3, 8, 256, 0},
+		{4, 9, 512, 0}, {4, 10, 1024, 0}, {6, 32, -2049, jbig2LineLower},
+		{6, 32, 2048, jbig2LineUpper},
+	},
+	7: {
+		{4, 9, -1024, 0}, {3, 8, -512, 0}, {4, 7, -256, 0}, {5, 6, -128, 0}, {5, 5, -64, 0},
+		{4, 5, -32, 0}, {4, 5, 0, 0}, {5, 5, 32, 0}, {5, 6, 64, 0}, {4, 7, 128, 0},
+		{3, 8, 256, 0}, {3, 9, 512, 0}, {3, 10, 1024, 0}, {5, 32, -1025, jbig2LineLower},
+		{5, 32, 2048, jbig2LineUpper},
+	},
+	8: {
+		{8, 3, -15, 0}, {9, 1, -7, 0}, {8, 1, -5, 0}, {9, 0, -3, 0}, {7, 0, -2, 0},
+		{4, 0, -1, 0}, {2, 1, 0, 0}, {5, 0, 2, 0}, {6, 0, 3, 0}, {3, 4, 4, 0}, {6, 1, 20, 0},
+		{4, 4, 22, 0}, {4, 5, 38, 0}, {5, 6, 70, 0}, {5, 7, 134, 0}, {6, 7, 262, 0},
+		{7, 8, 390, 0}, {6, 10, 646, 0}, {9, 32, -16, jbig2LineLower},
+		{9, 32, 1670, jbig2LineUpper}, {2, 0, 0, jbig2LineOOB},
+	},
+	9: {
+		{8, 4, -31, 0}, {9, 2, -15, 0}, {8, 2, -11, 0}, {9, 1, -7, 0}, {7, 1, -5, 0},
+		{4, 1, -3, 0}, {3, 1, -1, 0}, {3, 1, 1, 0}, {5, 1, 3, 0}, {6, 1, 5, 0}, {3, 5, 7, 0},
+		{6, 2, 39, 0}, {4, 5, 43, 0}, {4, 6, 75, 0}, {5, 7, 139, 0}, {5, 8, 267, 0},
+		{6, 8, 523, 0}, {7, 9, 779, 0}, {6, 11, 1291, 0}, {9, 32, -32, jbig2LineLower},
+		{9, 32, 3339, jbig2LineUpper}, {2, 0, 0, jbig2LineOOB},
+	},
+	10: {
+		{7, 4, -21, 0}, {8, 0, -5, 0}, {7, 0, -4, 0}, {5, 0, -3, 0}, {2, 2, -2, 0},
+		{5, 0, 2, 0}, {6, 0, 3, 0}, {7, 0, 4, 0}, {8, 0, 5, 0}, {2, 6, 6, 0}, {5, 5, 70, 0},
+		{6, 5, 102, 0}, {6, 6, 134, 0}, {6, 7, 198, 0}, {6, 8, 326, 0}, {6, 9, 582, 0},
+		{6, 10, 1094, 0}, {7, 11, 2118, 0}, {8, 32, -22, jbig2LineLower},
+		{8, 32, 4166, jbig2LineUpper}, {2, 0, 0, jbig2LineOOB},
+	},
+	11: {
+		{1, 0, 1, 0}, {2, 1, 2, 0}, {4, 0, 4, 0}, {4, 1, 5, 0}, {5, 1, 7, 0}, {5, 2, 9, 0},
+		{6, 2, 13, 0}, {7, 2, 17, 0}, {7, 3, 21, 0}, {7, 4, 29, 0}, {7, 5, 45, 0},
+		{7, 6, 77, 0}, {7, 32, 141, jbig2LineUpper},
+	},
+	12: {
+		{1, 0, 1, 0}, {2, 0, 2, 0}, {3, 1, 3, 0}, {5, 0, 5, 0}, {5, 1, 6, 0}, {6, 1, 8, 0},
+		{7, 0, 10, 0}, {7, 1, 11, 0}, {7, 2, 13, 0}, {7, 3, 17, 0}, {7, 4, 25, 0},
+		{8, 5, 41, 0}, {8, 32, 73, jbig2LineUpper},
+	},
+	13: {
+		{1, 0, 1, 0}, {3, 0, 2, 0}, {4, 0, 3, 0}, {5, 0, 4, 0}, {4, 1, 5, 0}, {3, 3, 7, 0},
+		{6, 1, 15, 0}, {6, 2, 17, 0}, {6, 3, 21, 0}, {6, 4, 29, 0}, {6, 5, 45, 0},
+		{7, 6, 77, 0}, {7, 32, 141, jbig2LineUpper},
+	},
+	14: {
+		{3, 0, -2, 0}, {3, 0, -1, 0}, {1, 0, 0, 0}, {3, 0, 1, 0}, {3, 0, 2, 0},
+	},
+	15: {
+		{7, 4, -24, 0}, {6, 2, -8, 0}, {5, 1, -4, 0}, {4, 0, -2, 0}, {3, 0, -1, 0},
+		{1, 0, 0, 0}, {3, 0, 1, 0}, {4, 0, 2, 0}, {5, 1, 3, 0}, {6, 2, 5, 0}, {7, 4, 9, 0},
+		{7, 32, -25, jbig2LineLower}, {7, 32, 25, jbig2LineUpper},
+	},
+}
+
+// nolint:gochecknoglobals
+var jbig2StandardTables = newJBIG2StandardTables()
+
+func newJBIG2StandardTables() []*jbig2HuffmanTable {
+	tables := make([]*jbig2HuffmanTable, len(jbig2StandardLines))
+
+	for i, lines := range jbig2StandardLines {
+		if lines != nil {
+			tables[i], _ = newJBIG2HuffmanTable(lines)
+		}
+	}
+
+	return tables
+}
+
+// jbig2SelectTable returns the standard table of the selection. The
+// selection following the standard ones takes the next custom table.
+func jbig2SelectTable(selection int, standard []int, custom *[]*jbig2HuffmanTable) (*jbig2HuffmanTable, error) {
+	if selection < len(standard) && standard[selection] > 0 {
+		return jbig2StandardTables[standard[selection]], nil
+	}
+
+	if selection != len(standard) || len(*custom) == 0 {
+		return nil, fmt.Errorf("%w: JBIG2 Huffman table selection %d", ErrCorruptData, selection)
+	}
+
+	table := (*custom)[0]
+	*custom = (*custom)[1:]
+
+	return table, nil
+}
+
+// readJBIG2SymbolIDTable reads the Huffman table of the symbol IDs
+// of the text region (see ITU-T T.88, 7.4.3.1.7).
+func readJBIG2SymbolIDTable(br *jbig2BitReader, numSyms int) (*jbig2HuffmanTable, error) {
+	const (
+		runCodes   = 35
+		runCodeLen = 4
+		repeatPrev = 32
+		zeros3     = 33
+		zeros11    = 34
+	)
+
+	runLines := make([]jbig2HuffmanLine, runCodes)
+
+	for i := range runLines {
+		prefLen, err := br.readBits(runCodeLen)
+		if err != nil {
+			return nil, err
+		}
+
+		runLines[i] = jbig2HuffmanLine{prefLen, 0, i, jbig2LineNormal}
+	}
+
+	runTable, err := newJBIG2HuffmanTable(runLines)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]jbig2HuffmanLine, numSyms)
+
+	for i := 0; i < numSyms; {
+		code, _, err := runTable.decode(br)
+		if err != nil {
+			return nil, err
+		}
+
+		var n, prefLen, extra int
+
+		switch code {
+		case repeatPrev:
+			if i == 0 {
+				return nil, fmt.Errorf("%w: JBIG2 symbol ID code repeats nothing", ErrCorruptData)
+			}
+
+			extra, err = br.readBits(2)
+			n, prefLen = 3+extra, lines[i-1].prefLen
+		case zeros3:
+			extra, err = br.readBits(3)
+			n = 3 + extra
+		case zeros11:
+			extra, err = br.readBits(7)
+			n = 11 + extra
+		default:
+			n, prefLen = 1, code
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if n > numSyms-i {
+			return nil, fmt.Errorf("%w: JBIG2 symbol ID code lengths overflow", ErrCorruptData)
+		}
+
+		for ; n > 0; n-- {
+			lines[i] = jbig2HuffmanLine{prefLen, 0, i, jbig2LineNormal}
+			i++
+		}
+	}
+
+	br.align()
+
+	return newJBIG2HuffmanTable(lines)
+}
@@ -0,0 +1,799 @@
+package filter
+
+import (
+	"fmt"
+)
+
+// jbig2MaxSymbols limits the number of the symbols of a dictionary.
+const jbig2MaxSymbols = 1 << 20
+
+// jbig2IntDecoder decodes the integers of the arithmetic or of the
+// Huffman coded segment, br is nil for the arithmetic coding.
+type jbig2IntDecoder struct {
+	ad *jbig2ArithDecoder
+	br *jbig2BitReader
+}
+
+// intOrOOB decodes the integer with the arithmetic contexts cx
+// or with the Huffman table, ok is false for the out-of-band value.
+func (d *jbig2IntDecoder) intOrOOB(cx *jbig2IntContexts, table *jbig2HuffmanTable) (v int, ok bool, err error) {
+	if d.br == nil {
+		v, ok = d.ad.decodeInt(cx)
+
+		return v, ok, nil
+	}
+
+	v, oob, err := table.decode(d.br)
+
+	return v, !oob, err
+}
+
+// int decodes the integer, the out-of-band value is an error.
+func (d *jbig2IntDecoder) int(cx *jbig2IntContexts, table *jbig2HuffmanTable) (int, error) {
+	v, ok, err := d.intOrOOB(cx, table)
+	if err == nil && !ok {
+		err = fmt.Errorf("%w: JBIG2 unexpected out-of-band value", ErrCorruptData)
+	}
+
+	return v, err
+}
+
+// chunk starts the arithmetic decoder of the refinement of the Huffman
+// coded segment, which follows its size (see ITU-T T.88, 6.4.11.1).
+func (d *jbig2IntDecoder) chunk(sizeTable *jbig2HuffmanTable) error {
+	size, err := d.int(nil, sizeTable)
+	if err != nil {
+		return err
+	}
+
+	d.br.align()
+
+	data, err := d.br.bytes(size)
+	if err != nil {
+		return err
+	}
+
+	d.ad.mq = newMQDecoder(data)
+
+	return nil
+}
+
+// ceilLog2 returns the number of bits of the codes of n values.
+func ceilLog2(n int) int {
+	bits := 0
+	for 1<<bits < n {
+		bits++
+	}
+
+	return bits
+}
+
+// The reference corners of the text region symbols (see ITU-T T.88, 7.4.3.1.1).
+const (
+	jbig2CornerTop   = 1
+	jbig2CornerRight = 2
+)
+
+// jbig2TextParams are the parameters of the text region decoding
+// procedure (see ITU-T T.88, 6.4.2).
+type jbig2TextParams struct {
+	width        int
+	height       int
+	refine       bool
+	defPixel     byte
+	op           int
+	transposed   bool
+	refCorner    int
+	dsOffset     int
+	logStrips    int
+	numInstances int
+	symbols      []*jbig2Bitmap
+	codeLen      int
+	rTemplate    int
+	rat          []jbig2Point
+
+	// The Huffman tables, symID is nil for the
+	// symbol IDs coded as codeLen bits.
+	fs    *jbig2HuffmanTable
+	ds    *jbig2HuffmanTable
+	dt    *jbig2HuffmanTable
+	rdw   *jbig2HuffmanTable
+	rdh   *jbig2HuffmanTable
+	rdx   *jbig2HuffmanTable
+	rdy   *jbig2HuffmanTable
+	rsize *jbig2HuffmanTable
+	symID *jbig2HuffmanTable
+}
+
+// selectTables selects the Huffman tables of the
+// text region segment (see ITU-T T.88, 7.4.3.1.2).
+func (p *jbig2TextParams) selectTables(flags int, custom []*jbig2HuffmanTable) error {
+	selections := []struct {
+		table    **jbig2HuffmanTable
+		shift    int
+		standard []int
+	}{
+		{&p.fs, 0, []int{6, 7, 0}},
+		{&p.ds, 2, []int{8, 9, 10}},
+		{&p.dt, 4, []int{11, 12, 13}},
+		{&p.rdw, 6, []int{14, 15, 0}},
+		{&p.rdh, 8, []int{14, 15, 0}},
+		{&p.rdx, 10, []int{14, 15, 0}},
+		{&p.rdy, 12, []int{14, 15, 0}},
+		{&p.rsize, 14, []int{1}},
+	}
+
+	for _, s := range selections {
+		mask := 3
+		if len(s.standard) == 1 {
+			mask = 1
+		}
+
+		var err error
+
+		if *s.table, err = jbig2SelectTable(flags>>s.shift&mask, s.standard, &custom); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeText decodes the text region (see ITU-T T.88, 6.4.5).
+func (d *jbig2IntDecoder) decodeText(p *jbig2TextParams) (*jbig2Bitmap, error) {
+	bm, err := newJBIG2Bitmap(p.width, p.height)
+	if err != nil {
+		return nil, err
+	}
+
+	bm.fill(p.defPixel)
+
+	strips := 1 << p.logStrips
+
+	stripT, err := d.int(&d.ad.iadt, p.dt)
+	if err != nil {
+		return nil, err
+	}
+
+	stripT *= -strips
+	firstS := 0
+
+	for n := 0; n < p.numInstances; {
+		dt, err := d.int(&d.ad.iadt, p.dt)
+		if err != nil {
+			return nil, err
+		}
+
+		stripT += dt * strips
+
+		dfs, err := d.int(&d.ad.iafs, p.fs)
+		if err != nil {
+			return nil, err
+		}
+
+		firstS += dfs
+		curS := firstS
+
+		// The strip ends with the out-of-band value.
+		for first := true; ; first = false {
+			if !first {
+				ids, ok, err := d.intOrOOB(&d.ad.iads, p.ds)
+				if err != nil {
+					return nil, err
+				}
+
+				if !ok {
+					break
+				}
+
+				curS += ids + p.dsOffset
+			}
+
+			if n >= p.numInstances {
+				return nil, fmt.Errorf("%w: JBIG2 text region has over %d instances", ErrCorruptData, p.numInstances)
+			}
+
+			curT, err := d.stripT(p.logStrips)
+			if err != nil {
+				return nil, err
+			}
+
+			ib, err := d.instance(p)
+			if err != nil {
+				return nil, err
+			}
+
+			if !p.transposed && p.refCorner&jbig2CornerRight != 0 {
+				curS += ib.width - 1
+			} else if p.transposed && p.refCorner&jbig2CornerTop == 0 {
+				curS += ib.height - 1
+			}
+
+			x, y := curS, stripT+curT
+			if p.transposed {
+				x, y = y, x
+			}
+
+			if p.refCorner&jbig2CornerRight != 0 {
+				x -= ib.width - 1
+			}
+
+			if p.refCorner&jbig2CornerTop == 0 {
+				y -= ib.height - 1
+			}
+
+			bm.compose(ib, x, y, p.op)
+
+			if !p.transposed && p.refCorner&jbig2CornerRight == 0 {
+				curS += ib.width - 1
+			} else if p.transposed && p.refCorner&jbig2CornerTop != 0 {
+				curS += ib.height - 1
+			}
+
+			n++
+		}
+	}
+
+	return bm, nil
+}
+
+// stripT decodes the T coordinate of the instance within the strip.
+func (d *jbig2IntDecoder) stripT(logStrips int) (int, error) {
+	switch {
+	case logStrips == 0:
+		return 0, nil
+	case d.br != nil:
+		return d.br.readBits(logStrips)
+	default:
+		return d.int(&d.ad.iait, nil)
+	}
+}
+
+// instance decodes the symbol ID of the instance and its refinement.
+func (d *jbig2IntDecoder) instance(p *jbig2TextParams) (*jbig2Bitmap, error) {
+	var (
+		id  int
+		err error
+	)
+
+	switch {
+	case d.br == nil:
+		id = d.ad.decodeIAID(p.codeLen)
+	case p.symID != nil:
+		id, _, err = p.symID.decode(d.br)
+	default:
+		id, err = d.br.readBits(p.codeLen)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if id < 0 || id >= len(p.symbols) {
+		return nil, fmt.Errorf("%w: JBIG2 symbol ID %d", ErrCorruptData, id)
+	}
+
+	ib := p.symbols[id]
+
+	if !p.refine {
+		return ib, nil
+	}
+
+	var ri int
+
+	if d.br != nil {
+		ri, err = d.br.readBit()
+	} else {
+		ri, err = d.int(&d.ad.iari, nil)
+	}
+
+	if err != nil || ri == 0 {
+		return ib, err
+	}
+
+	var rd [4]int
+
+	cxs := [...]*jbig2IntContexts{&d.ad.iardw, &d.ad.iardh, &d.ad.iardx, &d.ad.iardy}
+	tables := [...]*jbig2HuffmanTable{p.rdw, p.rdh, p.rdx, p.rdy}
+
+	for i := range rd {
+		if rd[i], err = d.int(cxs[i], tables[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	rdw, rdh, rdx, rdy := rd[0], rd[1], rd[2], rd[3]
+
+	if d.br != nil {
+		if err := d.chunk(p.rsize); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.ad.decodeRefinement(&jbig2RefinementParams{
+		width:    ib.width + rdw,
+		height:   ib.height + rdh,
+		template: p.rTemplate,
+		ref:      ib,
+		dx:       rdw>>1 + rdx,
+		dy:       rdh>>1 + rdy,
+		at:       p.rat,
+	})
+}
+
+// decodeTextRegion decodes the text region segment
+// (see ITU-T T.88, 7.4.3).
+func (dec *jbig2Decoder) decodeTextRegion(seg *jbig2Segment) (jbig2RegionInfo, *jbig2Bitmap, error) {
+	const (
+		flagHuffman    = 1
+		flagRefine     = 2
+		flagTransposed = 0x40
+		flagDefPixel   = 0x200
+		maxDSOffset    = 15
+	)
+
+	rd := &jbig2Reader{data: seg.data}
+	info := rd.regionInfo()
+	flags := rd.u16()
+
+	p := &jbig2TextParams{
+		width:      info.width,
+		height:     info.height,
+		refine:     flags&flagRefine != 0,
+		logStrips:  flags >> 2 & 3,
+		refCorner:  flags >> 4 & 3,
+		transposed: flags&flagTransposed != 0,
+		op:         flags >> 7 & 3,
+		dsOffset:   flags >> 10 & 0x1F,
+		rTemplate:  flags >> 15 & 1,
+	}
+
+	if flags&flagDefPixel != 0 {
+		p.defPixel = 1
+	}
+
+	if p.dsOffset > maxDSOffset {
+		p.dsOffset -= 32
+	}
+
+	var huffFlags int
+	if flags&flagHuffman != 0 {
+		huffFlags = rd.u16()
+	}
+
+	if p.refine && p.rTemplate == 0 {
+		p.rat = rd.points(2)
+	}
+
+	p.numInstances = int(rd.u32())
+
+	if rd.err != nil {
+		return info, nil, rd.err
+	}
+
+	refs, err := dec.referred(seg)
+	if err != nil {
+		return info, nil, err
+	}
+
+	var custom []*jbig2HuffmanTable
+
+	for _, ref := range refs {
+		switch ref.typ {
+		case jbig2SymbolDictionary:
+			p.symbols = append(p.symbols, ref.symbols...)
+		case jbig2Tables:
+			custom = append(custom, ref.table)
+		}
+	}
+
+	p.codeLen = ceilLog2(len(p.symbols))
+	data := seg.data[rd.pos:]
+
+	d := &jbig2IntDecoder{ad: newJBIG2ArithDecoder(data)}
+
+	if flags&flagHuffman != 0 {
+		d.br = &jbig2BitReader{data: data}
+
+		if err := p.selectTables(huffFlags, custom); err != nil {
+			return info, nil, err
+		}
+
+		if p.symID, err = readJBIG2SymbolIDTable(d.br, len(p.symbols)); err != nil {
+			return info, nil, err
+		}
+	}
+
+	bm, err := d.decodeText(p)
+
+	return info, bm, err
+}
+
+// jbig2SymbolDict decodes the symbol dictionary segment
+// (see ITU-T T.88, 6.5 and 7.4.2).
+type jbig2SymbolDict struct {
+	jbig2IntDecoder
+
+	refAgg    bool
+	template  int
+	rTemplate int
+	at        []jbig2Point
+	rat       []jbig2Point
+	numNew    int
+	codeLen   int
+	inSyms    []*jbig2Bitmap
+	newSyms   []*jbig2Bitmap
+
+	// The Huffman tables.
+	dh      *jbig2HuffmanTable
+	dw      *jbig2HuffmanTable
+	bmSize  *jbig2HuffmanTable
+	aggInst *jbig2HuffmanTable
+}
+
+func (dec *jbig2Decoder) decodeSymbolDictionary(seg *jbig2Segment) error {
+	const (
+		flagHuffman         = 1
+		flagRefAgg          = 2
+		flagContextUsed     = 0x100
+		flagContextRetained = 0x200
+	)
+
+	rd := &jbig2Reader{data: seg.data}
+	flags := rd.u16()
+
+	sd := &jbig2SymbolDict{
+		refAgg:    flags&flagRefAgg != 0,
+		template:  flags >> 10 & 3,
+		rTemplate: flags >> 12 & 1,
+	}
+
+	if flags&flagHuffman == 0 {
+		n := 1
+		if sd.template == 0 {
+			n = 4
+		}
+
+		sd.at = rd.points(n)
+	}
+
+	if sd.refAgg && sd.rTemplate == 0 {
+		sd.rat = rd.points(2)
+	}
+
+	numExported := int(rd.u32())
+	sd.numNew = int(rd.u32())
+
+	if rd.err != nil {
+		return rd.err
+	}
+
+	if sd.numNew > jbig2MaxSymbols || numExported > jbig2MaxSymbols {
+		return fmt.Errorf("%w: JBIG2 symbol dictionary of %d symbols", ErrCorruptData, sd.numNew)
+	}
+
+	refs, err := dec.referred(seg)
+	if err != nil {
+		return err
+	}
+
+	var (
+		custom []*jbig2HuffmanTable
+		last   *jbig2Segment
+	)
+
+	for _, ref := range refs {
+		switch ref.typ {
+		case jbig2SymbolDictionary:
+			sd.inSyms = append(sd.inSyms, ref.symbols...)
+			last = ref
+		case jbig2Tables:
+			custom = append(custom, ref.table)
+		}
+	}
+
+	data := seg.data[rd.pos:]
+	sd.ad = newJBIG2ArithDecoder(data)
+	sd.codeLen = ceilLog2(len(sd.inSyms) + sd.numNew)
+
+	if flags&flagHuffman != 0 {
+		sd.br = &jbig2BitReader{data: data}
+
+		if err := sd.selectTables(flags, custom); err != nil {
+			return err
+		}
+	}
+
+	if flags&flagContextUsed != 0 && last != nil {
+		sd.ad.gb = append([]byte(nil), last.gb...)
+		sd.ad.gr = append([]byte(nil), last.gr...)
+	}
+
+	if err := sd.decodeSymbols(); err != nil {
+		return err
+	}
+
+	if seg.symbols, err = sd.exportSymbols(); err != nil {
+		return err
+	}
+
+	if flags&flagContextRetained != 0 {
+		seg.gb, seg.gr = sd.ad.gb, sd.ad.gr
+	}
+
+	return nil
+}
+
+// selectTables selects the Huffman tables of the
+// symbol dictionary (see ITU-T T.88, 7.4.2.1.1).
+func (sd *jbig2SymbolDict) selectTables(flags int, custom []*jbig2HuffmanTable) error {
+	var err error
+
+	if sd.dh, err = jbig2SelectTable(flags>>2&3, []int{4, 5, 0}, &custom); err != nil {
+		return err
+	}
+
+	if sd.dw, err = jbig2SelectTable(flags>>4&3, []int{2, 3, 0}, &custom); err != nil {
+		return err
+	}
+
+	if sd.bmSize, err = jbig2SelectTable(flags>>6&1, []int{1}, &custom); err != nil {
+		return err
+	}
+
+	sd.aggInst, err = jbig2SelectTable(flags>>7&1, []int{1}, &custom)
+
+	return err
+}
+
+// decodeSymbols decodes the new symbols by the height classes
+// (see ITU-T T.88, 6.5.5).
+func (sd *jbig2SymbolDict) decodeSymbols() error {
+	height := 0
+
+	for len(sd.newSyms) < sd.numNew {
+		dh, err := sd.int(&sd.ad.iadh, sd.dh)
+		if err != nil {
+			return err
+		}
+
+		if height += dh; height < 0 {
+			return fmt.Errorf("%w: JBIG2 symbol height %d", ErrCorruptData, height)
+		}
+
+		width, total := 0, 0
+
+		// widths are the symbols of the collective bitmap.
+		var widths []int
+
+		for {
+			dw, ok, err := sd.intOrOOB(&sd.ad.iadw, sd.dw)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				break
+			}
+
+			if len(sd.newSyms)+len(widths) >= sd.numNew {
+				return fmt.Errorf("%w: JBIG2 symbol dictionary has over %d symbols", ErrCorruptData, sd.numNew)
+			}
+
+			if width += dw; width < 0 {
+				return fmt.Errorf("%w: JBIG2 symbol width %d", ErrCorruptData, width)
+			}
+
+			total += width
+
+			var sym *jbig2Bitmap
+
+			switch {
+			case sd.refAgg:
+				sym, err = sd.decodeRefAgg(width, height)
+			case sd.br == nil:
+				sym, err = sd.ad.decodeGeneric(&jbig2GenericParams{
+					width:    width,
+					height:   height,
+					template: sd.template,
+					at:       sd.at,
+				})
+			default:
+				widths = append(widths, width)
+
+				continue
+			}
+
+			if err != nil {
+				return err
+			}
+
+			sd.newSyms = append(sd.newSyms, sym)
+		}
+
+		if sd.br != nil && !sd.refAgg {
+			if err := sd.decodeCollective(widths, total, height); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// decodeCollective decodes the collective bitmap of the height class
+// and splits it into the symbols (see ITU-T T.88, 6.5.9).
+func (sd *jbig2SymbolDict) decodeCollective(widths []int, total, height int) error {
+	size, err := sd.int(nil, sd.bmSize)
+	if err != nil {
+		return err
+	}
+
+	sd.br.align()
+
+	bm, err := newJBIG2Bitmap(total, height)
+	if err != nil {
+		return err
+	}
+
+	if size == 0 {
+		// The uncompressed rows are padded to the byte boundary.
+		stride := (total + 7) / 8
+
+		data, err := sd.br.bytes(stride * height)
+		if err != nil {
+			return err
+		}
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < total; x++ {
+				bm.pix[y*total+x] = data[y*stride+x/8] >> (7 - x%8) & 1
+			}
+		}
+	} else {
+		data, err := sd.br.bytes(size)
+		if err != nil {
+			return err
+		}
+
+		if err := newMMRDecoder(data).decodeMMR(bm); err != nil {
+			return err
+		}
+	}
+
+	x := 0
+
+	for _, width := range widths {
+		sym, err := bm.sub(x, 0, width, height)
+		if err != nil {
+			return err
+		}
+
+		sd.newSyms = append(sd.newSyms, sym)
+		x += width
+	}
+
+	return nil
+}
+
+// symbols returns the input symbols followed by the new ones.
+func (sd *jbig2SymbolDict) symbols() []*jbig2Bitmap {
+	syms := make([]*jbig2Bitmap, 0, len(sd.inSyms)+len(sd.newSyms))
+	syms = append(syms, sd.inSyms...)
+
+	return append(syms, sd.newSyms...)
+}
+
+// decodeRefAgg decodes the symbol, which is either the refinement of
+// another symbol or the aggregation of the symbols coded as the text
+// region (see ITU-T T.88, 6.5.8.2).
+func (sd *jbig2SymbolDict) decodeRefAgg(width, height int) (*jbig2Bitmap, error) {
+	n, err := sd.int(&sd.ad.iaai, sd.aggInst)
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 1 {
+		return nil, fmt.Errorf("%w: JBIG2 aggregation of %d instances", ErrCorruptData, n)
+	}
+
+	if n == 1 {
+		return sd.decodeRefined(width, height)
+	}
+
+	p := &jbig2TextParams{
+		width:        width,
+		height:       height,
+		refine:       true,
+		op:           jbig2OpOr,
+		refCorner:    jbig2CornerTop,
+		numInstances: n,
+		symbols:      sd.symbols(),
+		codeLen:      sd.codeLen,
+		rTemplate:    sd.rTemplate,
+		rat:          sd.rat,
+	}
+
+	if sd.br != nil {
+		std := jbig2StandardTables
+		p.fs, p.ds, p.dt = std[6], std[8], std[11]
+		p.rdw, p.rdh, p.rdx, p.rdy = std[15], std[15], std[15], std[15]
+		p.rsize = std[1]
+	}
+
+	return sd.decodeText(p)
+}
+
+// decodeRefined decodes the refinement of a symbol (see ITU-T T.88, 6.5.8.2.2).
+func (sd *jbig2SymbolDict) decodeRefined(width, height int) (*jbig2Bitmap, error) {
+	var (
+		id, rdx, rdy int
+		err          error
+	)
+
+	if sd.br != nil {
+		if id, err = sd.br.readBits(sd.codeLen); err == nil {
+			if rdx, err = sd.int(nil, jbig2StandardTables[15]); err == nil {
+				if rdy, err = sd.int(nil, jbig2StandardTables[15]); err == nil {
+					err = sd.chunk(jbig2StandardTables[1])
+				}
+			}
+		}
+	} else {
+		id = sd.ad.decodeIAID(sd.codeLen)
+		if rdx, err = sd.int(&sd.ad.iardx, nil); err == nil {
+			rdy, err = sd.int(&sd.ad.iardy, nil)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	syms := sd.symbols()
+	if id >= len(syms) {
+		return nil, fmt.Errorf("%w: JBIG2 symbol ID %d", ErrCorruptData, id)
+	}
+
+	return sd.ad.decodeRefinement(&jbig2RefinementParams{
+		width:    width,
+		height:   height,
+		template: sd.rTemplate,
+		ref:      syms[id],
+		dx:       rdx,
+		dy:       rdy,
+		at:       sd.rat,
+	})
+}
+
+// exportSymbols returns the exported symbols, which are coded as the
+// runs of the symbols alternately not exported and exported
+// (see ITU-T T.88, 6.5.10).
+func (sd *jbig2SymbolDict) exportSymbols() ([]*jbig2Bitmap, error) {
+	syms := sd.symbols()
+	exported := make([]*jbig2Bitmap, 0, len(syms))
+
+	for i, export, runs := 0, false, 0; i < len(syms); export = !export {
+		// The empty runs are limited, so the corrupt data ends.
+		if runs++; runs > 2*len(syms)+1 {
+			return nil, fmt.Errorf("%w: JBIG2 empty export runs", ErrCorruptData)
+		}
+
+		n, err := sd.int(&sd.ad.iaex, jbig2StandardTables[1])
+		if err != nil {
+			return nil, err
+		}
+
+		if n < 0 || n > len(syms)-i {
+			return nil, fmt.Errorf("%w: JBIG2 export run %d", ErrCorruptData, n)
+		}
+
+		if export {
+			exported = append(exported, syms[i:i+n]...)
+		}
+
+		i += n
+	}
+
+	return exported, nil
+}
@@ -0,0 +1,159 @@
+package filter_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/filter"
+)
+
+// streamParams are the params with the streams, e.g. /JBIG2Globals.
+type streamParams struct {
+	params
+	streams map[string][]byte
+}
+
+func (p streamParams) Stream(key string) []byte {
+	return p.streams[key]
+}
+
+// jbig2Segment returns the segment with the short form header, the
+// segment is associated with the page 1.
+func jbig2Segment(number uint32, typ byte, refs []byte, data ...[]byte) []byte {
+	body := bytes.Join(data, nil)
+
+	var seg []byte
+	seg = binary.BigEndian.AppendUint32(seg, number)
+	seg = append(seg, typ, byte(len(refs)<<5))
+	seg = append(seg, refs...)
+	seg = append(seg, 1)
+	seg = binary.BigEndian.AppendUint32(seg, uint32(len(body)))
+
+	return append(seg, body...)
+}
+
+func jbig2U32(values ...uint32) []byte {
+	var data []byte
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint32(data, v)
+	}
+
+	return data
+}
+
+// jbig2PageInfo returns the data of the page information segment.
+func jbig2PageInfo(width, height uint32) []byte {
+	return append(jbig2U32(width, height, 0, 0), 0, 0, 0)
+}
+
+// jbig2RegionInfo returns the region segment information field
+// of the region combined with OR.
+func jbig2RegionInfo(width, height, x, y uint32) []byte {
+	return append(jbig2U32(width, height, x, y), 0)
+}
+
+func TestJBIG2Decode(t *testing.T) {
+	t.Parallel()
+
+	// The MMR rows are WWBBBWWW and WWWBBBWW.
+	mmr := packBits(t, "001 0111 10 1 "+"011 011 1")
+	mmrRegion := jbig2Segment(1, 38, nil, jbig2RegionInfo(8, 2, 0, 0), []byte{1}, mmr)
+
+	// The Huffman coded dictionary of the 2×3 symbol of the black pixels
+	// and the 3×3 symbol of the X shape, their collective bitmap is
+	// uncompressed.
+	dict := jbig2Segment(0, 0, nil,
+		[]byte{0, 1}, jbig2U32(2, 2),
+		// DH 3, DW 2, DW 1, OOB, BMSIZE 0.
+		packBits(t, "110 110 10 111111 00000"),
+		[]byte{0xE8, 0xD0, 0xE8},
+		// The export runs 0 and 2.
+		packBits(t, "00000 00010"))
+
+	// The Huffman coded text region of the top left corners (0, 0)
+	// and (4, 0), the symbol ID codes are of 1 bit.
+	text := jbig2Segment(2, 6, []byte{0},
+		jbig2RegionInfo(8, 4, 0, 0), []byte{0, 0x11, 0, 0}, jbig2U32(2),
+		packBits(t, "0000 0001"+strings.Repeat(" 0000", 33)+" 0 0"),
+		// STRIPT, DT 1, FS 0, ID 0, DS 3, ID 1, OOB.
+		packBits(t, "0 0 000000000 0 111010 1 01"))
+
+	tests := []struct {
+		name    string
+		data    [][]byte
+		globals []byte
+		want    []byte
+		wantErr error
+	}{
+		{
+			name: "MMR generic region",
+			data: [][]byte{jbig2Segment(0, 48, nil, jbig2PageInfo(8, 2)), mmrRegion, jbig2Segment(2, 49, nil)},
+			want: []byte{0xC7, 0xE3},
+		},
+		{
+			name: "striped page",
+			data: [][]byte{
+				jbig2Segment(0, 48, nil, jbig2PageInfo(8, 0xFFFFFFFF)),
+				mmrRegion,
+				jbig2Segment(2, 50, nil, jbig2U32(2)),
+			},
+			want: []byte{0xC7, 0xE3, 0xFF},
+		},
+		{
+			name: "file header",
+			data: [][]byte{
+				[]byte("\x97JB2\r\n\x1A\n\x01"), jbig2U32(1),
+				jbig2Segment(0, 48, nil, jbig2PageInfo(8, 2)), mmrRegion,
+				jbig2Segment(2, 49, nil), jbig2Segment(3, 51, nil),
+			},
+			want: []byte{0xC7, 0xE3},
+		},
+		{
+			name:    "symbol dictionary and text region",
+			data:    [][]byte{jbig2Segment(1, 48, nil, jbig2PageInfo(8, 4)), text, jbig2Segment(3, 49, nil)},
+			globals: dict,
+			want:    []byte{0x35, 0x3B, 0x35, 0xFF},
+		},
+		{
+			name:    "missing globals",
+			data:    [][]byte{jbig2Segment(1, 48, nil, jbig2PageInfo(8, 4)), text},
+			wantErr: filter.ErrCorruptData,
+		},
+		{name: "missing page information", data: [][]byte{mmrRegion}, wantErr: filter.ErrCorruptData},
+		{
+			name:    "truncated",
+			data:    [][]byte{jbig2Segment(0, 48, nil, jbig2PageInfo(8, 2))[:20]},
+			wantErr: filter.ErrCorruptData,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := streamParams{streams: map[string][]byte{"JBIG2Globals": tt.globals}}
+
+			r, err := filter.NewReader(bytes.NewReader(bytes.Join(tt.data, nil)), filter.TypeJBIG2Decode, p)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			got, err := io.ReadAll(r)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
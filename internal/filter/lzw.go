@@ -0,0 +1,307 @@
+package filter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The LZW codes (see ISO 32000-2:2020, 7.4.4.2).
+const (
+	lzwClear      = 256
+	lzwEOD        = 257
+	lzwFirstCode  = 258
+	lzwMinWidth   = 9
+	lzwMaxWidth   = 12
+	lzwTableSize  = 1 << lzwMaxWidth
+	lzwLiteralMax = 255
+)
+
+// earlyChange reads /EarlyChange: 1 means the code width is
+// increased one code early, which is the default.
+func earlyChange(params Params) (int, error) {
+	switch early := paramInt(params, "EarlyChange", 1); early {
+	case 0, 1:
+		return int(early), nil
+	default:
+		return 0, fmt.Errorf("%w: /EarlyChange %d", ErrCorruptData, early)
+	}
+}
+
+type lzwDecoder struct {
+	r     *bufio.Reader
+	early int
+
+	bits  uint32
+	nBits uint
+	width uint
+
+	table [][]byte
+	prev  []byte
+
+	pending []byte
+	err     error
+}
+
+func newLZWDecoder(r io.Reader, params Params) (io.Reader, error) {
+	early, err := earlyChange(params)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := &lzwDecoder{
+		r:     bufio.NewReader(r),
+		early: early,
+		table: make([][]byte, lzwFirstCode, lzwTableSize),
+	}
+
+	for i := 0; i <= lzwLiteralMax; i++ {
+		dec.table[i] = []byte{byte(i)}
+	}
+
+	dec.reset()
+
+	return newPredictorDecoder(dec, params)
+}
+
+func (dec *lzwDecoder) reset() {
+	dec.table = dec.table[:lzwFirstCode]
+	dec.width = lzwMinWidth
+	dec.prev = nil
+}
+
+func (dec *lzwDecoder) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if len(dec.pending) > 0 {
+			copied := copy(p[n:], dec.pending)
+			dec.pending = dec.pending[copied:]
+			n += copied
+
+			continue
+		}
+
+		if dec.err != nil {
+			break
+		}
+
+		dec.err = dec.decode()
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+
+	return 0, dec.err
+}
+
+// decode decodes the next code into pending.
+func (dec *lzwDecoder) decode() error {
+	code, err := dec.readCode()
+	if err != nil {
+		// The missing EOD code is tolerated.
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return io.EOF
+		}
+
+		return err
+	}
+
+	switch {
+	case code == lzwClear:
+		dec.reset()
+
+		return nil
+	case code == lzwEOD:
+		return io.EOF
+	case code < len(dec.table):
+		dec.pending = dec.table[code]
+	case code == len(dec.table) && dec.prev != nil:
+		// The code being defined is the previous entry
+		// followed by its own first byte.
+		dec.pending = append(dec.prev[:len(dec.prev):len(dec.prev)], dec.prev[0])
+	default:
+		return fmt.Errorf("%w: LZW code %d", ErrCorruptData, code)
+	}
+
+	if dec.prev != nil && len(dec.table) < lzwTableSize {
+		entry := make([]byte, len(dec.prev)+1)
+		copy(entry, dec.prev)
+		entry[len(dec.prev)] = dec.pending[0]
+
+		dec.table = append(dec.table, entry)
+
+		if len(dec.table)+dec.early >= 1<<dec.width && dec.width < lzwMaxWidth {
+			dec.width++
+		}
+	}
+
+	dec.prev = dec.pending
+
+	return nil
+}
+
+func (dec *lzwDecoder) readCode() (int, error) {
+	for dec.nBits < dec.width {
+		c, err := dec.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		dec.bits = dec.bits<<8 | uint32(c)
+		dec.nBits += 8
+	}
+
+	dec.nBits -= dec.width
+	code := int(dec.bits>>dec.nBits) & (1<<dec.width - 1)
+
+	return code, nil
+}
+
+type lzwEncoder struct {
+	w     *bufio.Writer
+	early int
+
+	bits  uint32
+	nBits uint
+	width uint
+
+	// codes maps the prefix code and the next byte to the code.
+	codes map[uint32]int
+	next  int
+	// decoderNext is the next code of the decoder, which defines
+	// its entries one code later than the encoder does.
+	decoderNext int
+	// prefix is the code of the data written so far, if any.
+	prefix int
+}
+
+func newLZWEncoder(w io.Writer, params Params) (io.WriteCloser, error) {
+	early, err := earlyChange(params)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := &lzwEncoder{
+		w:      bufio.NewWriter(w),
+		early:  early,
+		width:  lzwMinWidth,
+		codes:  make(map[uint32]int),
+		prefix: -1,
+	}
+
+	if err = enc.clear(); err != nil {
+		return nil, err
+	}
+
+	return newPredictorEncoder(enc, params)
+}
+
+// clear writes the clear code and resets the table.
+func (enc *lzwEncoder) clear() error {
+	if err := enc.writeCode(lzwClear); err != nil {
+		return err
+	}
+
+	for key := range enc.codes {
+		delete(enc.codes, key)
+	}
+
+	enc.width = lzwMinWidth
+	enc.next = lzwFirstCode
+	enc.decoderNext = lzwFirstCode - 1
+
+	return nil
+}
+
+func (enc *lzwEncoder) Write(p []byte) (int, error) {
+	for _, c := range p {
+		if enc.prefix < 0 {
+			enc.prefix = int(c)
+
+			continue
+		}
+
+		key := uint32(enc.prefix)<<8 | uint32(c)
+		if code, ok := enc.codes[key]; ok {
+			enc.prefix = code
+
+			continue
+		}
+
+		if err := enc.emit(enc.prefix); err != nil {
+			return 0, err
+		}
+
+		enc.codes[key] = enc.next
+		enc.next++
+		enc.prefix = int(c)
+
+		// The table is cleared before the decoder needs a wider code.
+		if enc.next >= lzwTableSize-2 {
+			if err := enc.clear(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+// emit writes the code and then updates the code
+// width just like the decoder does after reading it.
+func (enc *lzwEncoder) emit(code int) error {
+	if err := enc.writeCode(code); err != nil {
+		return err
+	}
+
+	if enc.decoderNext >= lzwFirstCode {
+		if enc.decoderNext+1+enc.early >= 1<<enc.width && enc.width < lzwMaxWidth {
+			enc.width++
+		}
+	}
+
+	enc.decoderNext++
+
+	return nil
+}
+
+func (enc *lzwEncoder) writeCode(code int) error {
+	enc.bits = enc.bits<<enc.width | uint32(code)
+	enc.nBits += enc.width
+
+	for enc.nBits >= 8 {
+		enc.nBits -= 8
+
+		if err := enc.w.WriteByte(byte(enc.bits >> enc.nBits)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (enc *lzwEncoder) Close() error {
+	if enc.prefix >= 0 {
+		if err := enc.emit(enc.prefix); err != nil {
+			return err
+		}
+
+		enc.prefix = -1
+	}
+
+	if err := enc.writeCode(lzwEOD); err != nil {
+		return err
+	}
+
+	// The last byte is padded with zero bits.
+	if enc.nBits > 0 {
+		if err := enc.w.WriteByte(byte(enc.bits << (8 - enc.nBits))); err != nil {
+			return err
+		}
+
+		enc.nBits = 0
+	}
+
+	return enc.w.Flush()
+}
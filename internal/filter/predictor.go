@@ -0,0 +1,529 @@
+package filter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrInvalidPredictor = errors.New("invalid predictor")
+
+// Predictor is the /Predictor value of the Flate and LZW
+// filter parameters.
+type Predictor int
+
+const (
+	// PredictorNone means no prediction.
+	PredictorNone Predictor = 1
+	// PredictorTIFF is the TIFF Predictor 2, the horizontal
+	// differencing of the components.
+	PredictorTIFF Predictor = 2
+	// PredictorPNGNone is PNG prediction with the None filter
+	// on all rows.
+	PredictorPNGNone Predictor = 10
+	// PredictorPNGSub is PNG prediction with the Sub filter
+	// on all rows.
+	PredictorPNGSub Predictor = 11
+	// PredictorPNGUp is PNG prediction with the Up filter
+	// on all rows.
+	PredictorPNGUp Predictor = 12
+	// PredictorPNGAverage is PNG prediction with the Average
+	// filter on all rows.
+	PredictorPNGAverage Predictor = 13
+	// PredictorPNGPaeth is PNG prediction with the Paeth filter
+	// on all rows.
+	PredictorPNGPaeth Predictor = 14
+	// PredictorPNGOptimum is PNG prediction with the filter
+	// chosen for each row.
+	PredictorPNGOptimum Predictor = 15
+)
+
+// IsPNG returns true for any of the PNG predictors. When decoding,
+// all of them are handled the same way: each row carries its own
+// filter type byte.
+func (p Predictor) IsPNG() bool {
+	return p >= PredictorPNGNone && p <= PredictorPNGOptimum
+}
+
+// PNG row filter types (see RFC 2083, section 6).
+const (
+	pngFilterNone byte = iota
+	pngFilterSub
+	pngFilterUp
+	pngFilterAverage
+	pngFilterPaeth
+)
+
+// PredictorParams holds the predictor related entries of the
+// /DecodeParms dictionary.
+type PredictorParams struct {
+	Predictor        Predictor
+	Colors           int
+	BitsPerComponent int
+	Columns          int
+}
+
+// DefaultPredictorParams returns the parameters with the default
+// values defined by ISO 32000-1:2008, Table 8.
+func DefaultPredictorParams() PredictorParams {
+	return PredictorParams{
+		Predictor:        PredictorNone,
+		Colors:           1,
+		BitsPerComponent: 8,
+		Columns:          1,
+	}
+}
+
+// Validate checks the parameters.
+func (params PredictorParams) Validate() error {
+	if params.Predictor != PredictorNone && params.Predictor != PredictorTIFF && !params.Predictor.IsPNG() {
+		return fmt.Errorf("%w: unsupported predictor %d", ErrInvalidPredictor, params.Predictor)
+	}
+
+	switch params.BitsPerComponent {
+	case 1, 2, 4, 8, 16:
+	default:
+		return fmt.Errorf("%w: bits per component %d", ErrInvalidPredictor, params.BitsPerComponent)
+	}
+
+	if params.Colors < 1 || params.Columns < 1 {
+		return fmt.Errorf("%w: colors %d, columns %d", ErrInvalidPredictor,
+			params.Colors, params.Columns)
+	}
+
+	return nil
+}
+
+// rowSize returns the number of bytes in a row without
+// the PNG filter type byte.
+func (params PredictorParams) rowSize() int {
+	const bitsPerByte = 8
+
+	return (params.Colors*params.BitsPerComponent*params.Columns + bitsPerByte - 1) / bitsPerByte
+}
+
+// pixelSize returns the number of bytes per complete pixel,
+// rounded up to one.
+func (params PredictorParams) pixelSize() int {
+	const bitsPerByte = 8
+
+	return (params.Colors*params.BitsPerComponent + bitsPerByte - 1) / bitsPerByte
+}
+
+type predictorDecoder struct {
+	r      io.Reader
+	params PredictorParams
+
+	prev []byte
+	curr []byte
+	// pending is the decoded part of curr not yet read.
+	pending []byte
+
+	bpp int
+	err error
+}
+
+// NewPredictorReader returns a reader that reverses the prediction
+// applied to the data read from r.
+func NewPredictorReader(r io.Reader, params PredictorParams) (io.Reader, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("new predictor reader: %w", err)
+	}
+
+	if params.Predictor == PredictorNone {
+		return r, nil
+	}
+
+	rowSize := params.rowSize()
+
+	dec := &predictorDecoder{
+		r:      r,
+		params: params,
+		prev:   make([]byte, rowSize),
+		curr:   make([]byte, rowSize),
+		bpp:    params.pixelSize(),
+	}
+
+	// Each PNG row starts with the filter type byte.
+	if params.Predictor.IsPNG() {
+		dec.curr = make([]byte, rowSize+1)
+	}
+
+	return dec, nil
+}
+
+func (dec *predictorDecoder) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if len(dec.pending) == 0 {
+			if dec.err != nil {
+				break
+			}
+
+			dec.err = dec.readRow()
+
+			continue
+		}
+
+		copied := copy(p[n:], dec.pending)
+		dec.pending = dec.pending[copied:]
+		n += copied
+	}
+
+	if n > 0 {
+		return n, nil
+	}
+
+	return 0, dec.err
+}
+
+func (dec *predictorDecoder) readRow() error {
+	n, err := io.ReadFull(dec.r, dec.curr)
+
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		// Many producers truncate the last row, decode what we have.
+		err = io.EOF
+	case err != nil:
+		return err
+	}
+
+	if n == 0 {
+		return err
+	}
+
+	if !dec.params.Predictor.IsPNG() {
+		row := dec.curr[:n]
+		undoTIFFPrediction(row, dec.params)
+		dec.pending = row
+
+		return err
+	}
+
+	row := dec.curr[1:n]
+	if err := unfilterPNGRow(dec.curr[0], row, dec.prev[:len(row)], dec.bpp); err != nil {
+		return err
+	}
+
+	copy(dec.prev, row)
+	dec.pending = row
+
+	return err
+}
+
+type predictorEncoder struct {
+	w      io.WriteCloser
+	params PredictorParams
+
+	prev []byte
+	curr []byte
+	// n is the length of the data in curr.
+	n int
+	// out is the encoded row for each PNG filter type.
+	out [pngFilterPaeth + 1][]byte
+
+	bpp int
+}
+
+// NewPredictorWriter returns a writer that applies the prediction to
+// the data written to w. Close writes the last, possibly incomplete,
+// row and closes w. The PNG filter of PredictorPNGOptimum is chosen
+// for each row by the minimum sum of absolute differences heuristic.
+func NewPredictorWriter(w io.WriteCloser, params PredictorParams) (io.WriteCloser, error) {
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("new predictor writer: %w", err)
+	}
+
+	if params.Predictor == PredictorNone {
+		return w, nil
+	}
+
+	rowSize := params.rowSize()
+
+	enc := &predictorEncoder{
+		w:      w,
+		params: params,
+		prev:   make([]byte, rowSize),
+		curr:   make([]byte, rowSize),
+		bpp:    params.pixelSize(),
+	}
+
+	for i := range enc.out {
+		enc.out[i] = make([]byte, rowSize+1)
+		enc.out[i][0] = byte(i)
+	}
+
+	return enc, nil
+}
+
+func (enc *predictorEncoder) Write(p []byte) (n int, err error) {
+	for n < len(p) {
+		copied := copy(enc.curr[enc.n:], p[n:])
+		enc.n += copied
+		n += copied
+
+		if enc.n == len(enc.curr) {
+			if err = enc.writeRow(); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+func (enc *predictorEncoder) Close() error {
+	if enc.n > 0 {
+		if err := enc.writeRow(); err != nil {
+			return err
+		}
+	}
+
+	return enc.w.Close()
+}
+
+func (enc *predictorEncoder) writeRow() error {
+	row := enc.curr[:enc.n]
+	enc.n = 0
+
+	if !enc.params.Predictor.IsPNG() {
+		applyTIFFPrediction(row, enc.params)
+
+		_, err := enc.w.Write(row)
+
+		return err
+	}
+
+	prev := enc.prev[:len(row)]
+
+	var filter byte
+
+	if enc.params.Predictor == PredictorPNGOptimum {
+		best := -1
+
+		for i := range enc.out {
+			out := enc.out[i][1 : len(row)+1]
+			filterPNGRow(byte(i), out, row, prev, enc.bpp)
+
+			if sum := sumAbs(out); best < 0 || sum < best {
+				best, filter = sum, byte(i)
+			}
+		}
+	} else {
+		filter = byte(enc.params.Predictor - PredictorPNGNone)
+		filterPNGRow(filter, enc.out[filter][1:len(row)+1], row, prev, enc.bpp)
+	}
+
+	copy(enc.prev, row)
+
+	_, err := enc.w.Write(enc.out[filter][:len(row)+1])
+
+	return err
+}
+
+// sumAbs is the sum of the filtered bytes as signed values,
+// the smaller the sum is, the better the data is compressed.
+func sumAbs(data []byte) (sum int) {
+	for _, c := range data {
+		sum += abs(int(int8(c)))
+	}
+
+	return sum
+}
+
+// filterPNGRow applies the PNG filter to row and stores the result in dst.
+func filterPNGRow(filter byte, dst, row, prev []byte, bpp int) {
+	for i := range row {
+		var left, upperLeft byte
+
+		if i >= bpp {
+			left, upperLeft = row[i-bpp], prev[i-bpp]
+		}
+
+		switch filter {
+		case pngFilterNone:
+			dst[i] = row[i]
+		case pngFilterSub:
+			dst[i] = row[i] - left
+		case pngFilterUp:
+			dst[i] = row[i] - prev[i]
+		case pngFilterAverage:
+			dst[i] = row[i] - average(left, prev[i])
+		case pngFilterPaeth:
+			dst[i] = row[i] - paeth(left, prev[i], upperLeft)
+		}
+	}
+}
+
+// unfilterPNGRow reverses the PNG filter in place.
+func unfilterPNGRow(filter byte, row, prev []byte, bpp int) error {
+	switch filter {
+	case pngFilterNone:
+	case pngFilterSub:
+		for i := bpp; i < len(row); i++ {
+			row[i] += row[i-bpp]
+		}
+	case pngFilterUp:
+		for i := range row {
+			row[i] += prev[i]
+		}
+	case pngFilterAverage:
+		for i := range row {
+			var left byte
+
+			if i >= bpp {
+				left = row[i-bpp]
+			}
+
+			row[i] += average(left, prev[i])
+		}
+	case pngFilterPaeth:
+		for i := range row {
+			var left, upperLeft byte
+
+			if i >= bpp {
+				left, upperLeft = row[i-bpp], prev[i-bpp]
+			}
+
+			row[i] += paeth(left, prev[i], upperLeft)
+		}
+	default:
+		return fmt.Errorf("%w: unknown PNG filter type %d", ErrInvalidPredictor, filter)
+	}
+
+	return nil
+}
+
+func average(a, b byte) byte {
+	return byte((uint16(a) + uint16(b)) >> 1)
+}
+
+// paeth is the Paeth predictor function (see RFC 2083, section 6.6).
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+
+	return x
+}
+
+// PredictorParamsFrom reads the predictor parameters
+// of the Flate and LZW filters.
+func PredictorParamsFrom(params Params) PredictorParams {
+	defaults := DefaultPredictorParams()
+
+	return PredictorParams{
+		Predictor:        Predictor(paramInt(params, "Predictor", int64(defaults.Predictor))),
+		Colors:           int(paramInt(params, "Colors", int64(defaults.Colors))),
+		BitsPerComponent: int(paramInt(params, "BitsPerComponent", int64(defaults.BitsPerComponent))),
+		Columns:          int(paramInt(params, "Columns", int64(defaults.Columns))),
+	}
+}
+
+// newPredictorDecoder reverses the prediction of the decoded data.
+func newPredictorDecoder(r io.Reader, params Params) (io.Reader, error) {
+	return NewPredictorReader(r, PredictorParamsFrom(params))
+}
+
+// newPredictorEncoder applies the prediction before the data is encoded.
+func newPredictorEncoder(wc io.WriteCloser, params Params) (io.WriteCloser, error) {
+	return NewPredictorWriter(wc, PredictorParamsFrom(params))
+}
+
+// undoTIFFPrediction reverses the TIFF horizontal
+// differencing of the components of a row in place.
+func undoTIFFPrediction(row []byte, params PredictorParams) {
+	colors := params.Colors
+
+	switch params.BitsPerComponent {
+	case 8:
+		for i := colors; i < len(row); i++ {
+			row[i] += row[i-colors]
+		}
+	case 16:
+		for i := 2 * colors; i+1 < len(row); i += 2 {
+			v := binary.BigEndian.Uint16(row[i:]) + binary.BigEndian.Uint16(row[i-2*colors:])
+			binary.BigEndian.PutUint16(row[i:], v)
+		}
+	default:
+		bits := params.BitsPerComponent
+		mask := byte(1<<bits - 1)
+
+		for k := colors; k < componentCount(row, params); k++ {
+			setComponent(row, k, bits, (component(row, k, bits)+component(row, k-colors, bits))&mask)
+		}
+	}
+}
+
+// applyTIFFPrediction replaces the components of a row with
+// the differences to the previous ones in place.
+func applyTIFFPrediction(row []byte, params PredictorParams) {
+	colors := params.Colors
+
+	switch params.BitsPerComponent {
+	case 8:
+		for i := len(row) - 1; i >= colors; i-- {
+			row[i] -= row[i-colors]
+		}
+	case 16:
+		for i := (len(row)/2 - 1) * 2; i >= 2*colors; i -= 2 {
+			v := binary.BigEndian.Uint16(row[i:]) - binary.BigEndian.Uint16(row[i-2*colors:])
+			binary.BigEndian.PutUint16(row[i:], v)
+		}
+	default:
+		bits := params.BitsPerComponent
+		mask := byte(1<<bits - 1)
+
+		for k := componentCount(row, params) - 1; k >= colors; k-- {
+			setComponent(row, k, bits, (component(row, k, bits)-component(row, k-colors, bits))&mask)
+		}
+	}
+}
+
+// componentCount returns the number of the components of
+// less than 8 bits in a row, which may be truncated.
+func componentCount(row []byte, params PredictorParams) int {
+	const bitsPerByte = 8
+
+	n := params.Colors * params.Columns
+	if m := len(row) * bitsPerByte / params.BitsPerComponent; m < n {
+		n = m
+	}
+
+	return n
+}
+
+// component returns the component k of the given bits,
+// which are packed starting at the high-order bit.
+func component(row []byte, k, bits int) byte {
+	const bitsPerByte = 8
+
+	offset := k * bits
+	shift := bitsPerByte - bits - offset%bitsPerByte
+
+	return row[offset/bitsPerByte] >> shift & (1<<bits - 1)
+}
+
+func setComponent(row []byte, k, bits int, v byte) {
+	const bitsPerByte = 8
+
+	offset := k * bits
+	shift := bitsPerByte - bits - offset%bitsPerByte
+	mask := byte(1<<bits-1) << shift
+
+	row[offset/bitsPerByte] = row[offset/bitsPerByte]&^mask | v<<shift
+}
@@ -0,0 +1,237 @@
+package filter_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/filter"
+)
+
+func TestPredictorReaderPNG(t *testing.T) {
+	t.Parallel()
+
+	params := filter.PredictorParams{
+		Predictor:        filter.PredictorPNGOptimum,
+		Colors:           3,
+		BitsPerComponent: 8,
+		Columns:          2,
+	}
+
+	// Two RGB pixels per row, every row uses a different filter:
+	// None, Sub, Up, Average, Paeth.
+	encoded := []byte{
+		0, 10, 20, 30, 40, 50, 60,
+		1, 1, 2, 3, 4, 4, 4,
+		2, 5, 5, 5, 5, 5, 5,
+		3, 8, 9, 10, 9, 10, 11,
+		4, 1, 1, 1, 1, 1, 1,
+	}
+	want := []byte{
+		10, 20, 30, 40, 50, 60,
+		1, 2, 3, 5, 6, 7,
+		6, 7, 8, 10, 11, 12,
+		11, 12, 14, 19, 21, 24,
+		12, 13, 15, 20, 22, 25,
+	}
+
+	r, err := filter.NewPredictorReader(bytes.NewReader(encoded), params)
+	assert.NoError(t, err)
+
+	got, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	t.Run("truncated row", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := filter.NewPredictorReader(bytes.NewReader(encoded[:10]), params)
+		assert.NoError(t, err)
+
+		got, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, want[:8], got)
+	})
+
+	t.Run("invalid filter type", func(t *testing.T) {
+		t.Parallel()
+
+		r, err := filter.NewPredictorReader(bytes.NewReader([]byte{5, 1, 2, 3}), params)
+		assert.NoError(t, err)
+
+		_, err = io.ReadAll(r)
+		assert.ErrorIs(t, err, filter.ErrInvalidPredictor)
+	})
+}
+
+func TestPredictorParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	params := filter.DefaultPredictorParams()
+	assert.NoError(t, params.Validate())
+
+	params.BitsPerComponent = 3
+	assert.ErrorIs(t, params.Validate(), filter.ErrInvalidPredictor)
+
+	params = filter.DefaultPredictorParams()
+	params.Predictor = 7
+	assert.ErrorIs(t, params.Validate(), filter.ErrInvalidPredictor)
+}
+
+func TestPredictorReaderTIFF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		params  filter.PredictorParams
+		encoded []byte
+		want    []byte
+	}{
+		{
+			name:    "8 bits",
+			params:  filter.PredictorParams{Colors: 2, BitsPerComponent: 8, Columns: 3},
+			encoded: []byte{10, 20, 1, 2, 2, 3, 5, 5, 0, 0, 255, 1},
+			want:    []byte{10, 20, 11, 22, 13, 25, 5, 5, 5, 5, 4, 6},
+		},
+		{
+			name:    "16 bits",
+			params:  filter.PredictorParams{Colors: 1, BitsPerComponent: 16, Columns: 3},
+			encoded: []byte{0x01, 0x00, 0x00, 0x02, 0xFF, 0xFD},
+			want:    []byte{0x01, 0x00, 0x01, 0x02, 0x00, 0xFF},
+		},
+		{
+			name:    "1 bit",
+			params:  filter.PredictorParams{Colors: 1, BitsPerComponent: 1, Columns: 8},
+			encoded: []byte{0x27},
+			want:    []byte{0x3A},
+		},
+		{
+			name:    "4 bits with padding",
+			params:  filter.PredictorParams{Colors: 1, BitsPerComponent: 4, Columns: 3},
+			encoded: []byte{0x32, 0xF0},
+			want:    []byte{0x35, 0x40},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.params.Predictor = filter.PredictorTIFF
+
+			r, err := filter.NewPredictorReader(bytes.NewReader(tt.encoded), tt.params)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			got, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestPredictorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	predictors := []filter.Predictor{
+		filter.PredictorTIFF,
+		filter.PredictorPNGNone,
+		filter.PredictorPNGSub,
+		filter.PredictorPNGUp,
+		filter.PredictorPNGAverage,
+		filter.PredictorPNGPaeth,
+		filter.PredictorPNGOptimum,
+	}
+	layouts := []filter.PredictorParams{
+		{Colors: 3, BitsPerComponent: 8, Columns: 5},
+		{Colors: 2, BitsPerComponent: 16, Columns: 3},
+		{Colors: 1, BitsPerComponent: 1, Columns: 13},
+		{Colors: 3, BitsPerComponent: 2, Columns: 7},
+		{Colors: 4, BitsPerComponent: 4, Columns: 1},
+	}
+
+	rnd := rand.New(rand.NewSource(1)) // nolint:gosec
+
+	// The smooth data with a truncated last row.
+	data := make([]byte, 1001)
+	for i := range data {
+		data[i] = byte(i/3 + rnd.Intn(3))
+	}
+
+	for _, predictor := range predictors {
+		for _, layout := range layouts {
+			params := layout
+			params.Predictor = predictor
+
+			t.Run(fmt.Sprintf("%d/%d colors/%d bits", predictor, params.Colors, params.BitsPerComponent),
+				func(t *testing.T) {
+					t.Parallel()
+
+					var encoded bytes.Buffer
+
+					w, err := filter.NewPredictorWriter(nopCloser{&encoded}, params)
+					if !assert.NoError(t, err) {
+						return
+					}
+
+					_, err = w.Write(data[:500])
+					assert.NoError(t, err)
+					_, err = w.Write(data[500:])
+					assert.NoError(t, err)
+					assert.NoError(t, w.Close())
+
+					r, err := filter.NewPredictorReader(&encoded, params)
+					if !assert.NoError(t, err) {
+						return
+					}
+
+					got, err := io.ReadAll(r)
+					assert.NoError(t, err)
+					assert.Equal(t, data, got)
+				})
+		}
+	}
+}
+
+func TestPredictorWriterOptimum(t *testing.T) {
+	t.Parallel()
+
+	params := filter.PredictorParams{
+		Predictor:        filter.PredictorPNGOptimum,
+		Colors:           1,
+		BitsPerComponent: 8,
+		Columns:          8,
+	}
+
+	// A horizontal gradient is best predicted by Sub and
+	// the repeated row is best predicted by Up.
+	gradient := []byte{100, 110, 120, 130, 140, 150, 160, 170}
+
+	var encoded bytes.Buffer
+
+	w, err := filter.NewPredictorWriter(nopCloser{&encoded}, params)
+	assert.NoError(t, err)
+
+	_, err = w.Write(append(append([]byte{}, gradient...), gradient...))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	want := []byte{
+		1, 100, 10, 10, 10, 10, 10, 10, 10,
+		2, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+	assert.Equal(t, want, encoded.Bytes())
+}
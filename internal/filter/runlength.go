@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The RunLength length bytes (see ISO 32000-2:2020, 7.4.5).
+const (
+	runLengthEOD    = 128
+	runLengthMaxRun = 128
+)
+
+type runLengthDecoder struct {
+	r *bufio.Reader
+	// literal is the number of bytes left to copy.
+	literal int
+	// repeat is the number of times c is left to repeat.
+	repeat int
+	c      byte
+	err    error
+}
+
+func newRunLengthDecoder(r io.Reader, _ Params) (io.Reader, error) {
+	return &runLengthDecoder{r: bufio.NewReader(r)}, nil
+}
+
+func (dec *runLengthDecoder) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		switch {
+		case dec.repeat > 0:
+			for ; dec.repeat > 0 && n < len(p); dec.repeat-- {
+				p[n] = dec.c
+				n++
+			}
+		case dec.literal > 0:
+			end := len(p)
+			if end-n > dec.literal {
+				end = n + dec.literal
+			}
+
+			m, err := dec.r.Read(p[n:end])
+			n += m
+			dec.literal -= m
+
+			if err != nil {
+				dec.err, dec.literal = err, 0
+			}
+		case dec.err != nil:
+			if n > 0 {
+				return n, nil
+			}
+
+			return 0, dec.err
+		default:
+			dec.err = dec.readRun()
+		}
+	}
+
+	return n, nil
+}
+
+// readRun reads the length byte of the next run.
+func (dec *runLengthDecoder) readRun() error {
+	length, err := dec.r.ReadByte()
+
+	switch {
+	case err != nil:
+		// The missing EOD marker is tolerated.
+		return err
+	case length == runLengthEOD:
+		return io.EOF
+	case length < runLengthEOD:
+		dec.literal = int(length) + 1
+	default:
+		if dec.c, err = dec.r.ReadByte(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("%w: RunLength run without data", ErrCorruptData)
+			}
+
+			return err
+		}
+
+		dec.repeat = 257 - int(length)
+	}
+
+	return nil
+}
+
+type runLengthEncoder struct {
+	w   io.Writer
+	buf []byte
+	out []byte
+}
+
+func newRunLengthEncoder(w io.Writer, _ Params) (io.WriteCloser, error) {
+	return &runLengthEncoder{w: w}, nil
+}
+
+func (enc *runLengthEncoder) Write(p []byte) (int, error) {
+	enc.buf = append(enc.buf, p...)
+
+	if err := enc.encode(false); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func (enc *runLengthEncoder) Close() error {
+	if err := enc.encode(true); err != nil {
+		return err
+	}
+
+	_, err := enc.w.Write([]byte{runLengthEOD})
+
+	return err
+}
+
+// encode encodes the buffered data. Unless it is final, the last
+// bytes are kept, as they may start a run continued by the next write.
+func (enc *runLengthEncoder) encode(final bool) error {
+	data, out := enc.buf, enc.out[:0]
+
+	for len(data) > 0 && (final || len(data) > runLengthMaxRun) {
+		run := 1
+		for run < len(data) && run < runLengthMaxRun && data[run] == data[0] {
+			run++
+		}
+
+		if run > 1 {
+			out = append(out, byte(257-run), data[0])
+			data = data[run:]
+
+			continue
+		}
+
+		// The literal ends where a run of two bytes starts.
+		literal := 1
+		for literal < len(data) && literal < runLengthMaxRun &&
+			(literal+1 >= len(data) || data[literal] != data[literal+1]) {
+			literal++
+		}
+
+		out = append(out, byte(literal-1))
+		out = append(out, data[:literal]...)
+		data = data[literal:]
+	}
+
+	enc.buf = append(enc.buf[:0], data...)
+	enc.out = out
+
+	if len(out) == 0 {
+		return nil
+	}
+
+	_, err := enc.w.Write(out)
+
+	return err
+}
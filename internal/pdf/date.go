@@ -0,0 +1,126 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatDate formats the time as a PDF date, i.e.
+// D:YYYYMMDDHHmmSSOHH'mm' (see ISO 32000-1:2008, 7.9.4).
+func FormatDate(t time.Time) string {
+	const (
+		layout        = "20060102150405"
+		secondsInHour = 3600
+		secondsInMin  = 60
+	)
+
+	date := "D:" + t.Format(layout)
+
+	_, offset := t.Zone()
+
+	switch {
+	case offset == 0:
+		return date + "Z"
+	case offset < 0:
+		date += "-"
+		offset = -offset
+	default:
+		date += "+"
+	}
+
+	return date + fmt.Sprintf("%02d'%02d'", offset/secondsInHour, offset%secondsInHour/secondsInMin)
+}
+
+// ParseDate parses the PDF date D:YYYYMMDDHHmmSSOHH'mm'. All the
+// fields but the year are optional. The date is parsed leniently:
+// the prefix and the apostrophes may be missing, the year 19100 and
+// so on written by the producers counting the years since 1900 is
+// read as 2000 and so on, and the text following the date is
+// ignored. A date without the time zone is UTC.
+func ParseDate(text string) (time.Time, error) {
+	const minYearDigits = 4
+
+	s := strings.TrimPrefix(strings.TrimSpace(text), "D:")
+
+	var year int
+
+	switch n := leadingDigits(s); {
+	case n%2 == 1 && n >= 5 && strings.HasPrefix(s, "191"):
+		year = 1900 + atoi(s[2:5])
+		s = s[5:]
+	case n >= minYearDigits:
+		year = atoi(s[:minYearDigits])
+		s = s[minYearDigits:]
+	default:
+		return time.Time{}, fmt.Errorf("%w: %q", ErrDate, text)
+	}
+
+	// The month, the day, the hours, the minutes and the seconds.
+	fields := [...]int{1, 1, 0, 0, 0}
+	limits := [...][2]int{{1, 12}, {1, 31}, {0, 23}, {0, 59}, {0, 59}}
+
+	for i := range fields {
+		if leadingDigits(s) < 2 {
+			break
+		}
+
+		fields[i], s = atoi(s[:2]), s[2:]
+
+		if fields[i] < limits[i][0] || fields[i] > limits[i][1] {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrDate, text)
+		}
+	}
+
+	loc := time.UTC
+
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+
+		s = s[1:]
+
+		var hours, minutes int
+
+		if leadingDigits(s) >= 2 {
+			hours, s = atoi(s[:2]), s[2:]
+			s = strings.TrimPrefix(s, "'")
+
+			if leadingDigits(s) >= 2 {
+				minutes = atoi(s[:2])
+			}
+		}
+
+		if hours > 23 || minutes > 59 {
+			return time.Time{}, fmt.Errorf("%w: %q", ErrDate, text)
+		}
+
+		if offset := sign * (hours*3600 + minutes*60); offset != 0 {
+			loc = time.FixedZone("", offset)
+		}
+	}
+
+	return time.Date(year, time.Month(fields[0]), fields[1],
+		fields[2], fields[3], fields[4], 0, loc), nil
+}
+
+// leadingDigits returns the number of the digits the text starts with.
+func leadingDigits(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return i
+		}
+	}
+
+	return len(s)
+}
+
+// atoi converts the digits to int.
+func atoi(digits string) int {
+	n, _ := strconv.Atoi(digits)
+
+	return n
+}
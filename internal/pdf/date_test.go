@@ -0,0 +1,90 @@
+package pdf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+func TestParseDate(t *testing.T) {
+	t.Parallel()
+
+	pst := time.FixedZone("", -8*3600)
+	ist := time.FixedZone("", 5*3600+30*60)
+
+	tests := []struct {
+		name string
+		text string
+		want time.Time
+	}{
+		{name: "full", text: "D:19981223195210-08'00'", want: time.Date(1998, 12, 23, 19, 52, 10, 0, pst)},
+		{name: "utc", text: "D:20230405060708Z", want: time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)},
+		{name: "utc with offset", text: "D:20230405060708Z00'00'", want: time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC)},
+		{name: "year only", text: "D:2001", want: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "no prefix", text: "20010203", want: time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{name: "no apostrophes", text: "D:20010203040506+0530", want: time.Date(2001, 2, 3, 4, 5, 6, 0, ist)},
+		{name: "no trailing apostrophe", text: "D:20010203040506+05'30", want: time.Date(2001, 2, 3, 4, 5, 6, 0, ist)},
+		{name: "hours offset", text: "D:20010203040506-08", want: time.Date(2001, 2, 3, 4, 5, 6, 0, pst)},
+		{name: "spaces", text: " D:20010203040506Z ", want: time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)},
+		{name: "trailing text", text: "D:20010203040506Z (local)", want: time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)},
+		{name: "year 19100", text: "D:191000102030405", want: time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := pdf.ParseDate(tt.text)
+			if assert.NoError(t, err) {
+				assert.True(t, tt.want.Equal(got), "want %v, got %v", tt.want, got)
+				_, wantOffset := tt.want.Zone()
+				_, gotOffset := got.Zone()
+				assert.Equal(t, wantOffset, gotOffset)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{"", "D:", "D:99", "yesterday", "D:20011301", "D:20010132", "D:2001010125", "D:20010101+25"} {
+		_, err := pdf.ParseDate(text)
+		assert.ErrorIs(t, err, pdf.ErrDate, text)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		time time.Time
+		want string
+	}{
+		{name: "utc", time: time.Date(2023, 4, 5, 6, 7, 8, 0, time.UTC), want: "D:20230405060708Z"},
+		{name: "west", time: time.Date(1998, 12, 23, 19, 52, 10, 0, time.FixedZone("", -8*3600)), want: "D:19981223195210-08'00'"},
+		{name: "east", time: time.Date(2001, 2, 3, 4, 5, 6, 0, time.FixedZone("", 5*3600+30*60)), want: "D:20010203040506+05'30'"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := pdf.FormatDate(tt.time)
+			assert.Equal(t, tt.want, got)
+
+			parsed, err := pdf.ParseDate(got)
+			if assert.NoError(t, err) {
+				assert.True(t, tt.time.Equal(parsed))
+			}
+		})
+	}
+}
@@ -6,12 +6,24 @@ type Element struct {
 
 func (e *Element) Object() Object { return e.object }
 
-func (e *Element) Document() *Document { return e.object.Document() }
+// Document returns the document of the object,
+// nil is returned if the object has none.
+func (e *Element) Document() *Document {
+	if obj, ok := e.object.(interface{ Document() *Document }); ok {
+		return obj.Document()
+	}
+
+	return nil
+}
 
 type DictionaryElement struct{ Element }
 
+// Dictionary returns the dictionary of the element,
+// nil is returned if the object is not a dictionary.
 func (e *DictionaryElement) Dictionary() *Dictionary {
-	return e.object.Dictionary()
+	dict, _ := e.object.(*Dictionary)
+
+	return dict
 }
 
 type ArrayElement struct{ Element }
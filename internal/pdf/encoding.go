@@ -100,6 +100,8 @@ var utf8ToPDFEncodingMap struct {
 
 func getUTF8ToPDFEncodingMap() map[rune]byte {
 	utf8ToPDFEncodingMap.once.Do(func() {
+		utf8ToPDFEncodingMap.encMap = make(map[rune]byte, len(cEncoding))
+
 		for i, mapped := range cEncoding {
 			if mapped == 0 && i > 0 {
 				continue
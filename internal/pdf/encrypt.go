@@ -1,9 +1,21 @@
 package pdf
 
-// TODO: implement Encryptor
-
-type Encrypt interface{}
+// Encrypt encrypts the strings and the streams of the indirect
+// objects written by Writer. The data of the zero reference,
+// e.g. the encryption dictionary itself, is never encrypted.
+type Encrypt interface {
+	// EncryptString encrypts a string of the indirect object ref.
+	EncryptString(ref Reference, data []byte) ([]byte, error)
+	// EncryptStream encrypts the stream data of the indirect object
+	// ref. The metadata streams may be left unencrypted.
+	EncryptStream(ref Reference, data []byte, isMetadata bool) ([]byte, error)
+}
 
+// encrypt is the Encrypt that does not encrypt anything.
 type encrypt struct{}
 
 func NewEncrypt() Encrypt { return new(encrypt) }
+
+func (*encrypt) EncryptString(_ Reference, data []byte) ([]byte, error) { return data, nil }
+
+func (*encrypt) EncryptStream(_ Reference, data []byte, _ bool) ([]byte, error) { return data, nil }
@@ -8,4 +8,6 @@ var (
 	ErrNotImplemented = errors.New("not implemented")
 
 	ErrCannotConvertColor = errors.New("cannot convert color")
+
+	ErrDate = errors.New("bad date")
 )
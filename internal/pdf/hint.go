@@ -0,0 +1,467 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+var ErrInvalidHintTable = errors.New("invalid hint table")
+
+// PageHint is the entry of the page offset hint table, the values
+// are the actual ones, not the differences from the least values.
+type PageHint struct {
+	// Objects is the number of objects of the page,
+	// including the page object.
+	Objects int64
+	// Length is the length of the page in bytes.
+	Length int64
+	// SharedObjects are the identifiers of the shared object
+	// groups the page refers to, i.e. their indices in the
+	// shared object hint table.
+	SharedObjects []int64
+	// Numerators are the numerators of the fractional positions
+	// of the shared object references in the content stream.
+	Numerators []int64
+	// ContentOffset is the offset of the content stream
+	// relative to the beginning of the page.
+	ContentOffset int64
+	// ContentLength is the length of the content stream.
+	ContentLength int64
+}
+
+// PageOffsetHints is the page offset hint table of a linearized
+// file (see ISO 32000-1:2008, F.4.1). The offsets are computed as
+// if the primary hint stream were not present in the file.
+type PageOffsetHints struct {
+	// FirstPageOffset is the location of
+	// the page object of the first page.
+	FirstPageOffset int64
+	// Denominator is the denominator of the fractional
+	// positions of the shared object references.
+	Denominator int64
+	// Pages are the entries of the pages in their order.
+	Pages []PageHint
+}
+
+// SharedObjectGroup is the entry of the shared object hint table.
+type SharedObjectGroup struct {
+	// Length is the length of the group in bytes.
+	Length int64
+	// Objects is the number of objects of the group.
+	Objects int64
+	// MD5 is the signature of the group, nil if there is none.
+	MD5 []byte
+}
+
+// SharedObjectHints is the shared object hint table of a linearized
+// file (see ISO 32000-1:2008, F.4.2). The groups of the objects of
+// the first page come first, followed by the ones of the shared
+// objects section.
+type SharedObjectHints struct {
+	// FirstObjectNo is the object number of the
+	// first object of the shared objects section.
+	FirstObjectNo int64
+	// FirstObjectOffset is the location of the
+	// first object of the shared objects section.
+	FirstObjectOffset int64
+	// FirstPageGroups is the number of the groups
+	// of the objects of the first page.
+	FirstPageGroups int
+	// Groups are the groups of the objects.
+	Groups []SharedObjectGroup
+}
+
+// AppendPageOffsetHints appends the page offset hint table. The least
+// values and the numbers of bits of the header are computed from the
+// entries of the pages.
+func AppendPageOffsetHints(buf []byte, hints *PageOffsetHints) []byte {
+	pages := hints.Pages
+
+	leastObjects, objectsBits := hintRange(len(pages), func(i int) int64 { return pages[i].Objects })
+	leastLength, lengthBits := hintRange(len(pages), func(i int) int64 { return pages[i].Length })
+	leastOffset, offsetBits := hintRange(len(pages), func(i int) int64 { return pages[i].ContentOffset })
+	leastContent, contentBits := hintRange(len(pages), func(i int) int64 { return pages[i].ContentLength })
+
+	var maxShared, maxID, maxNumerator int64
+
+	for _, page := range pages {
+		maxShared = maxInt64(maxShared, int64(len(page.SharedObjects)))
+
+		for _, id := range page.SharedObjects {
+			maxID = maxInt64(maxID, id)
+		}
+
+		for _, numerator := range page.Numerators {
+			maxNumerator = maxInt64(maxNumerator, numerator)
+		}
+	}
+
+	sharedBits, idBits, numeratorBits := bitsLen(maxShared), bitsLen(maxID), bitsLen(maxNumerator)
+
+	w := &bitWriter{buf: buf}
+
+	w.write(uint64(leastObjects), 32)
+	w.write(uint64(hints.FirstPageOffset), 32)
+	w.write(uint64(objectsBits), 16)
+	w.write(uint64(leastLength), 32)
+	w.write(uint64(lengthBits), 16)
+	w.write(uint64(leastOffset), 32)
+	w.write(uint64(offsetBits), 16)
+	w.write(uint64(leastContent), 32)
+	w.write(uint64(contentBits), 16)
+	w.write(uint64(sharedBits), 16)
+	w.write(uint64(idBits), 16)
+	w.write(uint64(numeratorBits), 16)
+	w.write(uint64(hints.Denominator), 16)
+
+	// Every item of all the pages starts at a byte boundary.
+	w.writeItems(len(pages), objectsBits, func(i int) int64 { return pages[i].Objects - leastObjects })
+	w.writeItems(len(pages), lengthBits, func(i int) int64 { return pages[i].Length - leastLength })
+	w.writeItems(len(pages), sharedBits, func(i int) int64 { return int64(len(pages[i].SharedObjects)) })
+
+	for _, page := range pages {
+		for _, id := range page.SharedObjects {
+			w.write(uint64(id), idBits)
+		}
+	}
+
+	w.flush()
+
+	for _, page := range pages {
+		for i := range page.SharedObjects {
+			var numerator int64
+			if i < len(page.Numerators) {
+				numerator = page.Numerators[i]
+			}
+
+			w.write(uint64(numerator), numeratorBits)
+		}
+	}
+
+	w.flush()
+	w.writeItems(len(pages), offsetBits, func(i int) int64 { return pages[i].ContentOffset - leastOffset })
+	w.writeItems(len(pages), contentBits, func(i int) int64 { return pages[i].ContentLength - leastContent })
+
+	return w.buf
+}
+
+// ParsePageOffsetHints parses the page offset hint table of numPages
+// pages. The number of bytes read is returned as well.
+func ParsePageOffsetHints(data []byte, numPages int) (*PageOffsetHints, int, error) {
+	r := &bitReader{data: data}
+
+	leastObjects := r.read(32)
+	firstPageOffset := r.read(32)
+	objectsBits := r.readBits()
+	leastLength := r.read(32)
+	lengthBits := r.readBits()
+	leastOffset := r.read(32)
+	offsetBits := r.readBits()
+	leastContent := r.read(32)
+	contentBits := r.readBits()
+	sharedBits := r.readBits()
+	idBits := r.readBits()
+	numeratorBits := r.readBits()
+	denominator := r.read(16)
+
+	if r.err != nil {
+		return nil, 0, fmt.Errorf("parse page offset hints: header: %w", r.err)
+	}
+
+	hints := &PageOffsetHints{
+		FirstPageOffset: int64(firstPageOffset),
+		Denominator:     int64(denominator),
+		Pages:           make([]PageHint, numPages),
+	}
+	pages := hints.Pages
+
+	r.readItems(numPages, objectsBits, func(i int, v uint64) { pages[i].Objects = int64(leastObjects + v) })
+	r.readItems(numPages, lengthBits, func(i int, v uint64) { pages[i].Length = int64(leastLength + v) })
+
+	shared := make([]uint64, numPages)
+	r.readItems(numPages, sharedBits, func(i int, v uint64) { shared[i] = v })
+
+	for i := range pages {
+		// The number is checked before the identifiers
+		// are allocated, as a page can not refer to more
+		// shared objects than the bits the table has.
+		if shared[i] > uint64(len(data))*8 {
+			return nil, 0, fmt.Errorf("parse page offset hints: %w: %d shared objects of page %d",
+				ErrInvalidHintTable, shared[i], i)
+		}
+
+		pages[i].SharedObjects = make([]int64, 0, shared[i])
+
+		for j := uint64(0); j < shared[i] && r.err == nil; j++ {
+			pages[i].SharedObjects = append(pages[i].SharedObjects, int64(r.read(idBits)))
+		}
+	}
+
+	r.align()
+
+	for i := range pages {
+		pages[i].Numerators = make([]int64, 0, len(pages[i].SharedObjects))
+
+		for range pages[i].SharedObjects {
+			if r.err != nil {
+				break
+			}
+
+			pages[i].Numerators = append(pages[i].Numerators, int64(r.read(numeratorBits)))
+		}
+	}
+
+	r.align()
+	r.readItems(numPages, offsetBits, func(i int, v uint64) { pages[i].ContentOffset = int64(leastOffset + v) })
+	r.readItems(numPages, contentBits, func(i int, v uint64) { pages[i].ContentLength = int64(leastContent + v) })
+
+	if r.err != nil {
+		return nil, 0, fmt.Errorf("parse page offset hints: %w", r.err)
+	}
+
+	return hints, r.pos, nil
+}
+
+// AppendSharedObjectHints appends the shared object hint table. The
+// least length and the numbers of bits of the header are computed
+// from the groups.
+func AppendSharedObjectHints(buf []byte, hints *SharedObjectHints) []byte {
+	const md5Len = 16
+
+	groups := hints.Groups
+
+	leastLength, lengthBits := hintRange(len(groups), func(i int) int64 { return groups[i].Length })
+
+	var maxObjects int64
+
+	for _, group := range groups {
+		maxObjects = maxInt64(maxObjects, group.Objects-1)
+	}
+
+	objectsBits := bitsLen(maxObjects)
+
+	w := &bitWriter{buf: buf}
+
+	w.write(uint64(hints.FirstObjectNo), 32)
+	w.write(uint64(hints.FirstObjectOffset), 32)
+	w.write(uint64(hints.FirstPageGroups), 32)
+	w.write(uint64(len(groups)), 32)
+	w.write(uint64(objectsBits), 16)
+	w.write(uint64(leastLength), 32)
+	w.write(uint64(lengthBits), 16)
+
+	w.writeItems(len(groups), lengthBits, func(i int) int64 { return groups[i].Length - leastLength })
+
+	for _, group := range groups {
+		if len(group.MD5) == md5Len {
+			w.write(1, 1)
+		} else {
+			w.write(0, 1)
+		}
+	}
+
+	w.flush()
+
+	for _, group := range groups {
+		if len(group.MD5) == md5Len {
+			for _, b := range group.MD5 {
+				w.write(uint64(b), 8)
+			}
+		}
+	}
+
+	w.writeItems(len(groups), objectsBits, func(i int) int64 { return groups[i].Objects - 1 })
+
+	return w.buf
+}
+
+// ParseSharedObjectHints parses the shared object hint table.
+func ParseSharedObjectHints(data []byte) (*SharedObjectHints, error) {
+	const md5Len = 16
+
+	r := &bitReader{data: data}
+
+	firstObjectNo := r.read(32)
+	firstObjectOffset := r.read(32)
+	firstPageGroups := r.read(32)
+	numGroups := r.read(32)
+	objectsBits := r.readBits()
+	leastLength := r.read(32)
+	lengthBits := r.readBits()
+
+	if r.err != nil {
+		return nil, fmt.Errorf("parse shared object hints: header: %w", r.err)
+	}
+
+	// Every group takes at least a bit, the number of the
+	// groups is checked before the groups are allocated.
+	if numGroups > uint64(len(data))*8 || firstPageGroups > numGroups {
+		return nil, fmt.Errorf("parse shared object hints: %w: %d groups", ErrInvalidHintTable, numGroups)
+	}
+
+	hints := &SharedObjectHints{
+		FirstObjectNo:     int64(firstObjectNo),
+		FirstObjectOffset: int64(firstObjectOffset),
+		FirstPageGroups:   int(firstPageGroups),
+		Groups:            make([]SharedObjectGroup, numGroups),
+	}
+	groups := hints.Groups
+
+	r.readItems(len(groups), lengthBits, func(i int, v uint64) { groups[i].Length = int64(leastLength + v) })
+	r.readItems(len(groups), 1, func(i int, v uint64) {
+		if v != 0 {
+			groups[i].MD5 = make([]byte, md5Len)
+		}
+	})
+
+	for i := range groups {
+		for j := range groups[i].MD5 {
+			groups[i].MD5[j] = byte(r.read(8))
+		}
+	}
+
+	r.readItems(len(groups), objectsBits, func(i int, v uint64) { groups[i].Objects = int64(v) + 1 })
+
+	if r.err != nil {
+		return nil, fmt.Errorf("parse shared object hints: %w", r.err)
+	}
+
+	return hints, nil
+}
+
+// hintRange returns the least of the n values and the number
+// of bits the differences between the values and the least
+// one need.
+func hintRange(n int, value func(i int) int64) (least int64, nbits int) {
+	if n == 0 {
+		return 0, 0
+	}
+
+	least, greatest := value(0), value(0)
+
+	for i := 1; i < n; i++ {
+		v := value(i)
+		least, greatest = minInt64(least, v), maxInt64(greatest, v)
+	}
+
+	return least, bitsLen(greatest - least)
+}
+
+// bitsLen returns the number of bits the value needs.
+func bitsLen(value int64) int {
+	if value <= 0 {
+		return 0
+	}
+
+	return bits.Len64(uint64(value))
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+// bitWriter writes the big-endian bit fields of the hint tables.
+type bitWriter struct {
+	buf   []byte
+	acc   byte
+	nbits int
+}
+
+func (w *bitWriter) write(value uint64, nbits int) {
+	for i := nbits - 1; i >= 0; i-- {
+		w.acc = w.acc<<1 | byte(value>>i&1)
+		w.nbits++
+
+		if w.nbits == 8 {
+			w.buf = append(w.buf, w.acc)
+			w.acc, w.nbits = 0, 0
+		}
+	}
+}
+
+// flush pads the last byte with zero bits.
+func (w *bitWriter) flush() {
+	if w.nbits > 0 {
+		w.write(0, 8-w.nbits)
+	}
+}
+
+// writeItems writes an item of n entries starting at a byte boundary.
+func (w *bitWriter) writeItems(n, nbits int, value func(i int) int64) {
+	for i := 0; i < n; i++ {
+		w.write(uint64(value(i)), nbits)
+	}
+
+	w.flush()
+}
+
+// bitReader reads the big-endian bit fields of the hint tables.
+// The first error is kept, the values read after it are zeroes.
+type bitReader struct {
+	data []byte
+	pos  int
+	bit  int
+	err  error
+}
+
+func (r *bitReader) read(nbits int) uint64 {
+	var value uint64
+
+	for i := 0; i < nbits && r.err == nil; i++ {
+		if r.pos >= len(r.data) {
+			r.err = io.ErrUnexpectedEOF
+
+			return 0
+		}
+
+		value = value<<1 | uint64(r.data[r.pos]>>(7-r.bit)&1)
+
+		if r.bit++; r.bit == 8 {
+			r.pos, r.bit = r.pos+1, 0
+		}
+	}
+
+	return value
+}
+
+// readBits reads the 16-bit number of bits of an item.
+func (r *bitReader) readBits() int {
+	const maxBits = 64
+
+	nbits := int(r.read(16))
+	if nbits > maxBits && r.err == nil {
+		r.err = fmt.Errorf("%w: %d bits", ErrInvalidHintTable, nbits)
+	}
+
+	return nbits
+}
+
+// align skips the rest of the byte.
+func (r *bitReader) align() {
+	if r.bit > 0 {
+		r.pos, r.bit = r.pos+1, 0
+	}
+}
+
+// readItems reads an item of n entries starting at a byte boundary.
+func (r *bitReader) readItems(n, nbits int, set func(i int, v uint64)) {
+	for i := 0; i < n && r.err == nil; i++ {
+		set(i, r.read(nbits))
+	}
+
+	r.align()
+}
@@ -0,0 +1,101 @@
+package pdf_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+func TestPageOffsetHints(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		hints pdf.PageOffsetHints
+	}{{
+		name: "single page",
+		hints: pdf.PageOffsetHints{
+			FirstPageOffset: 1234,
+			Denominator:     1,
+			Pages: []pdf.PageHint{{
+				Objects: 7, Length: 4567, ContentLength: 4567,
+				SharedObjects: []int64{}, Numerators: []int64{},
+			}},
+		},
+	}, {
+		name: "shared objects",
+		hints: pdf.PageOffsetHints{
+			FirstPageOffset: 803,
+			Denominator:     4,
+			Pages: []pdf.PageHint{{
+				Objects: 12, Length: 9000, ContentLength: 9000,
+				SharedObjects: []int64{}, Numerators: []int64{},
+			}, {
+				Objects: 3, Length: 311, ContentOffset: 17, ContentLength: 200,
+				SharedObjects: []int64{12, 14}, Numerators: []int64{1, 3},
+			}, {
+				Objects: 5, Length: 1024, ContentLength: 1024,
+				SharedObjects: []int64{13}, Numerators: []int64{0},
+			}},
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := pdf.AppendPageOffsetHints(nil, &tt.hints)
+
+			got, n, err := pdf.ParsePageOffsetHints(data, len(tt.hints.Pages))
+			if assert.NoError(t, err) {
+				assert.Equal(t, &tt.hints, got)
+				assert.Equal(t, len(data), n)
+			}
+		})
+	}
+}
+
+func TestSharedObjectHints(t *testing.T) {
+	t.Parallel()
+
+	md5 := []byte("0123456789abcdef")
+	hints := &pdf.SharedObjectHints{
+		FirstObjectNo:     3,
+		FirstObjectOffset: 4096,
+		FirstPageGroups:   2,
+		Groups: []pdf.SharedObjectGroup{
+			{Length: 100, Objects: 1},
+			{Length: 250, Objects: 1, MD5: md5},
+			{Length: 75, Objects: 3},
+		},
+	}
+
+	data := pdf.AppendSharedObjectHints(nil, hints)
+
+	got, err := pdf.ParseSharedObjectHints(data)
+	if assert.NoError(t, err) {
+		assert.Equal(t, hints, got)
+	}
+
+	_, err = pdf.ParseSharedObjectHints(data[:len(data)-1])
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestParseHintsInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, _, err := pdf.ParsePageOffsetHints(make([]byte, 10), 1)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	// The table claims far more groups than the data holds.
+	data := pdf.AppendSharedObjectHints(nil, &pdf.SharedObjectHints{})
+	data[8], data[12] = 0x7F, 0x7F
+
+	_, err = pdf.ParseSharedObjectHints(data)
+	assert.ErrorIs(t, err, pdf.ErrInvalidHintTable)
+}
@@ -1,5 +1,25 @@
 package pdf
 
-// TODO: implement
+import "time"
 
-type Info struct{}
+// Info is the document information dictionary
+// (see ISO 32000-1:2008, 14.3.3).
+type Info struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Creator  string
+	Producer string
+
+	// The zero times mean the dates are not set.
+	CreationDate time.Time
+	ModDate      time.Time
+
+	// Trapped is NameTrue, NameFalse or NameUnknown,
+	// the empty name means it is not set.
+	Trapped Name
+
+	// Custom are the other text entries of the dictionary.
+	Custom map[Name]string
+}
@@ -0,0 +1,487 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrInvalidHexString = errors.New("invalid hex string")
+)
+
+// TokenKind is the kind of a lexical token.
+type TokenKind uint8
+
+const (
+	// TokenKindUnknown is the zero value.
+	TokenKindUnknown TokenKind = iota
+	// TokenKindKeyword is a sequence of regular characters that is
+	// not a number, e.g. obj, R, true or null.
+	TokenKindKeyword
+	// TokenKindInteger is an integer number.
+	TokenKindInteger
+	// TokenKindReal is a real number.
+	TokenKindReal
+	// TokenKindName is a name object.
+	TokenKindName
+	// TokenKindString is a literal string.
+	TokenKindString
+	// TokenKindHexString is a hexadecimal string.
+	TokenKindHexString
+	// TokenKindArrayStart is the "[" delimiter.
+	TokenKindArrayStart
+	// TokenKindArrayEnd is the "]" delimiter.
+	TokenKindArrayEnd
+	// TokenKindDictionaryStart is the "<<" delimiter.
+	TokenKindDictionaryStart
+	// TokenKindDictionaryEnd is the ">>" delimiter.
+	TokenKindDictionaryEnd
+	// TokenKindProcedureStart is the "{" delimiter.
+	TokenKindProcedureStart
+	// TokenKindProcedureEnd is the "}" delimiter.
+	TokenKindProcedureEnd
+)
+
+// Token is a lexical token of a PDF file.
+type Token struct {
+	Kind TokenKind
+	// Value is the delimiter, the keyword or the number as is,
+	// the decoded name without the leading slash or the decoded
+	// string bytes.
+	Value []byte
+	// Offset is the offset of the token from the start
+	// of the lexer input.
+	Offset int64
+}
+
+// IsKeyword returns true if the token is the keyword.
+func (tok Token) IsKeyword(keyword string) bool {
+	return tok.Kind == TokenKindKeyword && string(tok.Value) == keyword
+}
+
+// Int64 returns the value of an integer token.
+func (tok Token) Int64() (int64, error) {
+	if tok.Kind != TokenKindInteger {
+		return 0, fmt.Errorf("%w: %q is not an integer", ErrInvalidToken, tok.Value)
+	}
+
+	return strconv.ParseInt(string(tok.Value), 10, 64)
+}
+
+// Lexer splits its input into the PDF tokens
+// (see ISO 32000-1:2008, 7.2).
+type Lexer struct {
+	r io.ByteScanner
+	// offset is the number of bytes consumed.
+	offset int64
+	// queue holds the tokens peeked or put back.
+	queue []Token
+}
+
+// NewLexer creates a lexer reading from r.
+func NewLexer(r io.ByteScanner) *Lexer {
+	return &Lexer{r: r}
+}
+
+// Offset returns the offset from the start of the input right
+// after the last token returned by Next. Whitespace and comments
+// following the token may or may not have been consumed.
+func (lex *Lexer) Offset() int64 {
+	if len(lex.queue) > 0 {
+		return lex.queue[0].Offset
+	}
+
+	return lex.offset
+}
+
+// Next returns the next token. The io.EOF error
+// is returned at the end of the input.
+func (lex *Lexer) Next() (Token, error) {
+	if len(lex.queue) > 0 {
+		tok := lex.queue[0]
+		lex.queue = lex.queue[1:]
+
+		return tok, nil
+	}
+
+	return lex.read()
+}
+
+// Peek returns the next token without consuming it.
+func (lex *Lexer) Peek() (Token, error) {
+	tok, err := lex.Next()
+	if err == nil {
+		lex.Unread(tok)
+	}
+
+	return tok, err
+}
+
+// Unread puts the token back, so it is returned by the next call
+// of Next. Tokens must be put back in the reverse order.
+func (lex *Lexer) Unread(tok Token) {
+	lex.queue = append([]Token{tok}, lex.queue...)
+}
+
+func (lex *Lexer) readByte() (byte, error) {
+	ch, err := lex.r.ReadByte()
+	if err == nil {
+		lex.offset++
+	}
+
+	return ch, err
+}
+
+func (lex *Lexer) unreadByte() error {
+	if err := lex.r.UnreadByte(); err != nil {
+		return err
+	}
+
+	lex.offset--
+
+	return nil
+}
+
+func (lex *Lexer) read() (tok Token, err error) {
+	ch, err := lex.skipWhitespaces()
+	if err != nil {
+		return tok, err
+	}
+
+	tok.Offset = lex.offset - 1
+
+	switch ch {
+	case '[':
+		tok.Kind = TokenKindArrayStart
+	case ']':
+		tok.Kind = TokenKindArrayEnd
+	case '{':
+		tok.Kind = TokenKindProcedureStart
+	case '}':
+		tok.Kind = TokenKindProcedureEnd
+	case '<':
+		next, err := lex.readByte()
+		if err != nil {
+			return tok, unexpectedEOF(err)
+		}
+
+		if next == '<' {
+			tok.Kind, tok.Value = TokenKindDictionaryStart, []byte("<<")
+
+			return tok, nil
+		}
+
+		if err = lex.unreadByte(); err != nil {
+			return tok, err
+		}
+
+		tok.Kind = TokenKindHexString
+		tok.Value, err = lex.readHexString()
+
+		return tok, err
+	case '>':
+		next, err := lex.readByte()
+		if err != nil || next != '>' {
+			return tok, fmt.Errorf("%w: unbalanced '>'", ErrInvalidToken)
+		}
+
+		tok.Kind, tok.Value = TokenKindDictionaryEnd, []byte(">>")
+
+		return tok, nil
+	case '(':
+		tok.Kind = TokenKindString
+		tok.Value, err = lex.readLiteralString()
+
+		return tok, err
+	case ')':
+		return tok, fmt.Errorf("%w: unbalanced ')'", ErrInvalidToken)
+	case '/':
+		raw, err := lex.readRegular(nil)
+		if err != nil {
+			return tok, err
+		}
+
+		tok.Kind, tok.Value = TokenKindName, decodeName(raw)
+
+		return tok, nil
+	default:
+		tok.Value, err = lex.readRegular([]byte{ch})
+		if err != nil {
+			return tok, err
+		}
+
+		tok.Kind = classifyRegular(tok.Value)
+
+		return tok, nil
+	}
+
+	tok.Value = []byte{ch}
+
+	return tok, nil
+}
+
+// skipWhitespaces skips whitespace and comments
+// and returns the next character.
+func (lex *Lexer) skipWhitespaces() (byte, error) {
+	for {
+		ch, err := lex.readByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case IsWhitespace(rune(ch)):
+		case ch == '%':
+			if err = lex.skipComment(); err != nil {
+				return 0, err
+			}
+		default:
+			return ch, nil
+		}
+	}
+}
+
+func (lex *Lexer) skipComment() error {
+	for {
+		ch, err := lex.readByte()
+		if err != nil {
+			return err
+		}
+
+		if ch == '\r' || ch == '\n' {
+			return nil
+		}
+	}
+}
+
+// readRegular appends the regular characters up to the next
+// whitespace or delimiter to buf.
+func (lex *Lexer) readRegular(buf []byte) ([]byte, error) {
+	for {
+		ch, err := lex.readByte()
+		if errors.Is(err, io.EOF) {
+			return buf, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if IsWhitespace(rune(ch)) || IsDelimiter(rune(ch)) {
+			return buf, lex.unreadByte()
+		}
+
+		buf = append(buf, ch)
+	}
+}
+
+// readLiteralString reads a literal string after the opening
+// parenthesis (see ISO 32000-1:2008, 7.3.4.2).
+func (lex *Lexer) readLiteralString() ([]byte, error) {
+	buf := []byte{}
+	depth := 1
+
+	for {
+		ch, err := lex.readByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return buf, nil
+			}
+		case '\\':
+			if buf, err = lex.readEscape(buf); err != nil {
+				return nil, err
+			}
+
+			continue
+		case '\r':
+			// An unescaped EOL is always read as a line feed.
+			if ch, err = lex.readByte(); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+
+			if ch != '\n' {
+				if err = lex.unreadByte(); err != nil {
+					return nil, err
+				}
+			}
+
+			ch = '\n'
+		}
+
+		buf = append(buf, ch)
+	}
+}
+
+// readEscape reads an escape sequence after the backslash.
+func (lex *Lexer) readEscape(buf []byte) ([]byte, error) {
+	const maxOctalDigits = 3
+
+	ch, err := lex.readByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	switch ch {
+	case 'n':
+		return append(buf, '\n'), nil
+	case 'r':
+		return append(buf, '\r'), nil
+	case 't':
+		return append(buf, '\t'), nil
+	case 'b':
+		return append(buf, '\b'), nil
+	case 'f':
+		return append(buf, '\f'), nil
+	case '\r':
+		// The line continuation.
+		if ch, err = lex.readByte(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if ch != '\n' {
+			err = lex.unreadByte()
+		}
+
+		return buf, err
+	case '\n':
+		return buf, nil
+	}
+
+	if ch < '0' || ch > '7' {
+		// Unknown escapes are ignored, the character is kept.
+		return append(buf, ch), nil
+	}
+
+	value := ch - '0'
+
+	for i := 1; i < maxOctalDigits; i++ {
+		if ch, err = lex.readByte(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if ch < '0' || ch > '7' {
+			if err = lex.unreadByte(); err != nil {
+				return nil, err
+			}
+
+			break
+		}
+
+		// High-order overflow is ignored.
+		value = value<<3 | (ch - '0')
+	}
+
+	return append(buf, value), nil
+}
+
+// readHexString reads a hexadecimal string after the opening
+// angle bracket (see ISO 32000-1:2008, 7.3.4.3).
+func (lex *Lexer) readHexString() ([]byte, error) {
+	buf := []byte{}
+	odd := false
+
+	for {
+		ch, err := lex.readByte()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if ch == '>' {
+			return buf, nil
+		}
+
+		if IsWhitespace(rune(ch)) {
+			continue
+		}
+
+		nibble, ok := hexValue(ch)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid character %q", ErrInvalidHexString, ch)
+		}
+
+		// A missing final digit is assumed to be zero.
+		if odd {
+			buf[len(buf)-1] |= nibble
+		} else {
+			buf = append(buf, nibble<<4)
+		}
+
+		odd = !odd
+	}
+}
+
+// decodeName decodes the #xx sequences of a name. Invalid
+// sequences are left as is.
+func decodeName(raw []byte) []byte {
+	name := make([]byte, 0, len(raw))
+
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			hi, okHi := hexValue(raw[i+1])
+			lo, okLo := hexValue(raw[i+2])
+
+			if okHi && okLo {
+				name = append(name, hi<<4|lo)
+				i += 2
+
+				continue
+			}
+		}
+
+		name = append(name, raw[i])
+	}
+
+	return name
+}
+
+func hexValue(ch byte) (byte, bool) {
+	const ten = 10
+
+	switch {
+	case ch >= '0' && ch <= '9':
+		return ch - '0', true
+	case ch >= 'a' && ch <= 'f':
+		return ch - 'a' + ten, true
+	case ch >= 'A' && ch <= 'F':
+		return ch - 'A' + ten, true
+	default:
+		return 0, false
+	}
+}
+
+// classifyRegular returns the kind of a sequence of regular
+// characters: an integer, a real or a keyword.
+func classifyRegular(value []byte) TokenKind {
+	digits, dots := 0, 0
+
+	for i, ch := range value {
+		switch {
+		case ch >= '0' && ch <= '9':
+			digits++
+		case ch == '.':
+			dots++
+		case (ch == '+' || ch == '-') && i == 0:
+		default:
+			return TokenKindKeyword
+		}
+	}
+
+	switch {
+	case digits == 0 || dots > 1:
+		return TokenKindKeyword
+	case dots == 1:
+		return TokenKindReal
+	default:
+		return TokenKindInteger
+	}
+}
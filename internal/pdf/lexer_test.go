@@ -0,0 +1,190 @@
+package pdf_test
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+func TestLexer(t *testing.T) {
+	t.Parallel()
+
+	type token struct {
+		kind  pdf.TokenKind
+		value string
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []token
+		wantErr error
+	}{{
+		name:  "keywords and numbers",
+		input: "12 0 obj\r\n-3 +4.5 .5 -.002 6. 1.2.3 true null R endobj",
+		want: []token{
+			{pdf.TokenKindInteger, "12"},
+			{pdf.TokenKindInteger, "0"},
+			{pdf.TokenKindKeyword, "obj"},
+			{pdf.TokenKindInteger, "-3"},
+			{pdf.TokenKindReal, "+4.5"},
+			{pdf.TokenKindReal, ".5"},
+			{pdf.TokenKindReal, "-.002"},
+			{pdf.TokenKindReal, "6."},
+			{pdf.TokenKindKeyword, "1.2.3"},
+			{pdf.TokenKindKeyword, "true"},
+			{pdf.TokenKindKeyword, "null"},
+			{pdf.TokenKindKeyword, "R"},
+			{pdf.TokenKindKeyword, "endobj"},
+		},
+	}, {
+		name:  "delimiters",
+		input: "<</A[1]>>{}[/B<<>>]",
+		want: []token{
+			{pdf.TokenKindDictionaryStart, "<<"},
+			{pdf.TokenKindName, "A"},
+			{pdf.TokenKindArrayStart, "["},
+			{pdf.TokenKindInteger, "1"},
+			{pdf.TokenKindArrayEnd, "]"},
+			{pdf.TokenKindDictionaryEnd, ">>"},
+			{pdf.TokenKindProcedureStart, "{"},
+			{pdf.TokenKindProcedureEnd, "}"},
+			{pdf.TokenKindArrayStart, "["},
+			{pdf.TokenKindName, "B"},
+			{pdf.TokenKindDictionaryStart, "<<"},
+			{pdf.TokenKindDictionaryEnd, ">>"},
+			{pdf.TokenKindArrayEnd, "]"},
+		},
+	}, {
+		name:  "comments",
+		input: "%PDF-1.7\n%\xe2\xe3\xcf\xd3\r1%comment\n2",
+		want: []token{
+			{pdf.TokenKindInteger, "1"},
+			{pdf.TokenKindInteger, "2"},
+		},
+	}, {
+		name: "names",
+		input: "/Name1 /A;Name_With-Various***Characters? /lime#20Green /paired#28#29parentheses /#4" +
+			" /The_Key_of_F#23_Minor / /a#zz",
+		want: []token{
+			{pdf.TokenKindName, "Name1"},
+			{pdf.TokenKindName, "A;Name_With-Various***Characters?"},
+			{pdf.TokenKindName, "lime Green"},
+			{pdf.TokenKindName, "paired()parentheses"},
+			{pdf.TokenKindName, "#4"},
+			{pdf.TokenKindName, "The_Key_of_F#_Minor"},
+			{pdf.TokenKindName, ""},
+			{pdf.TokenKindName, "a#zz"},
+		},
+	}, {
+		name: "literal strings",
+		input: `(simple) (with (balanced) parens) (\(\)\\) (\n\r\t\b\f) (\0533\101\7x) (\q) ` +
+			"(line\\\r\ncontinued) (eol\r\nnormalized\rhere) ()",
+		want: []token{
+			{pdf.TokenKindString, "simple"},
+			{pdf.TokenKindString, "with (balanced) parens"},
+			{pdf.TokenKindString, `()\`},
+			{pdf.TokenKindString, "\n\r\t\b\f"},
+			{pdf.TokenKindString, "+3A\x07x"},
+			{pdf.TokenKindString, "q"},
+			{pdf.TokenKindString, "linecontinued"},
+			{pdf.TokenKindString, "eol\nnormalized\nhere"},
+			{pdf.TokenKindString, ""},
+		},
+	}, {
+		name:  "hex strings",
+		input: "<48656C6C6F> <4 8 6\n5> <901FA> <>",
+		want: []token{
+			{pdf.TokenKindHexString, "Hello"},
+			{pdf.TokenKindHexString, "He"},
+			{pdf.TokenKindHexString, "\x90\x1f\xa0"},
+			{pdf.TokenKindHexString, ""},
+		},
+	}, {
+		name:    "invalid hex string",
+		input:   "<48 6X>",
+		wantErr: pdf.ErrInvalidHexString,
+	}, {
+		name:    "unbalanced angle bracket",
+		input:   "1 > 2",
+		want:    []token{{pdf.TokenKindInteger, "1"}},
+		wantErr: pdf.ErrInvalidToken,
+	}, {
+		name:    "unterminated string",
+		input:   "(abc (def)",
+		wantErr: io.ErrUnexpectedEOF,
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			lex := pdf.NewLexer(bufio.NewReader(strings.NewReader(tt.input)))
+
+			for _, want := range tt.want {
+				tok, err := lex.Next()
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Equal(t, want, token{tok.Kind, string(tok.Value)})
+			}
+
+			_, err := lex.Next()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.ErrorIs(t, err, io.EOF)
+			}
+		})
+	}
+}
+
+func TestLexerPeek(t *testing.T) {
+	t.Parallel()
+
+	lex := pdf.NewLexer(bufio.NewReader(strings.NewReader("1 0 R /Next")))
+
+	first, err := lex.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), lex.Offset())
+
+	peeked, err := lex.Peek()
+	assert.NoError(t, err)
+	assert.Equal(t, "0", string(peeked.Value))
+	assert.Equal(t, int64(2), lex.Offset())
+
+	second, err := lex.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, peeked, second)
+
+	third, err := lex.Next()
+	assert.NoError(t, err)
+	assert.True(t, third.IsKeyword("R"))
+
+	lex.Unread(third)
+	lex.Unread(second)
+	assert.Equal(t, int64(2), lex.Offset())
+
+	value, err := first.Int64()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), value)
+
+	for _, want := range []pdf.Token{second, third} {
+		tok, err := lex.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, want, tok)
+	}
+
+	name, err := lex.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, pdf.Token{Kind: pdf.TokenKindName, Value: []byte("Next"), Offset: 6}, name)
+	assert.Equal(t, int64(11), lex.Offset())
+}
@@ -10,8 +10,8 @@ const (
 	// that is readable in a text editor, i.e.
 	// isert spaces and linebreaks between tokens.
 	WriteFlagClean WriteFlag = 1 << iota
-	// WriteFlagNoInlineLiteral is used to prevent writing spaces before literal types
-	// (numerical, references, null).
+	// WriteFlagNoInlineLiteral is used to write spaces before literal types
+	// (numerical, references, null) even after a delimiter.
 	WriteFlagNoInlineLiteral
 	// WriteFlagNoFlateCompress is used to write
 	// PDF with Flate compression.
@@ -40,14 +40,34 @@ func WriteNoPDFAPreserve() WriterOptionFunc {
 	return func(w *Writer) { w.flags |= WriteFlagNoPDFAPreserve }
 }
 
-func WriteEncryptor(encrypt *Encrypt) WriterOptionFunc {
-	return func(w *Writer) { w.encrypt = encrypt }
+// WriteEncryptor sets the Encrypt the strings
+// and the streams are encrypted with.
+func WriteEncryptor(encrypt Encrypt) WriterOptionFunc {
+	return func(w *Writer) {
+		if encrypt != nil {
+			w.encrypt = encrypt
+		}
+	}
+}
+
+// WriteReferenceMap sets the references the objects are written
+// with when they are renumbered. A reference missing from the map
+// is written as null, as it refers to an object that is not written.
+func WriteReferenceMap(references map[Reference]Reference) WriterOptionFunc {
+	return func(w *Writer) { w.references = references }
 }
 
 type Writer struct {
 	out     io.Writer
 	encrypt Encrypt
-	flags   WriteFlag
+	// reference is the indirect object being written.
+	reference Reference
+	// references are the references of the renumbered objects.
+	references map[Reference]Reference
+	flags      WriteFlag
+	// last is the last byte written, it tells whether a literal
+	// must be separated from the preceding token.
+	last byte
 }
 
 func NewWriter(w io.Writer, options ...WriterOptionFunc) *Writer {
@@ -67,12 +87,64 @@ func (w *Writer) HasFlag(flag WriteFlag) bool { return w.flags&flag != 0 }
 
 func (w *Writer) IsCleanWrite() bool { return w.HasFlag(WriteFlagClean) }
 
+// SetReference sets the indirect object being written. Its strings
+// and stream are encrypted with its key. The zero reference means
+// the data is not encrypted.
+func (w *Writer) SetReference(ref Reference) { w.reference = ref }
+
+// Reference returns the indirect object being written.
+func (w *Writer) Reference() Reference { return w.reference }
+
+// MapReference returns the reference the object is written with,
+// false is returned if the object is not written, see
+// WriteReferenceMap.
+func (w *Writer) MapReference(ref Reference) (Reference, bool) {
+	if w.references == nil {
+		return ref, true
+	}
+
+	ref, ok := w.references[ref]
+
+	return ref, ok
+}
+
+// EncryptString encrypts a string of the object being written.
+func (w *Writer) EncryptString(data []byte) ([]byte, error) {
+	return w.encrypt.EncryptString(w.reference, data)
+}
+
+// EncryptStream encrypts the stream data of the object being written.
+func (w *Writer) EncryptStream(data []byte, isMetadata bool) ([]byte, error) {
+	return w.encrypt.EncryptStream(w.reference, data, isMetadata)
+}
+
 func (w *Writer) Write(p []byte) (n int, err error) {
-	panic("not implemented") // TODO: implement me
+	n, err = w.out.Write(p)
+	if n > 0 {
+		w.last = p[n-1]
+	}
+
+	return n, err
 }
 
 func (w *Writer) WriteByte(x byte) (err error) {
-	panic("not implemented") // TODO: implement me
+	_, err = w.Write([]byte{x})
+
+	return err
+}
+
+// WriteLiteralSeparator writes the space that separates a literal
+// (a number, a boolean, null or a reference) from the preceding
+// token. Nothing is written after a white-space or a delimiter other
+// than the solidus of an empty name unless WriteFlagNoInlineLiteral
+// is set.
+func (w *Writer) WriteLiteralSeparator() error {
+	if !w.HasFlag(WriteFlagNoInlineLiteral) && (IsWhitespace(rune(w.last)) ||
+		IsDelimiter(rune(w.last)) && w.last != '/') {
+		return nil
+	}
+
+	return w.WriteByte(' ')
 }
 
 // Marshaler is the interface implemented by PDF objects that can marshal
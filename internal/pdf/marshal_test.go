@@ -0,0 +1,104 @@
+package pdf_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+func mustParseNumber(t *testing.T, text string) *pdf.Number {
+	t.Helper()
+
+	num, err := pdf.ParseNumber([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return num
+}
+
+func TestMarshalPDF(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []pdf.WriterOptionFunc
+		objects []pdf.Marshaler
+		want    string
+	}{
+		{name: "name", objects: []pdf.Marshaler{pdf.Name("Type")}, want: "/Type"},
+		{name: "empty name", objects: []pdf.Marshaler{pdf.Name("")}, want: "/"},
+		{
+			name:    "name escapes",
+			objects: []pdf.Marshaler{pdf.Name("A B#(C)/\x00\xE9")},
+			want:    "/A#20B#23#28C#29#2F#00#E9",
+		},
+		{name: "name object", objects: []pdf.Marshaler{&pdf.NameObject{Name: "N 1"}}, want: "/N#201"},
+		{name: "integer", objects: []pdf.Marshaler{pdf.NewInt(-42)}, want: "-42"},
+		{name: "real", objects: []pdf.Marshaler{pdf.NewReal(0.5)}, want: "0.5"},
+		{name: "real without exponent", objects: []pdf.Marshaler{pdf.NewReal(1e-7)}, want: "0.0000001"},
+		{name: "large real", objects: []pdf.Marshaler{pdf.NewReal(2.5e10)}, want: "25000000000"},
+		{name: "negative zero", objects: []pdf.Marshaler{pdf.NewReal(-0.0)}, want: "0"},
+		{name: "parsed real", objects: []pdf.Marshaler{mustParseNumber(t, "-.25")}, want: "-.25"},
+		{name: "parsed exponent", objects: []pdf.Marshaler{mustParseNumber(t, "1.5e3")}, want: "1500"},
+		{
+			name:    "literals are separated",
+			objects: []pdf.Marshaler{pdf.NewInt(1), pdf.NewInt(2), pdf.NewReference(3, 0), pdf.Name("N"), pdf.NewInt(4)},
+			want:    "1 2 3 0 R/N 4",
+		},
+		{
+			name:    "name and reference",
+			objects: []pdf.Marshaler{pdf.Name("N"), pdf.NewReference(3, 0)},
+			want:    "/N 3 0 R",
+		},
+		{
+			name:    "empty name and number",
+			objects: []pdf.Marshaler{pdf.Name(""), pdf.NewInt(3)},
+			want:    "/ 3",
+		},
+		{
+			name:    "no inline literal",
+			options: []pdf.WriterOptionFunc{pdf.WriteNoInlineLiteral()},
+			objects: []pdf.Marshaler{pdf.NewInt(1), pdf.Name("N"), pdf.NewReference(3, 0)},
+			want:    " 1/N 3 0 R",
+		},
+		{
+			name: "renumbered references",
+			options: []pdf.WriterOptionFunc{pdf.WriteReferenceMap(map[pdf.Reference]pdf.Reference{
+				{ObjectNo: 3, GenerationNo: 2}: {ObjectNo: 7},
+			})},
+			objects: []pdf.Marshaler{pdf.Name("A"), pdf.NewReference(3, 2), pdf.Name("B"), pdf.NewReference(4, 0)},
+			want:    "/A 7 0 R/B null",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			w := pdf.NewWriter(&buf, tt.options...)
+
+			for _, obj := range tt.objects {
+				assert.NoError(t, obj.MarshalPDF(w))
+			}
+
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestParseNumberNotFinite(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{"NaN", "Inf", "-Inf"} {
+		_, err := pdf.ParseNumber([]byte(text))
+		assert.ErrorIs(t, err, pdf.ErrValueOutOfRange, text)
+	}
+}
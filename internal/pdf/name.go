@@ -1,9 +1,39 @@
 package pdf
 
+import "fmt"
+
 type Name string
 
 // TODO: need to make Name an object
 
+// MarshalPDF writes the name with the leading solidus. The bytes that
+// are not regular characters and the number sign are written as #xx
+// (see ISO 32000-1:2008, 7.3.5).
+func (name Name) MarshalPDF(w *Writer) error {
+	const hexDigits = "0123456789ABCDEF"
+
+	buf := make([]byte, 0, len(name)+1)
+	buf = append(buf, '/')
+
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+
+		if ch < '!' || ch > '~' || ch == '#' || IsDelimiter(rune(ch)) {
+			buf = append(buf, '#', hexDigits[ch>>4], hexDigits[ch&0x0F])
+
+			continue
+		}
+
+		buf = append(buf, ch)
+	}
+
+	if _, err := w.Write(buf); err != nil {
+		return fmt.Errorf("marshal name: %w", err)
+	}
+
+	return nil
+}
+
 const (
 	KeyNull     Name = ""
 	KeyContents Name = "Contents"
@@ -24,8 +54,121 @@ const (
 	KeyMarkInfo Name = "MarkInfo"
 	KeyLang     Name = "Lang"
 	KeyPageMode Name = "PageMode"
+	KeyPrev     Name = "Prev"
+	KeyPages    Name = "Pages"
+	KeyKids     Name = "Kids"
+	KeyModDate  Name = "ModDate"
+	KeyParent   Name = "Parent"
+	KeyVersion  Name = "Version"
+
+	KeyMediaBox  Name = "MediaBox"
+	KeyCropBox   Name = "CropBox"
+	KeyRotate    Name = "Rotate"
+	KeyResources Name = "Resources"
+	KeyBleedBox  Name = "BleedBox"
+	KeyTrimBox   Name = "TrimBox"
+	KeyArtBox    Name = "ArtBox"
+	KeyUserUnit  Name = "UserUnit"
+
+	KeyOutlines Name = "Outlines"
+	KeyLast     Name = "Last"
+	KeyNext     Name = "Next"
+	KeyTitle    Name = "Title"
+	KeyDest     Name = "Dest"
+	KeyDests    Name = "Dests"
+	KeyNames    Name = "Names"
+	KeyLimits   Name = "Limits"
+	KeyA        Name = "A"
+	KeyC        Name = "C"
+	KeyD        Name = "D"
+	KeyF        Name = "F"
+	KeyAnnots   Name = "Annots"
+	KeyFields   Name = "Fields"
+	KeyDA       Name = "DA"
+	KeyDR       Name = "DR"
+
+	KeyAuthor       Name = "Author"
+	KeySubject      Name = "Subject"
+	KeyKeywords     Name = "Keywords"
+	KeyCreator      Name = "Creator"
+	KeyProducer     Name = "Producer"
+	KeyCreationDate Name = "CreationDate"
+	KeyTrapped      Name = "Trapped"
+
+	KeyLinearized        Name = "Linearized"
+	KeyL                 Name = "L"
+	KeyH                 Name = "H"
+	KeyE                 Name = "E"
+	KeyT                 Name = "T"
+	KeyS                 Name = "S"
+	KeyAcroForm          Name = "AcroForm"
+	KeyOpenAction        Name = "OpenAction"
+	KeyThreads           Name = "Threads"
+	KeyViewerPreferences Name = "ViewerPreferences"
+
+	KeyBitsPerComponent Name = "BitsPerComponent"
+	KeyColors           Name = "Colors"
+	KeyColumns          Name = "Columns"
+	KeyDecodeParms      Name = "DecodeParms"
+	KeyIndex            Name = "Index"
+	KeyJBIG2Globals     Name = "JBIG2Globals"
+	KeyPredictor        Name = "Predictor"
+	KeyW                Name = "W"
+	KeyXRefStm          Name = "XRefStm"
+	KeyN                Name = "N"
+	KeyFirst            Name = "First"
+	KeyExtends          Name = "Extends"
+
+	KeyAuthEvent       Name = "AuthEvent"
+	KeyCF              Name = "CF"
+	KeyCFM             Name = "CFM"
+	KeyEncryptMetadata Name = "EncryptMetadata"
+	KeyName            Name = "Name"
+	KeyO               Name = "O"
+	KeyOE              Name = "OE"
+	KeyP               Name = "P"
+	KeyPerms           Name = "Perms"
+	KeyR               Name = "R"
+	KeyRecipients      Name = "Recipients"
+	KeyStmF            Name = "StmF"
+	KeyStrF            Name = "StrF"
+	KeySubFilter       Name = "SubFilter"
+	KeyU               Name = "U"
+	KeyUE              Name = "UE"
+	KeyV               Name = "V"
+
+	NameCatalog     Name = "Catalog"
+	NamePages       Name = "Pages"
+	NamePage        Name = "Page"
+	NameOutlines    Name = "Outlines"
+	NameFont        Name = "Font"
+	NameImage       Name = "Image"
+	NameWidget      Name = "Widget"
+	NameGoTo        Name = "GoTo"
+	NameTrue        Name = "True"
+	NameFalse       Name = "False"
+	NameUnknown     Name = "Unknown"
+	NameFlateDecode Name = "FlateDecode"
+	NameXRef        Name = "XRef"
+	NameObjStm      Name = "ObjStm"
 
-	NameXRef Name = "XRef"
+	NameAESV2              Name = "AESV2"
+	NameAdobePubSec        Name = "Adobe.PubSec"
+	NameAdbePKCS7S3        Name = "adbe.pkcs7.s3"
+	NameAdbePKCS7S4        Name = "adbe.pkcs7.s4"
+	NameAdbePKCS7S5        Name = "adbe.pkcs7.s5"
+	NameAESV3              Name = "AESV3"
+	NameCrypt              Name = "Crypt"
+	NameCryptFilter        Name = "CryptFilter"
+	NameDefaultCryptFilter Name = "DefaultCryptFilter"
+	NameDocOpen            Name = "DocOpen"
+	NameDocTimeStamp       Name = "DocTimeStamp"
+	NameIdentity           Name = "Identity"
+	NameMetadata           Name = "Metadata"
+	NameNone               Name = "None"
+	NameSig                Name = "Sig"
+	NameStandard           Name = "Standard"
+	NameV2                 Name = "V2"
 )
 
 type NameObject struct {
@@ -37,7 +180,7 @@ type NameObject struct {
 func (name *NameObject) Kind() ObjectKind { return ObjectKindName }
 
 func (name *NameObject) MarshalPDF(w *Writer) error {
-	panic("not implemented") // TODO: implement me
+	return name.Name.MarshalPDF(w)
 }
 
 func (name *NameObject) SetParent(parent Object) {
@@ -1,6 +1,8 @@
 package pdf
 
 import (
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -9,9 +11,63 @@ type Number struct {
 	value string
 }
 
+// ParseNumber creates a number from its PDF representation.
+func ParseNumber(text []byte) (*Number, error) {
+	value, err := strconv.ParseFloat(string(text), 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse number: %w", err)
+	}
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("parse number %q: %w", text, ErrValueOutOfRange)
+	}
+
+	return &Number{value: string(text)}, nil
+}
+
+// NewInt creates an integer number.
+func NewInt(value int64) *Number {
+	return &Number{value: strconv.FormatInt(value, 10)}
+}
+
+// NewReal creates a real number. The NaN and the infinities
+// cannot be written to PDF, they are replaced with zero.
+func NewReal(value float64) *Number {
+	return &Number{value: formatReal(value)}
+}
+
+// formatReal formats the real number without the exponent
+// notation, as it is not allowed in PDF.
+func formatReal(value float64) string {
+	if value == 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return "0"
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// String returns the PDF representation of the number.
+func (num Number) String() string {
+	if strings.Trim(num.value, "+-.0123456789") != "" {
+		// The number was parsed from the exponent notation.
+		return formatReal(num.Float64())
+	}
+
+	return num.value
+}
+
 // MarshalPDF encodes the receiver a PDF bytes.
-func (num *Number) MarshalPDF(_ *Writer) error {
-	panic("not implemented") // TODO: Implement
+func (num *Number) MarshalPDF(w *Writer) error {
+	err := w.WriteLiteralSeparator()
+	if err == nil {
+		_, err = w.Write([]byte(num.String()))
+	}
+
+	if err != nil {
+		return fmt.Errorf("marshal number: %w", err)
+	}
+
+	return nil
 }
 
 func (num *Number) SetParent(_ Object) {
@@ -45,12 +101,11 @@ func (num *Number) GetIndirectReference() *Reference {
 func (num Number) Int() int { return int(num.Int64()) }
 
 func (num Number) Int64() int64 {
-	if strings.Contains(num.value, ".") {
+	result, err := strconv.ParseInt(num.value, 10, 64)
+	if err != nil {
 		return int64(num.Float64())
 	}
 
-	result, _ := strconv.ParseInt(num.value, 10, 64)
-
 	return result
 }
 
@@ -1,6 +1,9 @@
 package pdf
 
-import "io"
+import (
+	"fmt"
+	"io"
+)
 
 type ObjectKind uint8
 
@@ -30,14 +33,17 @@ const (
 type Object interface {
 	Marshaler
 
-	SetParent(Object)
-	Parent() Object
-
 	Kind() ObjectKind
-	Copy() (Object, error)
-	Document() *Document
-	Dictionary() *Dictionary
-	GetIndirectReference() *Reference
+}
+
+// DelayedLoader reads an object from its source
+// when the object is accessed first.
+type DelayedLoader interface {
+	// LoadObject reads the object.
+	LoadObject() error
+	// LoadStream reads the stream of the object, if any.
+	// It is called after LoadObject.
+	LoadStream() error
 }
 
 type BaseObject struct {
@@ -47,12 +53,25 @@ type BaseObject struct {
 	indirect *Reference
 
 	reader io.ReadSeeker
+	loader DelayedLoader
 
 	isDelayedLoadDone       bool
 	isDelayedLoadStreamDone bool
+	// dirty is set when the object is changed,
+	// so it is written by the incremental update.
+	dirty bool
+}
+
+// dirtyObject is an object that tracks its changes.
+type dirtyObject interface {
+	SetDirty()
 }
 
 func (obj *BaseObject) Copy() (BaseObject, error) {
+	if err := obj.DelayedLoadStream(); err != nil {
+		return BaseObject{}, err
+	}
+
 	retval := BaseObject{
 		variant:                 obj.variant,
 		isDelayedLoadDone:       true,
@@ -60,20 +79,63 @@ func (obj *BaseObject) Copy() (BaseObject, error) {
 		reader:                  obj.reader,
 	}
 
-	err := obj.DelayedLoadStream()
 	obj.reader = nil
 
 	retval.setVariantOwner()
 
-	return retval, err
+	return retval, nil
+}
+
+// EnableDelayedLoad makes the object to be read by
+// the loader when it is accessed first.
+func (obj *BaseObject) EnableDelayedLoad(loader DelayedLoader) {
+	obj.loader = loader
+	obj.isDelayedLoadDone = false
+	obj.isDelayedLoadStreamDone = false
+}
+
+// IsDelayedLoadDone returns true if the object has been read.
+func (obj *BaseObject) IsDelayedLoadDone() bool {
+	return obj.loader == nil || obj.isDelayedLoadDone
+}
+
+// DelayedLoad reads the object if it has not been read yet.
+func (obj *BaseObject) DelayedLoad() error {
+	if obj.IsDelayedLoadDone() {
+		return nil
+	}
+
+	if err := obj.loader.LoadObject(); err != nil {
+		return fmt.Errorf("delayed load: %w", err)
+	}
+
+	obj.isDelayedLoadDone = true
+
+	return nil
 }
 
 func (obj *BaseObject) setVariantOwner() {
 	panic("not implemented") // TODO: implement me
 }
 
+// DelayedLoadStream reads the object and its stream
+// if they have not been read yet.
 func (obj *BaseObject) DelayedLoadStream() error {
-	panic("not implemented") // TODO: implement me
+	if err := obj.DelayedLoad(); err != nil {
+		return err
+	}
+
+	if obj.loader == nil || obj.isDelayedLoadStreamDone {
+		return nil
+	}
+
+	if err := obj.loader.LoadStream(); err != nil {
+		return fmt.Errorf("delayed load stream: %w", err)
+	}
+
+	obj.isDelayedLoadStreamDone = true
+
+	return nil
 }
 
 func (obj *BaseObject) SetParent(parent Object) {
@@ -82,6 +144,22 @@ func (obj *BaseObject) SetParent(parent Object) {
 
 func (obj *BaseObject) Parent() Object { return obj.parent }
 
+// SetDirty marks the object as changed. The change of a direct
+// object is the change of its parent, so the parent is marked too.
+func (obj *BaseObject) SetDirty() {
+	obj.dirty = true
+
+	if parent, ok := obj.parent.(dirtyObject); ok {
+		parent.SetDirty()
+	}
+}
+
+// ResetDirty marks the object as unchanged, e.g. once it is read.
+func (obj *BaseObject) ResetDirty() { obj.dirty = false }
+
+// IsDirty returns true if the object has been changed.
+func (obj *BaseObject) IsDirty() bool { return obj.dirty }
+
 func (obj *BaseObject) Document() *Document {
 	return obj.document
 }
@@ -90,6 +168,12 @@ func (obj *BaseObject) GetIndirectReference() *Reference {
 	return obj.indirect
 }
 
+// SetIndirectReference makes the object an indirect object
+// with the reference. Nil makes the object direct.
+func (obj *BaseObject) SetIndirectReference(ref *Reference) {
+	obj.indirect = ref
+}
+
 // func (obj *Object) Kind() ObjectKind {
 // 	panic("not implemented") // TODO: implement me
 // }
@@ -0,0 +1,120 @@
+package pdf_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+type mockLoader struct {
+	objectLoads int
+	streamLoads int
+	err         error
+}
+
+func (loader *mockLoader) LoadObject() error {
+	loader.objectLoads++
+
+	return loader.err
+}
+
+func (loader *mockLoader) LoadStream() error {
+	loader.streamLoads++
+
+	return loader.err
+}
+
+func TestBaseObjectDelayedLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not delayed", func(t *testing.T) {
+		t.Parallel()
+
+		var obj pdf.BaseObject
+
+		assert.True(t, obj.IsDelayedLoadDone())
+		assert.NoError(t, obj.DelayedLoad())
+		assert.NoError(t, obj.DelayedLoadStream())
+	})
+
+	t.Run("loads once", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			obj    pdf.BaseObject
+			loader mockLoader
+		)
+
+		obj.EnableDelayedLoad(&loader)
+		assert.False(t, obj.IsDelayedLoadDone())
+
+		assert.NoError(t, obj.DelayedLoad())
+		assert.NoError(t, obj.DelayedLoad())
+		assert.True(t, obj.IsDelayedLoadDone())
+		assert.Equal(t, mockLoader{objectLoads: 1}, loader)
+
+		assert.NoError(t, obj.DelayedLoadStream())
+		assert.NoError(t, obj.DelayedLoadStream())
+		assert.Equal(t, mockLoader{objectLoads: 1, streamLoads: 1}, loader)
+	})
+
+	t.Run("stream loads object", func(t *testing.T) {
+		t.Parallel()
+
+		var (
+			obj    pdf.BaseObject
+			loader mockLoader
+		)
+
+		obj.EnableDelayedLoad(&loader)
+
+		assert.NoError(t, obj.DelayedLoadStream())
+		assert.Equal(t, mockLoader{objectLoads: 1, streamLoads: 1}, loader)
+	})
+
+	t.Run("failure is retried", func(t *testing.T) {
+		t.Parallel()
+
+		var obj pdf.BaseObject
+
+		loader := mockLoader{err: errMockFail}
+		obj.EnableDelayedLoad(&loader)
+
+		assert.ErrorIs(t, obj.DelayedLoad(), errMockFail)
+		assert.ErrorIs(t, obj.DelayedLoadStream(), errMockFail)
+		assert.False(t, obj.IsDelayedLoadDone())
+		assert.Equal(t, 2, loader.objectLoads)
+		assert.Zero(t, loader.streamLoads)
+	})
+}
+
+type dirtyParent struct {
+	pdf.Object
+
+	dirty bool
+}
+
+func (parent *dirtyParent) SetDirty() { parent.dirty = true }
+
+func TestBaseObjectDirty(t *testing.T) {
+	t.Parallel()
+
+	var parent dirtyParent
+
+	child := new(pdf.BaseObject)
+	child.SetParent(&parent)
+
+	assert.False(t, child.IsDirty())
+
+	child.SetDirty()
+
+	assert.True(t, child.IsDirty())
+	assert.True(t, parent.dirty)
+
+	child.ResetDirty()
+
+	assert.False(t, child.IsDirty())
+	assert.True(t, parent.dirty)
+}
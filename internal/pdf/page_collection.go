@@ -15,7 +15,12 @@ func (pc *PageCollection) Len() int {
 }
 
 func getChildCount(node Object) int {
-	countObj := node.Dictionary().Key(KeyCount)
+	dict, ok := node.(*Dictionary)
+	if !ok {
+		return 0
+	}
+
+	countObj := dict.Key(KeyCount)
 	if countObj == nil {
 		return 0
 	}
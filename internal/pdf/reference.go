@@ -1,6 +1,9 @@
 package pdf
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+)
 
 type Generation uint16
 
@@ -11,12 +14,23 @@ type Reference struct {
 	GenerationNo Generation
 }
 
-var _ = Marshaler((*Reference)(nil))
+var _ = Object((*Reference)(nil))
+
+// NewReference creates a reference to the object with the given
+// object and generation numbers.
+func NewReference(objectNo int, generationNo Generation) *Reference {
+	return &Reference{
+		ObjectNo:     uint32(objectNo),
+		GenerationNo: generationNo,
+	}
+}
 
 func (ref Reference) String() string {
 	return fmt.Sprintf("%d %d R", ref.ObjectNo, ref.GenerationNo)
 }
 
+func (ref Reference) Kind() ObjectKind { return ObjectKindReference }
+
 func (ref Reference) IsIndirect() bool {
 	return ref.ObjectNo != 0 || ref.GenerationNo != 0
 }
@@ -24,10 +38,18 @@ func (ref Reference) IsIndirect() bool {
 func (ref Reference) MarshalPDF(w *Writer) error {
 	const format = "%d %d R"
 
-	if w.HasFlag(WriteFlagNoInlineLiteral) {
-		if err := w.WriteByte(' '); err != nil {
-			return fmt.Errorf("marshal reference: %w", err)
+	if err := w.WriteLiteralSeparator(); err != nil {
+		return fmt.Errorf("marshal reference: %w", err)
+	}
+
+	ref, ok := w.MapReference(ref)
+	if !ok {
+		_, err := io.WriteString(w, "null")
+		if err != nil {
+			err = fmt.Errorf("marshal reference: %w", err)
 		}
+
+		return err
 	}
 
 	_, err := fmt.Fprintf(w, format, ref.ObjectNo, ref.GenerationNo)
@@ -0,0 +1,69 @@
+package pdf
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DecodeTextString decodes the text string, which is UTF-16BE or
+// UTF-8 with the byte order mark, or PDFDocEncoded otherwise (see
+// ISO 32000-1:2008, 7.9.2.2). The undefined codes are replaced with
+// the replacement character.
+func DecodeTextString(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		data = data[2:]
+
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		}
+
+		return string(utf16.Decode(units))
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:])
+	}
+
+	runes := make([]rune, len(data))
+
+	for i, ch := range data {
+		runes[i] = cEncoding[ch]
+		if runes[i] == 0 && ch != 0 {
+			runes[i] = utf8.RuneError
+		}
+	}
+
+	return string(runes)
+}
+
+// EncodeTextString encodes the text as PDFDocEncoding if it has
+// the characters of the encoding only, as UTF-16BE otherwise.
+func EncodeTextString(text string) []byte {
+	encMap := getUTF8ToPDFEncodingMap()
+	data := make([]byte, 0, len(text))
+
+	for _, r := range text {
+		ch, ok := encMap[r]
+		if !ok {
+			return encodeUTF16BE(text)
+		}
+
+		data = append(data, ch)
+	}
+
+	return data
+}
+
+// encodeUTF16BE encodes the text as UTF-16BE with the byte order mark.
+func encodeUTF16BE(text string) []byte {
+	units := utf16.Encode([]rune(text))
+	data := make([]byte, 2, 2+2*len(units))
+	data[0], data[1] = 0xFE, 0xFF
+
+	for _, unit := range units {
+		data = append(data, byte(unit>>8), byte(unit))
+	}
+
+	return data
+}
@@ -0,0 +1,39 @@
+package pdf_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+func TestTextString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		data []byte
+	}{
+		{name: "ascii", text: "Hello", data: []byte("Hello")},
+		{name: "pdf doc encoding", text: "€ • ©", data: []byte{0xA0, ' ', 0x80, ' ', 0xA9}},
+		{name: "utf-16", text: "Привет", data: []byte{
+			0xFE, 0xFF, 0x04, 0x1F, 0x04, 0x40, 0x04, 0x38, 0x04, 0x32, 0x04, 0x35, 0x04, 0x42,
+		}},
+		{name: "surrogates", text: "a😀", data: []byte{0xFE, 0xFF, 0x00, 'a', 0xD8, 0x3D, 0xDE, 0x00}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.data, pdf.EncodeTextString(tt.text))
+			assert.Equal(t, tt.text, pdf.DecodeTextString(tt.data))
+		})
+	}
+
+	assert.Equal(t, "UTF-8", pdf.DecodeTextString([]byte("\xEF\xBB\xBFUTF-8")))
+}
@@ -0,0 +1,165 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrInvalidXRef = errors.New("invalid XRef")
+
+// MaxGeneration is the greatest generation number an XRef entry
+// may have.
+const MaxGeneration = Generation(0xFFFF)
+
+// ReadXRefEntry reads a single entry of a classic cross-reference
+// table. A well-formed entry is exactly 20 bytes long:
+//
+//	nnnnnnnnnn ggggg n\r\n
+//
+// Real world files often deviate from this format, so the function
+// is lenient: leading whitespace is skipped, fields may be separated
+// by more than one whitespace, field widths are not checked and the
+// end-of-line marker may be one byte long or missing entirely.
+// The end-of-line marker is not consumed.
+//
+// The value is the byte offset for in-use ('n') entries or the next
+// free object number for free ('f') entries.
+func ReadXRefEntry(r io.ByteScanner) (value uint64, gen Generation, typ byte, err error) {
+	const (
+		maxOffsetDigits     = 20
+		maxGenerationDigits = 10
+	)
+
+	if err = skipWhitespaces(r); err != nil {
+		return 0, 0, 0, fmt.Errorf("read xref entry: %w", err)
+	}
+
+	value, err = readXRefDigits(r, maxOffsetDigits)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("read xref entry: offset: %w", err)
+	}
+
+	if err = skipWhitespaces(r); err != nil {
+		return 0, 0, 0, fmt.Errorf("read xref entry: %w", err)
+	}
+
+	generation, err := readXRefDigits(r, maxGenerationDigits)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("read xref entry: generation: %w", err)
+	}
+
+	if generation > uint64(MaxGeneration) {
+		return 0, 0, 0, fmt.Errorf("read xref entry: generation: %w", ErrValueOutOfRange)
+	}
+
+	if err = skipWhitespaces(r); err != nil {
+		return 0, 0, 0, fmt.Errorf("read xref entry: %w", err)
+	}
+
+	typ, err = r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("read xref entry: type: %w", unexpectedEOF(err))
+	}
+
+	if !CheckXRefEntryType(typ) {
+		return 0, 0, 0, fmt.Errorf("read xref entry: %w: invalid type %q, must be either 'n' or 'f'",
+			ErrInvalidXRef, typ)
+	}
+
+	return value, Generation(generation), typ, nil
+}
+
+// AppendXRefEntry appends the entry of a classic cross-reference
+// table, which is exactly 20 bytes long, including the two-byte
+// end-of-line marker (see ISO 32000-1:2008, 7.5.4).
+func AppendXRefEntry(buf []byte, value uint64, gen Generation, typ byte) []byte {
+	return append(buf, fmt.Sprintf("%010d %05d %c\r\n", value, gen, typ)...)
+}
+
+// AppendXRefStreamEntry appends the entry of a cross-reference
+// stream: the fields are big-endian numbers of the /W widths in
+// bytes (see ISO 32000-1:2008, 7.5.8.3).
+func AppendXRefStreamEntry(buf []byte, widths [3]int, fields [3]uint64) []byte {
+	const bitsPerByte = 8
+
+	for i, width := range widths {
+		for shift := (width - 1) * bitsPerByte; shift >= 0; shift -= bitsPerByte {
+			buf = append(buf, byte(fields[i]>>shift))
+		}
+	}
+
+	return buf
+}
+
+// XRefStreamFieldWidth returns the number of bytes
+// the field of a cross-reference stream needs to hold
+// the value.
+func XRefStreamFieldWidth(value uint64) int {
+	width := 1
+
+	for value > 0xFF {
+		value >>= 8
+		width++
+	}
+
+	return width
+}
+
+func skipWhitespaces(r io.ByteScanner) error {
+	for {
+		ch, err := r.ReadByte()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		if !IsWhitespace(rune(ch)) {
+			return r.UnreadByte()
+		}
+	}
+}
+
+func readXRefDigits(r io.ByteScanner, maxDigits int) (value uint64, err error) {
+	const base = 10
+
+	var digits int
+
+	for {
+		ch, err := r.ReadByte()
+		if errors.Is(err, io.EOF) && digits > 0 {
+			return value, nil
+		}
+
+		if err != nil {
+			return 0, unexpectedEOF(err)
+		}
+
+		if ch < '0' || ch > '9' {
+			if err = r.UnreadByte(); err != nil {
+				return 0, err
+			}
+
+			break
+		}
+
+		if digits++; digits > maxDigits {
+			return 0, fmt.Errorf("%w: too many digits", ErrInvalidXRef)
+		}
+
+		value = value*base + uint64(ch-'0')
+	}
+
+	if digits == 0 {
+		return 0, fmt.Errorf("%w: number expected", ErrInvalidXRef)
+	}
+
+	return value, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,112 @@
+package pdf
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ObjectHeader is the "N G obj" header of an indirect object.
+type ObjectHeader struct {
+	Reference
+
+	// Offset is the offset of the object number.
+	Offset int64
+}
+
+// XRefScan holds the object headers and the trailers
+// found by ScanXRef.
+type XRefScan struct {
+	// Objects are the object headers in the file order.
+	Objects []ObjectHeader
+	// Trailers are the offsets of the "trailer" keywords
+	// in the file order.
+	Trailers []int64
+}
+
+// scanToken is a token seen by ScanXRef. Only numbers are kept,
+// any other token or a delimiter is stored as a non-number.
+type scanToken struct {
+	value    uint64
+	offset   int64
+	isNumber bool
+}
+
+// ScanXRef reads r from the start to the end and collects the
+// headers of the indirect objects and the trailer keywords. It is
+// used to rebuild the cross-reference table of a broken file.
+//
+// The contents of streams are not skipped, so objects may be found
+// in uncompressed stream data as well.
+func ScanXRef(r io.ByteReader) (*XRefScan, error) {
+	var (
+		scan  XRefScan
+		prev  [2]scanToken
+		token []byte
+		start int64
+		pos   int64
+	)
+
+	flush := func() {
+		if len(token) == 0 {
+			return
+		}
+
+		switch string(token) {
+		case "obj":
+			if prev[0].isNumber && prev[1].isNumber && prev[1].value <= uint64(MaxGeneration) {
+				scan.Objects = append(scan.Objects, ObjectHeader{
+					Reference: Reference{
+						ObjectNo:     uint32(prev[0].value),
+						GenerationNo: Generation(prev[1].value),
+					},
+					Offset: prev[0].offset,
+				})
+			}
+		case "trailer":
+			scan.Trailers = append(scan.Trailers, start)
+		}
+
+		value, err := strconv.ParseUint(string(token), 10, 32)
+		prev[0], prev[1] = prev[1], scanToken{value: value, offset: start, isNumber: err == nil}
+		token = token[:0]
+	}
+
+	for ; ; pos++ {
+		ch, err := r.ReadByte()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case IsWhitespace(rune(ch)):
+			flush()
+		case IsDelimiter(rune(ch)):
+			flush()
+
+			prev[0], prev[1] = prev[1], scanToken{}
+		default:
+			if len(token) == 0 {
+				start = pos
+			}
+
+			token = append(token, ch)
+		}
+	}
+
+	flush()
+
+	return &scan, nil
+}
+
+// IsDelimiter returns true for the PDF delimiter characters.
+func IsDelimiter(r rune) bool {
+	const delimiters = "()<>[]{}/%"
+
+	return strings.ContainsRune(delimiters, r)
+}
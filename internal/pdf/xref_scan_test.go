@@ -0,0 +1,74 @@
+package pdf_test
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+func TestScanXRef(t *testing.T) {
+	t.Parallel()
+
+	header := func(objNo uint32, gen pdf.Generation, offset int64) pdf.ObjectHeader {
+		return pdf.ObjectHeader{
+			Reference: pdf.Reference{ObjectNo: objNo, GenerationNo: gen},
+			Offset:    offset,
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  pdf.XRefScan
+	}{{
+		name: "objects and trailer",
+		input: "%PDF-1.4\n1 0 obj\n<</Type/Catalog>>\nendobj\n" +
+			"2 0 obj<</Length 3>>stream\nabc\nendstream\nendobj\n" +
+			"trailer\n<</Root 1 0 R>>\n",
+		want: pdf.XRefScan{
+			Objects:  []pdf.ObjectHeader{header(1, 0, 9), header(2, 0, 42)},
+			Trailers: []int64{90},
+		},
+	}, {
+		name:  "object after endobj",
+		input: "1 0 obj null endobj 3 2 obj true endobj",
+		want: pdf.XRefScan{
+			Objects: []pdf.ObjectHeader{header(1, 0, 0), header(3, 2, 20)},
+		},
+	}, {
+		name:  "references are not headers",
+		input: "1 0 obj [2 0 R 3 0 R] endobj 4 0 R obj",
+		want: pdf.XRefScan{
+			Objects: []pdf.ObjectHeader{header(1, 0, 0)},
+		},
+	}, {
+		name:  "delimiters break headers",
+		input: "1 0 obj/Name 0 obj (1) 0 obj 5 70000 obj",
+		want: pdf.XRefScan{
+			Objects: []pdf.ObjectHeader{header(1, 0, 0)},
+		},
+	}, {
+		name:  "truncated",
+		input: "1 0 obj\n<</Type/Catalog",
+		want: pdf.XRefScan{
+			Objects: []pdf.ObjectHeader{header(1, 0, 0)},
+		},
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			scan, err := pdf.ScanXRef(bufio.NewReader(strings.NewReader(tt.input)))
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, *scan)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package pdf_test
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+func TestReadXRefEntry(t *testing.T) {
+	t.Parallel()
+
+	type entry struct {
+		value uint64
+		gen   pdf.Generation
+		typ   byte
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []entry
+		wantErr error
+	}{{
+		name:  "well-formed",
+		input: "0000000000 65535 f\r\n0000000015 00000 n\r\n",
+		want:  []entry{{0, 65535, 'f'}, {15, 0, 'n'}},
+	}, {
+		name:  "19-byte entries",
+		input: "0000000000 65535 f\n0000000015 00000 n\n",
+		want:  []entry{{0, 65535, 'f'}, {15, 0, 'n'}},
+	}, {
+		name:  "missing EOL",
+		input: "0000000000 65535 f0000000015 00000 n",
+		want:  []entry{{0, 65535, 'f'}, {15, 0, 'n'}},
+	}, {
+		name:  "extra whitespace",
+		input: "\r\n 0000000017  00002   n  \r\n\r\n0000000042 00000 n \n",
+		want:  []entry{{17, 2, 'n'}, {42, 0, 'n'}},
+	}, {
+		name:    "invalid type",
+		input:   "0000000015 00000 x\r\n",
+		wantErr: pdf.ErrInvalidXRef,
+	}, {
+		name:    "missing generation",
+		input:   "0000000015 n\r\n",
+		wantErr: pdf.ErrInvalidXRef,
+	}, {
+		name:    "generation out of range",
+		input:   "0000000015 65536 n\r\n",
+		wantErr: pdf.ErrValueOutOfRange,
+	}, {
+		name:    "truncated",
+		input:   "0000000015 00000",
+		wantErr: io.ErrUnexpectedEOF,
+	}}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := bufio.NewReader(strings.NewReader(tt.input))
+
+			for _, want := range tt.want {
+				value, gen, typ, err := pdf.ReadXRefEntry(r)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				assert.Equal(t, want, entry{value, gen, typ})
+			}
+
+			if tt.wantErr != nil {
+				_, _, _, err := pdf.ReadXRefEntry(r)
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppendXRefEntry(t *testing.T) {
+	t.Parallel()
+
+	buf := pdf.AppendXRefEntry(nil, 0, pdf.MaxGeneration, 'f')
+	buf = pdf.AppendXRefEntry(buf, 1234567, 2, 'n')
+
+	assert.Equal(t, "0000000000 65535 f\r\n0001234567 00002 n\r\n", string(buf))
+
+	r := bufio.NewReader(strings.NewReader(string(buf[20:])))
+
+	value, gen, typ, err := pdf.ReadXRefEntry(r)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{uint64(1234567), pdf.Generation(2), byte('n')}, []any{value, gen, typ})
+}
+
+func TestAppendXRefStreamEntry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		widths [3]int
+		fields [3]uint64
+		want   []byte
+	}{
+		{name: "in use", widths: [3]int{1, 3, 1}, fields: [3]uint64{1, 0x012345, 0}, want: []byte{1, 0x01, 0x23, 0x45, 0}},
+		{name: "compressed", widths: [3]int{1, 2, 2}, fields: [3]uint64{2, 7, 300}, want: []byte{2, 0, 7, 0x01, 0x2C}},
+		{name: "zero width", widths: [3]int{0, 1, 0}, fields: [3]uint64{1, 9, 0}, want: []byte{9}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, pdf.AppendXRefStreamEntry(nil, tt.widths, tt.fields))
+		})
+	}
+}
+
+func TestXRefStreamFieldWidth(t *testing.T) {
+	t.Parallel()
+
+	for value, want := range map[uint64]int{0: 1, 0xFF: 1, 0x100: 2, 0xFFFF: 2, 0x10000: 3, 1 << 32: 5} {
+		assert.Equal(t, want, pdf.XRefStreamFieldWidth(value), value)
+	}
+}
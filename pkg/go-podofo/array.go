@@ -25,8 +25,6 @@ func (array *Array) MarshalPDF(w *pdf.Writer) (err error) {
 		lf         = "\n"
 	)
 
-	// TODO? write mode?
-
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("array: write: %w", err)
@@ -44,8 +42,10 @@ func (array *Array) MarshalPDF(w *pdf.Writer) (err error) {
 	for i, obj := range array.objects {
 		err = obj.MarshalPDF(w)
 
-		if i%10 == 0 {
+		if (i+1)%10 == 0 {
 			err = errors.Join(err, writeStringClean(w, lf))
+		} else {
+			err = errors.Join(err, writeStringClean(w, space))
 		}
 
 		if err != nil {
@@ -67,7 +67,7 @@ func (array *Array) Remove(index int) error {
 
 	array.objects = append(array.objects[:index], array.objects[index+1:]...)
 
-	array.cont.SetDirty()
+	array.SetDirty()
 
 	return nil
 }
@@ -92,15 +92,15 @@ func (array *Array) Append(objects ...Object) error {
 	}
 
 	for _, obj := range objects {
-		obj, err := obj.Copy()
+		obj, err := copyObject(obj)
 		if err != nil {
 			return fmt.Errorf("array: append: %w", err)
 		}
-		obj.SetParent(array)
+		setParent(obj, array)
 		array.objects = append(array.objects, obj)
 	}
 
-	array.cont.SetDirty()
+	array.SetDirty()
 
 	return nil
 }
@@ -121,10 +121,10 @@ func (array *Array) AppendIndirect(objects ...Object) error {
 	}
 
 	for _, obj := range objects {
-		array.objects = append(array.objects, obj.GetIndirectReference())
+		array.objects = append(array.objects, indirectReference(obj))
 	}
 
-	array.cont.SetDirty()
+	array.SetDirty()
 
 	return nil
 }
@@ -140,9 +140,9 @@ func (array *Array) AppendIndirectSafe(objects ...Object) error {
 
 	for _, obj := range objects {
 		if array.cont.IsIndirectReferenceAllowed(obj) {
-			array.objects = append(array.objects, obj.GetIndirectReference())
+			array.objects = append(array.objects, indirectReference(obj))
 		} else {
-			cpy, err := obj.Copy()
+			cpy, err := copyObject(obj)
 			if err != nil {
 				return fmt.Errorf("array: append indirect safe: %w", err)
 			}
@@ -151,13 +151,15 @@ func (array *Array) AppendIndirectSafe(objects ...Object) error {
 		}
 	}
 
-	array.cont.SetDirty()
+	array.SetDirty()
 
 	return nil
 }
 
 func (array *Array) Set(index int, obj Object) {
 	array.objects[index] = obj
+
+	array.SetDirty()
 }
 
 func (array *Array) SetIndirect(index int, obj Object) (Object, error) {
@@ -174,10 +176,10 @@ func (array *Array) Clear() {
 	}
 
 	for i := range array.objects {
-		array.objects[i].SetParent(nil)
+		setParent(array.objects[i], nil)
 	}
 
-	array.cont.SetDirty()
+	array.SetDirty()
 }
 
 func (array *Array) Copy() (obj Object, err error) {
@@ -1,6 +1,7 @@
 package podofo
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/denisss025/go-podofo/internal/pdf"
@@ -10,6 +11,28 @@ type Bool bool
 
 func (b Bool) Kind() ObjectKind { return pdf.ObjectKindBool }
 
+// WriteTo writes the true or false keyword.
 func (b Bool) WriteTo(w io.Writer) (n int64, err error) {
-	panic("not implemented") // TODO: implement me
+	keyword := "false"
+	if b {
+		keyword = "true"
+	}
+
+	written, err := io.WriteString(w, keyword)
+
+	return int64(written), err
+}
+
+// MarshalPDF writes the boolean separated from the preceding token.
+func (b Bool) MarshalPDF(w *pdf.Writer) error {
+	err := w.WriteLiteralSeparator()
+	if err == nil {
+		_, err = b.WriteTo(w)
+	}
+
+	if err != nil {
+		return fmt.Errorf("marshal bool: %w", err)
+	}
+
+	return nil
 }
@@ -14,3 +14,38 @@ type DataContainer interface {
 
 	IsIndirectReferenceAllowed(obj Object) bool
 }
+
+// dirtyObject is an object that tracks its changes, so
+// only the changed objects are written by SaveUpdate.
+type dirtyObject interface {
+	SetDirty()
+	ResetDirty()
+	IsDirty() bool
+}
+
+// isDirty returns true if the indirect object has been changed or
+// created since it has been read. The objects that do not track
+// their changes are never dirty.
+func isDirty(obj Object) bool {
+	if parserObj, ok := obj.(*ParserObject); ok {
+		obj = parserObj.Object()
+	}
+
+	dirty, ok := obj.(dirtyObject)
+
+	return ok && dirty.IsDirty()
+}
+
+// setDirty marks the object as changed.
+func setDirty(obj Object) {
+	if dirty, ok := obj.(dirtyObject); ok {
+		dirty.SetDirty()
+	}
+}
+
+// resetDirty marks the object read from the file as unchanged.
+func resetDirty(obj Object) {
+	if dirty, ok := obj.(dirtyObject); ok {
+		dirty.ResetDirty()
+	}
+}
@@ -0,0 +1,18 @@
+package podofo
+
+import (
+	"time"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// FormatDate formats the time as a PDF date, see pdf.FormatDate.
+func FormatDate(t time.Time) string { return pdf.FormatDate(t) }
+
+// ParseDate parses the PDF date leniently, see pdf.ParseDate.
+func ParseDate(text string) (time.Time, error) { return pdf.ParseDate(text) }
+
+// newDateString creates the PDF date string of the time.
+func newDateString(t time.Time) *String {
+	return newRawString([]byte(pdf.FormatDate(t)), false)
+}
@@ -1,6 +1,9 @@
 package podofo
 
-import "github.com/denisss025/go-podofo/internal/pdf"
+import (
+	"github.com/denisss025/go-podofo/internal/filter"
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
 
 const (
 	// BufferSize is used for internal buffers.
@@ -9,6 +12,10 @@ const (
 	// DefaultPDFVersion is the default PDF version
 	// used by new PDF documents.
 	DefaultPDFVersion = pdf.Version14
+
+	// Producer is the /Producer written to the information
+	// dictionary of a new document, see InfoInitial.
+	Producer = "go-podofo"
 )
 
 type PDFVersion = pdf.Version
@@ -79,20 +86,20 @@ const (
 	XObjectTypePostScript
 )
 
-type FilterType uint8
+type FilterType = filter.Type
 
 const (
-	FilterTypeNone FilterType = iota
-	FilterTypeASCIIHexDecode
-	FilterTypeASCII85Decode
-	FilterTypeLZWDecode
-	FilterTypeFlateDecode
-	FilterTypeRunLengthDecode
-	FilterTypeCCITTFaxDecode
-	FilterTypeJBIG2Decode
-	FilterTypeDCTDecode
-	FilterTypeJPXDecode
-	FilterTypeCrypt
+	FilterTypeNone            = filter.TypeNone
+	FilterTypeASCIIHexDecode  = filter.TypeASCIIHexDecode
+	FilterTypeASCII85Decode   = filter.TypeASCII85Decode
+	FilterTypeLZWDecode       = filter.TypeLZWDecode
+	FilterTypeFlateDecode     = filter.TypeFlateDecode
+	FilterTypeRunLengthDecode = filter.TypeRunLengthDecode
+	FilterTypeCCITTFaxDecode  = filter.TypeCCITTFaxDecode
+	FilterTypeJBIG2Decode     = filter.TypeJBIG2Decode
+	FilterTypeDCTDecode       = filter.TypeDCTDecode
+	FilterTypeJPXDecode       = filter.TypeJPXDecode
+	FilterTypeCrypt           = filter.TypeCrypt
 )
 
 type ExportFormat uint8
@@ -231,11 +238,13 @@ const (
 	StrokeStyleDashDotDot
 )
 
+// InfoInitial are the flags of the entries written
+// to the information dictionary of a new document.
 type InfoInitial uint8
 
 const (
-	InfoInitialNone InfoInitial = iota
-	InfoInitialWriteCreationTime
+	InfoInitialNone              InfoInitial = 0
+	InfoInitialWriteCreationTime InfoInitial = 1 << (iota - 1)
 	InfoInitialWriteModificationTime
 	InfoInitialWriteProducer
 )
@@ -282,6 +291,10 @@ const (
 	SaveOptionNoCollectGarbage
 	SaveOptionNoModifyDateUpdate
 	SaveOptionClean
+	// SaveOptionLinearize writes the linearized file, which
+	// is displayed before it is fully read (see ISO
+	// 32000-1:2008, Annex F).
+	SaveOptionLinearize
 )
 
 type Standard14FontType uint8
@@ -519,3 +532,14 @@ const (
 	BlendModeColor
 	BlendModeLuminosity
 )
+
+// PageBox is a boundary of a page (see ISO 32000-1:2008, 14.11.2).
+type PageBox uint8
+
+const (
+	PageBoxMedia PageBox = iota
+	PageBoxCrop
+	PageBoxBleed
+	PageBoxTrim
+	PageBoxArt
+)
@@ -1,6 +1,9 @@
 package podofo
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/denisss025/go-podofo/internal/pdf"
 )
 
@@ -12,19 +15,105 @@ type Dictionary struct {
 	keys map[pdf.Name]Object
 }
 
+// NewDictionary creates an empty dictionary.
+func NewDictionary() *Dictionary {
+	return &Dictionary{keys: make(map[pdf.Name]Object)}
+}
+
 // Kind returns the kind of the PDFObject.
 func (d *Dictionary) Kind() ObjectKind {
 	return pdf.ObjectKindDictionary
 }
 
-func (d *Dictionary) MarshalPDF(w *pdf.Writer) error {
-	panic("not implemented") // TODO: implement me
+// MarshalPDF writes the dictionary. The /Type key is written first,
+// the other keys are sorted, so the output is reproducible.
+func (d *Dictionary) MarshalPDF(w *pdf.Writer) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("dictionary: write: %w", err)
+		}
+	}()
+
+	if err = writeString(w, "<<"); err != nil {
+		return err
+	}
+
+	if err = writeStringClean(w, "\n"); err != nil {
+		return err
+	}
+
+	for _, key := range d.sortedKeys() {
+		if err = key.MarshalPDF(w); err != nil {
+			return err
+		}
+
+		if err = writeStringClean(w, " "); err != nil {
+			return err
+		}
+
+		if err = d.keys[key].MarshalPDF(w); err != nil {
+			return fmt.Errorf("/%s: %w", key, err)
+		}
+
+		if err = writeStringClean(w, "\n"); err != nil {
+			return err
+		}
+	}
+
+	return writeString(w, ">>")
+}
+
+// sortedKeys returns the keys in the order they are written.
+func (d *Dictionary) sortedKeys() []pdf.Name {
+	keys := make([]pdf.Name, 0, len(d.keys))
+
+	for key := range d.keys {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == pdf.KeyType || keys[j] == pdf.KeyType {
+			return keys[i] == pdf.KeyType
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+// shallowCopy copies the dictionary, but not its values.
+func (d *Dictionary) shallowCopy() *Dictionary {
+	cpy := &Dictionary{keys: make(map[pdf.Name]Object, len(d.keys))}
+
+	for key, value := range d.keys {
+		cpy.keys[key] = value
+	}
+
+	return cpy
+}
+
+// RemoveKey removes the key from the dictionary.
+func (d *Dictionary) RemoveKey(name pdf.Name) {
+	if _, ok := d.keys[name]; ok {
+		delete(d.keys, name)
+		d.SetDirty()
+	}
 }
 
 // AddKey adds key to the dictionary.
 func (d *Dictionary) AddKey(name pdf.Name, obj Object) {
 	// TODO? need copy?
 	d.keys[name] = obj
+
+	switch obj := obj.(type) {
+	case *Dictionary:
+		obj.SetParent(d)
+	case *Array:
+		obj.SetParent(d)
+	}
+
+	d.SetDirty()
 }
 
 func (d *Dictionary) Copy() (Object, error) {
@@ -43,6 +132,25 @@ func (d *Dictionary) Key(name pdf.Name) Object {
 }
 
 // Int finds an object and converts it to int64.
+// The defval is returned if the key does not exist
+// or the object is not a number.
 func (d *Dictionary) Int(name pdf.Name, defval int64) int64 {
-	panic("not implemented") // TODO: implement me
+	num, ok := d.Key(name).(*pdf.Number)
+	if !ok {
+		return defval
+	}
+
+	return num.Int64()
+}
+
+// Bool finds an object and converts it to bool.
+// The defval is returned if the key does not exist
+// or the object is not a boolean.
+func (d *Dictionary) Bool(name pdf.Name, defval bool) bool {
+	b, ok := d.Key(name).(Bool)
+	if !ok {
+		return defval
+	}
+
+	return bool(b)
 }
@@ -1,11 +1,821 @@
 package podofo
 
-type Encrypt struct{}
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
 
+	"github.com/denisss025/go-podofo/internal/crypt"
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// Encrypt is the security handler of an encrypted PDF file.
+// Once authenticated, it decrypts the strings and the streams
+// of the objects. The zero value decrypts nothing, e.g. the
+// XRef streams are never encrypted.
+type Encrypt struct {
+	// Either security or pubSec is the handler,
+	// i.e. /Standard or /Adobe.PubSec.
+	security *crypt.StandardSecurity
+	pubSec   *crypt.PublicKeySecurity
+	v        int
+	// filters are the crypt filters by the name, see /CF.
+	filters map[pdf.Name]crypt.Method
+	// stmMethod and strMethod are the methods of the
+	// /StmF and /StrF crypt filters.
+	stmMethod crypt.Method
+	strMethod crypt.Method
+
+	key     []byte
+	isOwner bool
+
+	// userPassword and ownerPassword are the passwords
+	// of a new handler, see GenerateEncryptionKey.
+	userPassword  string
+	ownerPassword string
+	// recipients are the certificates of a new
+	// public-key handler, see GenerateEncryptionKey.
+	recipients []*x509.Certificate
+	isNew      bool
+}
+
+var _ pdf.Encrypt = (*Encrypt)(nil)
+
+// The file key lengths in bytes.
+const (
+	aes128KeyLen = 16
+	aes256KeyLen = 32
+	rc4KeyLen    = 16
+)
+
+// NewEncrypt creates the standard security handler that encrypts a
+// document with the passwords. The user password may be empty, so the
+// document is opened without a password, but only with the permissions.
+// GenerateEncryptionKey must be called before the document is written.
+func NewEncrypt(userPassword, ownerPassword string, permissions Permissions,
+	algorithm EncryptAlgorithm, options ...EncryptOption,
+) (*Encrypt, error) {
+	enc := &Encrypt{
+		security: &crypt.StandardSecurity{
+			P:               permissions.p(),
+			EncryptMetadata: true,
+		},
+		filters:       map[pdf.Name]crypt.Method{pdf.NameIdentity: crypt.MethodNone},
+		userPassword:  userPassword,
+		ownerPassword: ownerPassword,
+		isNew:         true,
+	}
+
+	for _, opt := range options {
+		opt(enc)
+	}
+
+	sec := enc.security
+
+	switch algorithm {
+	case EncryptAlgorithmRC4V2:
+		enc.v, sec.R, sec.Length = 2, 3, rc4KeyLen
+		enc.stmMethod, enc.strMethod = crypt.MethodRC4, crypt.MethodRC4
+
+		// Only the crypt filters can leave the metadata unencrypted.
+		if !sec.EncryptMetadata {
+			enc.v, sec.R = 4, 4
+		}
+	case EncryptAlgorithmAESV2:
+		enc.v, sec.R, sec.Length = 4, 4, aes128KeyLen
+		enc.stmMethod, enc.strMethod = crypt.MethodAESV2, crypt.MethodAESV2
+	case EncryptAlgorithmAESV3:
+		enc.v, sec.R, sec.Length = 5, 6, aes256KeyLen
+		enc.stmMethod, enc.strMethod = crypt.MethodAESV3, crypt.MethodAESV3
+	default:
+		return nil, fmt.Errorf("new encrypt: %w: algorithm %d", ErrInvalidEnumValue, algorithm)
+	}
+
+	return enc, nil
+}
+
+// NewPublicKeyEncrypt creates the public-key security handler that
+// encrypts a document for the recipient certificates with the RSA
+// public keys. All the recipients are granted the permissions.
+// GenerateEncryptionKey must be called before the document is written.
+func NewPublicKeyEncrypt(recipients []*x509.Certificate, permissions Permissions,
+	algorithm EncryptAlgorithm, options ...EncryptOption,
+) (*Encrypt, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("new public-key encrypt: %w", ErrRecipientNotFound)
+	}
+
+	enc := &Encrypt{
+		pubSec: &crypt.PublicKeySecurity{
+			P:               permissions.p(),
+			EncryptMetadata: true,
+		},
+		filters:    map[pdf.Name]crypt.Method{pdf.NameIdentity: crypt.MethodNone},
+		recipients: recipients,
+		isNew:      true,
+	}
+
+	for _, opt := range options {
+		opt(enc)
+	}
+
+	sec := enc.pubSec
+
+	switch algorithm {
+	case EncryptAlgorithmRC4V2:
+		enc.v, sec.Length, sec.Method = 2, rc4KeyLen, crypt.MethodRC4
+
+		// Only the crypt filters can leave the metadata unencrypted.
+		if !sec.EncryptMetadata {
+			enc.v = 4
+		}
+	case EncryptAlgorithmAESV2:
+		enc.v, sec.Length, sec.Method = 4, aes128KeyLen, crypt.MethodAESV2
+	case EncryptAlgorithmAESV3:
+		enc.v, sec.Length, sec.Method = 5, aes256KeyLen, crypt.MethodAESV3
+	default:
+		return nil, fmt.Errorf("new public-key encrypt: %w: algorithm %d", ErrInvalidEnumValue, algorithm)
+	}
+
+	enc.stmMethod, enc.strMethod = sec.Method, sec.Method
+
+	return enc, nil
+}
+
+// NewDocumentID creates a random file identifier to be used
+// for both elements of the trailer /ID (see ISO 32000-2:2020, 14.4).
+func NewDocumentID() (*String, error) {
+	const idLen = 16
+
+	id := make([]byte, idLen)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("new document ID: %w", err)
+	}
+
+	return newRawString(id, true), nil
+}
+
+// EncryptFromObject creates the security handler from the
+// encryption dictionary. The standard and the public-key
+// security handlers are supported (see ISO 32000-2:2020, 7.6.4
+// and 7.6.5).
 func EncryptFromObject(obj Object) (*Encrypt, error) {
-	panic("not implemented") // TODO: implement me
+	dict, err := encryptDictionary(obj)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt from object: %w", err)
+	}
+
+	var enc *Encrypt
+
+	name, _ := dict.Key(pdf.KeyFilter).(*pdf.NameObject)
+
+	switch {
+	case name == nil:
+		return nil, fmt.Errorf("encrypt from object: %w: no /Filter", ErrInvalidEncryptionDict)
+	case name.Name == pdf.NameStandard:
+		enc, err = newStandardEncrypt(dict)
+	case name.Name == pdf.NameAdobePubSec:
+		enc, err = newPublicKeyEncrypt(dict)
+	default:
+		return nil, fmt.Errorf("encrypt from object: %w: /Filter /%s", ErrUnsupportedEncryption, name.Name)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("encrypt from object: %w", err)
+	}
+
+	return enc, nil
+}
+
+// newStandardEncrypt reads the standard security handler.
+func newStandardEncrypt(dict *Dictionary) (*Encrypt, error) {
+	const defaultLength = 40
+
+	enc := &Encrypt{
+		security: &crypt.StandardSecurity{
+			R:               int(dict.Int(pdf.KeyR, 0)),
+			Length:          int(dict.Int(pdf.KeyLength, defaultLength) / 8),
+			O:               stringBytes(dict, pdf.KeyO),
+			U:               stringBytes(dict, pdf.KeyU),
+			OE:              stringBytes(dict, pdf.KeyOE),
+			UE:              stringBytes(dict, pdf.KeyUE),
+			Perms:           stringBytes(dict, pdf.KeyPerms),
+			P:               int32(dict.Int(pdf.KeyP, 0)),
+			EncryptMetadata: true,
+		},
+		filters: map[pdf.Name]crypt.Method{pdf.NameIdentity: crypt.MethodNone},
+	}
+
+	if encryptMetadata, ok := dict.Key(pdf.KeyEncryptMetadata).(Bool); ok {
+		enc.security.EncryptMetadata = bool(encryptMetadata)
+	}
+
+	switch enc.v = int(dict.Int(pdf.KeyV, 0)); enc.v {
+	case 1, 2:
+		enc.stmMethod, enc.strMethod = crypt.MethodRC4, crypt.MethodRC4
+	case 4, 5:
+		if err := enc.readCryptFilters(dict); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: /V %d", ErrUnsupportedEncryption, enc.v)
+	}
+
+	if r := enc.security.R; r < 2 || r > 6 {
+		return nil, fmt.Errorf("%w: /R %d", ErrUnsupportedEncryption, r)
+	}
+
+	return enc, nil
+}
+
+// newPublicKeyEncrypt reads the public-key security handler. The
+// recipients of adbe.pkcs7.s3 and adbe.pkcs7.s4 are in the encryption
+// dictionary and the ones of adbe.pkcs7.s5 are in the crypt filter.
+func newPublicKeyEncrypt(dict *Dictionary) (*Encrypt, error) {
+	const (
+		bitsPerByte   = 8
+		defaultLength = 40
+	)
+
+	enc := &Encrypt{
+		pubSec:  &crypt.PublicKeySecurity{EncryptMetadata: true},
+		filters: map[pdf.Name]crypt.Method{pdf.NameIdentity: crypt.MethodNone},
+		v:       int(dict.Int(pdf.KeyV, 0)),
+	}
+
+	sec := enc.pubSec
+
+	subFilter, _ := dict.Key(pdf.KeySubFilter).(*pdf.NameObject)
+	if subFilter == nil {
+		return nil, fmt.Errorf("%w: no /SubFilter", ErrInvalidEncryptionDict)
+	}
+
+	switch subFilter.Name {
+	case pdf.NameAdbePKCS7S3, pdf.NameAdbePKCS7S4:
+		if enc.v != 1 && enc.v != 2 {
+			return nil, fmt.Errorf("%w: /V %d", ErrUnsupportedEncryption, enc.v)
+		}
+
+		sec.Method = crypt.MethodRC4
+		sec.Length = int(dict.Int(pdf.KeyLength, defaultLength) / bitsPerByte)
+		sec.Recipients = recipientsBytes(dict.Key(pdf.KeyRecipients))
+	case pdf.NameAdbePKCS7S5:
+		if enc.v != 4 && enc.v != 5 {
+			return nil, fmt.Errorf("%w: /V %d", ErrUnsupportedEncryption, enc.v)
+		}
+
+		if err := enc.readCryptFilters(dict); err != nil {
+			return nil, err
+		}
+
+		filter := defaultCryptFilter(dict)
+		if filter == nil {
+			return nil, fmt.Errorf("%w: no default crypt filter", ErrInvalidEncryptionDict)
+		}
+
+		sec.Method, _ = cryptFilterMethod(filter)
+		sec.Recipients = recipientsBytes(filter.Key(pdf.KeyRecipients))
+
+		switch sec.Method {
+		case crypt.MethodAESV2:
+			sec.Length = aes128KeyLen
+		case crypt.MethodAESV3:
+			sec.Length = aes256KeyLen
+		default:
+			// The public-key handlers express the length in bits.
+			sec.Length = int(filter.Int(pdf.KeyLength, rc4KeyLen*bitsPerByte) / bitsPerByte)
+		}
+
+		if encryptMetadata, ok := filter.Key(pdf.KeyEncryptMetadata).(Bool); ok {
+			sec.EncryptMetadata = bool(encryptMetadata)
+		}
+	default:
+		return nil, fmt.Errorf("%w: /SubFilter /%s", ErrUnsupportedEncryption, subFilter.Name)
+	}
+
+	if len(sec.Recipients) == 0 {
+		return nil, fmt.Errorf("%w: no /Recipients", ErrInvalidEncryptionDict)
+	}
+
+	return enc, nil
+}
+
+// defaultCryptFilter returns the crypt filter
+// dictionary named by either /StmF or /StrF.
+func defaultCryptFilter(dict *Dictionary) *Dictionary {
+	filters, _ := dict.Key(pdf.KeyCF).(*Dictionary)
+	if filters == nil {
+		return nil
+	}
+
+	for _, key := range []pdf.Name{pdf.KeyStmF, pdf.KeyStrF} {
+		if name, ok := dict.Key(key).(*pdf.NameObject); ok {
+			if filter, ok := filters.Key(name.Name).(*Dictionary); ok {
+				return filter
+			}
+		}
+	}
+
+	return nil
+}
+
+// recipientsBytes returns the PKCS#7 objects of /Recipients,
+// which is either an array of strings or a single string.
+func recipientsBytes(obj Object) [][]byte {
+	switch obj := obj.(type) {
+	case *String:
+		return [][]byte{obj.RawData()}
+	case *Array:
+		recipients := make([][]byte, 0, len(obj.objects))
+
+		for _, item := range obj.objects {
+			if s, ok := item.(*String); ok {
+				recipients = append(recipients, s.RawData())
+			}
+		}
+
+		return recipients
+	default:
+		return nil
+	}
+}
+
+// encryptDictionary returns the encryption dictionary
+// of either a dictionary or a parser object.
+func encryptDictionary(obj Object) (*Dictionary, error) {
+	switch obj := obj.(type) {
+	case *Dictionary:
+		return obj, nil
+	case *ParserObject:
+		if err := obj.DelayedLoad(); err != nil {
+			return nil, err
+		}
+
+		if obj.Dictionary != nil {
+			return obj.Dictionary, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: not a dictionary", ErrInvalidEncryptionDict)
+}
+
+// readCryptFilters reads the /CF crypt filters and
+// selects the /StmF and /StrF ones (PDF 1.5 and later).
+func (enc *Encrypt) readCryptFilters(dict *Dictionary) error {
+	if filters, ok := dict.Key(pdf.KeyCF).(*Dictionary); ok {
+		for name, obj := range filters.keys {
+			filter, ok := obj.(*Dictionary)
+			if !ok {
+				return fmt.Errorf("%w: crypt filter /%s is not a dictionary", ErrInvalidEncryptionDict, name)
+			}
+
+			method, err := cryptFilterMethod(filter)
+			if err != nil {
+				return fmt.Errorf("crypt filter /%s: %w", name, err)
+			}
+
+			enc.filters[name] = method
+		}
+	}
+
+	var err error
+
+	if enc.stmMethod, err = enc.filterMethod(dict.Key(pdf.KeyStmF)); err != nil {
+		return fmt.Errorf("/StmF: %w", err)
+	}
+
+	if enc.strMethod, err = enc.filterMethod(dict.Key(pdf.KeyStrF)); err != nil {
+		return fmt.Errorf("/StrF: %w", err)
+	}
+
+	return nil
+}
+
+// cryptFilterMethod returns the /CFM of a crypt filter
+// (see ISO 32000-2:2020, Table 25).
+func cryptFilterMethod(filter *Dictionary) (crypt.Method, error) {
+	name, _ := filter.Key(pdf.KeyCFM).(*pdf.NameObject)
+	if name == nil {
+		return crypt.MethodNone, nil
+	}
+
+	switch name.Name {
+	case pdf.NameNone:
+		return crypt.MethodNone, nil
+	case pdf.NameV2:
+		return crypt.MethodRC4, nil
+	case pdf.NameAESV2:
+		return crypt.MethodAESV2, nil
+	case pdf.NameAESV3:
+		return crypt.MethodAESV3, nil
+	default:
+		return crypt.MethodNone, fmt.Errorf("%w: /CFM /%s", ErrUnsupportedEncryption, name.Name)
+	}
+}
+
+// filterMethod returns the method of the crypt filter
+// named by obj. The default is the /Identity filter.
+func (enc *Encrypt) filterMethod(obj Object) (crypt.Method, error) {
+	name, _ := obj.(*pdf.NameObject)
+	if name == nil {
+		return crypt.MethodNone, nil
+	}
+
+	method, ok := enc.filters[name.Name]
+	if !ok {
+		return crypt.MethodNone, fmt.Errorf("%w: crypt filter /%s not found", ErrInvalidEncryptionDict, name.Name)
+	}
+
+	return method, nil
+}
+
+// Authenticate tries the password as the user password and then
+// as the owner password. The documentID is the first element of
+// the /ID array of the trailer.
+func (enc *Encrypt) Authenticate(password string, documentID *String) bool {
+	if enc.security == nil {
+		log.Printf("Authentication failed: %v", ErrInvalidPassword)
+
+		return false
+	}
+
+	if documentID != nil {
+		enc.security.ID = documentID.RawData()
+	}
+
+	key, isOwner, err := enc.security.Authenticate(password)
+	if err != nil {
+		log.Printf("Authentication failed: %v", err)
+
+		return false
+	}
+
+	enc.key, enc.isOwner = key, isOwner
+
+	return true
+}
+
+// AuthenticateRecipient opens the file key enveloped for the recipient
+// certificate with its private key. It is used by the public-key
+// security handler, the recipient is granted its Permissions.
+func (enc *Encrypt) AuthenticateRecipient(cert *x509.Certificate, key crypto.Decrypter) bool {
+	if enc.pubSec == nil {
+		log.Printf("Authentication failed: %v", ErrRecipientNotFound)
+
+		return false
+	}
+
+	fileKey, err := enc.pubSec.Authenticate(cert, key)
+	if err != nil {
+		log.Printf("Authentication failed: %v", err)
+
+		return false
+	}
+
+	enc.key, enc.isOwner = fileKey, false
+
+	return true
+}
+
+// IsPublicKey returns true for the public-key security handler.
+func (enc *Encrypt) IsPublicKey() bool { return enc.pubSec != nil }
+
+// IsAuthenticated returns true if the objects can be decrypted.
+func (enc *Encrypt) IsAuthenticated() bool { return enc.key != nil }
+
+// IsOwnerPasswordAuthenticated returns true if
+// the owner password has been authenticated.
+func (enc *Encrypt) IsOwnerPasswordAuthenticated() bool { return enc.isOwner }
+
+// GenerateEncryptionKey computes the entries of the encryption
+// dictionary and the file key of a handler created by NewEncrypt
+// or NewPublicKeyEncrypt. The documentID is the first element of
+// the trailer /ID. The handler read from a file keeps its key, so
+// the /ID shall be kept as well.
+func (enc *Encrypt) GenerateEncryptionKey(documentID *String) error {
+	if !enc.isNew {
+		return nil
+	}
+
+	if enc.pubSec != nil {
+		key, err := enc.pubSec.Setup(enc.recipients)
+		if err != nil {
+			return fmt.Errorf("generate encryption key: %w", err)
+		}
+
+		enc.key = key
+
+		return nil
+	}
+
+	if documentID != nil {
+		enc.security.ID = documentID.RawData()
+	}
+
+	key, err := enc.security.Setup(enc.userPassword, enc.ownerPassword)
+	if err != nil {
+		return fmt.Errorf("generate encryption key: %w", err)
+	}
+
+	enc.key, enc.isOwner = key, true
+
+	return nil
+}
+
+// Dictionary creates the encryption dictionary. It shall be
+// written as the zero reference, so it is not encrypted.
+func (enc *Encrypt) Dictionary() *Dictionary {
+	if enc.pubSec != nil {
+		return enc.publicKeyDictionary()
+	}
+
+	const (
+		bitsPerByte   = 8
+		stmFilterName = "StdCF"
+		strFilterName = "StrCF"
+	)
+
+	sec := enc.security
+	dict := NewDictionary()
+
+	dict.AddKey(pdf.KeyFilter, &pdf.NameObject{Name: pdf.NameStandard})
+	dict.AddKey(pdf.KeyV, pdf.NewInt(int64(enc.v)))
+	dict.AddKey(pdf.KeyR, pdf.NewInt(int64(sec.R)))
+	dict.AddKey(pdf.KeyLength, pdf.NewInt(int64(sec.Length*bitsPerByte)))
+	dict.AddKey(pdf.KeyO, newRawString(sec.O, true))
+	dict.AddKey(pdf.KeyU, newRawString(sec.U, true))
+	dict.AddKey(pdf.KeyP, pdf.NewInt(int64(sec.P)))
+
+	if sec.R >= 5 {
+		dict.AddKey(pdf.KeyOE, newRawString(sec.OE, true))
+		dict.AddKey(pdf.KeyUE, newRawString(sec.UE, true))
+		dict.AddKey(pdf.KeyPerms, newRawString(sec.Perms, true))
+	}
+
+	if enc.v < 4 {
+		return dict
+	}
+
+	filters := NewDictionary()
+	filterName := func(method crypt.Method, name pdf.Name) *pdf.NameObject {
+		if method == crypt.MethodNone {
+			return &pdf.NameObject{Name: pdf.NameIdentity}
+		}
+
+		if _, ok := filters.keys[name]; !ok {
+			filters.AddKey(name, newCryptFilter(method, sec.Length))
+		}
+
+		return &pdf.NameObject{Name: name}
+	}
+
+	dict.AddKey(pdf.KeyStmF, filterName(enc.stmMethod, stmFilterName))
+
+	if enc.strMethod == enc.stmMethod {
+		dict.AddKey(pdf.KeyStrF, filterName(enc.strMethod, stmFilterName))
+	} else {
+		dict.AddKey(pdf.KeyStrF, filterName(enc.strMethod, strFilterName))
+	}
+
+	dict.AddKey(pdf.KeyCF, filters)
+
+	if !sec.EncryptMetadata {
+		dict.AddKey(pdf.KeyEncryptMetadata, Bool(false))
+	}
+
+	return dict
 }
 
-func (enc *Encrypt) Authenticate(password string, objectID *String) bool {
-	panic("not implemented") // TODO: implement me
+// publicKeyDictionary creates the encryption dictionary of the
+// public-key security handler. The crypt filters are used by
+// adbe.pkcs7.s5 only.
+func (enc *Encrypt) publicKeyDictionary() *Dictionary {
+	const bitsPerByte = 8
+
+	sec := enc.pubSec
+	dict := NewDictionary()
+
+	recipients := new(Array)
+	for _, recipient := range sec.Recipients {
+		recipients.objects = append(recipients.objects, newRawString(recipient, true))
+	}
+
+	dict.AddKey(pdf.KeyFilter, &pdf.NameObject{Name: pdf.NameAdobePubSec})
+	dict.AddKey(pdf.KeyV, pdf.NewInt(int64(enc.v)))
+
+	if enc.v < 4 {
+		dict.AddKey(pdf.KeySubFilter, &pdf.NameObject{Name: pdf.NameAdbePKCS7S4})
+		dict.AddKey(pdf.KeyLength, pdf.NewInt(int64(sec.Length*bitsPerByte)))
+		dict.AddKey(pdf.KeyRecipients, recipients)
+
+		return dict
+	}
+
+	filter := newCryptFilter(sec.Method, sec.Length*bitsPerByte)
+	filter.AddKey(pdf.KeyRecipients, recipients)
+
+	if !sec.EncryptMetadata {
+		filter.AddKey(pdf.KeyEncryptMetadata, Bool(false))
+	}
+
+	filters := NewDictionary()
+	filters.AddKey(pdf.NameDefaultCryptFilter, filter)
+
+	dict.AddKey(pdf.KeySubFilter, &pdf.NameObject{Name: pdf.NameAdbePKCS7S5})
+	dict.AddKey(pdf.KeyCF, filters)
+	dict.AddKey(pdf.KeyStmF, &pdf.NameObject{Name: pdf.NameDefaultCryptFilter})
+	dict.AddKey(pdf.KeyStrF, &pdf.NameObject{Name: pdf.NameDefaultCryptFilter})
+
+	return dict
+}
+
+// newCryptFilter creates the crypt filter dictionary of the method.
+func newCryptFilter(method crypt.Method, length int) *Dictionary {
+	filter := NewDictionary()
+
+	filter.AddKey(pdf.KeyType, &pdf.NameObject{Name: pdf.NameCryptFilter})
+	filter.AddKey(pdf.KeyCFM, &pdf.NameObject{Name: pdf.Name(method.String())})
+	filter.AddKey(pdf.KeyAuthEvent, &pdf.NameObject{Name: pdf.NameDocOpen})
+	filter.AddKey(pdf.KeyLength, pdf.NewInt(int64(length)))
+
+	return filter
+}
+
+// EncryptString encrypts a string of the indirect object ref.
+// It implements pdf.Encrypt.
+func (enc *Encrypt) EncryptString(ref Reference, data []byte) ([]byte, error) {
+	if !ref.IsIndirect() || !enc.IsAuthenticated() {
+		return data, nil
+	}
+
+	key := crypt.ObjectKey(enc.strMethod, enc.key, ref.ObjectNo, uint16(ref.GenerationNo))
+
+	encrypted, err := crypt.Encrypt(enc.strMethod, key, data)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt string: %w", err)
+	}
+
+	return encrypted, nil
+}
+
+// EncryptStream encrypts the stream data of the indirect
+// object ref. It implements pdf.Encrypt.
+func (enc *Encrypt) EncryptStream(ref Reference, data []byte, isMetadata bool) ([]byte, error) {
+	if !ref.IsIndirect() || !enc.IsAuthenticated() || (isMetadata && !enc.encryptMetadata()) {
+		return data, nil
+	}
+
+	key := crypt.ObjectKey(enc.stmMethod, enc.key, ref.ObjectNo, uint16(ref.GenerationNo))
+
+	encrypted, err := crypt.Encrypt(enc.stmMethod, key, data)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt stream: %w", err)
+	}
+
+	return encrypted, nil
+}
+
+// Permissions returns the access permissions of
+// the user or of the authenticated recipient.
+func (enc *Encrypt) Permissions() Permissions {
+	var p int32
+
+	switch {
+	case enc.pubSec != nil:
+		p = enc.pubSec.P
+	case enc.security != nil:
+		p = enc.security.P
+	}
+
+	return Permissions(uint32(p)) & PermissionsAll
+}
+
+// encryptMetadata returns true if the metadata streams are encrypted.
+func (enc *Encrypt) encryptMetadata() bool {
+	if enc.pubSec != nil {
+		return enc.pubSec.EncryptMetadata
+	}
+
+	return enc.security == nil || enc.security.EncryptMetadata
+}
+
+// decryptObject decrypts the strings of the indirect object ref
+// in place. The /Contents of the signatures are not encrypted.
+func (enc *Encrypt) decryptObject(ref *Reference, obj Object) error {
+	if !enc.IsAuthenticated() || enc.strMethod == crypt.MethodNone {
+		return nil
+	}
+
+	key := crypt.ObjectKey(enc.strMethod, enc.key, ref.ObjectNo, uint16(ref.GenerationNo))
+
+	return enc.decryptStrings(key, obj)
+}
+
+func (enc *Encrypt) decryptStrings(key []byte, obj Object) error {
+	switch obj := obj.(type) {
+	case *String:
+		data, err := crypt.Decrypt(enc.strMethod, key, obj.data.Chars)
+		if err != nil {
+			return fmt.Errorf("decrypt string: %w", err)
+		}
+
+		obj.data.Chars = data
+	case *Array:
+		for _, item := range obj.objects {
+			if err := enc.decryptStrings(key, item); err != nil {
+				return err
+			}
+		}
+	case *Dictionary:
+		typeName, _ := obj.Key(pdf.KeyType).(*pdf.NameObject)
+		isSignature := typeName != nil &&
+			(typeName.Name == pdf.NameSig || typeName.Name == pdf.NameDocTimeStamp)
+
+		for name, item := range obj.keys {
+			if isSignature && name == pdf.KeyContents {
+				continue
+			}
+
+			if err := enc.decryptStrings(key, item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// newStreamReader returns a reader that decrypts the stream data of
+// the indirect object ref. A /Crypt filter of the stream overrides
+// /StmF and the metadata streams may be left unencrypted.
+func (enc *Encrypt) newStreamReader(ref *Reference, dict *Dictionary, r io.Reader) (io.Reader, error) {
+	if !enc.IsAuthenticated() {
+		return r, nil
+	}
+
+	method := enc.stmMethod
+
+	if name, ok := dict.Key(pdf.KeyType).(*pdf.NameObject); ok && name.Name == pdf.NameMetadata &&
+		!enc.encryptMetadata() {
+		method = crypt.MethodNone
+	}
+
+	if filterName, ok := streamCryptFilter(dict); ok {
+		var err error
+
+		if method, err = enc.filterMethod(&pdf.NameObject{Name: filterName}); err != nil {
+			return nil, fmt.Errorf("decrypt stream: %w", err)
+		}
+	}
+
+	key := crypt.ObjectKey(method, enc.key, ref.ObjectNo, uint16(ref.GenerationNo))
+
+	dr, err := crypt.NewDecryptReader(method, key, r)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt stream: %w", err)
+	}
+
+	return dr, nil
+}
+
+// streamCryptFilter returns the name of the crypt filter of a stream
+// with the /Crypt filter, which shall be the first one in /Filter.
+func streamCryptFilter(dict *Dictionary) (pdf.Name, bool) {
+	var (
+		filter Object = dict.Key(pdf.KeyFilter)
+		params Object = dict.Key(pdf.KeyDecodeParms)
+	)
+
+	if array, ok := filter.(*Array); ok && array.NumObjects() > 0 {
+		filter = array.objects[0]
+
+		if paramsArray, ok := params.(*Array); ok && paramsArray.NumObjects() > 0 {
+			params = paramsArray.objects[0]
+		}
+	}
+
+	if name, ok := filter.(*pdf.NameObject); !ok || name.Name != pdf.NameCrypt {
+		return "", false
+	}
+
+	if paramsDict, ok := params.(*Dictionary); ok {
+		if name, ok := paramsDict.Key(pdf.KeyName).(*pdf.NameObject); ok {
+			return name.Name, true
+		}
+	}
+
+	return pdf.NameIdentity, true
+}
+
+// stringBytes returns the bytes of the string
+// value of the key or nil if it is not a string.
+func stringBytes(dict *Dictionary, key pdf.Name) []byte {
+	if s, ok := dict.Key(key).(*String); ok {
+		return s.RawData()
+	}
+
+	return nil
 }
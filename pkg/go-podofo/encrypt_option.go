@@ -0,0 +1,73 @@
+package podofo
+
+// Permissions are the user access permissions of an encrypted
+// document (see ISO 32000-2:2020, Table 22). The owner password
+// grants all of them.
+type Permissions uint32
+
+const (
+	// PermissionPrint allows printing the document.
+	PermissionPrint Permissions = 1 << 2
+	// PermissionModify allows modifying the contents of the document
+	// other than by PermissionAnnotate, PermissionFillForms and
+	// PermissionAssemble.
+	PermissionModify Permissions = 1 << 3
+	// PermissionCopy allows copying or extracting the text and graphics.
+	PermissionCopy Permissions = 1 << 4
+	// PermissionAnnotate allows adding or modifying the annotations and
+	// filling in the interactive form fields.
+	PermissionAnnotate Permissions = 1 << 5
+	// PermissionFillForms allows filling in the existing interactive
+	// form fields, including the signature fields.
+	PermissionFillForms Permissions = 1 << 8
+	// PermissionExtractForAccessibility allows extracting the text
+	// and graphics for the accessibility purposes.
+	PermissionExtractForAccessibility Permissions = 1 << 9
+	// PermissionAssemble allows inserting, rotating and deleting
+	// the pages and creating the bookmarks and the thumbnails.
+	PermissionAssemble Permissions = 1 << 10
+	// PermissionPrintHighQuality allows printing in a faithful digital
+	// representation of the document. PermissionPrint is required.
+	PermissionPrintHighQuality Permissions = 1 << 11
+
+	// PermissionsAll are all the permissions.
+	PermissionsAll = PermissionPrint | PermissionModify | PermissionCopy |
+		PermissionAnnotate | PermissionFillForms | PermissionExtractForAccessibility |
+		PermissionAssemble | PermissionPrintHighQuality
+)
+
+// p returns the /P value of the permissions. The reserved bits
+// 7, 8 and 13 to 32 shall be set.
+func (perms Permissions) p() int32 {
+	const reserved = 0xFFFFF0C0
+
+	return int32(uint32(perms&PermissionsAll) | reserved)
+}
+
+// EncryptAlgorithm is the algorithm of the standard security handler.
+type EncryptAlgorithm uint8
+
+const (
+	// EncryptAlgorithmRC4V2 is the 128-bit RC4 encryption (PDF 1.4).
+	EncryptAlgorithmRC4V2 EncryptAlgorithm = iota
+	// EncryptAlgorithmAESV2 is the 128-bit AES encryption (PDF 1.6).
+	EncryptAlgorithmAESV2
+	// EncryptAlgorithmAESV3 is the 256-bit AES encryption of the
+	// revision 6 (PDF 2.0).
+	EncryptAlgorithmAESV3
+)
+
+// EncryptOption is used for the security handler created by NewEncrypt.
+type EncryptOption func(*Encrypt)
+
+// DontEncryptMetadata tells the security handler to leave the
+// metadata streams unencrypted, so they can be indexed.
+func DontEncryptMetadata() EncryptOption {
+	return func(enc *Encrypt) {
+		if enc.pubSec != nil {
+			enc.pubSec.EncryptMetadata = false
+		} else {
+			enc.security.EncryptMetadata = false
+		}
+	}
+}
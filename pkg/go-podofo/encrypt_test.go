@@ -0,0 +1,162 @@
+package podofo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// newEncryptedFile returns the file of numPages pages encrypted
+// with the user password "user" and the owner password "owner".
+func newEncryptedFile(t *testing.T, algorithm podofo.EncryptAlgorithm, numPages int) []byte {
+	t.Helper()
+
+	enc, err := podofo.NewEncrypt("user", "owner", podofo.PermissionPrint, algorithm)
+	require.NoError(t, err)
+
+	doc := newDocument(t, numPages)
+	doc.SetEncrypt(enc)
+
+	return saveDocument(t, doc)
+}
+
+func TestSaveLoadedEncrypted(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		algorithm podofo.EncryptAlgorithm
+	}{
+		{name: "rc4", algorithm: podofo.EncryptAlgorithmRC4V2},
+		{name: "aes-128", algorithm: podofo.EncryptAlgorithmAESV2},
+		{name: "aes-256", algorithm: podofo.EncryptAlgorithmAESV3},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := newEncryptedFile(t, tt.algorithm, 2)
+
+			doc, err := podofo.LoadMemDocument(bytes.NewReader(file), podofo.Password("user"))
+			require.NoError(t, err)
+			require.NotNil(t, doc.AddPage(podofo.PageSizeA4()))
+
+			for _, option := range []podofo.SaveOption{
+				podofo.SaveOptionNone, podofo.SaveOptionNoCollectGarbage, podofo.SaveOptionLinearize,
+			} {
+				saved := saveDocument(t, doc, option)
+
+				loaded, err := podofo.LoadMemDocument(bytes.NewReader(saved), podofo.Password("user"))
+				if assert.NoError(t, err) {
+					assert.Equal(t, 3, loaded.PageCollection().Count())
+				}
+			}
+		})
+	}
+}
+
+func TestSaveUpdateEncrypted(t *testing.T) {
+	t.Parallel()
+
+	file := newEncryptedFile(t, podofo.EncryptAlgorithmAESV2, 2)
+
+	doc, err := podofo.LoadMemDocument(bytes.NewReader(file), podofo.Password("user"))
+	require.NoError(t, err)
+	require.NotNil(t, doc.AddPage(podofo.PageSizeA4()))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, doc.SaveUpdate(&buf))
+
+	loaded, err := podofo.LoadMemDocument(bytes.NewReader(buf.Bytes()), podofo.Password("user"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, loaded.PageCollection().Count())
+	assert.True(t, loaded.Encrypt().IsAuthenticated())
+}
+
+func TestSaveLoadedKeepsPerms(t *testing.T) {
+	t.Parallel()
+
+	perms := func(doc *podofo.MemDocument) []byte {
+		str, ok := doc.Encrypt().Dictionary().Key(pdf.KeyPerms).(*podofo.String)
+		require.True(t, ok)
+
+		return str.RawData()
+	}
+
+	file := newEncryptedFile(t, podofo.EncryptAlgorithmAESV3, 1)
+
+	doc, err := podofo.LoadMemDocument(bytes.NewReader(file), podofo.Password("user"))
+	require.NoError(t, err)
+
+	want := perms(doc)
+	assert.Len(t, want, 16)
+
+	saved := saveDocument(t, doc)
+
+	loaded, err := podofo.LoadMemDocument(bytes.NewReader(saved), podofo.Password("owner"))
+	require.NoError(t, err)
+	assert.True(t, loaded.Encrypt().IsOwnerPasswordAuthenticated())
+	assert.Equal(t, want, perms(loaded))
+}
+
+func TestParseEncryptObject(t *testing.T) {
+	t.Parallel()
+
+	file := newEncryptedFile(t, podofo.EncryptAlgorithmAESV2, 1)
+
+	parser, err := podofo.Parse(bytes.NewReader(file), podofo.Password("user"))
+	require.NoError(t, err)
+
+	ref, ok := parsedDictionary(t, parser.Trailer()).Key(pdf.KeyEncrypt).(*pdf.Reference)
+	require.True(t, ok)
+
+	// The encryption dictionary is kept as it is in the file,
+	// so its number is neither free nor reused.
+	assert.Contains(t, string(file), marshalObject(t, parser.Objects().GetObject(ref)))
+	assert.NotContains(t, parser.Objects().FreeObjects(), *pdf.NewReference(int(ref.ObjectNo), 0))
+	assert.NotContains(t, parser.Objects().FreeObjects(), *pdf.NewReference(int(ref.ObjectNo), 1))
+}
+
+func TestSaveLoadedEncryptedStrings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		algorithm podofo.EncryptAlgorithm
+	}{
+		{name: "rc4", algorithm: podofo.EncryptAlgorithmRC4V2},
+		{name: "aes-128", algorithm: podofo.EncryptAlgorithmAESV2},
+		{name: "aes-256 revision 6", algorithm: podofo.EncryptAlgorithmAESV3},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			enc, err := podofo.NewEncrypt("user", "owner", podofo.PermissionPrint, tt.algorithm)
+			require.NoError(t, err)
+
+			doc := newDocument(t, 1)
+			doc.SetInfo(podofo.Info{Title: "Hello"})
+			doc.SetEncrypt(enc)
+
+			file := saveDocument(t, doc, podofo.SaveOptionNoFlateCompress)
+			assert.NotContains(t, string(file), "Hello")
+
+			loaded, err := podofo.LoadMemDocument(bytes.NewReader(file), podofo.Password("user"))
+			require.NoError(t, err)
+			assert.Equal(t, "Hello", loaded.Info().Title)
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"io/fs"
 
+	"github.com/denisss025/go-podofo/internal/crypt"
+	"github.com/denisss025/go-podofo/internal/filter"
 	"github.com/denisss025/go-podofo/internal/pdf"
 )
 
@@ -19,6 +21,7 @@ var (
 	ErrInvalidEnumValue          = errors.New("invalid enum value")
 	ErrBrokenFile                = errors.New("file is broken")
 	ErrPageNotFound              = errors.New("page not found")
+	ErrInvalidPageRange          = errors.New("invalid page range")
 	ErrNoPDFFile                 = errors.New("not a PDF file")
 	ErrNoXRef                    = errors.New("no valid XRef")
 	ErrNoTrailer                 = errors.New("no trailer")
@@ -27,28 +30,31 @@ var (
 	ErrNoEOFToken                = errors.New("EOF token not found")
 	ErrInvalidTrailerSize        = errors.New("invalid trailer size")
 	ErrInvalidDataType           = errors.New("invalid data type")
-	ErrInvalidXRef               = errors.New("invalid XRef")
+	ErrInvalidXRef               = pdf.ErrInvalidXRef
 	ErrInvalidXRefStream         = errors.New("invalid XRef stream")
 	ErrInvalidXRefType           = errors.New("invalid XRef type")
-	ErrInvalidPredictor          = errors.New("invalid predictor")
+	ErrInvalidPredictor          = filter.ErrInvalidPredictor
 	ErrInvalidStrokeStyle        = errors.New("invalid stroke style")
-	ErrInvalidHexString          = errors.New("invalid hex string")
+	ErrInvalidHexString          = pdf.ErrInvalidHexString
+	ErrInvalidToken              = pdf.ErrInvalidToken
 	ErrInvalidStream             = errors.New("invalid stream")
 	ErrInvalidStreamLength       = errors.New("invalid stream len")
 	ErrInvalidKey                = errors.New("invalid key")
 	ErrInvalidName               = errors.New("invalid name")
 	ErrInvalidEncryptionDict     = errors.New("invalid encryption dict")
-	ErrInvalidPassword           = errors.New("invalid password")
+	ErrInvalidPassword           = crypt.ErrInvalidPassword
+	ErrRecipientNotFound         = crypt.ErrRecipientNotFound
 	ErrInvalidFontData           = errors.New("invalid font data")
 	ErrInvalidContentStream      = errors.New("invalid content stream")
 	ErrUnsupportedVersion        = pdf.ErrUnsupportedVersion
-	ErrUnsupportedFilter         = errors.New("unsupported filter")
+	ErrUnsupportedFilter         = filter.ErrUnsupportedFilter
+	ErrUnsupportedEncryption     = crypt.ErrUnsupportedEncryption
 	ErrUnsupportedFontFormat     = errors.New("unsupported font format")
 	ErrUnsupportedImageFormat    = errors.New("unsupported image format")
 	ErrActionAlreadyPresent      = errors.New("action already present")
 	ErrWrongDestinationType      = errors.New("wrong destination type")
 	ErrMissingEndStream          = errors.New("missing steram end")
-	ErrDate                      = errors.New("bad date")
+	ErrDate                      = pdf.ErrDate
 	ErrFlate                     = errors.New("flate")
 	ErrFreeType                  = errors.New("free type")
 	ErrSignature                 = errors.New("signature")
@@ -61,4 +67,5 @@ var (
 	ErrNotLoadedForUpdate        = errors.New("not loaded for update")
 	ErrCannotEncrypUpdate        = errors.New("cannot encrypt update")
 	ErrXMPMetadata               = errors.New("xmp metadata")
+	ErrInvalidHintTable          = pdf.ErrInvalidHintTable
 )
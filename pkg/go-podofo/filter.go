@@ -0,0 +1,103 @@
+package podofo
+
+import (
+	"fmt"
+
+	"github.com/denisss025/go-podofo/internal/filter"
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// filterParams are the /DecodeParms of a filter with
+// the data of the streams they refer to.
+type filterParams struct {
+	dict    *Dictionary
+	streams map[string][]byte
+}
+
+func (params filterParams) Int(key string, defval int64) int64 {
+	return params.dict.Int(pdf.Name(key), defval)
+}
+
+func (params filterParams) Bool(key string, defval bool) bool {
+	return params.dict.Bool(pdf.Name(key), defval)
+}
+
+func (params filterParams) Stream(key string) []byte {
+	return params.streams[key]
+}
+
+// newFilterParams reads the /JBIG2Globals stream of the params. It is
+// read beforehand, as the filters cannot read the file while the stream
+// is read from it.
+func newFilterParams(dict *Dictionary, objects *IndirectObjectList) (filterParams, error) {
+	params := filterParams{dict: dict}
+
+	ref, ok := dict.Key(pdf.KeyJBIG2Globals).(*Reference)
+	if !ok || objects == nil {
+		return params, nil
+	}
+
+	globals, ok := objects.GetObject(ref).(*ParserObject)
+	if !ok {
+		return params, fmt.Errorf("%w: /JBIG2Globals %s not found", ErrInvalidStream, ref)
+	}
+
+	data, err := globals.decodeStream()
+	if err != nil {
+		return params, fmt.Errorf("/JBIG2Globals: %w", err)
+	}
+
+	params.streams = map[string][]byte{string(pdf.KeyJBIG2Globals): data}
+
+	return params, nil
+}
+
+// streamFilters reads the /Filter of a stream and the matching
+// /DecodeParms. The params of a filter without them are nil. The
+// streams referred by the params are resolved with the objects.
+func streamFilters(dict *Dictionary, objects *IndirectObjectList) ([]FilterType, []filter.Params, error) {
+	var names, decodeParms []Object
+
+	switch f := dict.Key(pdf.KeyFilter).(type) {
+	case nil:
+		return nil, nil, nil
+	case *pdf.NameObject:
+		names = []Object{f}
+		decodeParms = []Object{dict.Key(pdf.KeyDecodeParms)}
+	case *Array:
+		names = f.objects
+
+		if array, ok := dict.Key(pdf.KeyDecodeParms).(*Array); ok {
+			decodeParms = array.objects
+		}
+	default:
+		return nil, nil, fmt.Errorf("%w: /Filter is neither a name nor an array", ErrUnsupportedFilter)
+	}
+
+	types := make([]FilterType, len(names))
+	params := make([]filter.Params, len(names))
+
+	for i, obj := range names {
+		name, ok := obj.(*pdf.NameObject)
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: /Filter %d is not a name", ErrUnsupportedFilter, i)
+		}
+
+		t, err := filter.ParseType(string(name.Name))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		types[i] = t
+
+		if i < len(decodeParms) {
+			if parms, ok := decodeParms[i].(*Dictionary); ok {
+				if params[i], err = newFilterParams(parms, objects); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	return types, params, nil
+}
@@ -1,23 +1,613 @@
 package podofo
 
-type IndirectObjectList struct{}
+import (
+	"fmt"
+	"log"
+	"sort"
 
-func (list *IndirectObjectList) AddFreeObject(obj Object) {
-	panic("not implemented") // TODO: implement me
+	"github.com/denisss025/go-podofo/internal/pdf"
+	"github.com/denisss025/go-podofo/internal/set"
+)
+
+// delayedObject is an object read from
+// the file when it is accessed first.
+type delayedObject interface {
+	DelayedLoad() error
+}
+
+// objectStreamLoader reads the listed objects of a compressed
+// object stream into the list.
+type objectStreamLoader func(streamObjNo int, objects []int) error
+
+// IndirectObjectList is the list of the indirect objects
+// of a document. The zero value is an empty list.
+type IndirectObjectList struct {
+	document *Document
+
+	objects map[Reference]Object
+	// objectNumbers counts the objects of each object number.
+	objectNumbers map[uint32]int
+
+	// pendingObjectStreams holds the object numbers of the
+	// compressed objects of each object stream not read yet.
+	pendingObjectStreams map[int][]int
+	// compressedObjects maps the object number of a compressed
+	// object to its pending object stream.
+	compressedObjects map[uint32]int
+	loadObjectStream  objectStreamLoader
+
+	// freeObjects are the free object numbers with the generations
+	// to be used when they are reused, sorted by the object number.
+	freeObjects []Reference
+	// objectStreams are the object numbers
+	// of the object streams of the file.
+	objectStreams []int
+	// removed are the references of the objects removed
+	// from the list with the generations of their numbers.
+	removed []Reference
+	// maxObjNo is the greatest object number ever used or freed,
+	// the new objects are numbered after it.
+	maxObjNo uint32
+}
+
+// indirectObject is an object that can be made indirect.
+type indirectObject interface {
+	SetIndirectReference(ref *Reference)
+}
+
+// AddFreeObject marks the object number of the reference as free.
+// The generation is the one to be used when the number is reused,
+// the numbers of the greatest generation are never reused.
+func (list *IndirectObjectList) AddFreeObject(ref *Reference) {
+	list.useObjectNo(ref.ObjectNo)
+
+	i := sort.Search(len(list.freeObjects), func(i int) bool {
+		return list.freeObjects[i].ObjectNo >= ref.ObjectNo
+	})
+
+	if i < len(list.freeObjects) && list.freeObjects[i].ObjectNo == ref.ObjectNo {
+		if list.freeObjects[i].GenerationNo < ref.GenerationNo {
+			list.freeObjects[i].GenerationNo = ref.GenerationNo
+		}
+
+		return
+	}
+
+	list.freeObjects = append(list.freeObjects, Reference{})
+	copy(list.freeObjects[i+1:], list.freeObjects[i:])
+	list.freeObjects[i] = *ref
+}
+
+// SafeAddFreeObject is like AddFreeObject, but the object number
+// is not marked as free if an object with the number exists.
+func (list *IndirectObjectList) SafeAddFreeObject(ref *Reference) {
+	if list.hasObjectNo(ref.ObjectNo) {
+		return
+	}
+
+	list.AddFreeObject(ref)
 }
 
-func (list *IndirectObjectList) SafeAddFreeObject(obj Object) {
-	panic("not implemented") // TODO: implement me
+// FreeObjects returns the free object numbers with the generations
+// to be used when they are reused, sorted by the object number.
+func (list *IndirectObjectList) FreeObjects() []Reference { return list.freeObjects }
+
+// hasObjectNo returns true if there is an object with the number.
+func (list *IndirectObjectList) hasObjectNo(objNo uint32) bool {
+	if _, ok := list.compressedObjects[objNo]; ok {
+		return true
+	}
+
+	return list.objectNumbers[objNo] > 0
+}
+
+// nextReference returns the reference for a new object. The free
+// object numbers are reused first.
+func (list *IndirectObjectList) nextReference() *Reference {
+	for i, ref := range list.freeObjects {
+		if ref.GenerationNo < pdf.MaxGeneration && ref.ObjectNo > 0 {
+			list.freeObjects = append(list.freeObjects[:i], list.freeObjects[i+1:]...)
+
+			return pdf.NewReference(int(ref.ObjectNo), ref.GenerationNo)
+		}
+	}
+
+	return pdf.NewReference(int(list.maxObjectNo())+1, 0)
+}
+
+// maxObjectNo returns the greatest object number in use or free.
+func (list *IndirectObjectList) maxObjectNo() uint32 { return list.maxObjNo }
+
+// useObjectNo records the object number as used or freed.
+func (list *IndirectObjectList) useObjectNo(objNo uint32) {
+	if objNo > list.maxObjNo {
+		list.maxObjNo = objNo
+	}
 }
 
-func (list *IndirectObjectList) Document() *Document {
-	panic("not implemented") // TODO: implement me
+// CreateObject makes the object indirect with a new reference
+// and inserts it into the list.
+func (list *IndirectObjectList) CreateObject(obj Object) (*Reference, error) {
+	indirect, ok := obj.(indirectObject)
+	if !ok {
+		return nil, fmt.Errorf("create object: %w: %T cannot be indirect", ErrInvalidDataType, obj)
+	}
+
+	ref := list.nextReference()
+	indirect.SetIndirectReference(ref)
+	list.PushObject(obj)
+
+	// A new object is written by the incremental update.
+	setDirty(obj)
+
+	return ref, nil
 }
 
+// RemoveObject removes the object from the list and frees its
+// object number with the next generation. The removed object
+// is returned, nil is returned if there is no object.
+func (list *IndirectObjectList) RemoveObject(ref *Reference) Object {
+	obj, ok := list.objects[*ref]
+	if !ok {
+		return nil
+	}
+
+	delete(list.objects, *ref)
+
+	list.objectNumbers[ref.ObjectNo]--
+	if list.objectNumbers[ref.ObjectNo] == 0 {
+		delete(list.objectNumbers, ref.ObjectNo)
+	}
+
+	freed := *ref
+	if freed.GenerationNo < pdf.MaxGeneration {
+		freed.GenerationNo++
+	}
+
+	list.AddFreeObject(&freed)
+	list.removed = append(list.removed, freed)
+
+	return obj
+}
+
+// RemovedObjects returns the references of the objects removed
+// from the list with the generations of their numbers.
+func (list *IndirectObjectList) RemovedObjects() []Reference { return list.removed }
+
+// Document returns the document the list belongs to.
+func (list *IndirectObjectList) Document() *Document { return list.document }
+
+// PushObject inserts the object into the list. An object
+// with the same reference is replaced.
 func (list *IndirectObjectList) PushObject(obj Object) {
-	panic("not implemented") // TODO: implement me
+	ref := indirectReference(obj)
+	if ref == nil {
+		log.Printf("Cannot push a direct object into the indirect objects list")
+
+		return
+	}
+
+	if list.objects == nil {
+		list.objects = make(map[Reference]Object)
+		list.objectNumbers = make(map[uint32]int)
+	}
+
+	if _, ok := list.objects[*ref]; !ok {
+		list.objectNumbers[ref.ObjectNo]++
+	}
+
+	list.objects[*ref] = obj
+	list.useObjectNo(ref.ObjectNo)
+}
+
+// GetObject finds the object by its reference. If the object is
+// stored in an object stream that has not been read yet, the
+// stream is read first. Nil is returned if there is no object.
+//
+// Objects read from the file on demand are loaded here,
+// nil is returned if the object cannot be loaded.
+func (list *IndirectObjectList) GetObject(ref *Reference) Object {
+	if obj, ok := list.objects[*ref]; ok {
+		return delayedLoad(obj)
+	}
+
+	streamObjNo, ok := list.compressedObjects[ref.ObjectNo]
+	if !ok {
+		return nil
+	}
+
+	if err := list.readObjectStream(streamObjNo); err != nil {
+		log.Printf("Cannot read object %s: %v", ref, err)
+	}
+
+	return list.objects[*ref]
 }
 
+// resolve returns the value the object refers to. The parsed objects
+// are unwrapped, so the value is a dictionary, an array, a number,
+// etc. Nil is returned if the object cannot be loaded.
+func (list *IndirectObjectList) resolve(obj Object) Object {
+	if ref, ok := obj.(*Reference); ok {
+		obj = list.GetObject(ref)
+	}
+
+	if parsed, ok := obj.(*ParserObject); ok {
+		if err := parsed.DelayedLoad(); err != nil {
+			return nil
+		}
+
+		return parsed.Object()
+	}
+
+	return obj
+}
+
+// Objects returns the objects sorted by their references. The
+// objects read from the file on demand may be not loaded yet.
+func (list *IndirectObjectList) Objects() []Object {
+	refs := make([]Reference, 0, len(list.objects))
+	for ref := range list.objects {
+		refs = append(refs, ref)
+	}
+
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].ObjectNo < refs[j].ObjectNo ||
+			(refs[i].ObjectNo == refs[j].ObjectNo && refs[i].GenerationNo < refs[j].GenerationNo)
+	})
+
+	objects := make([]Object, len(refs))
+	for i, ref := range refs {
+		objects[i] = list.objects[ref]
+	}
+
+	return objects
+}
+
+// CreateDictionaryObject creates an indirect dictionary of the
+// /Type and inserts it into the list. An empty type means the
+// dictionary has no /Type.
+func (list *IndirectObjectList) CreateDictionaryObject(typ pdf.Name) *Dictionary {
+	dict := NewDictionary()
+	if typ != pdf.KeyNull {
+		dict.AddKey(pdf.KeyType, &pdf.NameObject{Name: typ})
+	}
+
+	dict.SetIndirectReference(list.nextReference())
+	list.PushObject(dict)
+	dict.SetDirty()
+
+	return dict
+}
+
+// CollectGarbage removes the objects that are not reachable from the
+// /Root, /Info and /Encrypt keys of the trailer. The numbers of the
+// removed objects are freed with the next generation, so they are
+// reused, unless the generation is the greatest one. The number of
+// the objects removed is returned.
+func (list *IndirectObjectList) CollectGarbage(trailer *Dictionary) (int, error) {
+	if err := list.loadAll(); err != nil {
+		return 0, fmt.Errorf("collect garbage: %w", err)
+	}
+
+	roots := &Array{}
+
+	for _, key := range []pdf.Name{pdf.KeyRoot, pdf.KeyInfo, pdf.KeyEncrypt} {
+		if value := trailer.Key(key); value != nil {
+			roots.objects = append(roots.objects, value)
+		}
+	}
+
+	reachable := list.reachable(roots)
+
+	var removed int
+
+	for _, obj := range list.Objects() {
+		if ref := indirectReference(obj); !reachable.Contains(*ref) {
+			list.RemoveObject(ref)
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// RenumberObjects collects the garbage and numbers the objects left
+// compactly starting with 1 in the order of their old numbers, the
+// generations are 0. The references of the objects and the trailer
+// are rewritten, the references to the objects that do not exist
+// become null. The list has no free objects afterwards.
+func (list *IndirectObjectList) RenumberObjects(trailer *Dictionary) error {
+	if _, err := list.CollectGarbage(trailer); err != nil {
+		return fmt.Errorf("renumber objects: %w", err)
+	}
+
+	objects := list.Objects()
+	refs := make(map[Reference]Reference, len(objects))
+
+	for i, obj := range objects {
+		refs[*indirectReference(obj)] = *pdf.NewReference(i+1, 0)
+	}
+
+	for _, obj := range objects {
+		rewriteReferences(obj, refs)
+	}
+
+	rewriteReferences(trailer, refs)
+
+	list.objects = make(map[Reference]Object, len(objects))
+	list.objectNumbers = make(map[uint32]int, len(objects))
+
+	for _, obj := range objects {
+		indirect, ok := obj.(indirectObject)
+		if !ok {
+			return fmt.Errorf("renumber objects: %w: object %s can not be renumbered",
+				ErrInternalLogic, indirectReference(obj))
+		}
+
+		ref := refs[*indirectReference(obj)]
+		indirect.SetIndirectReference(&ref)
+		list.objects[ref] = obj
+		list.objectNumbers[ref.ObjectNo] = 1
+	}
+
+	list.freeObjects, list.removed, list.objectStreams = nil, nil, nil
+	list.maxObjNo = uint32(len(objects))
+
+	return nil
+}
+
+// rewriteReferences replaces the references of the object with the
+// ones of the renumbered objects. The references missing from refs
+// are replaced with null, as their objects do not exist.
+func rewriteReferences(obj Object, refs map[Reference]Reference) {
+	switch obj := obj.(type) {
+	case *Array:
+		for i, item := range obj.objects {
+			obj.objects[i] = renumberedValue(item, refs)
+		}
+	case *Dictionary:
+		for key, value := range obj.keys {
+			obj.keys[key] = renumberedValue(value, refs)
+		}
+	case *Stream:
+		rewriteReferences(obj.Dictionary, refs)
+	case *ParserObject:
+		rewriteReferences(obj.Object(), refs)
+	}
+}
+
+// renumberedValue returns the value with the references rewritten.
+// A new reference is returned, as the references may be shared, e.g.
+// with the object the reference refers to.
+func renumberedValue(value Object, refs map[Reference]Reference) Object {
+	ref, ok := value.(*Reference)
+	if !ok {
+		rewriteReferences(value, refs)
+
+		return value
+	}
+
+	newRef, ok := refs[*ref]
+	if !ok {
+		return Null{}
+	}
+
+	return &newRef
+}
+
+// reachable returns the references of the objects reachable
+// from the root, e.g. the trailer.
+func (list *IndirectObjectList) reachable(root Object) *set.Set[Reference] {
+	visited := set.New[Reference]()
+
+	list.walkReachable(root, func(ref Reference) bool {
+		visited.Put(ref)
+
+		return true
+	})
+
+	return visited
+}
+
+// walkReachable calls visit once for each object reachable from the
+// root. The objects visit returns false for are not walked into.
+func (list *IndirectObjectList) walkReachable(root Object, visit func(ref Reference) bool) {
+	visited := set.New[Reference]()
+	queue := []Object{root}
+
+	for len(queue) > 0 {
+		obj := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		walkReferences(obj, func(ref *Reference) {
+			if visited.Contains(*ref) {
+				return
+			}
+
+			visited.Put(*ref)
+
+			if !visit(*ref) {
+				return
+			}
+
+			if target := list.GetObject(ref); target != nil {
+				queue = append(queue, target)
+			}
+		})
+	}
+}
+
+// pageTree returns the page objects of the page tree of the
+// catalog in the page order and the intermediate nodes of the
+// tree. The nodes are visited once, so a loop is never followed.
+func (list *IndirectObjectList) pageTree(catalog *Reference) (pages, nodes []Reference) {
+	catalogDict := objectDictionary(list.GetObject(catalog))
+	if catalogDict == nil {
+		return nil, nil
+	}
+
+	root, ok := catalogDict.Key(pdf.KeyPages).(*Reference)
+	if !ok {
+		return nil, nil
+	}
+
+	return list.pageTreeFrom(root)
+}
+
+// pageTreeFrom returns the page objects and the intermediate
+// nodes of the page tree of the root node, see pageTree.
+func (list *IndirectObjectList) pageTreeFrom(root *Reference) (pages, nodes []Reference) {
+	visited := set.New[Reference]()
+
+	var walk func(ref *Reference)
+
+	walk = func(ref *Reference) {
+		if visited.Contains(*ref) {
+			return
+		}
+
+		visited.Put(*ref)
+
+		dict := objectDictionary(list.GetObject(ref))
+		if dict == nil {
+			return
+		}
+
+		kids, ok := dict.Key(pdf.KeyKids).(*Array)
+		if !ok || objectType(dict) == pdf.NamePage {
+			pages = append(pages, *ref)
+
+			return
+		}
+
+		nodes = append(nodes, *ref)
+
+		for _, kid := range kids.objects {
+			if kidRef, ok := kid.(*Reference); ok {
+				walk(kidRef)
+			}
+		}
+	}
+
+	walk(root)
+
+	return pages, nodes
+}
+
+// walkReferences calls visit for each reference of the object.
+// The direct arrays and dictionaries are walked recursively.
+func walkReferences(obj Object, visit func(ref *Reference)) {
+	switch obj := obj.(type) {
+	case *Reference:
+		visit(obj)
+	case *Array:
+		for _, item := range obj.objects {
+			walkReferences(item, visit)
+		}
+	case *Dictionary:
+		for _, value := range obj.keys {
+			walkReferences(value, visit)
+		}
+	case *Stream:
+		walkReferences(obj.Dictionary, visit)
+	case *ParserObject:
+		walkReferences(obj.Object(), visit)
+	}
+}
+
+// loadAll reads all the objects read from the file on demand,
+// including the compressed ones, so the list can be written.
+func (list *IndirectObjectList) loadAll() error {
+	streams := make([]int, 0, len(list.pendingObjectStreams))
+	for streamObjNo := range list.pendingObjectStreams {
+		streams = append(streams, streamObjNo)
+	}
+
+	sort.Ints(streams)
+
+	for _, streamObjNo := range streams {
+		if err := list.readObjectStream(streamObjNo); err != nil {
+			return fmt.Errorf("load objects: %w", err)
+		}
+	}
+
+	for ref, obj := range list.objects {
+		if delayed, ok := obj.(delayedObject); ok {
+			if err := delayed.DelayedLoad(); err != nil {
+				return fmt.Errorf("load object %s: %w", ref, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// AddObjectStream records the object number of an object stream of
+// the file. The objects of the stream are written anew, so the
+// number is reused for the object streams created by Writer.
 func (list *IndirectObjectList) AddObjectStream(objNum int) {
-	panic("not implemented") // TODO: implement me
+	i := sort.SearchInts(list.objectStreams, objNum)
+	if i < len(list.objectStreams) && list.objectStreams[i] == objNum {
+		return
+	}
+
+	list.objectStreams = append(list.objectStreams, 0)
+	copy(list.objectStreams[i+1:], list.objectStreams[i:])
+	list.objectStreams[i] = objNum
+}
+
+// ObjectStreams returns the sorted object numbers
+// of the object streams of the file.
+func (list *IndirectObjectList) ObjectStreams() []int { return list.objectStreams }
+
+// deferObjectStream registers the compressed objects of an object
+// stream to be read by load when any of them is accessed first.
+func (list *IndirectObjectList) deferObjectStream(
+	streamObjNo int, objects []int, load objectStreamLoader,
+) {
+	if list.pendingObjectStreams == nil {
+		list.pendingObjectStreams = make(map[int][]int)
+		list.compressedObjects = make(map[uint32]int)
+	}
+
+	list.pendingObjectStreams[streamObjNo] = objects
+	list.loadObjectStream = load
+
+	for _, objNo := range objects {
+		list.compressedObjects[uint32(objNo)] = streamObjNo
+		list.useObjectNo(uint32(objNo))
+	}
+}
+
+// readObjectStream reads the pending object stream.
+func (list *IndirectObjectList) readObjectStream(streamObjNo int) error {
+	objects := list.pendingObjectStreams[streamObjNo]
+
+	delete(list.pendingObjectStreams, streamObjNo)
+
+	for _, objNo := range objects {
+		delete(list.compressedObjects, uint32(objNo))
+	}
+
+	if err := list.loadObjectStream(streamObjNo, objects); err != nil {
+		return fmt.Errorf("read object stream %d: %w", streamObjNo, err)
+	}
+
+	return nil
+}
+
+// delayedLoad loads the object if it is read on demand.
+func delayedLoad(obj Object) Object {
+	delayed, ok := obj.(delayedObject)
+	if !ok {
+		return obj
+	}
+
+	if err := delayed.DelayedLoad(); err != nil {
+		log.Printf("Cannot load object %s: %v", indirectReference(obj), err)
+
+		return nil
+	}
+
+	return obj
 }
@@ -0,0 +1,234 @@
+package podofo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// garbageObjects are the objects of a file with the unreachable
+// objects 3, 4 and 5, the objects 6 and 7 are reachable from /Info,
+// the object 8 refers back to the catalog.
+var garbageObjects = []string{
+	"<</Type /Catalog /Pages 2 0 R /Extra 8 0 R>>",
+	"<</Type /Pages /Kids [] /Count 0>>",
+	"(orphan)",
+	"<</Next 5 0 R>>",
+	"<</Prev 4 0 R>>",
+	"<</Title 7 0 R>>",
+	"(title)",
+	"<</Root 1 0 R>>",
+}
+
+// objectNumbers returns the references of the objects of the list.
+func objectNumbers(list *podofo.IndirectObjectList) []pdf.Reference {
+	objects := list.Objects()
+	refs := make([]pdf.Reference, len(objects))
+
+	for i, obj := range objects {
+		refs[i] = *obj.(interface{ GetIndirectReference() *pdf.Reference }).GetIndirectReference()
+	}
+
+	return refs
+}
+
+// parsedDictionary returns the dictionary of the parsed object.
+func parsedDictionary(t *testing.T, obj podofo.Object) *podofo.Dictionary {
+	t.Helper()
+
+	parserObj, ok := obj.(*podofo.ParserObject)
+	require.True(t, ok)
+	require.NotNil(t, parserObj.Dictionary)
+
+	return parserObj.Dictionary
+}
+
+func TestIndirectObjectListCollectGarbage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		trailer string
+		removed int
+		want    []int
+	}{
+		{name: "root", trailer: "/Root 1 0 R", removed: 5, want: []int{1, 2, 8}},
+		{name: "root and info", trailer: "/Root 1 0 R /Info 6 0 R", removed: 3, want: []int{1, 2, 6, 7, 8}},
+		{name: "missing root", trailer: "/Root 9 0 R", removed: 8},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parser, err := podofo.Parse(bytes.NewReader(buildPDF("1.4", tt.trailer, garbageObjects...)))
+			require.NoError(t, err)
+
+			list := parser.Objects()
+
+			trailer := parsedDictionary(t, parser.Trailer())
+
+			removed, err := list.CollectGarbage(trailer)
+			require.NoError(t, err)
+			assert.Equal(t, tt.removed, removed)
+
+			want := make([]pdf.Reference, len(tt.want))
+			for i, objNo := range tt.want {
+				want[i] = *pdf.NewReference(objNo, 0)
+			}
+
+			assert.ElementsMatch(t, want, objectNumbers(list))
+
+			// The numbers of the removed objects are
+			// freed with the next generation.
+			assert.Contains(t, list.FreeObjects(), *pdf.NewReference(3, 1))
+		})
+	}
+}
+
+func TestIndirectObjectListRenumberObjects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		trailer string
+		want    int
+	}{
+		{name: "root", trailer: "/Root 1 0 R", want: 3},
+		{name: "root and info", trailer: "/Root 1 0 R /Info 6 0 R", want: 5},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			parser, err := podofo.Parse(bytes.NewReader(buildPDF("1.4", tt.trailer, garbageObjects...)))
+			require.NoError(t, err)
+
+			trailer := parsedDictionary(t, parser.Trailer())
+
+			list := parser.Objects()
+			require.NoError(t, list.RenumberObjects(trailer))
+
+			want := make([]pdf.Reference, tt.want)
+			for i := range want {
+				want[i] = *pdf.NewReference(i+1, 0)
+			}
+
+			assert.ElementsMatch(t, want, objectNumbers(list))
+			assert.Empty(t, list.FreeObjects())
+
+			// The references are rewritten, so the page tree
+			// and the back reference resolve to the same objects.
+			root, ok := trailer.Key(pdf.KeyRoot).(*pdf.Reference)
+			require.True(t, ok)
+
+			catalog := parsedDictionary(t, list.GetObject(root))
+			pages, ok := catalog.Key(pdf.KeyPages).(*pdf.Reference)
+			require.True(t, ok)
+			assert.Equal(t, &pdf.NameObject{Name: pdf.NamePages}, parsedDictionary(t, list.GetObject(pages)).Key(pdf.KeyType))
+
+			extra, ok := catalog.Key("Extra").(*pdf.Reference)
+			require.True(t, ok)
+			assert.Equal(t, root, parsedDictionary(t, list.GetObject(extra)).Key(pdf.KeyRoot))
+		})
+	}
+}
+
+func TestSaveCollectGarbage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options []podofo.SaveOption
+		want    int
+	}{
+		// The information dictionary is added on saving.
+		{name: "collect garbage", want: 4},
+		{name: "no collect garbage", options: []podofo.SaveOption{podofo.SaveOptionNoCollectGarbage}, want: 9},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := podofo.LoadMemDocument(bytes.NewReader(buildPDF("1.4", "/Root 1 0 R", garbageObjects...)))
+			require.NoError(t, err)
+
+			parser, err := podofo.Parse(bytes.NewReader(saveDocument(t, doc, tt.options...)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, len(parser.Objects().Objects()))
+		})
+	}
+}
+
+func TestIndirectObjectListRenumberStreams(t *testing.T) {
+	t.Parallel()
+
+	// The stream 5 refers to the catalog and the page tree 3,
+	// they become the objects 3 and 2 after renumbering.
+	objects := []string{
+		"<</Type /Catalog /Pages 3 0 R /Extra 5 0 R>>",
+		"(orphan)",
+		"<</Type /Pages /Kids [] /Count 0>>",
+		"(orphan)",
+		"<</Length 4 /Root 1 0 R /Pages 3 0 R>>\nstream\ndata\nendstream",
+	}
+
+	tests := []struct {
+		name          string
+		objectStreams bool
+	}{
+		{name: "stream dictionary"},
+		{name: "object stream", objectStreams: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := buildPDF("1.4", "/Root 1 0 R", objects...)
+
+			if tt.objectStreams {
+				doc, err := podofo.LoadMemDocument(bytes.NewReader(file))
+				require.NoError(t, err)
+				require.NoError(t, doc.SetPDFVersion("1.5"))
+
+				file = saveDocument(t, doc, podofo.SaveOptionNoCollectGarbage, podofo.SaveOptionNoFlateCompress)
+				require.True(t, objectStreamPattern.Match(file))
+			}
+
+			parser, err := podofo.Parse(bytes.NewReader(file))
+			require.NoError(t, err)
+
+			trailer := parsedDictionary(t, parser.Trailer())
+
+			list := parser.Objects()
+			require.NoError(t, list.RenumberObjects(trailer))
+
+			root, ok := trailer.Key(pdf.KeyRoot).(*pdf.Reference)
+			require.True(t, ok)
+
+			catalog := parsedDictionary(t, list.GetObject(root))
+			assert.Equal(t, pdf.NewReference(2, 0), catalog.Key(pdf.KeyPages))
+			assert.Equal(t, pdf.NewReference(3, 0), catalog.Key("Extra"))
+
+			stream := parsedDictionary(t, list.GetObject(pdf.NewReference(3, 0)))
+			assert.Equal(t, root, stream.Key(pdf.KeyRoot))
+			assert.Equal(t, pdf.NewReference(2, 0), stream.Key(pdf.KeyPages))
+		})
+	}
+}
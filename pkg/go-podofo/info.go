@@ -0,0 +1,205 @@
+package podofo
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+type Info = pdf.Info
+
+// infoTextKeys are the text entries of the information dictionary.
+var infoTextKeys = []pdf.Name{
+	pdf.KeyTitle, pdf.KeyAuthor, pdf.KeySubject,
+	pdf.KeyKeywords, pdf.KeyCreator, pdf.KeyProducer,
+}
+
+// Info returns the document information. The dates that cannot be
+// parsed are ignored.
+func (doc *MemDocument) Info() Info {
+	var info Info
+
+	dict := doc.findInfoDictionary()
+	if dict == nil {
+		return info
+	}
+
+	for _, key := range dict.sortedKeys() {
+		value := doc.objects.resolve(dict.Key(key))
+
+		switch key {
+		case pdf.KeyCreationDate, pdf.KeyModDate:
+			date := infoDate(key, value)
+			if key == pdf.KeyCreationDate {
+				info.CreationDate = date
+			} else {
+				info.ModDate = date
+			}
+		case pdf.KeyTrapped:
+			info.Trapped = trappedValue(value)
+		default:
+			str, ok := value.(*String)
+			if !ok {
+				continue
+			}
+
+			if field := infoTextField(&info, key); field != nil {
+				*field = pdf.DecodeTextString(str.RawData())
+
+				continue
+			}
+
+			if info.Custom == nil {
+				info.Custom = make(map[pdf.Name]string)
+			}
+
+			info.Custom[key] = pdf.DecodeTextString(str.RawData())
+		}
+	}
+
+	return info
+}
+
+// SetInfo replaces the document information. The empty strings and
+// the zero dates are not written.
+func (doc *MemDocument) SetInfo(info Info) {
+	dict := doc.infoDictionary()
+
+	for _, key := range dict.sortedKeys() {
+		dict.RemoveKey(key)
+	}
+
+	for _, key := range infoTextKeys {
+		setInfoText(dict, key, *infoTextField(&info, key))
+	}
+
+	for key, value := range info.Custom {
+		setInfoText(dict, key, value)
+	}
+
+	setInfoDate(dict, pdf.KeyCreationDate, info.CreationDate)
+	setInfoDate(dict, pdf.KeyModDate, info.ModDate)
+
+	if info.Trapped != pdf.KeyNull {
+		dict.AddKey(pdf.KeyTrapped, &pdf.NameObject{Name: info.Trapped})
+	}
+}
+
+// initInfo writes the entries of the flags to the
+// information dictionary of the new document.
+func (doc *MemDocument) initInfo(initial InfoInitial) {
+	now := time.Now()
+	dict := doc.infoDictionary()
+
+	if initial&InfoInitialWriteCreationTime != 0 {
+		setInfoDate(dict, pdf.KeyCreationDate, now)
+	}
+
+	if initial&InfoInitialWriteModificationTime != 0 {
+		setInfoDate(dict, pdf.KeyModDate, now)
+	}
+
+	if initial&InfoInitialWriteProducer != 0 {
+		setInfoText(dict, pdf.KeyProducer, Producer)
+	}
+}
+
+// applyMetadata writes the non-empty fields of
+// the Metadata to the information dictionary.
+func (doc *MemDocument) applyMetadata() {
+	meta := doc.Metadata
+
+	fields := map[pdf.Name]string{
+		pdf.KeyTitle:    meta.Title,
+		pdf.KeyAuthor:   meta.Author,
+		pdf.KeySubject:  meta.Subject,
+		pdf.KeyCreator:  meta.Creator,
+		pdf.KeyKeywords: strings.Join(meta.Keywords, ", "),
+	}
+
+	for _, key := range infoTextKeys {
+		if value := fields[key]; value != "" {
+			setInfoText(doc.infoDictionary(), key, value)
+		}
+	}
+}
+
+// infoTextField returns the field of the text entry,
+// nil is returned if the key is not a standard one.
+func infoTextField(info *Info, key pdf.Name) *string {
+	switch key {
+	case pdf.KeyTitle:
+		return &info.Title
+	case pdf.KeyAuthor:
+		return &info.Author
+	case pdf.KeySubject:
+		return &info.Subject
+	case pdf.KeyKeywords:
+		return &info.Keywords
+	case pdf.KeyCreator:
+		return &info.Creator
+	case pdf.KeyProducer:
+		return &info.Producer
+	default:
+		return nil
+	}
+}
+
+// infoDate parses the date entry, the zero time is returned
+// if the entry is not a date.
+func infoDate(key pdf.Name, value Object) time.Time {
+	str, ok := value.(*String)
+	if !ok {
+		return time.Time{}
+	}
+
+	date, err := pdf.ParseDate(pdf.DecodeTextString(str.RawData()))
+	if err != nil {
+		log.Printf("Cannot parse /%s: %v", key, err)
+
+		return time.Time{}
+	}
+
+	return date
+}
+
+// trappedValue returns the name of the /Trapped entry. The boolean
+// values written by some producers are converted to the names.
+func trappedValue(value Object) pdf.Name {
+	switch value := value.(type) {
+	case *pdf.NameObject:
+		return value.Name
+	case Bool:
+		if value {
+			return pdf.NameTrue
+		}
+
+		return pdf.NameFalse
+	default:
+		return pdf.KeyNull
+	}
+}
+
+// setInfoText sets the text entry, the empty text removes it.
+func setInfoText(dict *Dictionary, key pdf.Name, text string) {
+	if text == "" {
+		dict.RemoveKey(key)
+
+		return
+	}
+
+	dict.AddKey(key, newRawString(pdf.EncodeTextString(text), false))
+}
+
+// setInfoDate sets the date entry, the zero time removes it.
+func setInfoDate(dict *Dictionary, key pdf.Name, date time.Time) {
+	if date.IsZero() {
+		dict.RemoveKey(key)
+
+		return
+	}
+
+	dict.AddKey(key, newDateString(date))
+}
@@ -0,0 +1,91 @@
+package podofo_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// reloadInfo returns the information of the saved and loaded document.
+func reloadInfo(t *testing.T, doc *podofo.MemDocument, options ...podofo.SaveOption) podofo.Info {
+	t.Helper()
+
+	loaded, err := podofo.LoadMemDocument(bytes.NewReader(saveDocument(t, doc, options...)))
+	require.NoError(t, err)
+
+	return loaded.Info()
+}
+
+func TestSetInfo(t *testing.T) {
+	t.Parallel()
+
+	creationDate := time.Date(2024, time.March, 1, 10, 20, 30, 0, time.FixedZone("", 2*60*60))
+	modDate := time.Date(2025, time.June, 2, 11, 22, 33, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		options     []podofo.SaveOption
+		keepModDate bool
+	}{
+		{name: "no modify date update", options: []podofo.SaveOption{podofo.SaveOptionNoModifyDateUpdate}, keepModDate: true},
+		{name: "modify date update"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			want := podofo.Info{
+				Title:        "Title",
+				Author:       "Author",
+				Keywords:     "one, two",
+				Producer:     "Producer",
+				CreationDate: creationDate,
+				ModDate:      modDate,
+				Trapped:      pdf.NameTrue,
+				Custom:       map[pdf.Name]string{"Company": "Société"},
+			}
+
+			doc := newDocument(t, 1)
+			doc.SetInfo(want)
+
+			got := reloadInfo(t, doc, tt.options...)
+
+			assert.True(t, creationDate.Equal(got.CreationDate), got.CreationDate)
+
+			if tt.keepModDate {
+				assert.True(t, modDate.Equal(got.ModDate), got.ModDate)
+			} else {
+				assert.True(t, got.ModDate.After(modDate), got.ModDate)
+			}
+
+			want.CreationDate, want.ModDate = time.Time{}, time.Time{}
+			got.CreationDate, got.ModDate = time.Time{}, time.Time{}
+
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestSetInfoMetadata(t *testing.T) {
+	t.Parallel()
+
+	doc := podofo.NewMemDocument(podofo.WithTitle("T"), podofo.WithAuthor("A"))
+
+	info := doc.Info()
+	assert.Equal(t, "T", info.Title)
+	assert.Equal(t, "A", info.Author)
+
+	doc.SetInfo(podofo.Info{Title: "X"})
+
+	info = reloadInfo(t, doc, podofo.SaveOptionNoModifyDateUpdate)
+	assert.Equal(t, podofo.Info{Title: "X"}, info)
+}
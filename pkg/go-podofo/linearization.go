@@ -0,0 +1,249 @@
+package podofo
+
+import (
+	"fmt"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// maxLinearizationOffset is the greatest offset of the linearization
+// parameter dictionary, which is the first object of the file.
+const maxLinearizationOffset = 1024
+
+// Linearization is the linearization parameter dictionary of
+// a linearized file (see ISO 32000-1:2008, F.2).
+type Linearization struct {
+	// FileLength is the length of the file in bytes (/L).
+	FileLength int64
+	// HintOffset and HintLength are the offset and the
+	// length of the primary hint stream (/H).
+	HintOffset int64
+	HintLength int64
+	// FirstPageObjectNo is the object number of the page
+	// object of the first page (/O).
+	FirstPageObjectNo int
+	// FirstPageEnd is the offset of the end of the first page (/E).
+	FirstPageEnd int64
+	// NumPages is the number of pages of the document (/N).
+	NumPages int
+	// MainXRefOffset is the offset of the white-space preceding
+	// the first entry of the main XRef table (/T).
+	MainXRefOffset int64
+}
+
+// IsLinearized returns true if the file is linearized, i.e. the
+// first object is the linearization parameter dictionary and the
+// file has not been updated since it was linearized.
+func (p *Parser) IsLinearized() bool { return p.linearization != nil }
+
+// Linearization returns the linearization parameters of the file,
+// nil is returned if the file is not linearized.
+func (p *Parser) Linearization() *Linearization { return p.linearization }
+
+// HintTablesValid returns true if the file is linearized and its
+// hint tables match the objects of the file.
+func (p *Parser) HintTablesValid() bool {
+	return p.linearization != nil && p.hintTablesErr == nil
+}
+
+// HintTablesError returns the reason the hint tables of
+// the linearized file are not valid, if any.
+func (p *Parser) HintTablesError() error { return p.hintTablesErr }
+
+// readLinearization detects the linearized file and checks its hint
+// tables. The file is read as usual in any case, so the errors are
+// not fatal.
+func (p *Parser) readLinearization() {
+	p.linearization, p.hintTablesErr = nil, nil
+
+	dict := p.linearizationDictionary()
+	if dict == nil {
+		return
+	}
+
+	lin := &Linearization{
+		FileLength:        dict.Int(pdf.KeyL, -1),
+		FirstPageObjectNo: int(dict.Int(pdf.KeyO, -1)),
+		FirstPageEnd:      dict.Int(pdf.KeyE, -1),
+		NumPages:          int(dict.Int(pdf.KeyN, -1)),
+		MainXRefOffset:    dict.Int(pdf.KeyT, -1),
+	}
+
+	// The file has been updated after it has been linearized.
+	if lin.FileLength != p.fileSize {
+		return
+	}
+
+	p.linearization = lin
+
+	if hint, ok := dict.Key(pdf.KeyH).(*Array); ok && len(hint.objects) >= 2 {
+		lin.HintOffset = numberValue(hint.objects[0], -1)
+		lin.HintLength = numberValue(hint.objects[1], -1)
+	}
+
+	if err := p.checkHintTables(lin); err != nil {
+		p.hintTablesErr = fmt.Errorf("check hint tables: %w", err)
+	}
+}
+
+// linearizationDictionary returns the linearization parameter
+// dictionary, nil is returned if the first object of the file is
+// not the one.
+func (p *Parser) linearizationDictionary() *Dictionary {
+	var firstGen Generation
+
+	firstObjNo, firstOffset := -1, int64(0)
+
+	for objNo, entry := range p.entries {
+		inUse, ok := entry.Entry.(XRefEntryInUse)
+		if !ok || !entry.Parsed || inUse.Offset <= 0 {
+			continue
+		}
+
+		if firstObjNo < 0 || inUse.Offset < firstOffset {
+			firstObjNo, firstOffset, firstGen = objNo, inUse.Offset, inUse.Generation
+		}
+	}
+
+	if firstObjNo < 0 || firstOffset > maxLinearizationOffset {
+		return nil
+	}
+
+	dict := objectDictionary(p.objects.GetObject(pdf.NewReference(firstObjNo, firstGen)))
+	if dict == nil || dict.Key(pdf.KeyLinearized) == nil {
+		return nil
+	}
+
+	return dict
+}
+
+// checkHintTables checks the linearization parameters and the page
+// offset and the shared object hint tables against the page tree and
+// the XRef entries (see ISO 32000-1:2008, F.4).
+func (p *Parser) checkHintTables(lin *Linearization) error {
+	catalog, _ := p.trailer.Dictionary.Key(pdf.KeyRoot).(*Reference)
+	if catalog == nil {
+		return fmt.Errorf("%w: no catalog", ErrInvalidHintTable)
+	}
+
+	pages, _ := p.objects.pageTree(catalog)
+
+	switch {
+	case lin.NumPages != len(pages):
+		return fmt.Errorf("%w: /N is %d, the document has %d pages", ErrInvalidHintTable, lin.NumPages, len(pages))
+	case len(pages) == 0:
+		return fmt.Errorf("%w: the document has no pages", ErrInvalidHintTable)
+	case lin.FirstPageObjectNo != int(pages[0].ObjectNo):
+		return fmt.Errorf("%w: /O is %d, the first page is %s", ErrInvalidHintTable, lin.FirstPageObjectNo, pages[0])
+	case lin.HintOffset <= 0 || lin.HintLength <= 0 || lin.HintOffset+lin.HintLength > p.fileSize:
+		return fmt.Errorf("%w: /H [%d %d] is out of the file", ErrInvalidHintTable, lin.HintOffset, lin.HintLength)
+	case lin.FirstPageEnd <= 0 || lin.FirstPageEnd > p.fileSize:
+		return fmt.Errorf("%w: /E %d is out of the file", ErrInvalidHintTable, lin.FirstPageEnd)
+	case lin.MainXRefOffset <= 0 || lin.MainXRefOffset > p.fileSize:
+		return fmt.Errorf("%w: /T %d is out of the file", ErrInvalidHintTable, lin.MainXRefOffset)
+	}
+
+	data, sharedOffset, err := p.hintStreamData(lin.HintOffset)
+	if err != nil {
+		return err
+	}
+
+	pageHints, _, err := pdf.ParsePageOffsetHints(data, len(pages))
+	if err != nil {
+		return err
+	}
+
+	if sharedOffset < 0 || sharedOffset > int64(len(data)) {
+		return fmt.Errorf("%w: /S %d is out of the hint stream", ErrInvalidHintTable, sharedOffset)
+	}
+
+	sharedHints, err := pdf.ParseSharedObjectHints(data[sharedOffset:])
+	if err != nil {
+		return err
+	}
+
+	// The offsets of the hint tables are computed as if
+	// the hint stream were not present in the file.
+	adjust := func(offset int64) int64 {
+		if offset >= lin.HintOffset {
+			return offset + lin.HintLength
+		}
+
+		return offset
+	}
+
+	offset := pageHints.FirstPageOffset
+
+	for i, page := range pageHints.Pages {
+		if actual, ok := p.objectOffset(int(pages[i].ObjectNo)); ok && actual != adjust(offset) {
+			return fmt.Errorf("%w: page %d is at offset %d, the hint is %d",
+				ErrInvalidHintTable, i+1, actual, adjust(offset))
+		}
+
+		for _, id := range page.SharedObjects {
+			if id >= int64(len(sharedHints.Groups)) {
+				return fmt.Errorf("%w: page %d refers to the shared object %d of %d",
+					ErrInvalidHintTable, i+1, id, len(sharedHints.Groups))
+			}
+		}
+
+		offset += page.Length
+	}
+
+	if len(sharedHints.Groups) > sharedHints.FirstPageGroups {
+		actual, ok := p.objectOffset(int(sharedHints.FirstObjectNo))
+		if ok && actual != adjust(sharedHints.FirstObjectOffset) {
+			return fmt.Errorf("%w: shared object %d is at offset %d, the hint is %d", ErrInvalidHintTable,
+				sharedHints.FirstObjectNo, actual, adjust(sharedHints.FirstObjectOffset))
+		}
+	}
+
+	return nil
+}
+
+// hintStreamData returns the decoded data of the primary hint
+// stream at offset and the offset of its shared object hint table.
+func (p *Parser) hintStreamData(offset int64) ([]byte, int64, error) {
+	for objNo, entry := range p.entries {
+		inUse, ok := entry.Entry.(XRefEntryInUse)
+		if !ok || !entry.Parsed || inUse.Offset != offset {
+			continue
+		}
+
+		obj, ok := p.objects.GetObject(pdf.NewReference(objNo, inUse.Generation)).(*ParserObject)
+		if !ok || obj.Dictionary == nil {
+			break
+		}
+
+		data, err := obj.decodeStream()
+		if err != nil {
+			return nil, 0, fmt.Errorf("hint stream: %w", err)
+		}
+
+		return data, obj.Dictionary.Int(pdf.KeyS, -1), nil
+	}
+
+	return nil, 0, fmt.Errorf("%w: no hint stream at offset %d", ErrInvalidHintTable, offset)
+}
+
+// objectOffset returns the offset of the object
+// listed as in use by the XRef of the file.
+func (p *Parser) objectOffset(objNo int) (int64, bool) {
+	if objNo <= 0 || objNo >= len(p.entries) || !p.entries[objNo].Parsed {
+		return 0, false
+	}
+
+	inUse, ok := p.entries[objNo].Entry.(XRefEntryInUse)
+
+	return inUse.Offset, ok
+}
+
+// numberValue returns the integer value of a number object,
+// defval is returned if the object is not a number.
+func numberValue(obj Object, defval int64) int64 {
+	if num, ok := obj.(*pdf.Number); ok {
+		return num.Int64()
+	}
+
+	return defval
+}
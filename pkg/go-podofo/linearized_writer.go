@@ -0,0 +1,508 @@
+package podofo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	"github.com/denisss025/go-podofo/internal/set"
+)
+
+// linearizationFormat is the linearization parameter dictionary.
+// The values that depend on the layout of the file are padded, so
+// the dictionary has the same length before and after they are known.
+const linearizationFormat = "%d 0 obj\n<</Linearized 1/L %10d/H[%10d %10d]/O %d/E %10d/N %d/T %10d>>\nendobj\n"
+
+// linearizedLayout holds the objects of the linearized file in
+// the order they are written (see ISO 32000-1:2008, F.3).
+type linearizedLayout struct {
+	// document are the catalog and the objects the document
+	// is opened with, e.g. /OpenAction and /AcroForm (part 4).
+	document []Reference
+	// pages are the objects of each page starting with the page
+	// object. The first page holds every object it refers to,
+	// including the shared ones (parts 6 and 7).
+	pages [][]Reference
+	// shared are the objects the other pages share (part 8).
+	shared []Reference
+	// other are the objects no page refers to (part 9).
+	other []Reference
+	// pageShared are the objects of the first page and the shared
+	// objects each page refers to.
+	pageShared [][]Reference
+}
+
+// linearizedFile is the linearized file being laid out.
+type linearizedFile struct {
+	layout *linearizedLayout
+	// refs are the new references of the objects.
+	refs map[Reference]Reference
+	// bodies are the written objects by their new numbers.
+	bodies map[uint32][]byte
+	// offsets are the offsets of the objects by their
+	// new numbers, computed as if the hint stream were
+	// not present in the file.
+	offsets map[uint32]int64
+
+	linNo, hintNo uint32
+	// mainSize is the size of the main XRef table,
+	// size is the one of the whole file.
+	mainSize, size int
+}
+
+// writeLinearized writes the linearized file: the objects of the
+// first page are followed by the ones of the other pages, so the
+// first page is displayed before the whole file is read. The XRef
+// tables are written even for PDF 1.5 and later, as the objects
+// are never compressed. A document without pages is written as is.
+func (w *Writer) writeLinearized(out io.Writer) error {
+	catalog, _ := w.trailer.Key(pdf.KeyRoot).(*Reference)
+	if catalog == nil {
+		return w.write(out, 0)
+	}
+
+	pages, nodes := w.objects.pageTree(catalog)
+	if len(pages) == 0 {
+		log.Printf("The document has no pages, it is not linearized")
+
+		return w.write(out, 0)
+	}
+
+	w.entries = make(map[uint32]xrefEntry)
+	w.nextObjNo = w.objects.maxObjectNo() + 1
+
+	trailer, err := w.newTrailer()
+	if err != nil {
+		return err
+	}
+
+	file := &linearizedFile{
+		layout:  w.linearizedLayout(catalog, pages, nodes, w.collectObjects(trailer)),
+		refs:    make(map[Reference]Reference),
+		bodies:  make(map[uint32][]byte),
+		offsets: make(map[uint32]int64),
+	}
+
+	file.renumber()
+
+	for oldRef, newRef := range file.refs {
+		if file.bodies[newRef.ObjectNo], err = w.linearizedObject(file.refs, oldRef, newRef); err != nil {
+			return err
+		}
+	}
+
+	return w.writeLinearizedFile(out, file, trailer)
+}
+
+// linearizedLayout sorts the objects to the parts of the linearized
+// file. The objects the document is opened with come first, then
+// the objects the first page refers to, the objects of every other
+// page, the objects shared by the other pages and the rest.
+func (w *Writer) linearizedLayout(catalog *Reference, pages, nodes []Reference, objects []Object) *linearizedLayout {
+	// The catalog, the page tree and the pages are never walked
+	// into, so a page does not refer to the objects of the others.
+	stop := set.New[Reference]()
+	stop.Put(*catalog)
+
+	for _, ref := range append(nodes, pages...) {
+		stop.Put(ref)
+	}
+
+	walk := func(root Object, visit func(ref Reference)) {
+		w.objects.walkReachable(root, func(ref Reference) bool {
+			if stop.Contains(ref) {
+				return false
+			}
+
+			visit(ref)
+
+			return true
+		})
+	}
+
+	document := set.New[Reference]()
+	document.Put(*catalog)
+
+	if dict := objectDictionary(w.objects.GetObject(catalog)); dict != nil {
+		for _, key := range []pdf.Name{
+			pdf.KeyViewerPreferences, pdf.KeyOpenAction, pdf.KeyAcroForm, pdf.KeyThreads,
+		} {
+			if value := dict.Key(key); value != nil {
+				walk(value, document.Put)
+			}
+		}
+	}
+
+	users := make(map[Reference][]int)
+
+	for i, page := range pages {
+		users[page] = append(users[page], i)
+
+		walk(w.objects.GetObject(&page), func(ref Reference) {
+			if !document.Contains(ref) {
+				users[ref] = append(users[ref], i)
+			}
+		})
+	}
+
+	layout := &linearizedLayout{
+		pages:      make([][]Reference, len(pages)),
+		pageShared: make([][]Reference, len(pages)),
+	}
+
+	for i, page := range pages {
+		layout.pages[i] = []Reference{page}
+	}
+
+	for _, obj := range objects {
+		ref := *indirectReference(obj)
+		pageUsers := users[ref]
+
+		switch {
+		case document.Contains(ref):
+			layout.document = append(layout.document, ref)
+		case len(pageUsers) == 0:
+			layout.other = append(layout.other, ref)
+		case len(pageUsers) == 1 && pages[pageUsers[0]] == ref:
+			// The page object starts its part.
+		case pageUsers[0] == 0 || len(pageUsers) == 1:
+			layout.pages[pageUsers[0]] = append(layout.pages[pageUsers[0]], ref)
+		default:
+			layout.shared = append(layout.shared, ref)
+		}
+
+		if len(pageUsers) < 2 {
+			continue
+		}
+
+		for _, i := range pageUsers {
+			if i > 0 {
+				layout.pageShared[i] = append(layout.pageShared[i], ref)
+			}
+		}
+	}
+
+	// The encryption dictionary is needed to open the document.
+	if w.encryptRef != nil {
+		layout.document = append(layout.document, *w.encryptRef)
+	}
+
+	return layout
+}
+
+// renumber gives the objects the new numbers: the objects of the
+// main XRef table, i.e. the ones following the first page, are
+// numbered first, then the objects of the first page XRef table.
+func (file *linearizedFile) renumber() {
+	objNo := uint32(1)
+
+	add := func(refs []Reference) {
+		for _, ref := range refs {
+			file.refs[ref] = Reference{ObjectNo: objNo}
+			objNo++
+		}
+	}
+
+	for _, page := range file.layout.pages[1:] {
+		add(page)
+	}
+
+	add(file.layout.shared)
+	add(file.layout.other)
+
+	file.mainSize = int(objNo)
+	file.linNo = objNo
+	objNo++
+
+	add(file.layout.document)
+
+	file.hintNo = objNo
+	objNo++
+
+	add(file.layout.pages[0])
+
+	file.size = int(objNo)
+}
+
+// linearizedObject returns the object written with its new number.
+func (w *Writer) linearizedObject(refs map[Reference]Reference, oldRef, newRef Reference) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w.out = &countingWriter{w: &buf}
+	w.pw = pdf.NewWriter(w.out, append(w.writerOptions(), pdf.WriteReferenceMap(refs))...)
+
+	if w.encryptRef != nil && oldRef == *w.encryptRef {
+		w.pw.SetReference(Reference{})
+
+		if err := w.writeIndirect(newRef, w.encrypt.Dictionary(), nil, false); err != nil {
+			return nil, fmt.Errorf("encryption dictionary: %w", err)
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	if err := w.writeObjectAs(newRef, w.objects.GetObject(&oldRef)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeLinearizedFile lays the objects out and writes the file. The
+// offsets of the hint tables do not depend on the length of the hint
+// stream, so the stream is created once. The linearization dictionary
+// and the first page XRef table have fixed lengths, so they are
+// written with the placeholders first to lay the file out.
+func (w *Writer) writeLinearizedFile(out io.Writer, file *linearizedFile, trailer *Dictionary) error {
+	layout := file.layout
+	header := []byte("%PDF-" + string(w.version) + "\n" + binaryComment)
+	firstPageNo := file.refs[layout.pages[0][0]].ObjectNo
+
+	lin := file.linearizationDictionary(firstPageNo, len(layout.pages), [5]int64{})
+
+	firstXRef, err := w.firstPageXRef(file, trailer, 0)
+	if err != nil {
+		return err
+	}
+
+	offset := int64(len(header) + len(lin) + len(firstXRef))
+	firstXRefOffset := offset - int64(len(firstXRef))
+
+	place := func(refs []Reference) {
+		for _, ref := range refs {
+			objNo := file.refs[ref].ObjectNo
+			file.offsets[objNo] = offset
+			offset += int64(len(file.bodies[objNo]))
+		}
+	}
+
+	place(layout.document)
+
+	hintOffset := offset
+
+	for _, page := range layout.pages {
+		place(page)
+	}
+
+	place(layout.shared)
+	place(layout.other)
+
+	hint, err := w.hintStream(file, hintOffset)
+	if err != nil {
+		return err
+	}
+
+	hintLen := int64(len(hint))
+
+	// The objects following the hint stream are moved by its length.
+	for objNo, objOffset := range file.offsets {
+		if objOffset >= hintOffset {
+			file.offsets[objNo] = objOffset + hintLen
+		}
+	}
+
+	file.offsets[file.linNo] = int64(len(header))
+	file.offsets[file.hintNo] = hintOffset
+
+	mainXRefOffset := offset + hintLen
+	mainXRef := file.mainXRef(firstXRefOffset)
+
+	firstPageEnd := hintOffset + hintLen
+	for _, ref := range layout.pages[0] {
+		firstPageEnd += int64(len(file.bodies[file.refs[ref].ObjectNo]))
+	}
+
+	fileLen := mainXRefOffset + int64(len(mainXRef))
+	mainXRefEntries := mainXRefOffset + int64(len(fmt.Sprintf("xref\n0 %d", file.mainSize)))
+
+	final := file.linearizationDictionary(firstPageNo, len(layout.pages), [5]int64{
+		fileLen, hintOffset, hintLen, firstPageEnd, mainXRefEntries,
+	})
+
+	finalXRef, err := w.firstPageXRef(file, trailer, mainXRefOffset)
+	if err != nil {
+		return err
+	}
+
+	if len(final) != len(lin) || len(finalXRef) != len(firstXRef) {
+		return fmt.Errorf("linearize: %w: the layout is changed", ErrInternalLogic)
+	}
+
+	buf := bufio.NewWriter(out)
+
+	for _, data := range [][]byte{header, final, finalXRef} {
+		if _, err = buf.Write(data); err != nil {
+			return err
+		}
+	}
+
+	parts := [][]Reference{layout.document, nil}
+	parts = append(parts, layout.pages...)
+	parts = append(parts, layout.shared, layout.other)
+
+	for i, part := range parts {
+		if i == 1 {
+			if _, err = buf.Write(hint); err != nil {
+				return err
+			}
+		}
+
+		for _, ref := range part {
+			if _, err = buf.Write(file.bodies[file.refs[ref].ObjectNo]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err = buf.Write(mainXRef); err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+// linearizationDictionary returns the linearization parameter
+// dictionary, the values are the file length, the offset and the
+// length of the hint stream, the end of the first page and the
+// offset of the first entry of the main XRef table.
+func (file *linearizedFile) linearizationDictionary(firstPageNo uint32, numPages int, values [5]int64) []byte {
+	return []byte(fmt.Sprintf(linearizationFormat, file.linNo,
+		values[0], values[1], values[2], firstPageNo, values[3], numPages, values[4]))
+}
+
+// firstPageXRef returns the XRef table of the objects of the first
+// page and the trailer, which refers to the main XRef table.
+func (w *Writer) firstPageXRef(file *linearizedFile, trailer *Dictionary, prev int64) ([]byte, error) {
+	const entryLen = 20
+
+	var buf bytes.Buffer
+
+	start := file.mainSize
+	data := make([]byte, 0, entryLen*(file.size-start+1))
+	data = append(data, fmt.Sprintf("xref\n%d %d\n", start, file.size-start)...)
+
+	for objNo := start; objNo < file.size; objNo++ {
+		data = pdf.AppendXRefEntry(data, uint64(file.offsets[uint32(objNo)]), 0, 'n')
+	}
+
+	buf.Write(data)
+
+	firstTrailer := trailer.shallowCopy()
+	firstTrailer.AddKey(pdf.KeySize, pdf.NewInt(int64(file.size)))
+	firstTrailer.AddKey(pdf.KeyPrev, NewRawData([]byte(fmt.Sprintf(" %10d", prev))))
+
+	pw := pdf.NewWriter(&buf, append(w.writerOptions(), pdf.WriteReferenceMap(file.refs))...)
+
+	if err := writeString(pw, "trailer\n"); err != nil {
+		return nil, err
+	}
+
+	if err := firstTrailer.MarshalPDF(pw); err != nil {
+		return nil, err
+	}
+
+	// The first page XRef table is never the last one.
+	if err := writeString(pw, "\nstartxref\n0\n%%EOF\n"); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// mainXRef returns the XRef table of the objects following the first
+// page, the startxref of the file refers to the first page one.
+func (file *linearizedFile) mainXRef(firstXRefOffset int64) []byte {
+	const entryLen = 20
+
+	data := make([]byte, 0, entryLen*(file.mainSize+1))
+	data = append(data, fmt.Sprintf("xref\n0 %d\n", file.mainSize)...)
+	data = pdf.AppendXRefEntry(data, 0, pdf.MaxGeneration, 'f')
+
+	for objNo := 1; objNo < file.mainSize; objNo++ {
+		data = pdf.AppendXRefEntry(data, uint64(file.offsets[uint32(objNo)]), 0, 'n')
+	}
+
+	return append(data, fmt.Sprintf("trailer\n<</Size %d>>\nstartxref\n%d\n%%%%EOF\n",
+		file.mainSize, firstXRefOffset)...)
+}
+
+// hintStream returns the primary hint stream with the page offset
+// and the shared object hint tables. The hint stream is located at
+// offset and the offsets of the objects are the ones computed as if
+// it were not present (see ISO 32000-1:2008, F.4).
+func (w *Writer) hintStream(file *linearizedFile, offset int64) ([]byte, error) {
+	layout := file.layout
+	groups := make(map[Reference]int64)
+	shared := &pdf.SharedObjectHints{FirstPageGroups: len(layout.pages[0])}
+
+	for _, ref := range append(append([]Reference{}, layout.pages[0]...), layout.shared...) {
+		groups[ref] = int64(len(shared.Groups))
+		shared.Groups = append(shared.Groups, pdf.SharedObjectGroup{
+			Length:  int64(len(file.bodies[file.refs[ref].ObjectNo])),
+			Objects: 1,
+		})
+	}
+
+	if len(layout.shared) > 0 {
+		first := file.refs[layout.shared[0]].ObjectNo
+		shared.FirstObjectNo = int64(first)
+		shared.FirstObjectOffset = file.offsets[first]
+	}
+
+	pages := &pdf.PageOffsetHints{FirstPageOffset: offset, Denominator: 1}
+
+	for i, page := range layout.pages {
+		var length int64
+
+		for _, ref := range page {
+			length += int64(len(file.bodies[file.refs[ref].ObjectNo]))
+		}
+
+		ids := make([]int64, 0, len(layout.pageShared[i]))
+		for _, ref := range layout.pageShared[i] {
+			ids = append(ids, groups[ref])
+		}
+
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+		// The content stream fields are the ones
+		// of the whole page, as Acrobat writes them.
+		pages.Pages = append(pages.Pages, pdf.PageHint{
+			Objects:       int64(len(page)),
+			Length:        length,
+			SharedObjects: ids,
+			Numerators:    make([]int64, len(ids)),
+			ContentLength: length,
+		})
+	}
+
+	data := pdf.AppendPageOffsetHints(nil, pages)
+	sharedOffset := len(data)
+	data = pdf.AppendSharedObjectHints(data, shared)
+
+	dict := NewDictionary()
+	dict.AddKey(pdf.KeyS, pdf.NewInt(int64(sharedOffset)))
+
+	var buf bytes.Buffer
+
+	ref := Reference{ObjectNo: file.hintNo}
+
+	w.out = &countingWriter{w: &buf}
+	w.pw = pdf.NewWriter(w.out, w.writerOptions()...)
+	w.pw.SetReference(ref)
+
+	dict, data, err := w.encodeStream(dict, data)
+	if err != nil {
+		return nil, fmt.Errorf("hint stream: %w", err)
+	}
+
+	if err = w.writeIndirect(ref, dict, data, true); err != nil {
+		return nil, fmt.Errorf("hint stream: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,75 @@
+package podofo_test
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// objectHeaderPattern matches the beginning of an indirect object.
+var objectHeaderPattern = regexp.MustCompile(`^\d+ \d+ obj\s`)
+
+func TestSaveLinearized(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version podofo.PDFVersion
+		widths  []int
+	}{
+		{name: "one page", version: "1.4", widths: []int{100}},
+		{name: "three pages", version: "1.4", widths: []int{100, 200, 300}},
+		{name: "many pages", version: "1.7", widths: []int{
+			1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20,
+		}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := newSizedDocument(t, tt.widths...)
+			require.NoError(t, doc.SetPDFVersion(tt.version))
+
+			file := saveDocument(t, doc, podofo.SaveOptionLinearize)
+
+			parser, err := podofo.Parse(bytes.NewReader(file))
+			require.NoError(t, err)
+			require.True(t, parser.IsLinearized())
+			assert.NoError(t, parser.HintTablesError())
+
+			lin := parser.Linearization()
+			assert.EqualValues(t, len(file), lin.FileLength)
+			assert.Equal(t, len(tt.widths), lin.NumPages)
+
+			// The file ends with the offset of the first-page XRef
+			// table, which precedes the end of the first page.
+			firstXRef := parser.XRefOffset()
+			assert.True(t, bytes.HasPrefix(file[firstXRef:], []byte("xref")))
+			assert.Less(t, firstXRef, lin.FirstPageEnd)
+
+			// /T is the white-space preceding the first entry
+			// of the main XRef table.
+			require.Less(t, lin.MainXRefOffset, int64(len(file)))
+			assert.Contains(t, "\r\n ", string(file[lin.MainXRefOffset]))
+			assert.True(t, bytes.HasPrefix(file[lin.MainXRefOffset+1:], []byte("0000000000 65535 f")))
+
+			// /H is the hint stream object.
+			require.LessOrEqual(t, lin.HintOffset+lin.HintLength, int64(len(file)))
+			hint := file[lin.HintOffset : lin.HintOffset+lin.HintLength]
+			assert.Regexp(t, objectHeaderPattern, string(hint))
+			assert.True(t, bytes.HasSuffix(bytes.TrimSpace(hint), []byte("endobj")))
+
+			loaded, err := podofo.LoadMemDocument(bytes.NewReader(file))
+			require.NoError(t, err)
+			assert.Equal(t, tt.widths, pageWidths(t, loaded.PageCollection()))
+		})
+	}
+}
@@ -1,7 +1,18 @@
 package podofo
 
-import "github.com/denisss025/go-podofo/internal/pdf"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
 
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// Metadata is the document information set by the options. The
+// non-empty fields are written to the information dictionary when
+// the document is created, use SetInfo to change it afterwards.
 type Metadata struct {
 	Author   string
 	Creator  string
@@ -12,7 +23,20 @@ type Metadata struct {
 
 type MemDocument struct {
 	base     *pdf.Document
+	encrypt  *Encrypt
 	Metadata Metadata
+
+	objects *IndirectObjectList
+	trailer *Dictionary
+	version PDFVersion
+
+	objectStreamSize int
+	infoInitial      InfoInitial
+
+	// parser and source are the parser and the file
+	// the document is loaded from, see SaveUpdate.
+	parser *Parser
+	source Reader
 }
 
 type DocumentOptionFunc func(doc *MemDocument)
@@ -33,6 +57,13 @@ func WithSubjects(subj string) DocumentOptionFunc {
 	return func(doc *MemDocument) { doc.Metadata.Subject = subj }
 }
 
+// WithInfoInitial sets the entries written to the information
+// dictionary of the new document. The creation time and the
+// producer are written by default.
+func WithInfoInitial(initial InfoInitial) DocumentOptionFunc {
+	return func(doc *MemDocument) { doc.infoInitial = initial }
+}
+
 func WithKeyword(keywords ...string) DocumentOptionFunc {
 	return func(doc *MemDocument) {
 		doc.Metadata.Keywords = append(
@@ -42,24 +73,284 @@ func WithKeyword(keywords ...string) DocumentOptionFunc {
 	}
 }
 
+// NewMemDocument creates an empty document
+// with the catalog and the page tree.
 func NewMemDocument(options ...DocumentOptionFunc) *MemDocument {
-	doc := &MemDocument{base: pdf.NewDocument()}
+	doc := &MemDocument{
+		base:             pdf.NewDocument(),
+		trailer:          NewDictionary(),
+		version:          pdf.Version17,
+		objectStreamSize: defaultObjectStreamSize,
+		infoInitial:      InfoInitialWriteCreationTime | InfoInitialWriteProducer,
+	}
+
+	doc.objects = &IndirectObjectList{document: doc.base}
+
+	catalog := doc.objects.CreateDictionaryObject(pdf.NameCatalog)
+	pages := doc.objects.CreateDictionaryObject(pdf.NamePages)
+	pages.AddKey(pdf.KeyKids, &Array{})
+	pages.AddKey(pdf.KeyCount, pdf.NewInt(0))
+	catalog.AddKey(pdf.KeyPages, pages.GetIndirectReference())
+
+	info := doc.objects.CreateDictionaryObject(pdf.KeyNull)
+
+	doc.trailer.AddKey(pdf.KeyRoot, catalog.GetIndirectReference())
+	doc.trailer.AddKey(pdf.KeyInfo, info.GetIndirectReference())
 
 	for _, option := range options {
 		option(doc)
 	}
 
+	doc.initInfo(doc.infoInitial)
+	doc.applyMetadata()
+
 	return doc
 }
 
+// LoadMemDocument reads the document from r, see Parse. The security
+// handler of an encrypted document is kept, so the document is
+// encrypted the same way when saved.
+func LoadMemDocument(r Reader, options ...ParserOption) (*MemDocument, error) {
+	parser, err := Parse(r, options...)
+	if err != nil {
+		return nil, fmt.Errorf("load document: %w", err)
+	}
+
+	doc := &MemDocument{
+		base:             parser.Objects().Document(),
+		encrypt:          parser.GetEncrypt(),
+		objects:          parser.Objects(),
+		trailer:          parser.trailer.Dictionary,
+		version:          parser.PDFVersion(),
+		objectStreamSize: defaultObjectStreamSize,
+		parser:           parser,
+		source:           r,
+	}
+
+	if doc.base == nil {
+		doc.base = pdf.NewDocument()
+	}
+
+	return doc, nil
+}
+
+// Objects returns the indirect objects of the document.
+func (doc *MemDocument) Objects() *IndirectObjectList { return doc.objects }
+
+// Trailer returns the trailer dictionary of the document.
+func (doc *MemDocument) Trailer() *Dictionary { return doc.trailer }
+
+// PDFVersion returns the version the document is written as.
+func (doc *MemDocument) PDFVersion() PDFVersion { return doc.version }
+
+// SetPDFVersion sets the version the document is written as.
+// The XRef stream is written for PDF 1.5 and later.
+func (doc *MemDocument) SetPDFVersion(version PDFVersion) error {
+	if err := version.Validate(); err != nil {
+		return fmt.Errorf("set pdf version: %w", err)
+	}
+
+	doc.version = version
+
+	return nil
+}
+
+// SetEncrypt sets the security handler the document is encrypted
+// with when saved. The nil handler means the document is not encrypted.
+func (doc *MemDocument) SetEncrypt(encrypt *Encrypt) { doc.encrypt = encrypt }
+
+// Encrypt returns the security handler of the document.
+func (doc *MemDocument) Encrypt() *Encrypt { return doc.encrypt }
+
+// PageCollection returns the page tree of the document. The catalog
+// and the page tree are created if the document has none.
+func (doc *MemDocument) PageCollection() *PageCollection {
+	return newPageCollection(doc.objects, doc.trailer)
+}
+
+// Pages returns the simplified view of the page tree.
+func (doc *MemDocument) Pages() *Pages {
+	return &Pages{collection: doc.PageCollection()}
+}
+
+// AddPage appends a new page of the size,
+// nil is returned if it cannot be added.
 func (doc *MemDocument) AddPage(size PageSize) *Page {
-	panic("not implemented") // TODO: implement me
+	return doc.Pages().Add(size)
 }
 
 func (doc *MemDocument) FindFont(name string) *Font {
 	panic("not implemented") // TODO: implement me
 }
 
-func (doc *MemDocument) SaveToFile(filename string) error {
-	panic("not implemented") // TODO: implement me
+// SetObjectStreamSize sets the number of objects an object stream
+// holds. The objects of PDF 1.5 and later documents are written to
+// the object streams, 0 means the objects are not compressed.
+func (doc *MemDocument) SetObjectStreamSize(size int) { doc.objectStreamSize = size }
+
+// CollectGarbage removes the objects that are not reachable from the
+// trailer and renumbers the others compactly, see RenumberObjects.
+// The numbers of the objects of the file are not kept, so the document
+// can not be saved with SaveUpdate afterwards.
+func (doc *MemDocument) CollectGarbage() error {
+	if err := doc.objects.RenumberObjects(doc.trailer); err != nil {
+		return fmt.Errorf("collect garbage: %w", err)
+	}
+
+	doc.parser, doc.source = nil, nil
+
+	return nil
+}
+
+// Save writes the whole document to w. The options are combined.
+func (doc *MemDocument) Save(w io.Writer, options ...SaveOption) error {
+	opts, err := doc.prepareSave(options)
+	if err != nil {
+		return fmt.Errorf("save document: %w", err)
+	}
+
+	writer := NewWriter(doc.objects, doc.trailer, doc.version, opts)
+	writer.SetEncrypt(doc.encrypt)
+	writer.SetObjectStreamSize(doc.objectStreamSize)
+
+	if err := writer.Write(w); err != nil {
+		return fmt.Errorf("save document: %w", err)
+	}
+
+	return nil
+}
+
+// SaveUpdate writes the original file the document is loaded from
+// to w, followed by the incremental update with the new and the
+// changed objects only, so the signatures of the file remain valid.
+// The update is encrypted the same way as the original file, so the
+// security handler of the document can not be changed.
+func (doc *MemDocument) SaveUpdate(w io.Writer, options ...SaveOption) error {
+	if doc.parser == nil || doc.source == nil {
+		return fmt.Errorf("save update: %w", ErrNotLoadedForUpdate)
+	}
+
+	if doc.encrypt != doc.parser.GetEncrypt() {
+		return fmt.Errorf("save update: %w", ErrCannotEncrypUpdate)
+	}
+
+	opts, err := doc.prepareSave(options)
+	if err != nil {
+		return fmt.Errorf("save update: %w", err)
+	}
+
+	offset, err := copySource(w, doc.source)
+	if err != nil {
+		return fmt.Errorf("save update: %w", err)
+	}
+
+	writer := NewWriter(doc.objects, doc.trailer, doc.version, opts)
+	writer.SetEncrypt(doc.encrypt)
+	writer.SetObjectStreamSize(doc.objectStreamSize)
+	writer.SetUseXRefStream(doc.parser.HasXRefStream())
+
+	if err = writer.WriteUpdate(w, offset, doc.parser.XRefOffset()); err != nil {
+		return fmt.Errorf("save update: %w", err)
+	}
+
+	return nil
+}
+
+// prepareSave combines the options, loads all the objects and
+// updates the modification date unless it is disabled.
+func (doc *MemDocument) prepareSave(options []SaveOption) (SaveOption, error) {
+	var opts SaveOption
+
+	for _, option := range options {
+		opts |= option
+	}
+
+	if err := doc.objects.loadAll(); err != nil {
+		return opts, err
+	}
+
+	if opts&SaveOptionNoModifyDateUpdate == 0 {
+		doc.infoDictionary().AddKey(pdf.KeyModDate, newDateString(time.Now()))
+	}
+
+	return opts, nil
+}
+
+// copySource copies the original file verbatim and returns the number
+// of bytes written. The end-of-line marker is added if the file does
+// not end with one, so the update starts on a line of its own.
+func copySource(w io.Writer, source Reader) (int64, error) {
+	if _, err := source.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	tail := &lastByteWriter{w: w}
+
+	n, err := io.Copy(tail, source)
+	if err != nil {
+		return n, err
+	}
+
+	if n > 0 && tail.last != '\n' && tail.last != '\r' {
+		if _, err = io.WriteString(w, "\n"); err != nil {
+			return n, err
+		}
+
+		n++
+	}
+
+	return n, nil
+}
+
+// lastByteWriter remembers the last byte written.
+type lastByteWriter struct {
+	w    io.Writer
+	last byte
+}
+
+func (lw *lastByteWriter) Write(p []byte) (int, error) {
+	n, err := lw.w.Write(p)
+	if n > 0 {
+		lw.last = p[n-1]
+	}
+
+	return n, err
+}
+
+// SaveToFile writes the whole document to the file, see Save.
+func (doc *MemDocument) SaveToFile(filename string, options ...SaveOption) (err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("save document: %w", err)
+	}
+
+	defer func() { err = errors.Join(err, f.Close()) }()
+
+	return doc.Save(f, options...)
+}
+
+// infoDictionary returns the document information
+// dictionary. It is created if the document has none.
+func (doc *MemDocument) infoDictionary() *Dictionary {
+	if info := doc.findInfoDictionary(); info != nil {
+		return info
+	}
+
+	info := doc.objects.CreateDictionaryObject(pdf.KeyNull)
+	doc.trailer.AddKey(pdf.KeyInfo, info.GetIndirectReference())
+
+	return info
+}
+
+// findInfoDictionary returns the document information
+// dictionary, nil is returned if the document has none.
+func (doc *MemDocument) findInfoDictionary() *Dictionary {
+	switch info := doc.trailer.Key(pdf.KeyInfo).(type) {
+	case *Dictionary:
+		return info
+	case *Reference:
+		return objectDictionary(doc.objects.GetObject(info))
+	default:
+		return nil
+	}
 }
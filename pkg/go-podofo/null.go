@@ -1,6 +1,7 @@
 package podofo
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/denisss025/go-podofo/internal/pdf"
@@ -10,6 +11,23 @@ type Null struct{}
 
 func (null Null) Kind() ObjectKind { return pdf.ObjectKindNull }
 
+// WriteTo writes the null keyword.
 func (null Null) WriteTo(w io.Writer) (n int64, err error) {
-	panic("not implemented") // TODO: implement me
+	written, err := io.WriteString(w, "null")
+
+	return int64(written), err
+}
+
+// MarshalPDF writes null separated from the preceding token.
+func (null Null) MarshalPDF(w *pdf.Writer) error {
+	err := w.WriteLiteralSeparator()
+	if err == nil {
+		_, err = null.WriteTo(w)
+	}
+
+	if err != nil {
+		return fmt.Errorf("marshal null: %w", err)
+	}
+
+	return nil
 }
@@ -1,6 +1,7 @@
 package podofo
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/denisss025/go-podofo/internal/pdf"
@@ -10,6 +11,29 @@ type Number string
 
 func (num Number) Kind() ObjectKind { return pdf.ObjectKindNumber }
 
+// WriteTo writes the number, the reals are written
+// without the exponent notation.
 func (num Number) WriteTo(w io.Writer) (n int64, err error) {
-	panic("not implemented") // TODO: implement me
+	value, err := pdf.ParseNumber([]byte(num))
+	if err != nil {
+		return 0, fmt.Errorf("write number: %w", err)
+	}
+
+	written, err := io.WriteString(w, value.String())
+
+	return int64(written), err
+}
+
+// MarshalPDF writes the number separated from the preceding token.
+func (num Number) MarshalPDF(w *pdf.Writer) error {
+	err := w.WriteLiteralSeparator()
+	if err == nil {
+		_, err = num.WriteTo(w)
+	}
+
+	if err != nil {
+		return fmt.Errorf("marshal number: %w", err)
+	}
+
+	return nil
 }
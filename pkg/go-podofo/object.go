@@ -9,3 +9,45 @@ type Reference = pdf.Reference
 type Generation = pdf.Generation
 
 type Document = pdf.Document
+
+// referencedObject is an object that may be an indirect one.
+type referencedObject interface {
+	GetIndirectReference() *Reference
+}
+
+// copyableObject is an object that is copied by value.
+type copyableObject interface {
+	Copy() (Object, error)
+}
+
+// ownedObject is an object that has a parent.
+type ownedObject interface {
+	SetParent(parent Object)
+}
+
+// indirectReference returns the reference of the indirect
+// object, nil is returned for the direct objects.
+func indirectReference(obj Object) *Reference {
+	if obj, ok := obj.(referencedObject); ok {
+		return obj.GetIndirectReference()
+	}
+
+	return nil
+}
+
+// copyObject returns the copy of the object. The values that
+// cannot be changed, e.g. the numbers, are returned as is.
+func copyObject(obj Object) (Object, error) {
+	if obj, ok := obj.(copyableObject); ok {
+		return obj.Copy()
+	}
+
+	return obj, nil
+}
+
+// setParent sets the parent of the object if it has one.
+func setParent(obj, parent Object) {
+	if obj, ok := obj.(ownedObject); ok {
+		obj.SetParent(parent)
+	}
+}
@@ -0,0 +1,148 @@
+package podofo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	"github.com/denisss025/go-podofo/internal/set"
+)
+
+// ObjectStreamParser reads the compressed objects
+// of an object stream (PDF 1.5 and later).
+type ObjectStreamParser struct {
+	stream  *ParserObject
+	objects *IndirectObjectList
+}
+
+// NewObjectStreamParser creates a parser that reads the objects
+// of stream into objects.
+func NewObjectStreamParser(stream *ParserObject, objects *IndirectObjectList) *ObjectStreamParser {
+	return &ObjectStreamParser{stream: stream, objects: objects}
+}
+
+// Parse reads the objects with the numbers in list from the stream
+// and pushes them into the indirect objects list. The numbers of
+// the objects not found in the stream are returned.
+func (parser *ObjectStreamParser) Parse(list []int) (missing []int, err error) {
+	data, headers, err := parser.readHeaders()
+	if err != nil {
+		return nil, fmt.Errorf("parse object stream: %w", err)
+	}
+
+	wanted := set.New[int]()
+	for _, objNo := range list {
+		wanted.Put(objNo)
+	}
+
+	for _, header := range headers {
+		objNo := int(header.ObjectNo)
+		if !wanted.Contains(objNo) {
+			continue
+		}
+
+		obj, err := parser.readObject(data, header.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("parse object stream: read object %d: %w", objNo, err)
+		}
+
+		// Objects in a stream always have generation 0.
+		parser.objects.PushObject(newCompressedObject(parser.objects.Document(),
+			pdf.NewReference(objNo, 0), obj))
+		wanted.Remove(objNo)
+	}
+
+	for _, objNo := range list {
+		if wanted.Contains(objNo) {
+			missing = append(missing, objNo)
+		}
+	}
+
+	return missing, nil
+}
+
+// ObjectNumbers returns the numbers of the objects
+// in the stream in the stream order.
+func (parser *ObjectStreamParser) ObjectNumbers() ([]int, error) {
+	_, headers, err := parser.readHeaders()
+	if err != nil {
+		return nil, fmt.Errorf("object stream numbers: %w", err)
+	}
+
+	numbers := make([]int, len(headers))
+	for i, header := range headers {
+		numbers[i] = int(header.ObjectNo)
+	}
+
+	return numbers, nil
+}
+
+// readHeaders decodes the stream data and reads the pairs of the
+// object numbers and offsets. The offsets of the returned headers
+// are relative to the start of the data.
+func (parser *ObjectStreamParser) readHeaders() (data []byte, headers []pdf.ObjectHeader, err error) {
+	if err = parser.stream.DelayedLoad(); err != nil {
+		return nil, nil, err
+	}
+
+	dict := parser.stream.Dictionary
+	if dict == nil || !parser.stream.HasStream() {
+		return nil, nil, fmt.Errorf("%w: not a stream", ErrNoObject)
+	}
+
+	if name, ok := dict.Key(pdf.KeyType).(*pdf.NameObject); !ok || name.Name != pdf.NameObjStm {
+		return nil, nil, fmt.Errorf("%w: /Type is not /ObjStm", ErrInvalidDataType)
+	}
+
+	num := dict.Int(pdf.KeyN, -1)
+	first := dict.Int(pdf.KeyFirst, -1)
+
+	if num < 0 || first < 0 {
+		return nil, nil, fmt.Errorf("%w: missing /N or /First", ErrNoObject)
+	}
+
+	if data, err = parser.stream.decodeStream(); err != nil {
+		return nil, nil, err
+	}
+
+	if first > int64(len(data)) {
+		return nil, nil, fmt.Errorf("%w: /First is out of the stream data", ErrBrokenFile)
+	}
+
+	tokenizer := NewTokenizer()
+	r := bytes.NewReader(data[:first])
+
+	for i := int64(0); i < num; i++ {
+		objNo, err := tokenizer.ReadNextNumber(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read object number: %w", err)
+		}
+
+		offset, err := tokenizer.ReadNextNumber(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read object offset: %w", err)
+		}
+
+		if objNo <= 0 || objNo > maxObjectCount || offset < 0 || first+offset >= int64(len(data)) {
+			return nil, nil, fmt.Errorf("%w: object %d offset %d is out of the stream data",
+				ErrBrokenFile, objNo, offset)
+		}
+
+		headers = append(headers, pdf.ObjectHeader{
+			Reference: pdf.Reference{ObjectNo: uint32(objNo)},
+			Offset:    first + offset,
+		})
+	}
+
+	return data, headers, nil
+}
+
+func (parser *ObjectStreamParser) readObject(data []byte, offset int64) (Object, error) {
+	r := bytes.NewReader(data)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	return NewTokenizer().ReadNextObject(r)
+}
@@ -0,0 +1,156 @@
+package podofo_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// objectStream returns the object stream of the objects by their
+// numbers with the extra entries of its dictionary.
+func objectStream(entries string, objects map[int]string) string {
+	numbers := make([]int, 0, len(objects))
+	for objNo := range objects {
+		numbers = append(numbers, objNo)
+	}
+
+	sort.Ints(numbers)
+
+	var header, data bytes.Buffer
+
+	for _, objNo := range numbers {
+		fmt.Fprintf(&header, "%d %d ", objNo, data.Len())
+		data.WriteString(objects[objNo] + " ")
+	}
+
+	return fmt.Sprintf("<</Type /ObjStm /N %d /First %d /Length %d%s>>\nstream\n%s%s\nendstream",
+		len(numbers), header.Len(), header.Len()+data.Len(), entries, header.String(), data.String())
+}
+
+// buildXRefStreamPDF returns the file of the objects numbered from 1
+// and the compressed objects with the XRef stream. The compressed
+// map the object numbers to the numbers of their object streams.
+func buildXRefStreamPDF(objects []string, compressed map[int]int) []byte {
+	size := len(objects) + 1
+	for objNo := range compressed {
+		if objNo >= size {
+			size = objNo + 1
+		}
+	}
+
+	xrefObjNo := size
+	size++
+
+	var buf bytes.Buffer
+
+	buf.WriteString("%PDF-1.5\n")
+
+	offsets := make(map[int]int, len(objects))
+
+	for i, obj := range objects {
+		offsets[i+1] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	offsets[xrefObjNo] = buf.Len()
+
+	var entries bytes.Buffer
+
+	for objNo := 0; objNo < size; objNo++ {
+		entry := make([]byte, 7)
+
+		if offset, ok := offsets[objNo]; ok {
+			entry[0] = 1
+			binary.BigEndian.PutUint32(entry[1:], uint32(offset))
+		} else if streamObjNo, ok := compressed[objNo]; ok {
+			entry[0] = 2
+			binary.BigEndian.PutUint32(entry[1:], uint32(streamObjNo))
+		} else if objNo == 0 {
+			binary.BigEndian.PutUint16(entry[5:], 65535)
+		}
+
+		entries.Write(entry)
+	}
+
+	fmt.Fprintf(&buf, "%d 0 obj\n<</Type /XRef /Size %d /W [1 4 2] /Root 1 0 R /Length %d>>\nstream\n",
+		xrefObjNo, size, entries.Len())
+	buf.Write(entries.Bytes())
+	fmt.Fprintf(&buf, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF", offsets[xrefObjNo])
+
+	return buf.Bytes()
+}
+
+func TestParseObjectStreamExtends(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		extends [2]string
+		options []podofo.ParserOption
+		want    map[int]string
+	}{
+		{
+			name:    "extends",
+			extends: [2]string{" /Extends 4 0 R"},
+			want:    map[int]string{5: "five", 6: "six", 7: "seven", 8: ""},
+		},
+		{
+			name:    "extends on demand",
+			extends: [2]string{" /Extends 4 0 R"},
+			options: []podofo.ParserOption{podofo.LoadOnDemand()},
+			want:    map[int]string{5: "five", 6: "six", 7: "seven", 8: ""},
+		},
+		{
+			name: "no extends",
+			want: map[int]string{5: "five", 6: "", 7: "seven", 8: ""},
+		},
+		{
+			name:    "extends loop",
+			extends: [2]string{" /Extends 4 0 R", " /Extends 3 0 R"},
+			want:    map[int]string{5: "five", 6: "six", 7: "seven", 8: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The objects 5 to 8 are listed as the ones of the stream 3,
+			// the object 6 is in the stream 4, the object 8 is in none.
+			file := buildXRefStreamPDF([]string{
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [] /Count 0>>",
+				objectStream(tt.extends[0], map[int]string{5: "(five)", 7: "(seven)"}),
+				objectStream(tt.extends[1], map[int]string{6: "(six)"}),
+			}, map[int]int{5: 3, 6: 3, 7: 3, 8: 3})
+
+			parser, err := podofo.Parse(bytes.NewReader(file), tt.options...)
+			require.NoError(t, err)
+
+			for objNo, want := range tt.want {
+				var got string
+
+				obj, _ := parser.Objects().GetObject(pdf.NewReference(objNo, 0)).(*podofo.ParserObject)
+				if obj != nil {
+					require.NoError(t, obj.DelayedLoad())
+
+					if str, ok := obj.Object().(*podofo.String); ok {
+						got = string(str.RawData())
+					}
+				}
+
+				assert.Equal(t, want, got, "object %d", objNo)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package podofo
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// defaultObjectStreamSize is the number of
+// objects an object stream holds by default.
+const defaultObjectStreamSize = 100
+
+// ObjectStreamWriter packs the objects into an object
+// stream (PDF 1.5 and later). The strings of the objects
+// are not encrypted, the whole stream is encrypted instead.
+type ObjectStreamWriter struct {
+	pw     *pdf.Writer
+	body   bytes.Buffer
+	header []byte
+	count  int
+}
+
+// NewObjectStreamWriter creates an empty object stream.
+// The options are the ones of the objects, e.g. WriteClean.
+func NewObjectStreamWriter(options ...pdf.WriterOptionFunc) *ObjectStreamWriter {
+	osw := new(ObjectStreamWriter)
+	osw.pw = pdf.NewWriter(&osw.body, options...)
+
+	return osw
+}
+
+// Len returns the number of the objects in the stream.
+func (osw *ObjectStreamWriter) Len() int { return osw.count }
+
+// Add writes the object to the stream and returns its
+// index in the stream. Only the objects of the generation 0
+// that are not streams can be written to an object stream
+// (see ISO 32000-1:2008, 7.5.7).
+func (osw *ObjectStreamWriter) Add(ref Reference, value Object) (int, error) {
+	if ref.GenerationNo != 0 {
+		return 0, fmt.Errorf("add object %s to object stream: %w", ref, ErrInvalidDataType)
+	}
+
+	osw.header = append(osw.header, fmt.Sprintf("%d %d ", ref.ObjectNo, osw.body.Len())...)
+
+	if err := value.MarshalPDF(osw.pw); err != nil {
+		return 0, fmt.Errorf("add object %s to object stream: %w", ref, err)
+	}
+
+	if err := osw.pw.WriteByte('\n'); err != nil {
+		return 0, fmt.Errorf("add object %s to object stream: %w", ref, err)
+	}
+
+	osw.count++
+
+	return osw.count - 1, nil
+}
+
+// Stream returns the dictionary and the data of the object stream.
+// The data is not encoded.
+func (osw *ObjectStreamWriter) Stream() (*Dictionary, []byte) {
+	dict := NewDictionary()
+	dict.AddKey(pdf.KeyType, &pdf.NameObject{Name: pdf.NameObjStm})
+	dict.AddKey(pdf.KeyN, pdf.NewInt(int64(osw.count)))
+	dict.AddKey(pdf.KeyFirst, pdf.NewInt(int64(len(osw.header))))
+
+	data := make([]byte, 0, len(osw.header)+osw.body.Len())
+	data = append(data, osw.header...)
+	data = append(data, osw.body.Bytes()...)
+
+	return dict, data
+}
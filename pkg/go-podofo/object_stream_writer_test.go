@@ -0,0 +1,110 @@
+package podofo_test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// objectStreamPattern matches the dictionary of an object stream.
+var objectStreamPattern = regexp.MustCompile(`/Type/ObjStm[^>]*/N (\d+)`)
+
+// indexObjects returns n dictionaries with an /Index entry.
+func indexObjects(n int) []string {
+	objects := make([]string, n)
+	for i := range objects {
+		objects[i] = fmt.Sprintf("<</Index %d>>", i)
+	}
+
+	return objects
+}
+
+func TestSaveObjectStreams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version podofo.PDFVersion
+		size    int
+		encrypt bool
+		streams int
+	}{
+		{name: "xref table", version: "1.4", size: 100},
+		{name: "disabled", version: "1.7"},
+		{name: "one stream", version: "1.7", size: 100, streams: 1},
+		{name: "three objects", version: "1.7", size: 3, streams: 3},
+		{name: "one object", version: "1.5", size: 1, streams: 8},
+		{name: "encrypted", version: "1.7", size: 3, encrypt: true, streams: 3},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The catalog, the page tree, the information
+			// dictionary and 5 dictionaries can be compressed.
+			doc := loadDocument(t, indexObjects(5)...)
+			require.NoError(t, doc.SetPDFVersion(tt.version))
+			doc.SetObjectStreamSize(tt.size)
+
+			var options []podofo.ParserOption
+
+			if tt.encrypt {
+				enc, err := podofo.NewEncrypt("user", "owner", podofo.PermissionPrint, podofo.EncryptAlgorithmAESV3)
+				require.NoError(t, err)
+
+				doc.SetEncrypt(enc)
+
+				options = append(options, podofo.Password("user"))
+			}
+
+			file := saveDocument(t, doc, podofo.SaveOptionNoFlateCompress)
+
+			streams := objectStreamPattern.FindAllSubmatch(file, -1)
+			assert.Len(t, streams, tt.streams)
+
+			for _, stream := range streams {
+				n, err := strconv.Atoi(string(stream[1]))
+				require.NoError(t, err)
+				assert.LessOrEqual(t, n, tt.size)
+			}
+
+			loaded, err := podofo.LoadMemDocument(bytes.NewReader(file), options...)
+			require.NoError(t, err)
+
+			for i := 0; i < 5; i++ {
+				obj := loaded.Objects().GetObject(pdf.NewReference(i+3, 0))
+				assert.Equal(t, fmt.Sprintf("<</Index %d>>", i), marshalObject(t, obj))
+			}
+
+			parser, err := podofo.Parse(bytes.NewReader(file), options...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.version != "1.4", parser.HasXRefStream())
+		})
+	}
+}
+
+func TestSaveObjectStreamsSmaller(t *testing.T) {
+	t.Parallel()
+
+	doc := loadDocument(t, indexObjects(50)...)
+	require.NoError(t, doc.SetPDFVersion("1.7"))
+
+	compressed := saveDocument(t, doc)
+
+	doc.SetObjectStreamSize(0)
+
+	plain := saveDocument(t, doc)
+
+	assert.Less(t, len(compressed), len(plain))
+}
@@ -1,29 +1,268 @@
 package podofo
 
+import (
+	"fmt"
+	"math"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// Page is a page object of the page tree.
 type Page struct {
-	rect Rect
+	collection *PageCollection
+
+	object Object
+	dict   *Dictionary
+	ref    *Reference
+	index  int
+}
+
+func (page *Page) Document() *Document { return page.collection.objects.Document() }
+
+// Object returns the page object.
+func (page *Page) Object() Object { return page.object }
+
+// Index returns the index the page had when it was found.
+func (page *Page) Index() int { return page.index }
+
+// Rect returns the media box of the page.
+func (page *Page) Rect() Rect { return page.MediaBox() }
+
+// Box returns the boundary of the page. The media box of US Letter
+// size is assumed if the page has none. The crop box defaults to the
+// media box, the bleed, the trim and the art boxes default to the
+// crop box. The boxes are intersected with the media box.
+func (page *Page) Box(box PageBox) Rect {
+	mediaBox, ok := arrayRect(page.InheritedAttribute(pdf.KeyMediaBox))
+	if !ok {
+		mediaBox = pdf.PageSizeLetter()
+	}
+
+	if box == PageBoxMedia {
+		return mediaBox
+	}
+
+	cropBox, ok := arrayRect(page.InheritedAttribute(pdf.KeyCropBox))
+	if !ok {
+		cropBox = mediaBox
+	}
+
+	cropBox = intersectRect(cropBox, mediaBox)
+
+	if box == PageBoxCrop {
+		return cropBox
+	}
+
+	rect, ok := arrayRect(page.InheritedAttribute(box.key()))
+	if !ok {
+		return cropBox
+	}
+
+	return intersectRect(rect, mediaBox)
+}
+
+// SetBox sets the boundary of the page. The box is set on the page
+// itself, so it overrides the one inherited from the page tree.
+func (page *Page) SetBox(box PageBox, rect Rect) {
+	page.dict.AddKey(box.key(), rectArray(rect))
+}
+
+// MediaBox returns the media box of the page, see Box.
+func (page *Page) MediaBox() Rect { return page.Box(PageBoxMedia) }
+
+// CropBox returns the crop box of the page, see Box.
+func (page *Page) CropBox() Rect { return page.Box(PageBoxCrop) }
+
+// BleedBox returns the bleed box of the page, see Box.
+func (page *Page) BleedBox() Rect { return page.Box(PageBoxBleed) }
+
+// TrimBox returns the trim box of the page, see Box.
+func (page *Page) TrimBox() Rect { return page.Box(PageBoxTrim) }
+
+// ArtBox returns the art box of the page, see Box.
+func (page *Page) ArtBox() Rect { return page.Box(PageBoxArt) }
+
+// SetMediaBox sets the media box of the page.
+func (page *Page) SetMediaBox(rect Rect) { page.SetBox(PageBoxMedia, rect) }
+
+// SetCropBox sets the crop box of the page.
+func (page *Page) SetCropBox(rect Rect) { page.SetBox(PageBoxCrop, rect) }
+
+// SetBleedBox sets the bleed box of the page.
+func (page *Page) SetBleedBox(rect Rect) { page.SetBox(PageBoxBleed, rect) }
+
+// SetTrimBox sets the trim box of the page.
+func (page *Page) SetTrimBox(rect Rect) { page.SetBox(PageBoxTrim, rect) }
+
+// SetArtBox sets the art box of the page.
+func (page *Page) SetArtBox(rect Rect) { page.SetBox(PageBoxArt, rect) }
+
+// Rotation returns the clockwise rotation of the page when displayed,
+// which is 0, 90, 180 or 270. An invalid /Rotate means no rotation.
+func (page *Page) Rotation() int {
+	num, ok := page.InheritedAttribute(pdf.KeyRotate).(*pdf.Number)
+	if !ok {
+		return 0
+	}
+
+	rotation := (num.Int()%360 + 360) % 360
+	if rotation%90 != 0 {
+		return 0
+	}
+
+	return rotation
+}
+
+// SetRotation sets the clockwise rotation of the page in degrees,
+// which must be a multiple of 90.
+func (page *Page) SetRotation(degrees int) error {
+	if degrees%90 != 0 {
+		return fmt.Errorf("set rotation %d: %w", degrees, ErrValueOutOfRange)
+	}
+
+	page.dict.AddKey(pdf.KeyRotate, pdf.NewInt(int64((degrees%360+360)%360)))
+
+	return nil
 }
 
-func (page *Page) Document() *Document {
-	panic("not implemented") // TODO: implement me
+// UserUnit returns the size of the default user space unit
+// in multiples of 1/72 inch, 1 is returned by default.
+func (page *Page) UserUnit() float64 {
+	num, ok := page.InheritedAttribute(pdf.KeyUserUnit).(*pdf.Number)
+	if !ok || num.Float64() <= 0 {
+		return 1
+	}
+
+	return num.Float64()
 }
 
-func (page *Page) Object() Object {
-	panic("not implemented") // TODO: implement me
+// SetUserUnit sets the size of the default user
+// space unit in multiples of 1/72 inch.
+func (page *Page) SetUserUnit(unit float64) error {
+	if unit <= 0 {
+		return fmt.Errorf("set user unit %g: %w", unit, ErrValueOutOfRange)
+	}
+
+	page.dict.AddKey(pdf.KeyUserUnit, pdf.NewReal(unit))
+
+	return nil
 }
 
-func (page *Page) Rect() Rect { return page.rect }
+// VisibleRect returns the crop box in the rotated user space, i.e.
+// as the page is displayed. The width and the height of the pages
+// rotated by 90 or 270 degrees are swapped. The content placed in
+// the rotated user space is drawn upright with RotationMatrix.
+func (page *Page) VisibleRect() Rect {
+	box := page.CropBox()
+	llx, lly := box.X, box.Y
+	urx, ury := box.X+box.Width, box.Y+box.Height
 
-type Pages struct{}
+	switch page.Rotation() {
+	case 90:
+		return Rect{
+			Pos:  pdf.Pos{X: lly, Y: -urx},
+			Size: pdf.Size{Width: box.Height, Height: box.Width},
+		}
+	case 180:
+		return Rect{Pos: pdf.Pos{X: -urx, Y: -ury}, Size: box.Size}
+	case 270:
+		return Rect{
+			Pos:  pdf.Pos{X: -ury, Y: llx},
+			Size: pdf.Size{Width: box.Height, Height: box.Width},
+		}
+	default:
+		return box
+	}
+}
 
-func (pg *Pages) Count() int {
-	panic("not implemented") // TODO: implement me
+// RotationMatrix returns the matrix transforming the rotated user
+// space of VisibleRect to the default user space of the page.
+func (page *Page) RotationMatrix() Matrix2D {
+	switch page.Rotation() {
+	case 90:
+		return Matrix2D{0, 1, -1, 0, 0, 0}
+	case 180:
+		return Matrix2D{-1, 0, 0, -1, 0, 0}
+	case 270:
+		return Matrix2D{0, -1, 1, 0, 0, 0}
+	default:
+		return Matrix2D{1, 0, 0, 1, 0, 0}
+	}
 }
 
+// InheritedAttribute returns the attribute of the page. The inheritable
+// attributes, i.e. /Resources, /MediaBox, /CropBox and /Rotate, are
+// looked up in the ancestors of the page as well. Nil is returned if
+// the page has no such attribute.
+func (page *Page) InheritedAttribute(key pdf.Name) Object {
+	value := page.dict.Key(key)
+
+	for _, inheritable := range inheritableKeys {
+		if key == inheritable {
+			value = page.collection.inheritedAttribute(page.dict, key)
+
+			break
+		}
+	}
+
+	return page.collection.objects.resolve(value)
+}
+
+// key returns the page attribute of the box.
+func (box PageBox) key() pdf.Name {
+	switch box {
+	case PageBoxCrop:
+		return pdf.KeyCropBox
+	case PageBoxBleed:
+		return pdf.KeyBleedBox
+	case PageBoxTrim:
+		return pdf.KeyTrimBox
+	case PageBoxArt:
+		return pdf.KeyArtBox
+	default:
+		return pdf.KeyMediaBox
+	}
+}
+
+// intersectRect returns the intersection of the rectangles, an empty
+// rectangle is returned if they do not intersect.
+func intersectRect(a, b Rect) Rect {
+	llx, lly := math.Max(a.X, b.X), math.Max(a.Y, b.Y)
+	urx := math.Min(a.X+a.Width, b.X+b.Width)
+	ury := math.Min(a.Y+a.Height, b.Y+b.Height)
+
+	return Rect{
+		Pos:  pdf.Pos{X: llx, Y: lly},
+		Size: pdf.Size{Width: math.Max(urx-llx, 0), Height: math.Max(ury-lly, 0)},
+	}
+}
+
+// Pages is a simplified view of the page tree, see PageCollection.
+// The methods return zero values if the page tree is broken.
+type Pages struct {
+	collection *PageCollection
+}
+
+// Count returns the number of pages.
+func (pg *Pages) Count() int { return pg.collection.Count() }
+
+// Add appends a new page of the size,
+// nil is returned if it cannot be added.
 func (pg *Pages) Add(size PageSize) *Page {
-	panic("not implemented") // TODO: implement me
+	page, err := pg.collection.AddPage(size)
+	if err != nil {
+		return nil
+	}
+
+	return page
 }
 
+// Index returns the page at index, nil is returned if there is none.
 func (pg *Pages) Index(index int) *Page {
-	panic("not implemented") // TODO: implement me
+	page, err := pg.collection.Index(index)
+	if err != nil {
+		return nil
+	}
+
+	return page
 }
@@ -0,0 +1,513 @@
+package podofo
+
+import (
+	"fmt"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	"github.com/denisss025/go-podofo/internal/set"
+)
+
+// maxPageTreeKids is the greatest number of kids of a page tree node.
+// A node with more kids is split in two, so the tree stays balanced
+// when the pages are added one by one.
+const maxPageTreeKids = 32
+
+// inheritableKeys are the page attributes inherited from
+// the ancestors of the page (see ISO 32000-1:2008, 7.7.3.4).
+var inheritableKeys = []pdf.Name{
+	pdf.KeyResources, pdf.KeyMediaBox, pdf.KeyCropBox, pdf.KeyRotate,
+}
+
+// PageCollection is the page tree of a document
+// (see ISO 32000-1:2008, 7.7.3).
+type PageCollection struct {
+	objects *IndirectObjectList
+	catalog *Dictionary
+	root    *Reference
+}
+
+// pageTreeNode is an intermediate node of the page tree. The pos is
+// the index of the kid the path to a page goes through.
+type pageTreeNode struct {
+	ref  *Reference
+	dict *Dictionary
+	kids *Array
+	pos  int
+}
+
+// newPageCollection returns the page tree of the catalog the trailer
+// refers to. The catalog and the root node are created if missing.
+func newPageCollection(objects *IndirectObjectList, trailer *Dictionary) *PageCollection {
+	var catalog *Dictionary
+
+	if ref, ok := trailer.Key(pdf.KeyRoot).(*Reference); ok {
+		catalog = objectDictionary(objects.GetObject(ref))
+	}
+
+	if catalog == nil {
+		catalog = objects.CreateDictionaryObject(pdf.NameCatalog)
+		trailer.AddKey(pdf.KeyRoot, copyReference(catalog.GetIndirectReference()))
+	}
+
+	pc := &PageCollection{objects: objects, catalog: catalog}
+
+	if ref, ok := catalog.Key(pdf.KeyPages).(*Reference); ok {
+		if _, isNode := pc.node(ref); isNode {
+			pc.root = ref
+
+			return pc
+		}
+	}
+
+	root := objects.CreateDictionaryObject(pdf.NamePages)
+	root.AddKey(pdf.KeyKids, &Array{})
+	root.AddKey(pdf.KeyCount, pdf.NewInt(0))
+
+	pc.root = copyReference(root.GetIndirectReference())
+	catalog.AddKey(pdf.KeyPages, copyReference(pc.root))
+
+	return pc
+}
+
+// Count returns the number of pages.
+func (pc *PageCollection) Count() int {
+	root, ok := pc.node(pc.root)
+	if !ok {
+		return 0
+	}
+
+	return int(root.dict.Int(pdf.KeyCount, 0))
+}
+
+// Index returns the page at index.
+func (pc *PageCollection) Index(index int) (*Page, error) {
+	if index < 0 || index >= pc.Count() {
+		return nil, fmt.Errorf("page %d: %w", index, ErrPageNotFound)
+	}
+
+	path, pos, err := pc.locate(index, false)
+	if err != nil {
+		return nil, fmt.Errorf("page %d: %w", index, err)
+	}
+
+	ref, _ := path[len(path)-1].kids.objects[pos].(*Reference)
+
+	page := pc.page(ref, index)
+	if page == nil {
+		return nil, fmt.Errorf("page %d: %w", index, ErrBrokenFile)
+	}
+
+	return page, nil
+}
+
+// AddPage appends a new page of the size.
+func (pc *PageCollection) AddPage(size PageSize) (*Page, error) {
+	return pc.AddPageAt(pc.Count(), size)
+}
+
+// AddPageAt inserts a new page of the size before the page at index.
+// The page is appended if the index equals Count.
+func (pc *PageCollection) AddPageAt(index int, size PageSize) (*Page, error) {
+	if index < 0 || index > pc.Count() {
+		return nil, fmt.Errorf("add page at %d: %w", index, ErrValueOutOfRange)
+	}
+
+	dict := pc.objects.CreateDictionaryObject(pdf.NamePage)
+	dict.AddKey(pdf.KeyMediaBox, rectArray(size.PageSize()))
+	dict.AddKey(pdf.KeyResources, NewDictionary())
+
+	page, err := pc.insertPage(index, dict)
+	if err != nil {
+		return nil, fmt.Errorf("add page at %d: %w", index, err)
+	}
+
+	return page, nil
+}
+
+// insertPage inserts the indirect page object before the page at index.
+func (pc *PageCollection) insertPage(index int, dict *Dictionary) (*Page, error) {
+	path, pos, err := pc.locate(index, true)
+	if err != nil {
+		return nil, err
+	}
+
+	parent := path[len(path)-1]
+
+	dict.AddKey(pdf.KeyParent, copyReference(parent.ref))
+
+	// The page must not inherit the attributes of its new ancestors.
+	for _, key := range inheritableKeys {
+		if dict.Key(key) != nil || pc.inheritedAttribute(parent.dict, key) == nil {
+			continue
+		}
+
+		switch key {
+		case pdf.KeyResources:
+			dict.AddKey(key, NewDictionary())
+		case pdf.KeyCropBox:
+			if mediaBox := dict.Key(pdf.KeyMediaBox); mediaBox != nil {
+				dict.AddKey(key, inheritedCopy(mediaBox))
+			}
+		case pdf.KeyRotate:
+			dict.AddKey(key, pdf.NewInt(0))
+		}
+	}
+
+	ref := copyReference(dict.GetIndirectReference())
+
+	pc.insertKid(parent, pos, ref)
+	pc.updateCounts(path, 1)
+	pc.balance(path)
+
+	return pc.page(ref, index), nil
+}
+
+// RemovePage removes the page at index from the page tree. The page
+// object is kept, it is dropped on save if nothing refers to it.
+func (pc *PageCollection) RemovePage(index int) error {
+	if index < 0 || index >= pc.Count() {
+		return fmt.Errorf("remove page %d: %w", index, ErrPageNotFound)
+	}
+
+	path, pos, err := pc.locate(index, false)
+	if err != nil {
+		return fmt.Errorf("remove page %d: %w", index, err)
+	}
+
+	pc.removeKid(path[len(path)-1], pos)
+	pc.updateCounts(path, -1)
+
+	// The emptied nodes are removed, the root node is always kept.
+	for i := len(path) - 1; i > 0 && len(path[i].kids.objects) == 0; i-- {
+		pc.removeKid(path[i-1], path[i-1].pos)
+	}
+
+	return nil
+}
+
+// locate returns the path from the root to the node holding the page
+// at index and the position of the page in the kids of the node. If
+// insert is true, the position the new page at index goes to is
+// returned, which is in the deepest node when the page is appended.
+func (pc *PageCollection) locate(index int, insert bool) ([]*pageTreeNode, int, error) {
+	node, ok := pc.node(pc.root)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: no page tree", ErrBrokenFile)
+	}
+
+	var path []*pageTreeNode
+
+	visited := set.New[Reference]()
+
+	for {
+		if visited.Contains(*node.ref) {
+			return nil, 0, fmt.Errorf("%w: loop in the page tree at %s", ErrBrokenFile, node.ref)
+		}
+
+		visited.Put(*node.ref)
+
+		path = append(path, node)
+
+		var next *pageTreeNode
+
+		last := len(node.kids.objects) - 1
+		node.pos = last + 1
+
+		for i, kid := range node.kids.objects {
+			ref, ok := kid.(*Reference)
+			if !ok {
+				continue
+			}
+
+			child, isNode := pc.node(ref)
+
+			count := 1
+			if isNode {
+				count = int(child.dict.Int(pdf.KeyCount, 0))
+			}
+
+			if index < count || (insert && isNode && index == count && i == last) {
+				node.pos = i
+
+				if isNode {
+					next = child
+				}
+
+				break
+			}
+
+			index -= count
+		}
+
+		if next == nil {
+			if !insert && node.pos > last {
+				return nil, 0, fmt.Errorf("%w: /Count does not match the kids", ErrPageNotFound)
+			}
+
+			return path, node.pos, nil
+		}
+
+		node = next
+	}
+}
+
+// node returns the intermediate node the reference refers
+// to, false is returned if the object is not a node.
+func (pc *PageCollection) node(ref *Reference) (*pageTreeNode, bool) {
+	dict := objectDictionary(pc.objects.GetObject(ref))
+	if dict == nil || objectType(dict) == pdf.NamePage {
+		return nil, false
+	}
+
+	kids := pc.array(dict.Key(pdf.KeyKids))
+	if kids == nil {
+		return nil, false
+	}
+
+	return &pageTreeNode{ref: ref, dict: dict, kids: kids}, true
+}
+
+// array returns the array the object is or refers to.
+func (pc *PageCollection) array(obj Object) *Array {
+	array, _ := pc.objects.resolve(obj).(*Array)
+
+	return array
+}
+
+// page returns the page object the reference refers to.
+func (pc *PageCollection) page(ref *Reference, index int) *Page {
+	obj := pc.objects.GetObject(ref)
+
+	dict := objectDictionary(obj)
+	if dict == nil {
+		return nil
+	}
+
+	return &Page{collection: pc, object: obj, dict: dict, ref: ref, index: index}
+}
+
+// inheritedAttribute returns the attribute of the page or of its
+// nearest ancestor. Nil is returned if none of them has the key.
+func (pc *PageCollection) inheritedAttribute(dict *Dictionary, key pdf.Name) Object {
+	visited := set.New[Reference]()
+
+	for dict != nil {
+		if value := dict.Key(key); value != nil {
+			return value
+		}
+
+		parent, ok := dict.Key(pdf.KeyParent).(*Reference)
+		if !ok || visited.Contains(*parent) {
+			return nil
+		}
+
+		visited.Put(*parent)
+
+		dict = objectDictionary(pc.objects.GetObject(parent))
+	}
+
+	return nil
+}
+
+// kidCount returns the number of pages under the kid.
+func (pc *PageCollection) kidCount(kid Object) int {
+	ref, ok := kid.(*Reference)
+	if !ok {
+		return 0
+	}
+
+	if node, isNode := pc.node(ref); isNode {
+		return int(node.dict.Int(pdf.KeyCount, 0))
+	}
+
+	return 1
+}
+
+// updateCounts adds delta to /Count of the nodes of the path.
+func (pc *PageCollection) updateCounts(path []*pageTreeNode, delta int64) {
+	for _, node := range path {
+		node.dict.AddKey(pdf.KeyCount, pdf.NewInt(node.dict.Int(pdf.KeyCount, 0)+delta))
+	}
+}
+
+// balance splits the nodes of the path having too many kids,
+// starting from the deepest one.
+func (pc *PageCollection) balance(path []*pageTreeNode) {
+	for i := len(path) - 1; i >= 0; i-- {
+		if len(path[i].kids.objects) <= maxPageTreeKids {
+			return
+		}
+
+		if i == 0 {
+			pc.splitRoot(path[i])
+		} else {
+			pc.splitNode(path[i-1], path[i])
+		}
+	}
+}
+
+// splitNode moves the second half of the kids of the node
+// to a new node, which is inserted next to the node.
+func (pc *PageCollection) splitNode(parent, node *pageTreeNode) {
+	kids := node.kids.objects
+	half := len(kids) / 2
+
+	sibling := pc.newNode(parent.ref, kids[half:])
+
+	// The moved kids keep the attributes they inherited from the node.
+	for _, key := range inheritableKeys {
+		if value := node.dict.Key(key); value != nil {
+			sibling.dict.AddKey(key, inheritedCopy(value))
+		}
+	}
+
+	pc.setKids(node, kids[:half:half])
+	node.dict.AddKey(pdf.KeyCount, pdf.NewInt(pc.countKids(node.kids.objects)))
+
+	pc.insertKid(parent, parent.pos+1, copyReference(sibling.ref))
+}
+
+// splitRoot moves the kids of the root to two new nodes. The root
+// keeps its object number, as the catalog refers to it.
+func (pc *PageCollection) splitRoot(root *pageTreeNode) {
+	kids := root.kids.objects
+	half := len(kids) / 2
+
+	left := pc.newNode(root.ref, kids[:half])
+	right := pc.newNode(root.ref, kids[half:])
+
+	pc.setKids(root, []Object{copyReference(left.ref), copyReference(right.ref)})
+}
+
+// newNode creates an intermediate node with the kids.
+func (pc *PageCollection) newNode(parent *Reference, kids []Object) *pageTreeNode {
+	dict := pc.objects.CreateDictionaryObject(pdf.NamePages)
+	ref := copyReference(dict.GetIndirectReference())
+
+	node := &pageTreeNode{
+		ref:  ref,
+		dict: dict,
+		kids: &Array{objects: append([]Object(nil), kids...)},
+	}
+
+	dict.AddKey(pdf.KeyParent, copyReference(parent))
+	dict.AddKey(pdf.KeyKids, node.kids)
+	dict.AddKey(pdf.KeyCount, pdf.NewInt(pc.countKids(node.kids.objects)))
+
+	for _, kid := range node.kids.objects {
+		if kidRef, ok := kid.(*Reference); ok {
+			if kidDict := objectDictionary(pc.objects.GetObject(kidRef)); kidDict != nil {
+				kidDict.AddKey(pdf.KeyParent, copyReference(ref))
+			}
+		}
+	}
+
+	return node
+}
+
+// countKids returns the number of pages under the kids.
+func (pc *PageCollection) countKids(kids []Object) int64 {
+	var count int64
+
+	for _, kid := range kids {
+		count += int64(pc.kidCount(kid))
+	}
+
+	return count
+}
+
+// insertKid inserts the kid at the position of the node.
+func (pc *PageCollection) insertKid(node *pageTreeNode, pos int, kid Object) {
+	kids := append(node.kids.objects, nil)
+	copy(kids[pos+1:], kids[pos:])
+	kids[pos] = kid
+
+	pc.setKids(node, kids)
+}
+
+// removeKid removes the kid at the position of the node.
+func (pc *PageCollection) removeKid(node *pageTreeNode, pos int) {
+	kids := node.kids.objects
+
+	pc.setKids(node, append(kids[:pos:pos], kids[pos+1:]...))
+}
+
+// setKids replaces the kids of the node and marks the node dirty.
+func (pc *PageCollection) setKids(node *pageTreeNode, kids []Object) {
+	node.kids.objects = kids
+
+	if _, direct := node.dict.Key(pdf.KeyKids).(*Array); direct {
+		node.dict.AddKey(pdf.KeyKids, node.kids)
+	} else {
+		node.kids.SetDirty()
+	}
+}
+
+// inheritedCopy returns a copy of the inheritable attribute,
+// so the direct objects are not shared by the nodes.
+func inheritedCopy(value Object) Object {
+	switch value := value.(type) {
+	case *Reference:
+		return copyReference(value)
+	case *Dictionary:
+		return value.shallowCopy()
+	case *Array:
+		return &Array{objects: append([]Object(nil), value.objects...)}
+	default:
+		return value
+	}
+}
+
+// copyReference returns a new reference to the same object,
+// as the references may be shared by the objects.
+func copyReference(ref *Reference) *Reference {
+	cpy := *ref
+
+	return &cpy
+}
+
+// rectArray returns the rectangle array [llx lly urx ury].
+func rectArray(rect Rect) *Array {
+	return &Array{objects: []Object{
+		pdf.NewReal(rect.X),
+		pdf.NewReal(rect.Y),
+		pdf.NewReal(rect.X + rect.Width),
+		pdf.NewReal(rect.Y + rect.Height),
+	}}
+}
+
+// arrayRect returns the rectangle of the rectangle
+// array, false is returned if the array is not one.
+func arrayRect(obj Object) (Rect, bool) {
+	array, ok := obj.(*Array)
+	if !ok || len(array.objects) != 4 {
+		return Rect{}, false
+	}
+
+	var coords [4]float64
+
+	for i, obj := range array.objects {
+		num, ok := obj.(*pdf.Number)
+		if !ok {
+			return Rect{}, false
+		}
+
+		coords[i] = num.Float64()
+	}
+
+	// The rectangle may be given by any two opposite corners.
+	llx, urx := coords[0], coords[2]
+	if llx > urx {
+		llx, urx = urx, llx
+	}
+
+	lly, ury := coords[1], coords[3]
+	if lly > ury {
+		lly, ury = ury, lly
+	}
+
+	return Rect{
+		Pos:  pdf.Pos{X: llx, Y: lly},
+		Size: pdf.Size{Width: urx - llx, Height: ury - lly},
+	}, true
+}
@@ -0,0 +1,157 @@
+package podofo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// pageSize returns the page size of the width, so
+// the pages can be told apart by their widths.
+func pageSize(width int) podofo.PageSize {
+	return pdf.PageSizeFunc(func() pdf.Rect {
+		return pdf.Rect{Size: pdf.Size{Width: float64(width), Height: 100}}
+	})
+}
+
+// pageWidths returns the widths of the pages in order.
+func pageWidths(t *testing.T, pc *podofo.PageCollection) []int {
+	t.Helper()
+
+	widths := make([]int, pc.Count())
+
+	for i := range widths {
+		page, err := pc.Index(i)
+		require.NoError(t, err)
+		assert.Equal(t, i, page.Index())
+
+		widths[i] = int(page.MediaBox().Width)
+	}
+
+	return widths
+}
+
+// newDocument creates the document of numPages A4 pages.
+func newDocument(t *testing.T, numPages int) *podofo.MemDocument {
+	t.Helper()
+
+	doc := podofo.NewMemDocument()
+
+	for i := 0; i < numPages; i++ {
+		require.NotNil(t, doc.AddPage(podofo.PageSizeA4()))
+	}
+
+	return doc
+}
+
+// newSizedDocument creates the document of the pages of the widths.
+func newSizedDocument(t *testing.T, widths ...int) *podofo.MemDocument {
+	t.Helper()
+
+	doc := podofo.NewMemDocument()
+
+	for _, width := range widths {
+		_, err := doc.PageCollection().AddPage(pageSize(width))
+		require.NoError(t, err)
+	}
+
+	return doc
+}
+
+func TestPageCollectionAddPageAt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		indexes []int
+		want    []int
+	}{
+		{name: "append", indexes: []int{0, 1, 2}, want: []int{1, 2, 3}},
+		{name: "prepend", indexes: []int{0, 0, 0}, want: []int{3, 2, 1}},
+		{name: "middle", indexes: []int{0, 1, 1, 2}, want: []int{1, 3, 4, 2}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pc := podofo.NewMemDocument().PageCollection()
+
+			for i, index := range tt.indexes {
+				page, err := pc.AddPageAt(index, pageSize(i+1))
+				require.NoError(t, err)
+				assert.Equal(t, index, page.Index())
+			}
+
+			assert.Equal(t, tt.want, pageWidths(t, pc))
+		})
+	}
+}
+
+func TestPageCollectionRemovePage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		indexes []int
+		want    []int
+	}{
+		{name: "first", indexes: []int{0}, want: []int{2, 3, 4}},
+		{name: "last", indexes: []int{3}, want: []int{1, 2, 3}},
+		{name: "all", indexes: []int{1, 0, 1, 0}, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pc := newSizedDocument(t, 1, 2, 3, 4).PageCollection()
+
+			for _, index := range tt.indexes {
+				require.NoError(t, pc.RemovePage(index))
+			}
+
+			assert.Equal(t, tt.want, pageWidths(t, pc))
+		})
+	}
+}
+
+func TestPageCollectionOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	pc := newSizedDocument(t, 1, 2).PageCollection()
+
+	_, err := pc.AddPageAt(3, pageSize(3))
+	assert.ErrorIs(t, err, podofo.ErrValueOutOfRange)
+
+	_, err = pc.Index(2)
+	assert.ErrorIs(t, err, podofo.ErrPageNotFound)
+
+	assert.ErrorIs(t, pc.RemovePage(-1), podofo.ErrPageNotFound)
+}
+
+func TestPageCollectionManyPages(t *testing.T) {
+	t.Parallel()
+
+	const numPages = 20000
+
+	widths := make([]int, numPages)
+	for i := range widths {
+		widths[i] = i + 1
+	}
+
+	doc := newSizedDocument(t, widths...)
+
+	loaded, err := podofo.LoadMemDocument(bytes.NewReader(saveDocument(t, doc)))
+	require.NoError(t, err)
+	assert.Equal(t, widths, pageWidths(t, loaded.PageCollection()))
+}
@@ -0,0 +1,867 @@
+package podofo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	"github.com/denisss025/go-podofo/internal/set"
+)
+
+// AppendDocumentPages appends count pages of the source document
+// starting from the page at pageIndex, see InsertDocumentPages.
+func (pc *PageCollection) AppendDocumentPages(src *MemDocument, pageIndex, count int) error {
+	return pc.InsertDocumentPages(pc.Count(), src, pageIndex, count)
+}
+
+// InsertDocumentPages inserts count pages of the source document
+// starting from the page at pageIndex before the page at index.
+//
+// The pages are copied with all the objects they refer to. The fonts
+// and the images identical to the ones of the document are shared.
+// The outline items, the named destinations and the form fields of
+// the source pointing at the pages are copied as well, the copied
+// fields are renamed if the document has fields of the same names.
+func (pc *PageCollection) InsertDocumentPages(index int, src *MemDocument, pageIndex, count int) error {
+	return pc.insertPages(index, src.PageCollection(), pageIndex, count)
+}
+
+// insertPages inserts the pages of the source page tree, see
+// InsertDocumentPages.
+func (pc *PageCollection) insertPages(index int, srcPages *PageCollection, pageIndex, count int) error {
+	switch {
+	case index < 0 || index > pc.Count():
+		return fmt.Errorf("insert document pages at %d: %w", index, ErrValueOutOfRange)
+	case pageIndex < 0 || count < 0 || pageIndex+count > srcPages.Count():
+		return fmt.Errorf("insert document pages %d-%d: %w", pageIndex, pageIndex+count, ErrValueOutOfRange)
+	}
+
+	c := newPageCopier(srcPages, pc)
+
+	pages := make([]*Page, count)
+
+	for i := range pages {
+		page, err := srcPages.Index(pageIndex + i)
+		if err != nil {
+			return fmt.Errorf("insert document pages: %w", err)
+		}
+
+		pages[i] = page
+		c.copied.Put(*page.ref)
+	}
+
+	copies := make([]*Dictionary, count)
+
+	for i, page := range pages {
+		dict, err := c.copyPage(page)
+		if err != nil {
+			return fmt.Errorf("insert document pages: %w", err)
+		}
+
+		copies[i] = dict
+	}
+
+	c.run()
+
+	for i, dict := range copies {
+		if _, err := pc.insertPage(index+i, dict); err != nil {
+			return fmt.Errorf("insert document pages: %w", err)
+		}
+	}
+
+	c.copyOutlines()
+	c.copyDestinations()
+	c.copyFields(pages)
+	c.run()
+
+	return nil
+}
+
+// pageCopier deep-copies the objects of the pages of the source page
+// tree to the target one. The references of the source are mapped to
+// the ones of the copies, so each object is copied once.
+type pageCopier struct {
+	src, dst *PageCollection
+
+	// refs maps the source objects to their copies.
+	refs map[Reference]*Reference
+	// copied are the source pages being copied, tree are the
+	// pages and the nodes of the source page tree.
+	copied *set.Set[Reference]
+	tree   *set.Set[Reference]
+	// known are the fonts and the images of the target by their
+	// fingerprints, it is filled when the first one is copied.
+	known map[[sha256.Size]byte]*Reference
+	// names are the named destinations of the source.
+	names map[string]Object
+
+	queue []copyJob
+}
+
+// copyJob is a copied object whose values are to be copied.
+type copyJob struct {
+	key      pdf.Name
+	src, dst Object
+}
+
+// newPageCopier creates the copier of the pages from src to dst.
+func newPageCopier(src, dst *PageCollection) *pageCopier {
+	c := &pageCopier{
+		src:    src,
+		dst:    dst,
+		refs:   make(map[Reference]*Reference),
+		copied: set.New[Reference](),
+		tree:   set.New[Reference](),
+		names:  readDestinations(src.objects, src.catalog),
+	}
+
+	pages, nodes := src.objects.pageTreeFrom(src.root)
+
+	for _, refs := range [][]Reference{pages, nodes} {
+		for _, ref := range refs {
+			c.tree.Put(ref)
+		}
+	}
+
+	return c
+}
+
+// copyPage creates the copy of the page without its /Parent. The
+// inherited attributes are copied to the page, as the ancestors of
+// the page are not.
+func (c *pageCopier) copyPage(page *Page) (*Dictionary, error) {
+	dict := NewDictionary()
+
+	ref, err := c.dst.objects.CreateObject(dict)
+	if err != nil {
+		return nil, fmt.Errorf("copy page %d: %w", page.index, err)
+	}
+
+	c.refs[*page.ref] = ref
+
+	for _, key := range page.dict.sortedKeys() {
+		if key != pdf.KeyParent {
+			dict.AddKey(key, c.copyValue(key, page.dict.Key(key)))
+		}
+	}
+
+	for _, key := range inheritableKeys {
+		if dict.Key(key) != nil {
+			continue
+		}
+
+		if value := c.src.inheritedAttribute(page.dict, key); value != nil {
+			dict.AddKey(key, c.copyValue(key, value))
+		}
+	}
+
+	return dict, nil
+}
+
+// run copies the values of the objects copied so far.
+func (c *pageCopier) run() {
+	for len(c.queue) > 0 {
+		job := c.queue[0]
+		c.queue = c.queue[1:]
+
+		c.fill(job.key, job.src, job.dst)
+	}
+}
+
+// copyValue returns the copy of the value of the key. The direct
+// values are copied at once, the indirect ones are queued.
+func (c *pageCopier) copyValue(key pdf.Name, value Object) Object {
+	switch value := value.(type) {
+	case *Reference:
+		return c.copyIndirect(key, value)
+	case *Dictionary:
+		dict := NewDictionary()
+		c.fill(key, value, dict)
+
+		return dict
+	case *Array:
+		array := &Array{}
+		c.fill(key, value, array)
+
+		return array
+	default:
+		return value
+	}
+}
+
+// fill copies the values of the source dictionary or array to dst.
+// The items of /Kids, /Annots and /Fields referring to the objects
+// that are not copied are dropped.
+func (c *pageCopier) fill(key pdf.Name, src, dst Object) {
+	switch src := src.(type) {
+	case *Dictionary:
+		dict := dst.(*Dictionary)
+
+		for _, name := range src.sortedKeys() {
+			dict.AddKey(name, c.copyValue(name, src.Key(name)))
+		}
+	case *Array:
+		array := dst.(*Array)
+		items := make([]Object, 0, len(src.objects))
+
+		for _, item := range src.objects {
+			copied := c.copyValue(key, item)
+
+			if _, isRef := item.(*Reference); isRef && droppedItems(key) {
+				if _, isNull := copied.(Null); isNull {
+					continue
+				}
+			}
+
+			items = append(items, copied)
+		}
+
+		array.objects = items
+		array.SetDirty()
+	}
+}
+
+// copyIndirect returns the reference to the copy of the object. The
+// indirect numbers, names and strings are copied as direct values.
+func (c *pageCopier) copyIndirect(key pdf.Name, ref *Reference) Object {
+	if target, ok := c.refs[*ref]; ok {
+		return copyReference(target)
+	}
+
+	obj := c.src.objects.GetObject(ref)
+	if obj == nil || c.excluded(ref, obj) {
+		return Null{}
+	}
+
+	var fp [sha256.Size]byte
+
+	dedup := deduplicable(obj)
+	if dedup {
+		fp = fingerprint(c.src.objects, obj)
+
+		if target, ok := c.knownObjects()[fp]; ok {
+			c.refs[*ref] = target
+
+			return copyReference(target)
+		}
+	}
+
+	dict, data, isStream, err := streamData(obj)
+	if err != nil {
+		log.Printf("Cannot copy the stream %s: %v", ref, err)
+
+		return Null{}
+	}
+
+	var target, values, srcValues Object
+
+	if isStream {
+		stream := NewStream(nil, data)
+		target, values, srcValues = stream, stream.Dictionary, dict
+	} else {
+		switch value := c.src.objects.resolve(obj).(type) {
+		case nil:
+			return Null{}
+		case *Dictionary:
+			copied := NewDictionary()
+			target, values, srcValues = copied, copied, value
+		case *Array:
+			copied := &Array{}
+			target, values, srcValues = copied, copied, value
+		default:
+			return c.copyValue(key, value)
+		}
+	}
+
+	targetRef, err := c.dst.objects.CreateObject(target)
+	if err != nil {
+		log.Printf("Cannot copy the object %s: %v", ref, err)
+
+		return Null{}
+	}
+
+	c.refs[*ref] = targetRef
+	c.queue = append(c.queue, copyJob{key: key, src: srcValues, dst: values})
+
+	if dedup {
+		c.known[fp] = targetRef
+	}
+
+	return copyReference(targetRef)
+}
+
+// excluded returns true for the objects not to be copied: the pages
+// that are not copied and the nodes of the source page tree, which
+// would bring the whole page tree along, and the annotations of the
+// pages not copied.
+func (c *pageCopier) excluded(ref *Reference, obj Object) bool {
+	if c.tree.Contains(*ref) {
+		return !c.copied.Contains(*ref)
+	}
+
+	dict := objectDictionary(obj)
+	if dict == nil {
+		return false
+	}
+
+	switch objectType(dict) {
+	case pdf.NamePage, pdf.NamePages:
+		return true
+	}
+
+	page, ok := dict.Key(pdf.KeyP).(*Reference)
+
+	return ok && c.tree.Contains(*page) && !c.copied.Contains(*page)
+}
+
+// knownObjects returns the fonts and the images of the target.
+func (c *pageCopier) knownObjects() map[[sha256.Size]byte]*Reference {
+	if c.known != nil {
+		return c.known
+	}
+
+	c.known = make(map[[sha256.Size]byte]*Reference)
+
+	for _, obj := range c.dst.objects.Objects() {
+		ref := indirectReference(obj)
+		if ref == nil || !deduplicable(obj) {
+			continue
+		}
+
+		fp := fingerprint(c.dst.objects, obj)
+		if _, ok := c.known[fp]; !ok {
+			c.known[fp] = copyReference(ref)
+		}
+	}
+
+	return c.known
+}
+
+// droppedItems returns true if the items of the array of the
+// key referring to the objects that are not copied are dropped.
+func droppedItems(key pdf.Name) bool {
+	switch key {
+	case pdf.KeyKids, pdf.KeyAnnots, pdf.KeyFields:
+		return true
+	default:
+		return false
+	}
+}
+
+// deduplicable returns true for the fonts and the images,
+// the identical ones are not copied twice.
+func deduplicable(obj Object) bool {
+	dict := objectDictionary(obj)
+	if dict == nil {
+		return false
+	}
+
+	if objectType(dict) == pdf.NameFont {
+		return true
+	}
+
+	subtype, ok := dict.Key(pdf.KeySubtype).(*pdf.NameObject)
+
+	return ok && subtype.Name == pdf.NameImage
+}
+
+// fingerprint returns the hash of the object and of the objects it
+// refers to, so the identical objects have the same fingerprints
+// whatever their object numbers are.
+func fingerprint(list *IndirectObjectList, obj Object) [sha256.Size]byte {
+	h := sha256.New()
+	writeFingerprint(h, list, obj, set.New[Reference]())
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+
+	return sum
+}
+
+// writeFingerprint writes the canonical form of the object to w. The
+// objects being written are not followed, so loops are not followed.
+func writeFingerprint(w io.Writer, list *IndirectObjectList, obj Object, visiting *set.Set[Reference]) {
+	if ref, ok := obj.(*Reference); ok {
+		if visiting.Contains(*ref) {
+			_, _ = io.WriteString(w, "R")
+
+			return
+		}
+
+		visiting.Put(*ref)
+		defer visiting.Remove(*ref)
+
+		obj = list.GetObject(ref)
+	}
+
+	if dict, data, isStream, err := streamData(obj); err == nil && isStream {
+		writeFingerprint(w, list, dict, visiting)
+		_, _ = fmt.Fprintf(w, "stream %d ", len(data))
+		_, _ = w.Write(data)
+
+		return
+	}
+
+	switch value := list.resolve(obj).(type) {
+	case nil:
+		_, _ = io.WriteString(w, "null ")
+	case *Dictionary:
+		_, _ = io.WriteString(w, "<<")
+
+		for _, key := range value.sortedKeys() {
+			_, _ = fmt.Fprintf(w, "/%s ", strconv.Quote(string(key)))
+			writeFingerprint(w, list, value.Key(key), visiting)
+		}
+
+		_, _ = io.WriteString(w, ">>")
+	case *Array:
+		_, _ = io.WriteString(w, "[")
+
+		for _, item := range value.objects {
+			writeFingerprint(w, list, item, visiting)
+		}
+
+		_, _ = io.WriteString(w, "]")
+	default:
+		_ = value.MarshalPDF(pdf.NewWriter(w))
+		_, _ = io.WriteString(w, " ")
+	}
+}
+
+// readDestinations returns the named destinations of the catalog,
+// both the /Dests dictionary and the /Dests name tree.
+func readDestinations(list *IndirectObjectList, catalog *Dictionary) map[string]Object {
+	dests := make(map[string]Object)
+
+	if dict := objectDictionary(list.resolve(catalog.Key(pdf.KeyDests))); dict != nil {
+		for _, key := range dict.sortedKeys() {
+			dests[string(key)] = dict.Key(key)
+		}
+	}
+
+	names := objectDictionary(list.resolve(catalog.Key(pdf.KeyNames)))
+	if names != nil {
+		readNameTree(list, names.Key(pdf.KeyDests), func(name string, value Object) {
+			dests[name] = value
+		})
+	}
+
+	return dests
+}
+
+// readNameTree calls visit for each entry of the name tree.
+func readNameTree(list *IndirectObjectList, root Object, visit func(name string, value Object)) {
+	visited := set.New[Reference]()
+
+	var walk func(node Object)
+
+	walk = func(node Object) {
+		if ref, ok := node.(*Reference); ok {
+			if visited.Contains(*ref) {
+				return
+			}
+
+			visited.Put(*ref)
+		}
+
+		dict := objectDictionary(list.resolve(node))
+		if dict == nil {
+			return
+		}
+
+		if names, ok := list.resolve(dict.Key(pdf.KeyNames)).(*Array); ok {
+			for i := 0; i+1 < len(names.objects); i += 2 {
+				if name, ok := list.resolve(names.objects[i]).(*String); ok {
+					visit(string(name.RawData()), names.objects[i+1])
+				}
+			}
+		}
+
+		if kids, ok := list.resolve(dict.Key(pdf.KeyKids)).(*Array); ok {
+			for _, kid := range kids.objects {
+				walk(kid)
+			}
+		}
+	}
+
+	walk(root)
+}
+
+// destinationPage returns the page the destination refers to, the
+// named destinations are looked up in the names. Nil is returned if
+// the destination refers to no page.
+func destinationPage(list *IndirectObjectList, names map[string]Object, dest Object) *Reference {
+	switch name := list.resolve(dest).(type) {
+	case *String:
+		dest = names[string(name.RawData())]
+	case *pdf.NameObject:
+		dest = names[string(name.Name)]
+	}
+
+	dest = list.resolve(dest)
+	if dict := objectDictionary(dest); dict != nil {
+		dest = list.resolve(dict.Key(pdf.KeyD))
+	}
+
+	array, ok := dest.(*Array)
+	if !ok || len(array.objects) == 0 {
+		return nil
+	}
+
+	page, _ := array.objects[0].(*Reference)
+
+	return page
+}
+
+// itemDestination returns the destination of the outline item, either
+// the /Dest or the destination of the /GoTo action of the item.
+func itemDestination(list *IndirectObjectList, item *Dictionary) Object {
+	if dest := item.Key(pdf.KeyDest); dest != nil {
+		return dest
+	}
+
+	action := objectDictionary(list.resolve(item.Key(pdf.KeyA)))
+	if action == nil {
+		return nil
+	}
+
+	if s, ok := action.Key(pdf.KeyS).(*pdf.NameObject); !ok || s.Name != pdf.NameGoTo {
+		return nil
+	}
+
+	return action.Key(pdf.KeyD)
+}
+
+// pointsAtCopied returns true if the destination refers to a copied page.
+func (c *pageCopier) pointsAtCopied(dest Object) bool {
+	page := destinationPage(c.src.objects, c.names, dest)
+
+	return page != nil && c.copied.Contains(*page)
+}
+
+// copyOutlines appends the outline items of the source pointing at
+// the copied pages to the outline of the target. The items having
+// such descendants are copied as well to keep the hierarchy.
+func (c *pageCopier) copyOutlines() {
+	outlines := objectDictionary(c.src.objects.resolve(c.src.catalog.Key(pdf.KeyOutlines)))
+	if outlines == nil {
+		return
+	}
+
+	first, _ := outlines.Key(pdf.KeyFirst).(*Reference)
+	items := c.copyOutlineItems(first, set.New[Reference]())
+
+	if len(items) == 0 {
+		return
+	}
+
+	rootRef, _ := c.dst.catalog.Key(pdf.KeyOutlines).(*Reference)
+
+	var root *Dictionary
+	if rootRef != nil {
+		root = objectDictionary(c.dst.objects.GetObject(rootRef))
+	}
+
+	if root == nil {
+		root = c.dst.objects.CreateDictionaryObject(pdf.NameOutlines)
+		rootRef = copyReference(root.GetIndirectReference())
+		c.dst.catalog.AddKey(pdf.KeyOutlines, copyReference(rootRef))
+	}
+
+	c.appendOutlineItems(rootRef, root, items)
+
+	count := root.Int(pdf.KeyCount, 0)
+	if count < 0 {
+		count = -count
+	}
+
+	root.AddKey(pdf.KeyCount, pdf.NewInt(count+int64(len(items))))
+}
+
+// copyOutlineItems copies the item and its siblings. The copied items
+// are closed, so their /Count is the negated number of their kids.
+func (c *pageCopier) copyOutlineItems(first *Reference, visited *set.Set[Reference]) []*Dictionary {
+	var items []*Dictionary
+
+	for ref := first; ref != nil && !visited.Contains(*ref); {
+		visited.Put(*ref)
+
+		item := objectDictionary(c.src.objects.GetObject(ref))
+		if item == nil {
+			break
+		}
+
+		kidsFirst, _ := item.Key(pdf.KeyFirst).(*Reference)
+		kids := c.copyOutlineItems(kidsFirst, visited)
+		dest := itemDestination(c.src.objects, item)
+		points := c.pointsAtCopied(dest)
+
+		if points || len(kids) > 0 {
+			copied := c.dst.objects.CreateDictionaryObject(pdf.KeyNull)
+
+			keys := []pdf.Name{pdf.KeyTitle, pdf.KeyC, pdf.KeyF}
+			if points {
+				keys = append(keys, pdf.KeyDest, pdf.KeyA)
+			}
+
+			for _, key := range keys {
+				if value := item.Key(key); value != nil {
+					copied.AddKey(key, c.copyValue(key, value))
+				}
+			}
+
+			if len(kids) > 0 {
+				c.appendOutlineItems(copied.GetIndirectReference(), copied, kids)
+				copied.AddKey(pdf.KeyCount, pdf.NewInt(-int64(len(kids))))
+			}
+
+			items = append(items, copied)
+		}
+
+		ref, _ = item.Key(pdf.KeyNext).(*Reference)
+	}
+
+	return items
+}
+
+// appendOutlineItems links the items as the last kids of the parent.
+func (c *pageCopier) appendOutlineItems(parentRef *Reference, parent *Dictionary, items []*Dictionary) {
+	prev, _ := parent.Key(pdf.KeyLast).(*Reference)
+
+	for _, item := range items {
+		ref := item.GetIndirectReference()
+
+		item.AddKey(pdf.KeyParent, copyReference(parentRef))
+
+		if prev == nil {
+			parent.AddKey(pdf.KeyFirst, copyReference(ref))
+		} else {
+			item.AddKey(pdf.KeyPrev, copyReference(prev))
+
+			if prevItem := objectDictionary(c.dst.objects.GetObject(prev)); prevItem != nil {
+				prevItem.AddKey(pdf.KeyNext, copyReference(ref))
+			}
+		}
+
+		prev = ref
+	}
+
+	parent.AddKey(pdf.KeyLast, copyReference(prev))
+}
+
+// copyDestinations adds the named destinations of the source pointing
+// at the copied pages to the /Dests name tree of the target. The tree
+// is rewritten as a single node. The destinations of the target are
+// kept if the names are taken.
+func (c *pageCopier) copyDestinations() {
+	var added []string
+
+	for name, dest := range c.names {
+		if c.pointsAtCopied(dest) {
+			added = append(added, name)
+		}
+	}
+
+	sort.Strings(added)
+
+	if len(added) == 0 {
+		return
+	}
+
+	list := c.dst.objects
+
+	names := objectDictionary(list.resolve(c.dst.catalog.Key(pdf.KeyNames)))
+	if names == nil {
+		names = NewDictionary()
+		c.dst.catalog.AddKey(pdf.KeyNames, names)
+	}
+
+	entries := make(map[string]Object)
+	readNameTree(list, names.Key(pdf.KeyDests), func(name string, value Object) {
+		entries[name] = value
+	})
+
+	for _, name := range added {
+		if _, taken := entries[name]; taken {
+			log.Printf("Destination %q is taken, the copied one is dropped", name)
+
+			continue
+		}
+
+		entries[name] = c.copyValue(pdf.KeyD, c.names[name])
+	}
+
+	keys := make([]string, 0, len(entries))
+	for name := range entries {
+		keys = append(keys, name)
+	}
+
+	sort.Strings(keys)
+
+	tree := &Array{objects: make([]Object, 0, 2*len(keys))}
+	for _, name := range keys {
+		tree.objects = append(tree.objects, newRawString([]byte(name), false), entries[name])
+	}
+
+	root := objectDictionary(list.resolve(names.Key(pdf.KeyDests)))
+	if root == nil {
+		root = list.CreateDictionaryObject(pdf.KeyNull)
+		names.AddKey(pdf.KeyDests, copyReference(root.GetIndirectReference()))
+	}
+
+	root.RemoveKey(pdf.KeyKids)
+	root.RemoveKey(pdf.KeyLimits)
+	root.AddKey(pdf.KeyNames, tree)
+}
+
+// copyFields adds the top-level form fields of the widgets of the
+// copied pages to the /AcroForm of the target. A field is renamed
+// if the target has a top-level field of the same name.
+func (c *pageCopier) copyFields(pages []*Page) {
+	list := c.src.objects
+
+	var fields []*Reference
+
+	found := set.New[Reference]()
+
+	for _, page := range pages {
+		annots, _ := list.resolve(page.dict.Key(pdf.KeyAnnots)).(*Array)
+		if annots == nil {
+			continue
+		}
+
+		for _, annot := range annots.objects {
+			field, ok := annot.(*Reference)
+			if !ok {
+				continue
+			}
+
+			dict := objectDictionary(list.GetObject(field))
+			if dict == nil {
+				continue
+			}
+
+			if subtype, ok := dict.Key(pdf.KeySubtype).(*pdf.NameObject); !ok || subtype.Name != pdf.NameWidget {
+				continue
+			}
+
+			for visited := set.New[Reference](); dict != nil; {
+				visited.Put(*field)
+
+				parent, ok := dict.Key(pdf.KeyParent).(*Reference)
+				if !ok || visited.Contains(*parent) {
+					break
+				}
+
+				field, dict = parent, objectDictionary(list.GetObject(parent))
+			}
+
+			if target, ok := c.refs[*field]; ok && !found.Contains(*target) {
+				found.Put(*target)
+				fields = append(fields, target)
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	acroForm := objectDictionary(c.dst.objects.resolve(c.dst.catalog.Key(pdf.KeyAcroForm)))
+	if acroForm == nil {
+		acroForm = c.dst.objects.CreateDictionaryObject(pdf.KeyNull)
+		c.dst.catalog.AddKey(pdf.KeyAcroForm, copyReference(acroForm.GetIndirectReference()))
+	}
+
+	// The form defaults of the source are used if the target has none.
+	if srcForm := objectDictionary(list.resolve(c.src.catalog.Key(pdf.KeyAcroForm))); srcForm != nil {
+		for _, key := range []pdf.Name{pdf.KeyDA, pdf.KeyDR} {
+			if acroForm.Key(key) == nil && srcForm.Key(key) != nil {
+				acroForm.AddKey(key, c.copyValue(key, srcForm.Key(key)))
+			}
+		}
+	}
+
+	targetFields, _ := c.dst.objects.resolve(acroForm.Key(pdf.KeyFields)).(*Array)
+	if targetFields == nil {
+		targetFields = &Array{}
+		acroForm.AddKey(pdf.KeyFields, targetFields)
+	}
+
+	taken := set.New[string]()
+
+	for _, field := range targetFields.objects {
+		if name, ok := fieldName(c.dst.objects, field); ok {
+			taken.Put(string(name))
+		}
+	}
+
+	for _, field := range fields {
+		name, ok := fieldName(c.dst.objects, field)
+		if !ok {
+			continue
+		}
+
+		if taken.Contains(string(name)) {
+			renamed := name
+
+			for n := 2; taken.Contains(string(renamed)); n++ {
+				renamed = appendTextString(name, "_"+strconv.Itoa(n))
+			}
+
+			if dict := objectDictionary(c.dst.objects.GetObject(field)); dict != nil {
+				dict.AddKey(pdf.KeyT, newRawString(renamed, false))
+			}
+
+			name = renamed
+		}
+
+		taken.Put(string(name))
+	}
+
+	for _, field := range fields {
+		targetFields.objects = append(targetFields.objects, copyReference(field))
+	}
+
+	if _, direct := acroForm.Key(pdf.KeyFields).(*Array); direct {
+		acroForm.AddKey(pdf.KeyFields, targetFields)
+	} else {
+		targetFields.SetDirty()
+	}
+}
+
+// fieldName returns the partial name (/T) of the form field.
+func fieldName(list *IndirectObjectList, field Object) ([]byte, bool) {
+	dict := objectDictionary(list.resolve(field))
+	if dict == nil {
+		return nil, false
+	}
+
+	name, ok := list.resolve(dict.Key(pdf.KeyT)).(*String)
+	if !ok {
+		return nil, false
+	}
+
+	return name.RawData(), true
+}
+
+// appendTextString appends the ASCII suffix to the text string,
+// which is either UTF-16BE with the byte order mark or PDFDocEncoded.
+func appendTextString(text []byte, suffix string) []byte {
+	result := append([]byte(nil), text...)
+
+	if !bytes.HasPrefix(text, []byte{0xFE, 0xFF}) {
+		return append(result, suffix...)
+	}
+
+	for i := 0; i < len(suffix); i++ {
+		result = append(result, 0, suffix[i])
+	}
+
+	return result
+}
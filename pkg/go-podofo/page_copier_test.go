@@ -0,0 +1,154 @@
+package podofo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// newSourceDocument loads the document of the pages 100 and 200
+// points wide. The pages use the identical fonts 5 and 8, the first
+// page has the image 7 and the text field 6.
+func newSourceDocument(t *testing.T) *podofo.MemDocument {
+	t.Helper()
+
+	file := buildPDF("1.4", "/Root 1 0 R",
+		"<</Type /Catalog /Pages 2 0 R /AcroForm <</Fields [6 0 R]>>>>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R] /Count 2>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 100 100] "+
+			"/Resources <</Font <</F1 5 0 R>> /XObject <</Im1 7 0 R>>>> /Annots [6 0 R]>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 200 100] /Resources <</Font <</F1 8 0 R>>>>>>",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>",
+		"<</Type /Annot /Subtype /Widget /FT /Tx /T (name) /P 3 0 R /Rect [0 0 10 10]>>",
+		"<</Type /XObject /Subtype /Image /Width 1 /Height 1 /ColorSpace /DeviceGray "+
+			"/BitsPerComponent 8 /Length 1>>\nstream\nA\nendstream",
+		"<</Type /Font /Subtype /Type1 /BaseFont /Helvetica>>")
+
+	doc, err := podofo.LoadMemDocument(bytes.NewReader(file))
+	require.NoError(t, err)
+
+	return doc
+}
+
+func TestPageCollectionInsertDocumentPages(t *testing.T) {
+	t.Parallel()
+
+	type insert struct {
+		index, pageIndex, count int
+	}
+
+	tests := []struct {
+		name    string
+		widths  []int
+		inserts []insert
+		want    []int
+		images  int
+		fields  []string
+	}{
+		{
+			name:    "append",
+			inserts: []insert{{index: 0, pageIndex: 0, count: 2}},
+			want:    []int{100, 200},
+			images:  1,
+			fields:  []string{"(name)"},
+		},
+		{
+			name:    "prepend",
+			widths:  []int{300},
+			inserts: []insert{{index: 0, pageIndex: 1, count: 1}},
+			want:    []int{200, 300},
+		},
+		{
+			name:    "middle",
+			widths:  []int{300, 400},
+			inserts: []insert{{index: 1, pageIndex: 0, count: 2}},
+			want:    []int{300, 100, 200, 400},
+			images:  1,
+			fields:  []string{"(name)"},
+		},
+		{
+			name: "twice",
+			inserts: []insert{
+				{index: 0, pageIndex: 0, count: 1},
+				{index: 1, pageIndex: 0, count: 2},
+			},
+			want:   []int{100, 100, 200},
+			images: 1,
+			fields: []string{"(name)", "(name_2)"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := newSourceDocument(t)
+			doc := newSizedDocument(t, tt.widths...)
+			pc := doc.PageCollection()
+
+			for _, insert := range tt.inserts {
+				require.NoError(t, pc.InsertDocumentPages(insert.index, src, insert.pageIndex, insert.count))
+			}
+
+			assert.Equal(t, tt.want, pageWidths(t, pc))
+
+			file := saveDocument(t, doc, podofo.SaveOptionNoFlateCompress)
+
+			// The identical fonts and images are copied once.
+			assert.Equal(t, 1, bytes.Count(file, []byte("/Helvetica")))
+			assert.Equal(t, tt.images, bytes.Count(file, []byte("/Image")))
+
+			for _, field := range tt.fields {
+				assert.Equal(t, 1, bytes.Count(file, []byte(field)), field)
+			}
+
+			loaded, err := podofo.LoadMemDocument(bytes.NewReader(file))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pageWidths(t, loaded.PageCollection()))
+		})
+	}
+}
+
+func TestPageCollectionAppendDocumentPages(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		pageIndex int
+		count     int
+		want      []int
+		wantErr   error
+	}{
+		{name: "all", count: 2, want: []int{300, 100, 200}},
+		{name: "last", pageIndex: 1, count: 1, want: []int{300, 200}},
+		{name: "none", want: []int{300}},
+		{name: "too many", count: 3, wantErr: podofo.ErrValueOutOfRange},
+		{name: "negative index", pageIndex: -1, count: 1, wantErr: podofo.ErrValueOutOfRange},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := newSizedDocument(t, 300)
+
+			err := doc.PageCollection().AppendDocumentPages(newSourceDocument(t), tt.pageIndex, tt.count)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pageWidths(t, doc.PageCollection()))
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package podofo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// loadPage loads the page of the single-page document with the page
+// attributes and the attributes inherited from the page tree.
+func loadPage(t *testing.T, inherited, attributes string) *podofo.Page {
+	t.Helper()
+
+	file := buildPDF("1.4", "/Root 1 0 R",
+		"<</Type /Catalog /Pages 2 0 R>>",
+		"<</Type /Pages /Kids [3 0 R] /Count 1 "+inherited+">>",
+		"<</Type /Page /Parent 2 0 R "+attributes+">>")
+
+	doc, err := podofo.LoadMemDocument(bytes.NewReader(file))
+	require.NoError(t, err)
+
+	page, err := doc.PageCollection().Index(0)
+	require.NoError(t, err)
+
+	return page
+}
+
+// rect returns the rectangle of the lower-left corner and the size.
+func rect(x, y, width, height float64) podofo.Rect {
+	return podofo.Rect{Pos: pdf.Pos{X: x, Y: y}, Size: pdf.Size{Width: width, Height: height}}
+}
+
+func TestPageBox(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		inherited  string
+		attributes string
+		want       [5]podofo.Rect
+	}{
+		{
+			name: "letter",
+			want: [5]podofo.Rect{
+				rect(0, 0, 612, 792), rect(0, 0, 612, 792), rect(0, 0, 612, 792),
+				rect(0, 0, 612, 792), rect(0, 0, 612, 792),
+			},
+		},
+		{
+			name:      "inherited",
+			inherited: "/MediaBox [0 0 200 300] /CropBox [10 10 110 110]",
+			want: [5]podofo.Rect{
+				rect(0, 0, 200, 300), rect(10, 10, 100, 100), rect(10, 10, 100, 100),
+				rect(10, 10, 100, 100), rect(10, 10, 100, 100),
+			},
+		},
+		{
+			name:       "overridden",
+			inherited:  "/MediaBox [0 0 200 300]",
+			attributes: "/MediaBox [0 0 100 100] /TrimBox [5 5 50 50]",
+			want: [5]podofo.Rect{
+				rect(0, 0, 100, 100), rect(0, 0, 100, 100), rect(0, 0, 100, 100),
+				rect(5, 5, 45, 45), rect(0, 0, 100, 100),
+			},
+		},
+		{
+			name:       "intersected",
+			attributes: "/MediaBox [0 0 50 200] /CropBox [10 10 100 100] /BleedBox [60 0 100 10]",
+			want: [5]podofo.Rect{
+				rect(0, 0, 50, 200), rect(10, 10, 40, 90), rect(60, 0, 0, 10),
+				rect(10, 10, 40, 90), rect(10, 10, 40, 90),
+			},
+		},
+		{
+			name:       "reversed corners",
+			attributes: "/MediaBox [100 200 0 0]",
+			want: [5]podofo.Rect{
+				rect(0, 0, 100, 200), rect(0, 0, 100, 200), rect(0, 0, 100, 200),
+				rect(0, 0, 100, 200), rect(0, 0, 100, 200),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page := loadPage(t, tt.inherited, tt.attributes)
+
+			boxes := []podofo.PageBox{
+				podofo.PageBoxMedia, podofo.PageBoxCrop, podofo.PageBoxBleed,
+				podofo.PageBoxTrim, podofo.PageBoxArt,
+			}
+
+			for i, box := range boxes {
+				assert.Equal(t, tt.want[i], page.Box(box), "box %d", box)
+			}
+
+			assert.Equal(t, tt.want[0], page.MediaBox())
+			assert.Equal(t, tt.want[1], page.CropBox())
+			assert.Equal(t, tt.want[2], page.BleedBox())
+			assert.Equal(t, tt.want[3], page.TrimBox())
+			assert.Equal(t, tt.want[4], page.ArtBox())
+		})
+	}
+}
+
+func TestPageSetBox(t *testing.T) {
+	t.Parallel()
+
+	page := loadPage(t, "/MediaBox [0 0 200 300] /CropBox [0 0 100 100]", "")
+
+	page.SetCropBox(rect(10, 20, 30, 40))
+	page.SetArtBox(rect(0, 0, 500, 500))
+
+	assert.Equal(t, rect(0, 0, 200, 300), page.MediaBox())
+	assert.Equal(t, rect(10, 20, 30, 40), page.CropBox())
+	assert.Equal(t, rect(0, 0, 200, 300), page.ArtBox())
+
+	page.SetMediaBox(rect(0, 0, 20, 30))
+
+	assert.Equal(t, rect(10, 20, 10, 10), page.CropBox())
+}
+
+func TestPageRotation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		inherited  string
+		attributes string
+		want       int
+	}{
+		{name: "none"},
+		{name: "page", attributes: "/Rotate 90", want: 90},
+		{name: "inherited", inherited: "/Rotate 180", want: 180},
+		{name: "overridden", inherited: "/Rotate 180", attributes: "/Rotate 0"},
+		{name: "negative", attributes: "/Rotate -90", want: 270},
+		{name: "full turns", attributes: "/Rotate 450", want: 90},
+		{name: "not a multiple of 90", attributes: "/Rotate 45"},
+		{name: "not a number", attributes: "/Rotate /R90"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, loadPage(t, tt.inherited, tt.attributes).Rotation())
+		})
+	}
+}
+
+func TestPageSetRotation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		degrees int
+		want    int
+		wantErr error
+	}{
+		{name: "quarter", degrees: 90, want: 90},
+		{name: "negative", degrees: -90, want: 270},
+		{name: "full turns", degrees: 720},
+		{name: "invalid", degrees: 45, want: 180, wantErr: podofo.ErrValueOutOfRange},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page := loadPage(t, "", "/Rotate 180")
+
+			err := page.SetRotation(tt.degrees)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, page.Rotation())
+		})
+	}
+}
+
+func TestPageUserUnit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		attributes string
+		unit       float64
+		want       float64
+		wantErr    error
+	}{
+		{name: "default", want: 1},
+		{name: "page", attributes: "/UserUnit 2", want: 2},
+		{name: "invalid", attributes: "/UserUnit 0", want: 1},
+		{name: "set", unit: 2.5, want: 2.5},
+		{name: "set invalid", attributes: "/UserUnit 3", unit: -1, want: 3, wantErr: podofo.ErrValueOutOfRange},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page := loadPage(t, "", tt.attributes)
+
+			if tt.unit != 0 {
+				err := page.SetUserUnit(tt.unit)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+
+			assert.Equal(t, tt.want, page.UserUnit())
+		})
+	}
+}
+
+func TestPageVisibleRect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		rotate string
+		want   podofo.Rect
+		matrix podofo.Matrix2D
+	}{
+		{name: "upright", want: rect(10, 20, 200, 100), matrix: podofo.Matrix2D{1, 0, 0, 1, 0, 0}},
+		{name: "90", rotate: "/Rotate 90", want: rect(20, -210, 100, 200), matrix: podofo.Matrix2D{0, 1, -1, 0, 0, 0}},
+		{name: "180", rotate: "/Rotate 180", want: rect(-210, -120, 200, 100), matrix: podofo.Matrix2D{-1, 0, 0, -1, 0, 0}},
+		{name: "270", rotate: "/Rotate 270", want: rect(-120, 10, 100, 200), matrix: podofo.Matrix2D{0, -1, 1, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			page := loadPage(t, "", "/MediaBox [10 20 210 120] "+tt.rotate)
+
+			assert.Equal(t, tt.want, page.VisibleRect())
+			assert.Equal(t, tt.matrix, page.RotationMatrix())
+
+			// The corners of the visible rectangle are
+			// transformed to the ones of the crop box.
+			m, visible, crop := tt.matrix, tt.want, page.CropBox()
+			x, y := visible.X, visible.Y
+			cx, cy := m[0]*x+m[2]*y+m[4], m[1]*x+m[3]*y+m[5]
+			x, y = visible.X+visible.Width, visible.Y+visible.Height
+			dx, dy := m[0]*x+m[2]*y+m[4], m[1]*x+m[3]*y+m[5]
+
+			assert.ElementsMatch(t, []float64{crop.X, crop.X + crop.Width}, []float64{cx, dx})
+			assert.ElementsMatch(t, []float64{crop.Y, crop.Y + crop.Height}, []float64{cy, dy})
+		})
+	}
+}
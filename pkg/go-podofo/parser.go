@@ -1,11 +1,15 @@
 package podofo
 
 import (
+	"bufio"
 	"bytes"
+	"crypto"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"strconv"
 
 	"github.com/denisss025/go-podofo/internal/pdf"
 	"github.com/denisss025/go-podofo/internal/set"
@@ -17,6 +21,10 @@ const (
 	xrefEntrySize       = 20
 	xrefBuf             = 512
 	maxXRefSessionCount = 512
+
+	// maxObjectCount is the maximum number of indirect objects
+	// in a PDF file (see ISO 32000-1:2008 Annex C.2).
+	maxObjectCount = 8388607
 )
 
 type Reader interface {
@@ -28,6 +36,9 @@ type Reader interface {
 // Writer. Most PDF features are supported.
 type Parser struct {
 	visitedXRefOffsets *set.Set[int64]
+	// objectStreams holds the object streams being read,
+	// to detect /Extends loops (CVE-2021-30470).
+	objectStreams *set.Set[int]
 
 	tokenizer *Tokenizer
 	entries   []XRefEntry
@@ -36,6 +47,10 @@ type Parser struct {
 	encrypt   *Encrypt
 
 	password string
+	// certificate and privateKey are the recipient
+	// of the public-key security handler.
+	certificate *x509.Certificate
+	privateKey  crypto.Decrypter
 
 	magicOffset   int64
 	fileSize      int64
@@ -44,12 +59,25 @@ type Parser struct {
 
 	incUpdatesCount int
 
+	// revisions are the revisions of the file, the revision
+	// is the one the document is opened as of, -1 for the last.
+	revisions []Revision
+	revision  int
+
 	pdfVersion PDFVersion
 
 	loadOnDemand  bool
 	strictParsing bool
 	ignoreBroken  bool
 	hasXrefStream bool
+
+	recoverBrokenXRef bool
+
+	// linearization are the parameters of the linearized
+	// file, hintTablesErr tells why its hint tables are
+	// not valid.
+	linearization *Linearization
+	hintTablesErr error
 }
 
 // Parse opens a PDF file and parses it.
@@ -66,6 +94,7 @@ func Parse(
 		objects:            new(IndirectObjectList),
 		trailer:            new(ParserObject),
 		visitedXRefOffsets: set.New[int64](),
+		objectStreams:      set.New[int](),
 	}
 
 	p.reset()
@@ -88,11 +117,12 @@ func Parse(
 	}
 
 	if err = p.readObjects(r); err != nil {
-		p = nil
-		err = fmt.Errorf("pdf parse: %w", err)
+		return nil, fmt.Errorf("pdf parse: %w", err)
 	}
 
-	return p, err
+	p.readLinearization()
+
+	return p, nil
 }
 
 // IsEncrypted returns true if the PDF file is encrypted.
@@ -121,7 +151,7 @@ func (p *Parser) Password() string { return p.password }
 
 // NumIncrementalUpdates returns the number of incremental
 // updates that have been applied to the last parsed
-// PDF file.
+// PDF file, up to the revision it is opened as of.
 //
 // The function returs 0 if there is no updade has been
 // applied.
@@ -159,15 +189,14 @@ func (p *Parser) readDocumentStructure(r Reader) (err error) {
 		return fmt.Errorf("read document structure: %w", err)
 	}
 
-	if err = p.checkEOFMarker(r); err != nil {
-		return fmt.Errorf("read document structure: %w", err)
-	}
+	err = p.readXRef(r)
+	if p.recoverBrokenXRef && (err != nil || !p.hasRoot()) {
+		log.Printf("Cannot read the XRef of the file, rebuilding it: %v", err)
 
-	if p.xrefOffset, err = p.findXRef(r); err != nil {
-		return fmt.Errorf("read document structure: %w", err)
+		err = p.rebuildXRef(r)
 	}
 
-	if err = p.readXRefContents(r, p.xrefOffset, false); err != nil {
+	if err != nil {
 		return fmt.Errorf("read document structure: %w", err)
 	}
 
@@ -186,6 +215,30 @@ func (p *Parser) readDocumentStructure(r Reader) (err error) {
 	return nil
 }
 
+// readXRef reads the XRef sections of the file
+// starting from the last one.
+func (p *Parser) readXRef(r Reader) (err error) {
+	if err = p.checkEOFMarker(r); err != nil {
+		return err
+	}
+
+	if p.xrefOffset, err = p.findXRef(r); err != nil {
+		return err
+	}
+
+	if err = p.readXRefContents(r, p.xrefOffset, false); err != nil {
+		return err
+	}
+
+	p.finishRevisions()
+
+	if p.revision < 0 {
+		return nil
+	}
+
+	return p.readRevision(r, p.revision)
+}
+
 func (p *Parser) readXRefContents(r Reader, offset int64, atEnd bool) (err error) {
 	var firstObject, objectCount int64
 
@@ -220,6 +273,8 @@ func (p *Parser) readXRefContents(r Reader, offset int64, atEnd bool) (err error
 		_, _ = r.Seek(offset, io.SeekStart)
 	}
 
+	p.beginRevision(offset)
+
 	token, err := p.tokenizer.TryReadNextToken(r)
 	if err != nil {
 		return fmt.Errorf("read xref contents: read next token: %w", ErrNoXRef)
@@ -246,27 +301,31 @@ func (p *Parser) readXRefContents(r Reader, offset int64, atEnd bool) (err error
 			return fmt.Errorf("read xref contents: %w", ErrNoEOFToken)
 		}
 
+		tokenOffset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("read xref contents: %w", err)
+		}
+
 		token, err := p.tokenizer.TryReadNextToken(r)
 		if err != nil {
 			return fmt.Errorf("read xref contents: %w", ErrNoXRef)
 		}
 
 		if string(token) == "trailer" {
+			// Leave the keyword for readNextTrailer.
+			if _, err = r.Seek(tokenOffset, io.SeekStart); err != nil {
+				return fmt.Errorf("read xref contents: %w", err)
+			}
+
 			break
 		}
 
-		firstObject, err = NewTokenizer().ReadNextNumber(r)
+		firstObject, err = strconv.ParseInt(string(token), 10, 64)
 		if err != nil {
-			if errors.Is(err, ErrNoNumber) ||
-				errors.Is(err, ErrInvalidXRef) ||
-				errors.Is(err, ErrUnexpectedEOF) {
-				break
-			}
-
-			return fmt.Errorf("read xref contents: %w", err)
+			break
 		}
 
-		objectCount, err = NewTokenizer().ReadNextNumber(r)
+		objectCount, err = p.tokenizer.ReadNextNumber(r)
 		if err != nil {
 			if errors.Is(err, ErrNoNumber) ||
 				errors.Is(err, ErrInvalidXRef) ||
@@ -278,7 +337,7 @@ func (p *Parser) readXRefContents(r Reader, offset int64, atEnd bool) (err error
 		}
 
 		if atEnd {
-			_, err = r.Seek(objectCount*xrefEntrySize, io.SeekStart)
+			_, err = r.Seek(objectCount*xrefEntrySize, io.SeekCurrent)
 			if err != nil {
 				return fmt.Errorf("read xref contents: %w", err)
 			}
@@ -294,6 +353,8 @@ func (p *Parser) readXRefContents(r Reader, offset int64, atEnd bool) (err error
 				return fmt.Errorf("read xref contents: %w", err)
 			}
 		}
+
+		xrefSectionCount++
 	}
 
 	if err = p.readNextTrailer(r); err != nil {
@@ -320,15 +381,65 @@ func (p *Parser) readXRefSubsection(r Reader, firstObject int64, objectCount int
 		return fmt.Errorf("%w: object count is negative", ErrValueOutOfRange)
 	}
 
-	p.entries = pdf.ResizeSlice(p.entries, int(firstObject+objectCount))
+	if firstObject+objectCount > maxObjectCount {
+		return fmt.Errorf("%w: object count exceeds %d", ErrValueOutOfRange, maxObjectCount)
+	}
+
+	p.entries = enlargeXRefEntries(p.entries, int(firstObject+objectCount))
+
+	// The entries are read through a buffer, the reader is positioned
+	// right after the last consumed entry when we are done.
+	br := bufio.NewReaderSize(r, BufferSize)
+
+	defer func() {
+		offset, seekErr := r.Seek(0, io.SeekCurrent)
+		if seekErr == nil {
+			_, seekErr = r.Seek(offset-int64(br.Buffered()), io.SeekStart)
+		}
+
+		err = errors.Join(err, seekErr)
+	}()
+
+	var index int64
+
+	revisionEntries := p.revisionEntries()
+
+	for ; index < objectCount; index++ {
+		value, gen, typ, err := pdf.ReadXRefEntry(br)
+		if err != nil {
+			log.Printf("Count of read objects is %d. Expected %d: %v", index, objectCount, err)
+
+			return errors.Join(ErrNoXRef, err)
+		}
+
+		if index == 0 && firstObject == 1 && typ == 'f' && value == 0 && gen == pdf.MaxGeneration {
+			// Some producers start the first subsection with object 1
+			// though its first entry is the head of the free list,
+			// which always belongs to object 0.
+			firstObject = 0
+		}
+
+		listed := XRefEntry{Parsed: true}
 
-	// TODO?
-	// consume all whitespaces
-	//     char ch;
-	//     while (device.Peek(ch) && m_tokenizer.IsWhitespace(ch))
-	//         (void)device.ReadChar();
+		switch typ {
+		case 'f':
+			listed.Entry = XRefEntryFree{ObjectNumber: int(value), Generation: gen}
+		case 'n':
+			listed.Entry = XRefEntryInUse{Offset: int64(value), Generation: gen}
+		default:
+			return ErrInvalidEnumValue
+		}
+
+		recordRevisionEntry(revisionEntries, int(firstObject+index), listed)
 
-	panic("not implemented") // TODO: implement me
+		// Sections are read from the newest to the oldest one, so
+		// a parsed entry has been overridden by an update.
+		if entry := &p.entries[firstObject+index]; !entry.Parsed {
+			*entry = listed
+		}
+	}
+
+	return nil
 }
 
 func (p *Parser) readXRefStreamContents(r Reader, offset int64, trailerOnly bool) error {
@@ -337,11 +448,149 @@ func (p *Parser) readXRefStreamContents(r Reader, offset int64, trailerOnly bool
 		return fmt.Errorf("read xref stream contents: %w", err)
 	}
 
-	panic("not implemented") // TODO: implement me
+	xrefStream, err := NewXRefStreamParser(p.objects.Document(), r, &p.entries)
+	if err == nil {
+		err = xrefStream.Parse()
+	}
+
+	if err != nil {
+		return fmt.Errorf("read xref stream contents: %w", err)
+	}
+
+	if p.trailer == nil {
+		p.trailer = &ParserObject{Dictionary: NewDictionary()}
+	}
+
+	p.mergeTrailer(xrefStream.Dictionary)
+	p.setRevisionTrailer(xrefStream.Dictionary)
+
+	if trailerOnly {
+		return nil
+	}
+
+	xrefStream.listed = p.revisionEntries()
+
+	if err = xrefStream.ParseStream(); err != nil {
+		return fmt.Errorf("read xref stream contents: %w", err)
+	}
+
+	prevOffset := xrefStream.Dictionary.Int(pdf.KeyPrev, -1)
+	if prevOffset < 0 || prevOffset == offset {
+		return nil
+	}
+
+	// PDFs that have been through multiple PDF tools may have a mix of
+	// XRef tables and XRef streams in the /Prev chain, so call
+	// readXRefContents, which deals with both.
+	if err = p.readXRefContents(r, prevOffset, trailerOnly); err != nil {
+		// Be forgiving, the error happens when an entry in XRef
+		// stream points to a wrong place (offset) in the PDF file.
+		if !errors.Is(err, ErrNoObject) {
+			return fmt.Errorf("read xref stream contents: %w", err)
+		}
+
+		log.Printf("Cannot read the previous XRef section at offset %d: %v", prevOffset, err)
+	}
+
+	return nil
+}
+
+// readHybridXRefStream reads the XRef stream the /XRefStm key of
+// a hybrid-reference file trailer points to (see ISO 32000-1:2008,
+// 7.5.8.4). Only the stream entries are used, its dictionary
+// is not merged into the trailer.
+func (p *Parser) readHybridXRefStream(r Reader, offset int64) error {
+	if p.visitedXRefOffsets.Contains(offset) {
+		return fmt.Errorf("read hybrid xref stream: %w: cycle in xref structure: offset %d already visited",
+			ErrInvalidXRef, offset)
+	}
+
+	p.visitedXRefOffsets.Put(offset)
+
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("read hybrid xref stream: %w", err)
+	}
+
+	xrefStream, err := NewXRefStreamParser(p.objects.Document(), r, &p.entries)
+	if err == nil {
+		err = xrefStream.Parse()
+	}
+
+	if err == nil {
+		xrefStream.hybrid = true
+		xrefStream.listed = p.revisionEntries()
+		err = xrefStream.ParseStream()
+	}
+
+	if err != nil {
+		err = fmt.Errorf("read hybrid xref stream: %w", err)
+	}
+
+	return err
 }
 
+// readNextTrailer reads the trailer dictionary that follows
+// a classic XRef section and then the previous XRef section
+// the trailer's /Prev key points to, if any.
 func (p *Parser) readNextTrailer(r Reader) error {
-	panic("not implemented") // TODO: implement me
+	token, err := p.tokenizer.TryReadNextToken(r)
+	if err != nil || string(token) != "trailer" {
+		return errors.Join(fmt.Errorf("read next trailer: %w", ErrNoTrailer), err)
+	}
+
+	// Ignore the encryption in the trailer as the trailer may not be encrypted.
+	trailer, err := NewParserObject(r, -1,
+		WithDocument(p.objects.Document()), AsTrailer())
+	if err == nil {
+		err = trailer.Parse()
+	}
+
+	if err != nil {
+		return errors.Join(fmt.Errorf("read next trailer: %w", ErrNoTrailer), err)
+	}
+
+	if trailer.Dictionary == nil {
+		return fmt.Errorf("read next trailer: %w: not a dictionary", ErrNoTrailer)
+	}
+
+	if p.trailer == nil {
+		p.trailer = trailer
+	} else {
+		p.mergeTrailer(trailer.Dictionary)
+	}
+
+	p.setRevisionTrailer(trailer.Dictionary)
+
+	if xrefStmOffset := trailer.Dictionary.Int(pdf.KeyXRefStm, -1); xrefStmOffset >= 0 {
+		if err = p.readHybridXRefStream(r, xrefStmOffset); err != nil {
+			if p.strictParsing {
+				return fmt.Errorf("read next trailer: %w", err)
+			}
+
+			log.Printf("Unable to load /XRefStm XRef stream at offset %d: %v", xrefStmOffset, err)
+		}
+	}
+
+	prevOffset := trailer.Dictionary.Int(pdf.KeyPrev, -1)
+	if prevOffset < 0 {
+		return nil
+	}
+
+	if p.visitedXRefOffsets.Contains(prevOffset) {
+		log.Printf("XRef contents at offset %d requested twice, skipping the second read", prevOffset)
+
+		return nil
+	}
+
+	if err = p.readXRefContents(r, prevOffset, false); err != nil {
+		if p.strictParsing {
+			return fmt.Errorf("read next trailer: %w", err)
+		}
+
+		log.Printf("Cannot read the previous XRef section at offset %d: %v", prevOffset, err)
+	}
+
+	return nil
 }
 
 func (p *Parser) readObjects(r Reader) (err error) {
@@ -351,8 +600,7 @@ func (p *Parser) readObjects(r Reader) (err error) {
 
 	encrypt := p.trailer.Dictionary.Key(pdf.KeyEncrypt)
 	if encrypt != nil && encrypt.Kind() != pdf.ObjectKindNull {
-		p.encrypt, err = EncryptFromObject(encrypt)
-		if err != nil {
+		if err = p.parseEncrypt(r, encrypt); err != nil {
 			return fmt.Errorf("read objects: %w", err)
 		}
 	}
@@ -384,7 +632,7 @@ func (p *Parser) isPDFFile(r Reader) (ok bool, err error) {
 	}
 
 	p.magicOffset, _ = r.Seek(0, io.SeekCurrent)
-	p.pdfVersion = PDFVersion(buf[:versionLen])
+	p.pdfVersion = PDFVersion(buf[len(pdfStart):])
 
 	return p.pdfVersion.Validate() == nil, nil
 }
@@ -406,12 +654,15 @@ func (p *Parser) findTokenBackward(r Reader, token string, bytesRange int64, sea
 	}
 
 	buffer := p.tokenizer.buffer[:searchSize]
+	if _, err = io.ReadFull(r, buffer); err != nil {
+		return fmt.Errorf("find token backward: %w", err)
+	}
+
 	// search backwards in the buffer in case the buffer contains null bytes
 	// because it is right after a stream (can't use strstr for this reason)
 
 	i := int64(bytes.LastIndex(buffer, []byte(token)))
-	if i <= 0 {
-		// TODO? if (i == 0) ???
+	if i < 0 {
 		return fmt.Errorf("find token backwards: %w", ErrInternalLogic)
 	}
 
@@ -424,10 +675,6 @@ func (p *Parser) findTokenBackward(r Reader, token string, bytesRange int64, sea
 }
 
 func (p *Parser) mergeTrailer(trailer *Dictionary) {
-	if p.trailer == nil {
-		// TODO: create trailer
-	}
-
 	for _, key := range []pdf.Name{
 		pdf.KeySize,
 		pdf.KeyRoot,
@@ -437,7 +684,7 @@ func (p *Parser) mergeTrailer(trailer *Dictionary) {
 	} {
 		obj := trailer.Key(key)
 
-		if p.trailer.Key(key) == nil {
+		if obj != nil && p.trailer.Key(key) == nil {
 			p.trailer.AddKey(key, obj)
 		}
 	}
@@ -486,29 +733,9 @@ func (p *Parser) readObjectsInternal(r Reader) error {
 			switch entry := entry.Entry.(type) {
 			case XRefEntryInUse:
 				if entry.Offset > 0 {
-					ref := pdf.NewReference(i, entry.Generation)
-					obj, err := NewParserObject(r, entry.Offset,
-						WithDocument(p.objects.Document()), WithReference(ref),
-						DelayedLoad(p.loadOnDemand))
-					if err != nil {
-						// TODO: ignore broken objects
+					if err := p.readObject(r, i, entry); err != nil {
 						return fmt.Errorf("read object internal: %w", err)
 					}
-
-					obj.Encrypt = *p.encrypt
-
-					if p.encrypt != nil && obj.Dictionary != nil {
-						typeObj := obj.Dictionary.Key(pdf.KeyType)
-
-						name, ok := typeObj.(*pdf.NameObject)
-						if ok && name.Name == pdf.NameXRef {
-							// TODO: ignore broken objects
-							// XRef is never encrypted
-							panic("not implemented") // TODO: implement me
-						}
-					}
-
-					p.objects.PushObject(obj)
 				} else if entry.Generation == 0 {
 					if p.strictParsing {
 						return fmt.Errorf("read object internal: %w: found object with 0 offset which should be 'f' instead of 'n'", ErrInvalidXRef)
@@ -546,7 +773,17 @@ func (p *Parser) readObjectsInternal(r Reader) error {
 	//
 
 	for objNo, list := range compressedObjects {
-		p.readCompressedObjectFromStream(objNo, list)
+		p.objects.AddObjectStream(objNo)
+
+		if p.loadOnDemand {
+			p.objects.deferObjectStream(objNo, list, p.readCompressedObjectFromStream)
+
+			continue
+		}
+
+		if err := p.readCompressedObjectFromStream(objNo, list); err != nil {
+			return fmt.Errorf("read object internal: %w", err)
+		}
 	}
 
 	if !p.loadOnDemand {
@@ -554,7 +791,20 @@ func (p *Parser) readObjectsInternal(r Reader) error {
 		// run that populates m_Objects because a stream might have a /Length
 		// key that references an object we haven't yet read. So we must do it here
 		// in a second pass, or (if demand loading is enabled) defer it for later.
-		panic("not implemented") // TODO: implement me
+		for _, obj := range p.objects.Objects() {
+			parserObj, ok := obj.(*ParserObject)
+			if !ok {
+				continue
+			}
+
+			if err := parserObj.DelayedLoadStream(); err != nil {
+				if !p.ignoreBroken {
+					return fmt.Errorf("read object internal: %w", err)
+				}
+
+				log.Printf("Cannot load the stream of object %s: %v", parserObj.GetIndirectReference(), err)
+			}
+		}
 	}
 
 	if err := p.updateDocumentVersion(); err != nil {
@@ -564,8 +814,99 @@ func (p *Parser) readObjectsInternal(r Reader) error {
 	return nil
 }
 
+// readObject reads the in-use object objNo
+// and pushes it into the objects list.
+func (p *Parser) readObject(r Reader, objNo int, entry XRefEntryInUse) error {
+	ref := pdf.NewReference(objNo, entry.Generation)
+
+	// The encryption dictionary has been read by parseEncrypt.
+	if _, ok := p.objects.objects[*ref]; ok {
+		return nil
+	}
+
+	obj, err := NewParserObject(r, entry.Offset, WithObjects(p.objects),
+		WithReference(ref), WithEncrypt(p.encrypt), DelayedLoad(p.loadOnDemand))
+	if err == nil {
+		err = obj.Parse()
+	}
+
+	if err != nil {
+		if !p.ignoreBroken {
+			return fmt.Errorf("read object %s: %w", ref, err)
+		}
+
+		log.Printf("Cannot read object %s at offset %d, skipping: %v", ref, entry.Offset, err)
+
+		p.objects.SafeAddFreeObject(ref)
+
+		return nil
+	}
+
+	p.objects.PushObject(obj)
+
+	return nil
+}
+
+// readCompressedObjectFromStream reads the objects from the object
+// stream objNo. The objects not found in the stream are looked up in
+// the streams it extends.
 func (p *Parser) readCompressedObjectFromStream(objNo int, objects []int) error {
-	panic("not implemented") // TODO: implement me
+	var visited []int
+
+	defer func() {
+		for _, streamNo := range visited {
+			p.objectStreams.Remove(streamNo)
+		}
+	}()
+
+	for len(objects) > 0 {
+		if p.objectStreams.Contains(objNo) {
+			log.Printf("Object stream %d is extended by itself, skipping", objNo)
+
+			break
+		}
+
+		p.objectStreams.Put(objNo)
+		visited = append(visited, objNo)
+
+		stream, ok := p.objects.GetObject(pdf.NewReference(objNo, 0)).(*ParserObject)
+		if !ok {
+			if p.ignoreBroken {
+				log.Printf("Object stream %d not found, skipping", objNo)
+
+				return nil
+			}
+
+			return fmt.Errorf("read object stream %d: %w", objNo, ErrNoObject)
+		}
+
+		missing, err := NewObjectStreamParser(stream, p.objects).Parse(objects)
+		if err != nil {
+			if p.ignoreBroken {
+				log.Printf("Cannot read object stream %d: %v", objNo, err)
+
+				return nil
+			}
+
+			return fmt.Errorf("read object stream %d: %w", objNo, err)
+		}
+
+		objects = missing
+		if len(objects) == 0 {
+			break
+		}
+
+		extends, ok := stream.Dictionary.Key(pdf.KeyExtends).(*Reference)
+		if !ok {
+			log.Printf("Objects %v not found in object stream %d", objects, objNo)
+
+			break
+		}
+
+		objNo = int(extends.ObjectNo)
+	}
+
+	return nil
 }
 
 func (p *Parser) checkEOFMarker(r Reader) error {
@@ -598,7 +939,14 @@ func (p *Parser) checkEOFMarker(r Reader) error {
 			return fmt.Errorf("check EOF: %w", ErrNoEOFToken)
 		}
 	} else {
-		panic("not implemented") // TODO: implement me
+		// Some producers append garbage after the marker.
+		if err = p.findTokenBackward(r, EOFToken, xrefBuf, p.fileSize); err != nil {
+			return fmt.Errorf("check EOF: %w: %w", ErrNoEOFToken, err)
+		}
+
+		if currentPos, err = r.Seek(0, io.SeekCurrent); err != nil {
+			return fmt.Errorf("check EOF: %w", err)
+		}
 	}
 
 	p.lastEOFOffset = currentPos
@@ -618,6 +966,8 @@ func (p *Parser) reset() {
 	p.encrypt = nil
 	p.ignoreBroken = true
 	p.incUpdatesCount = 0
+	p.revisions = nil
+	p.revision = -1
 }
 
 func (p *Parser) documentID() (*String, error) {
@@ -627,27 +977,67 @@ func (p *Parser) documentID() (*String, error) {
 	}
 
 	array, ok := id.(*Array)
-	if !ok {
+	if !ok || array.NumObjects() == 0 {
 		return nil, fmt.Errorf("get document ID: not an array: %w", ErrInvalidEncryptionDict)
 	}
 
-	// TODO? check the type of the first object?
-	return array.objects[0].(*String), nil
+	documentID, ok := array.objects[0].(*String)
+	if !ok {
+		return nil, fmt.Errorf("get document ID: not a string: %w", ErrInvalidEncryptionDict)
+	}
+
+	return documentID, nil
 }
 
+// updateDocumentVersion takes the /Version of the catalog if it is
+// later than the version of the header. The unknown versions are
+// ignored, the /Version must be a name when parsing strictly.
 func (p *Parser) updateDocumentVersion() error {
-	panic("not implemented") // TODO: implement me
+	catalog := objectDictionary(p.objects.resolve(p.trailer.Key(pdf.KeyRoot)))
+	if catalog == nil {
+		return nil
+	}
+
+	obj := catalog.Key(pdf.KeyVersion)
+	if obj == nil {
+		return nil
+	}
+
+	name, ok := p.objects.resolve(obj).(*pdf.NameObject)
+	if !ok {
+		if p.strictParsing {
+			return fmt.Errorf("update document version: %w: /Version is not a name", ErrInvalidName)
+		}
+
+		return nil
+	}
+
+	version := PDFVersion(name.Name)
+	if version.Validate() == nil && version > p.pdfVersion {
+		p.pdfVersion = version
+	}
+
+	return nil
 }
 
+// parseEncrypt reads the encryption dictionary and authenticates
+// the password. The encryption dictionary itself is not encrypted,
+// so its object is not read once again.
 func (p *Parser) parseEncrypt(r Reader, obj Object) (err error) {
 	switch obj := obj.(type) {
 	case *Reference:
-		i := obj.NumObjects()
+		i := int(obj.ObjectNo)
 		if i <= 0 || i >= len(p.entries) {
 			return fmt.Errorf("parse encrypt object: %w", ErrInvalidEncryptionDict)
 		}
 
-		parserObj, err := NewParserObject(r, p.entries[i].Entry.(XRefEntryInUse).Offset)
+		entry, ok := p.entries[i].Entry.(XRefEntryInUse)
+		if !ok {
+			return fmt.Errorf("parse encrypt object: %w: object %s is not in use", ErrInvalidEncryptionDict, obj)
+		}
+
+		parserObj, err := NewParserObject(r, entry.Offset,
+			WithDocument(p.objects.Document()), WithReference(obj))
 		if err != nil {
 			return fmt.Errorf("parse encrypt object: %w", err)
 		}
@@ -656,12 +1046,15 @@ func (p *Parser) parseEncrypt(r Reader, obj Object) (err error) {
 			return fmt.Errorf("parse encrypt object: %w", err)
 		}
 
-		p.entries[i].Parsed = false
-
 		p.encrypt, err = EncryptFromObject(parserObj)
 		if err != nil {
 			return fmt.Errorf("parse encrypt object: %w", err)
 		}
+
+		// The object is pushed as it is, not decrypted, and its entry
+		// stays parsed, so its number is neither free nor given to a
+		// new object. readObject does not read it again.
+		p.objects.PushObject(parserObj)
 	case *Dictionary:
 		p.encrypt, err = EncryptFromObject(obj)
 		if err != nil {
@@ -671,9 +1064,18 @@ func (p *Parser) parseEncrypt(r Reader, obj Object) (err error) {
 		return fmt.Errorf("parse encrypt: %w", ErrInvalidEncryptionDict)
 	}
 
+	if p.encrypt.IsPublicKey() {
+		if !p.encrypt.AuthenticateRecipient(p.certificate, p.privateKey) {
+			return fmt.Errorf("parse encrypt: auth: %w", ErrRecipientNotFound)
+		}
+
+		return nil
+	}
+
+	// The revisions 5 and 6 do not need the document ID.
 	documentID, err := p.documentID()
 	if err != nil {
-		return fmt.Errorf("parse encrypt: %w", err)
+		log.Printf("Cannot read the document ID: %v", err)
 	}
 
 	isAuthenticated := p.encrypt.Authenticate(p.password, documentID)
@@ -681,5 +1083,5 @@ func (p *Parser) parseEncrypt(r Reader, obj Object) (err error) {
 		return fmt.Errorf("parse encrypt: auth: %w", ErrInvalidPassword)
 	}
 
-	return err
+	return nil
 }
@@ -1,36 +1,552 @@
 package podofo
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/denisss025/go-podofo/internal/filter"
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// tokenizers holds the tokenizers shared by the parser objects.
+var tokenizers = sync.Pool{New: func() any { return NewTokenizer() }}
+
+// ParserObject is an object read from a PDF file. If the delayed
+// load is enabled, the object is read when it is accessed first,
+// see DelayedLoad and DelayedLoadStream.
 type ParserObject struct {
+	pdf.BaseObject
 	*Dictionary
 	Encrypt Encrypt
+
+	// object is the parsed object. The Dictionary
+	// points to it as well if it is a dictionary.
+	object    Object
+	reference *Reference
+	document  *Document
+	objects   *IndirectObjectList
+	reader    Reader
+
+	// fileRef is the reference the object has in the file,
+	// if it has been renumbered, see SetIndirectReference.
+	fileRef *Reference
+
+	// offset is the offset of the object in the file.
+	offset int64
+	// streamOffset is the offset of the stream data
+	// following the "stream" keyword, if any.
+	streamOffset int64
+	// streamLength is the length of the stream data,
+	// it is known after the stream is loaded.
+	streamLength int64
+
+	isTrailer bool
+	delayed   bool
 }
 
 type ParserObjectOption func(*ParserObject)
 
+// WithDocument sets the document of the object.
 func WithDocument(doc *Document) ParserObjectOption {
-	panic("implement me") // TODO: implement me
+	return func(obj *ParserObject) { obj.document = doc }
+}
+
+// WithObjects sets the indirect objects list used to
+// resolve the indirect stream /Length. The document of
+// the object is set to the document of the list.
+func WithObjects(objects *IndirectObjectList) ParserObjectOption {
+	return func(obj *ParserObject) {
+		obj.objects = objects
+		obj.document = objects.Document()
+	}
 }
 
+// WithReference sets the reference of the object
+// as found in the XRef table.
 func WithReference(ref *Reference) ParserObjectOption {
-	panic("implement me") // TODO: implement me
+	return func(obj *ParserObject) { obj.reference = ref }
+}
+
+// WithEncrypt sets the security handler that decrypts the strings
+// and the stream of the object. Nil means the object is not encrypted.
+func WithEncrypt(enc *Encrypt) ParserObjectOption {
+	return func(obj *ParserObject) {
+		if enc != nil {
+			obj.Encrypt = *enc
+		}
+	}
 }
 
+// DelayedLoad tells the parser object to be read
+// when it is accessed first instead of in Parse.
 func DelayedLoad(delayed bool) ParserObjectOption {
-	panic("implement me") // TODO: implement me
+	return func(obj *ParserObject) { obj.delayed = delayed }
+}
+
+// AsTrailer tells the parser object that it is a trailer
+// dictionary, i.e. it is not preceded by the "N G obj" header.
+func AsTrailer() ParserObjectOption {
+	return func(obj *ParserObject) { obj.isTrailer = true }
 }
 
+// NewParserObject creates an object to be read from r at offset.
+// A negative offset means the current position of r.
 func NewParserObject(r Reader, offset int64, options ...ParserObjectOption) (*ParserObject, error) {
-	panic("not implemented") // TODO: implement me
+	obj := &ParserObject{
+		reader:       r,
+		offset:       offset,
+		streamLength: -1,
+	}
+
+	for _, opt := range options {
+		opt(obj)
+	}
+
+	if offset < 0 {
+		var err error
+
+		if obj.offset, err = r.Seek(0, io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("new parser object: %w", err)
+		}
+	}
+
+	obj.EnableDelayedLoad(obj)
+
+	return obj, nil
+}
+
+// newCompressedObject wraps an object read from
+// a compressed object stream.
+func newCompressedObject(doc *Document, ref *Reference, obj Object) *ParserObject {
+	parserObj := &ParserObject{
+		object:       obj,
+		reference:    ref,
+		document:     doc,
+		streamLength: -1,
+	}
+
+	parserObj.Dictionary, _ = obj.(*Dictionary)
+
+	resetDirty(obj)
+
+	return parserObj
+}
+
+// Object returns the parsed object. DelayedLoad must
+// be called first if the delayed load is enabled.
+func (obj *ParserObject) Object() Object { return obj.object }
+
+// GetIndirectReference returns the reference of the object.
+func (obj *ParserObject) GetIndirectReference() *Reference { return obj.reference }
+
+// SetIndirectReference renumbers the object. The strings and the
+// stream of the object are still decrypted with the key of the
+// reference the object has in the file.
+func (obj *ParserObject) SetIndirectReference(ref *Reference) {
+	if obj.fileRef == nil {
+		obj.fileRef = obj.reference
+	}
+
+	obj.reference = ref
+}
+
+// fileReference returns the reference the object has in the file.
+func (obj *ParserObject) fileReference() *Reference {
+	if obj.fileRef != nil {
+		return obj.fileRef
+	}
+
+	return obj.reference
 }
 
-// func (obj *ParserObject) IsDictionary() bool {
-// 	panic("not implemented") // TODO: implement me
-// }
+// Document returns the document of the object.
+func (obj *ParserObject) Document() *Document { return obj.document }
 
-// func (obj *ParserObject) Dictionary() *Dictionary {
-// 	panic("not implemented") // TODO: implement me
-// }
+// HasStream returns true if the object is a stream.
+func (obj *ParserObject) HasStream() bool { return obj.streamOffset > 0 }
 
+// StreamOffset returns the offset of the stream data
+// in the file.
+func (obj *ParserObject) StreamOffset() int64 { return obj.streamOffset }
+
+// Offset returns the offset of the object in the file.
+func (obj *ParserObject) Offset() int64 { return obj.offset }
+
+// Parse reads the object unless the delayed load is enabled.
+// The stream data is never read here, see DelayedLoadStream.
 func (obj *ParserObject) Parse() error {
-	panic("not implemented") // TODO: implement me
+	if obj.delayed {
+		return nil
+	}
+
+	if err := obj.DelayedLoad(); err != nil {
+		return fmt.Errorf("parse object at offset %d: %w", obj.offset, err)
+	}
+
+	return nil
+}
+
+// LoadObject reads the object from the file. It implements
+// pdf.DelayedLoader, use DelayedLoad instead.
+func (obj *ParserObject) LoadObject() error {
+	tokenizer, _ := tokenizers.Get().(*Tokenizer)
+	defer tokenizers.Put(tokenizer)
+
+	if _, err := obj.reader.Seek(obj.offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	if !obj.isTrailer {
+		if err := obj.readObjectHeader(tokenizer); err != nil {
+			return err
+		}
+	}
+
+	object, err := tokenizer.ReadNextObject(obj.reader)
+	if err != nil {
+		return err
+	}
+
+	obj.object = object
+	obj.Dictionary, _ = object.(*Dictionary)
+
+	// The object is changed as it is read.
+	resetDirty(object)
+
+	if obj.isTrailer {
+		return nil
+	}
+
+	if err = obj.decrypt(); err != nil {
+		return err
+	}
+
+	if obj.Dictionary == nil {
+		return nil
+	}
+
+	token, err := tokenizer.PeekNextToken(obj.reader)
+	if err != nil || !token.IsKeyword("stream") {
+		// Either "endobj" or a broken object, but
+		// the object itself has been read.
+		return nil
+	}
+
+	if _, err = tokenizer.ReadNextToken(obj.reader); err != nil {
+		return err
+	}
+
+	obj.streamOffset, err = skipStreamEOL(obj.reader)
+
+	return err
+}
+
+// decrypt decrypts the strings of the object.
+// The XRef streams are never encrypted.
+func (obj *ParserObject) decrypt() error {
+	if obj.Dictionary != nil {
+		if name, ok := obj.Dictionary.Key(pdf.KeyType).(*pdf.NameObject); ok && name.Name == pdf.NameXRef {
+			obj.Encrypt = Encrypt{}
+
+			return nil
+		}
+	}
+
+	ref := obj.fileReference()
+	if ref == nil {
+		return nil
+	}
+
+	if err := obj.Encrypt.decryptObject(ref, obj.object); err != nil {
+		return fmt.Errorf("object %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// readObjectHeader reads the "N G obj" header.
+func (obj *ParserObject) readObjectHeader(tokenizer *Tokenizer) error {
+	objNo, err := tokenizer.ReadNextNumber(obj.reader)
+	if err != nil {
+		return fmt.Errorf("read object header: %w", err)
+	}
+
+	gen, err := tokenizer.ReadNextNumber(obj.reader)
+	if err != nil {
+		return fmt.Errorf("read object header: %w", err)
+	}
+
+	token, err := tokenizer.ReadNextToken(obj.reader)
+	if err != nil || !token.IsKeyword("obj") {
+		return errors.Join(fmt.Errorf("read object header: %w: \"obj\" keyword expected", ErrNoObject), err)
+	}
+
+	if objNo <= 0 || objNo > maxObjectCount || gen < 0 || gen > int64(pdf.MaxGeneration) {
+		return fmt.Errorf("read object header: %w: %d %d obj", ErrValueOutOfRange, objNo, gen)
+	}
+
+	ref := pdf.NewReference(int(objNo), Generation(gen))
+
+	switch fileRef := obj.fileReference(); {
+	case fileRef == nil:
+		obj.reference = ref
+	case *fileRef != *ref:
+		log.Printf("Found object %s at offset %d, but the XRef entry is %s", ref, obj.offset, fileRef)
+	}
+
+	return nil
+}
+
+// skipStreamEOL skips the end-of-line marker following the
+// "stream" keyword and returns the offset of the stream data.
+// The marker shall be either CRLF or LF, but CR alone and
+// spaces before the marker are accepted as well.
+func skipStreamEOL(r Reader) (int64, error) {
+	var buf [1]byte
+
+	for {
+		if _, err := io.ReadFull(r, buf[:]); err != nil {
+			return 0, fmt.Errorf("skip stream EOL: %w", unexpectedEOF(err))
+		}
+
+		switch buf[0] {
+		case ' ':
+			continue
+		case '\n':
+			return r.Seek(0, io.SeekCurrent)
+		case '\r':
+			if _, err := io.ReadFull(r, buf[:]); err != nil {
+				return 0, fmt.Errorf("skip stream EOL: %w", unexpectedEOF(err))
+			}
+
+			if buf[0] == '\n' {
+				return r.Seek(0, io.SeekCurrent)
+			}
+		}
+
+		// No EOL marker, the data starts right here.
+		return r.Seek(-1, io.SeekCurrent)
+	}
+}
+
+// LoadStream finds the stream data in the file. It implements
+// pdf.DelayedLoader, use DelayedLoadStream instead.
+func (obj *ParserObject) LoadStream() error {
+	if !obj.HasStream() {
+		return nil
+	}
+
+	length, err := obj.lengthKey()
+	if err == nil {
+		err = obj.checkStreamLength(length)
+	}
+
+	if err != nil {
+		log.Printf("Invalid /Length of the stream at offset %d, looking for \"endstream\": %v",
+			obj.streamOffset, err)
+
+		if length, err = obj.findStreamEnd(); err != nil {
+			return err
+		}
+	}
+
+	obj.streamLength = length
+
+	return nil
+}
+
+// lengthKey returns the /Length of the stream,
+// resolving the indirect reference if needed.
+func (obj *ParserObject) lengthKey() (int64, error) {
+	switch length := obj.Dictionary.Key(pdf.KeyLength).(type) {
+	case *pdf.Number:
+		return length.Int64(), nil
+	case *Reference:
+		if obj.objects == nil {
+			return -1, fmt.Errorf("%w: cannot resolve %s", ErrInvalidStreamLength, length)
+		}
+
+		target, ok := obj.objects.GetObject(length).(*ParserObject)
+		if !ok {
+			return -1, fmt.Errorf("%w: object %s not found", ErrInvalidStreamLength, length)
+		}
+
+		num, ok := target.Object().(*pdf.Number)
+		if !ok {
+			return -1, fmt.Errorf("%w: object %s is not a number", ErrInvalidStreamLength, length)
+		}
+
+		return num.Int64(), nil
+	default:
+		return -1, fmt.Errorf("%w: missing /Length", ErrInvalidStreamLength)
+	}
+}
+
+// checkStreamLength checks that the stream data
+// of the length is followed by "endstream".
+func (obj *ParserObject) checkStreamLength(length int64) error {
+	if length < 0 {
+		return fmt.Errorf("%w: negative /Length", ErrInvalidStreamLength)
+	}
+
+	if _, err := obj.reader.Seek(obj.streamOffset+length, io.SeekStart); err != nil {
+		return err
+	}
+
+	tokenizer, _ := tokenizers.Get().(*Tokenizer)
+	defer tokenizers.Put(tokenizer)
+
+	token, err := tokenizer.ReadNextToken(obj.reader)
+	if err != nil || !token.IsKeyword("endstream") {
+		return errors.Join(fmt.Errorf("%w: \"endstream\" expected", ErrMissingEndStream), err)
+	}
+
+	return nil
+}
+
+// findStreamEnd searches for the "endstream" keyword and
+// returns the length of the stream data preceding it.
+func (obj *ParserObject) findStreamEnd() (int64, error) {
+	const token = "endstream"
+
+	if _, err := obj.reader.Seek(obj.streamOffset, io.SeekStart); err != nil {
+		return -1, err
+	}
+
+	buf := make([]byte, 0, BufferSize+len(token))
+	chunk := make([]byte, BufferSize)
+	base := obj.streamOffset
+
+	for {
+		n, err := obj.reader.Read(chunk)
+		buf = append(buf, chunk[:n]...)
+
+		if i := bytes.Index(buf, []byte(token)); i >= 0 {
+			// The EOL marker before the keyword is not a part of the data.
+			data := bytes.TrimSuffix(bytes.TrimSuffix(buf[:i], []byte("\n")), []byte("\r"))
+
+			return base + int64(len(data)) - obj.streamOffset, nil
+		}
+
+		if errors.Is(err, io.EOF) {
+			return -1, fmt.Errorf("find stream end: %w", ErrMissingEndStream)
+		}
+
+		if err != nil {
+			return -1, fmt.Errorf("find stream end: %w", err)
+		}
+
+		keep := len(token) - 1
+		if keep > len(buf) {
+			keep = len(buf)
+		}
+
+		base += int64(len(buf) - keep)
+		buf = append(buf[:0], buf[len(buf)-keep:]...)
+	}
+}
+
+// decodeStream reads the whole stream data, decrypted and decoded.
+func (obj *ParserObject) decodeStream() (data []byte, err error) {
+	r, err := obj.NewStreamReader()
+	if err != nil {
+		return nil, fmt.Errorf("decode stream: %w", err)
+	}
+
+	data, err = io.ReadAll(r)
+	if errors.Is(err, io.ErrUnexpectedEOF) && len(data) > 0 {
+		log.Printf("Stream at offset %d is truncated: %v", obj.streamOffset, err)
+
+		err = nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("decode stream: %w", err)
+	}
+
+	return data, nil
+}
+
+// NewStreamReader returns the reader of the stream data decrypted
+// and decoded with all the filters. The data is read from the file
+// as it is decoded, so the reader shall be used before any other
+// object is read.
+func (obj *ParserObject) NewStreamReader() (io.Reader, error) {
+	if err := obj.DelayedLoadStream(); err != nil {
+		return nil, fmt.Errorf("new stream reader: %w", err)
+	}
+
+	// The filters are read first, as /JBIG2Globals is read from the file.
+	types, params, err := streamFilters(obj.Dictionary, obj.objects)
+	if err != nil {
+		return nil, fmt.Errorf("new stream reader: %w", err)
+	}
+
+	r, err := obj.newRawStreamReader()
+	if err != nil {
+		return nil, fmt.Errorf("new stream reader: %w", err)
+	}
+
+	if r, err = filter.NewChainReader(r, types, params); err != nil {
+		return nil, fmt.Errorf("new stream reader: %w", err)
+	}
+
+	return r, nil
+}
+
+// NewUnwrappedStreamReader is like NewStreamReader, but the
+// data of the media filters, i.e. DCTDecode, JPXDecode and
+// JBIG2Decode, is left encoded. The media filters are returned.
+func (obj *ParserObject) NewUnwrappedStreamReader() (io.Reader, []FilterType, error) {
+	if err := obj.DelayedLoadStream(); err != nil {
+		return nil, nil, fmt.Errorf("new unwrapped stream reader: %w", err)
+	}
+
+	// The filters are read first, as /JBIG2Globals is read from the file.
+	types, params, err := streamFilters(obj.Dictionary, obj.objects)
+	if err != nil {
+		return nil, nil, fmt.Errorf("new unwrapped stream reader: %w", err)
+	}
+
+	r, err := obj.newRawStreamReader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("new unwrapped stream reader: %w", err)
+	}
+
+	r, media, err := filter.NewUnwrapReader(r, types, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("new unwrapped stream reader: %w", err)
+	}
+
+	return r, media, nil
+}
+
+// newRawStreamReader returns the reader of the
+// stream data, which is decrypted, but not decoded.
+func (obj *ParserObject) newRawStreamReader() (r io.Reader, err error) {
+	if err = obj.DelayedLoadStream(); err != nil {
+		return nil, err
+	}
+
+	if !obj.HasStream() {
+		return nil, fmt.Errorf("%w: not a stream", ErrInvalidStream)
+	}
+
+	if _, err = obj.reader.Seek(obj.streamOffset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	r = io.LimitReader(obj.reader, obj.streamLength)
+
+	if ref := obj.fileReference(); ref != nil {
+		if r, err = obj.Encrypt.newStreamReader(ref, obj.Dictionary, r); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
 }
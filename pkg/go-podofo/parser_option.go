@@ -1,5 +1,10 @@
 package podofo
 
+import (
+	"crypto"
+	"crypto/x509"
+)
+
 // ParserOption is used for PDF file Parser.
 type ParserOption func(*Parser)
 
@@ -18,7 +23,7 @@ func LoadOnDemand() ParserOption {
 // on a few more common PDF failuers.
 // Please note that Parser is by default very strict
 // already and does not recover from e.g. wrong XREF
-// tables.
+// tables unless RecoverBrokenXRef is used.
 func StrictMode() ParserOption {
 	return func(p *Parser) { p.strictParsing = true }
 }
@@ -27,10 +32,34 @@ func StrictMode() ParserOption {
 // object, i.e. XRef entries that do not point to valid
 // objects.
 func DontIgnoreBrokenObjects() ParserOption {
-	return func(p *Parser) { p.ignoreBroken = true }
+	return func(p *Parser) { p.ignoreBroken = false }
+}
+
+// RecoverBrokenXRef tells the parser to rebuild the XRef table
+// if it cannot be read, e.g. the file is truncated or its offsets
+// are wrong. The whole file is scanned for the object headers and
+// the trailer is taken from the last trailer or XRef stream
+// dictionaries or created from the document catalog.
+func RecoverBrokenXRef() ParserOption {
+	return func(p *Parser) { p.recoverBrokenXRef = true }
+}
+
+// AtRevision tells the parser to open the document as of the
+// revision n, where 0 is the original document and each
+// incremental update adds one more revision. The objects and
+// the trailer of the later revisions are ignored. A negative n
+// means the last revision, which is the default.
+func AtRevision(n int) ParserOption {
+	return func(p *Parser) { p.revision = n }
 }
 
 // Password sets the PDF file password.
 func Password(password string) ParserOption {
 	return func(p *Parser) { p.password = password }
 }
+
+// Recipient sets the certificate and its private key
+// the file encrypted for the recipients is opened with.
+func Recipient(cert *x509.Certificate, key crypto.Decrypter) ParserOption {
+	return func(p *Parser) { p.certificate, p.privateKey = cert, key }
+}
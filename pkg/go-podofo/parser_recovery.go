@@ -0,0 +1,245 @@
+package podofo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// recoveredTrailer is a trailer or a XRef stream
+// dictionary found while rebuilding the XRef.
+type recoveredTrailer struct {
+	dict   *Dictionary
+	offset int64
+}
+
+// hasRoot returns true if the trailer has the /Root key.
+func (p *Parser) hasRoot() bool {
+	return p.trailer != nil && p.trailer.Dictionary != nil &&
+		p.trailer.Dictionary.Key(pdf.KeyRoot) != nil
+}
+
+// rebuildXRef rebuilds the XRef entries and the trailer of a broken
+// file from the object headers found by scanning the whole file.
+func (p *Parser) rebuildXRef(r Reader) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("rebuild xref: %w", err)
+		}
+	}()
+
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	scan, err := pdf.ScanXRef(bufio.NewReaderSize(r, BufferSize))
+	if err != nil {
+		return err
+	}
+
+	if len(scan.Objects) == 0 {
+		return fmt.Errorf("%w: no objects found", ErrBrokenFile)
+	}
+
+	p.entries = p.entries[:0]
+	p.trailer = nil
+	p.hasXrefStream = false
+	p.xrefOffset = 0
+
+	for _, header := range scan.Objects {
+		if header.ObjectNo == 0 || header.ObjectNo > maxObjectCount {
+			continue
+		}
+
+		// The objects of the incremental updates follow
+		// the original ones, so the last header wins.
+		p.entries = enlargeXRefEntries(p.entries, int(header.ObjectNo)+1)
+		p.entries[header.ObjectNo] = XRefEntry{
+			Entry: XRefEntryInUse{
+				Offset:     header.Offset,
+				Generation: header.GenerationNo,
+			},
+			Parsed: true,
+		}
+	}
+
+	p.entries = enlargeXRefEntries(p.entries, 1)
+	p.entries[0] = XRefEntry{
+		Entry:  XRefEntryFree{Generation: pdf.MaxGeneration},
+		Parsed: true,
+	}
+
+	trailers, catalog := p.recoverObjects(r)
+
+	for _, offset := range scan.Trailers {
+		trailer, err := p.readRecoveredTrailer(r, offset)
+		if err != nil {
+			log.Printf("Cannot read the trailer at offset %d: %v", offset, err)
+
+			continue
+		}
+
+		trailers = append(trailers, recoveredTrailer{dict: trailer, offset: offset})
+	}
+
+	return p.recoverTrailer(trailers, catalog)
+}
+
+// recoverObjects reads the dictionaries of the recovered objects.
+// Broken objects are removed from the XRef entries and the objects
+// of the object streams are added to them. The XRef streams and
+// the last document catalog are returned.
+func (p *Parser) recoverObjects(r Reader) (trailers []recoveredTrailer, catalog *Reference) {
+	var catalogOffset int64
+
+	for objNo := 1; objNo < len(p.entries); objNo++ {
+		entry, ok := p.entries[objNo].Entry.(XRefEntryInUse)
+		if !ok {
+			continue
+		}
+
+		ref := pdf.NewReference(objNo, entry.Generation)
+
+		obj, err := NewParserObject(r, entry.Offset,
+			WithObjects(p.objects), WithReference(ref))
+		if err == nil {
+			err = obj.Parse()
+		}
+
+		if err != nil {
+			log.Printf("Cannot read object %s at offset %d, skipping: %v", ref, entry.Offset, err)
+
+			p.entries[objNo] = XRefEntry{}
+
+			continue
+		}
+
+		if obj.Dictionary == nil {
+			continue
+		}
+
+		name, _ := obj.Dictionary.Key(pdf.KeyType).(*pdf.NameObject)
+		if name == nil {
+			continue
+		}
+
+		switch name.Name {
+		case pdf.NameCatalog:
+			if catalog == nil || entry.Offset > catalogOffset {
+				catalog, catalogOffset = ref, entry.Offset
+			}
+		case pdf.NameXRef:
+			trailers = append(trailers, recoveredTrailer{dict: obj.Dictionary, offset: entry.Offset})
+		case pdf.NameObjStm:
+			p.recoverObjectStream(objNo, obj)
+		}
+	}
+
+	return trailers, catalog
+}
+
+// recoverObjectStream adds the objects of the object stream
+// that have not been found in the file to the XRef entries.
+func (p *Parser) recoverObjectStream(objNo int, stream *ParserObject) {
+	numbers, err := NewObjectStreamParser(stream, p.objects).ObjectNumbers()
+	if err != nil {
+		log.Printf("Cannot read object stream %d, skipping: %v", objNo, err)
+
+		return
+	}
+
+	for i, number := range numbers {
+		p.entries = enlargeXRefEntries(p.entries, number+1)
+
+		if entry := &p.entries[number]; !entry.Parsed {
+			entry.Entry = XRefEntryCompressed{ObjectNumber: objNo, Index: int64(i)}
+			entry.Parsed = true
+		}
+	}
+}
+
+// readRecoveredTrailer reads the trailer dictionary
+// following the "trailer" keyword at offset.
+func (p *Parser) readRecoveredTrailer(r Reader, offset int64) (*Dictionary, error) {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.tokenizer.TryReadNextToken(r); err != nil {
+		return nil, err
+	}
+
+	trailer, err := NewParserObject(r, -1, WithDocument(p.objects.Document()), AsTrailer())
+	if err == nil {
+		err = trailer.Parse()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if trailer.Dictionary == nil {
+		return nil, fmt.Errorf("%w: not a dictionary", ErrNoTrailer)
+	}
+
+	return trailer.Dictionary, nil
+}
+
+// recoverTrailer creates the trailer from the recovered trailers,
+// the newest keys win. If there is no valid /Root the catalog is
+// used instead.
+func (p *Parser) recoverTrailer(trailers []recoveredTrailer, catalog *Reference) error {
+	sort.Slice(trailers, func(i, j int) bool {
+		return trailers[i].offset > trailers[j].offset
+	})
+
+	p.trailer = &ParserObject{Dictionary: NewDictionary()}
+
+	for _, trailer := range trailers {
+		p.mergeTrailer(trailer.dict)
+	}
+
+	root, _ := p.trailer.Key(pdf.KeyRoot).(*Reference)
+	if root == nil || !p.isRecoveredObject(root) {
+		if catalog == nil {
+			return fmt.Errorf("%w: no document catalog found", ErrNoTrailer)
+		}
+
+		log.Printf("Using the document catalog %s as the trailer /Root", catalog)
+
+		p.trailer.AddKey(pdf.KeyRoot, catalog)
+	}
+
+	p.trailer.AddKey(pdf.KeySize, pdf.NewInt(int64(len(p.entries))))
+
+	// The revisions cannot be told apart any more.
+	p.revisions = []Revision{{Trailer: p.trailer.Dictionary}}
+	p.incUpdatesCount = 0
+
+	if p.revision > 0 {
+		return fmt.Errorf("%w: revision %d of a rebuilt file", ErrValueOutOfRange, p.revision)
+	}
+
+	return nil
+}
+
+// isRecoveredObject returns true if the referenced
+// object has been found in the file.
+func (p *Parser) isRecoveredObject(ref *Reference) bool {
+	if int(ref.ObjectNo) >= len(p.entries) {
+		return false
+	}
+
+	switch entry := p.entries[ref.ObjectNo].Entry.(type) {
+	case XRefEntryInUse:
+		return entry.Generation == ref.GenerationNo
+	case XRefEntryCompressed:
+		return ref.GenerationNo == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,114 @@
+package podofo_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// buildPDF returns the file of the objects numbered from 1 with
+// the classic XRef table and the trailer entries.
+func buildPDF(version, trailer string, objects ...string) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%%PDF-%s\n", version)
+
+	offsets := make([]int, len(objects))
+
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := buf.Len()
+
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(&buf, "trailer\n<</Size %d%s>>\nstartxref\n%d\n%%%%EOF", len(objects)+1, trailer, xref)
+
+	return buf.Bytes()
+}
+
+func TestParseCatalogVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		header  string
+		catalog string
+		want    podofo.PDFVersion
+	}{
+		{name: "later", header: "1.4", catalog: "/Version /1.6", want: "1.6"},
+		{name: "earlier", header: "1.7", catalog: "/Version /1.4", want: "1.7"},
+		{name: "unknown", header: "1.4", catalog: "/Version /9.9", want: "1.4"},
+		{name: "not a name", header: "1.4", catalog: "/Version (1.6)", want: "1.4"},
+		{name: "none", header: "1.5", want: "1.5"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := buildPDF(tt.header, "/Root 1 0 R",
+				"<</Type /Catalog /Pages 2 0 R "+tt.catalog+">>",
+				"<</Type /Pages /Kids [] /Count 0>>")
+
+			parser, err := podofo.Parse(bytes.NewReader(file))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, parser.PDFVersion())
+		})
+	}
+}
+
+func TestParseCatalogVersionStrict(t *testing.T) {
+	t.Parallel()
+
+	file := buildPDF("1.4", "/Root 1 0 R",
+		"<</Type /Catalog /Pages 2 0 R /Version (1.6)>>",
+		"<</Type /Pages /Kids [] /Count 0>>")
+
+	_, err := podofo.Parse(bytes.NewReader(file), podofo.StrictMode())
+	assert.ErrorIs(t, err, podofo.ErrInvalidName)
+}
+
+func TestParseTrailerNotDictionary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		trailer string
+	}{
+		{name: "number", trailer: "42"},
+		{name: "array", trailer: "[ ]"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := buildPDF("1.4", "/Root 1 0 R",
+				"<</Type /Catalog /Pages 2 0 R>>",
+				"<</Type /Pages /Kids [] /Count 0>>")
+
+			start := bytes.LastIndex(file, []byte("trailer\n")) + len("trailer\n")
+			end := bytes.LastIndex(file, []byte("\nstartxref"))
+			file = append(append(append([]byte{}, file[:start]...), tt.trailer...), file[end:]...)
+
+			_, err := podofo.Parse(bytes.NewReader(file))
+			assert.ErrorIs(t, err, podofo.ErrNoTrailer)
+		})
+	}
+}
@@ -1,15 +1,37 @@
 package podofo
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/denisss025/go-podofo/internal/pdf"
 )
 
-type RawData struct{}
+// RawData is the data that is written to PDF as is,
+// e.g. the data that is already serialized.
+type RawData struct {
+	data []byte
+}
+
+// NewRawData creates the raw data object.
+func NewRawData(data []byte) *RawData {
+	return &RawData{data: data}
+}
 
 func (data *RawData) Kind() ObjectKind { return pdf.ObjectKindRawData }
 
+// WriteTo writes the data as is.
 func (data *RawData) WriteTo(w io.Writer) (n int64, err error) {
-	panic("not implemented") // TODO: implement me
+	written, err := w.Write(data.data)
+
+	return int64(written), err
+}
+
+// MarshalPDF writes the data as is.
+func (data *RawData) MarshalPDF(w *pdf.Writer) error {
+	if _, err := data.WriteTo(w); err != nil {
+		return fmt.Errorf("marshal raw data: %w", err)
+	}
+
+	return nil
 }
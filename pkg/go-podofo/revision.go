@@ -0,0 +1,169 @@
+package podofo
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	"github.com/denisss025/go-podofo/internal/set"
+)
+
+// Revision is a revision of a PDF file, i.e. the original
+// document or one of its incremental updates.
+type Revision struct {
+	// Trailer is the trailer or the XRef stream
+	// dictionary of the revision.
+	Trailer *Dictionary
+	// Objects are the references of the objects
+	// added or changed by the revision.
+	Objects []Reference
+	// FreedObjects are the references of the objects deleted
+	// by the revision. The generation of a reference is the one
+	// to be used when the object number is reused.
+	FreedObjects []Reference
+	// XRefOffset is the offset of the XRef section
+	// of the revision, i.e. its startxref value.
+	XRefOffset int64
+
+	// entries are the XRef entries listed
+	// by the revision by the object number.
+	entries map[int]XRefEntry
+}
+
+// Revisions returns the revisions of the file from the oldest
+// one, the original document, to the newest one.
+func (p *Parser) Revisions() []Revision { return p.revisions }
+
+// Revision returns the index of the revision the document
+// has been opened as of, see AtRevision.
+func (p *Parser) Revision() int {
+	if p.revision < 0 {
+		return len(p.revisions) - 1
+	}
+
+	return p.revision
+}
+
+// beginRevision starts the revision with the XRef section
+// at offset. The sections are read from the newest one.
+//
+// The first-page XRef section of a linearized file refers to the
+// main XRef section that follows it with /Prev (see ISO 32000-1:2008,
+// F.3), while an update always refers to an earlier section. So the
+// section following the one of the revision continues the revision.
+func (p *Parser) beginRevision(offset int64) {
+	if n := len(p.revisions); n > 0 && offset > p.revisions[n-1].XRefOffset {
+		return
+	}
+
+	p.revisions = append(p.revisions, Revision{
+		XRefOffset: offset,
+		entries:    make(map[int]XRefEntry),
+	})
+}
+
+// revisionEntries returns the XRef entries
+// of the revision being read.
+func (p *Parser) revisionEntries() map[int]XRefEntry {
+	if len(p.revisions) == 0 {
+		return nil
+	}
+
+	return p.revisions[len(p.revisions)-1].entries
+}
+
+// setRevisionTrailer sets the trailer of the revision being read.
+// The trailer of the first-page XRef section of a linearized file
+// is kept, as the one of the main section has /Size only.
+func (p *Parser) setRevisionTrailer(trailer *Dictionary) {
+	if n := len(p.revisions); n > 0 && p.revisions[n-1].Trailer == nil {
+		p.revisions[n-1].Trailer = trailer
+	}
+}
+
+// recordRevisionEntry records the XRef entry listed by the revision.
+// In a hybrid-reference file the compressed objects are listed as
+// free by the XRef table and as compressed by the /XRefStm stream,
+// so a free entry never overrides another one.
+func recordRevisionEntry(entries map[int]XRefEntry, objNo int, entry XRefEntry) {
+	if entries == nil || objNo == 0 || !entry.Parsed {
+		return
+	}
+
+	if _, isFree := entry.Entry.(XRefEntryFree); isFree {
+		if _, ok := entries[objNo]; ok {
+			return
+		}
+	}
+
+	entries[objNo] = entry
+}
+
+// finishRevisions puts the revisions in order
+// once all the XRef sections have been read.
+func (p *Parser) finishRevisions() {
+	for i, j := 0, len(p.revisions)-1; i < j; i, j = i+1, j-1 {
+		p.revisions[i], p.revisions[j] = p.revisions[j], p.revisions[i]
+	}
+
+	for i := range p.revisions {
+		rev := &p.revisions[i]
+
+		objNumbers := make([]int, 0, len(rev.entries))
+		for objNo := range rev.entries {
+			objNumbers = append(objNumbers, objNo)
+		}
+
+		sort.Ints(objNumbers)
+
+		for _, objNo := range objNumbers {
+			switch entry := rev.entries[objNo].Entry.(type) {
+			case XRefEntryInUse:
+				rev.Objects = append(rev.Objects, *pdf.NewReference(objNo, entry.Generation))
+			case XRefEntryCompressed:
+				rev.Objects = append(rev.Objects, *pdf.NewReference(objNo, 0))
+			case XRefEntryFree:
+				rev.FreedObjects = append(rev.FreedObjects, *pdf.NewReference(objNo, entry.Generation))
+			}
+		}
+
+		rev.entries = nil
+	}
+
+	// Each /Prev key means the file has been updated.
+	p.incUpdatesCount = 0
+	if len(p.revisions) > 0 {
+		p.incUpdatesCount = len(p.revisions) - 1
+	}
+}
+
+// readRevision reads the XRef sections once again
+// starting with the one of the revision n.
+func (p *Parser) readRevision(r Reader, n int) error {
+	if n >= len(p.revisions) {
+		return fmt.Errorf("read revision: %w: revision %d, the file has %d",
+			ErrValueOutOfRange, n, len(p.revisions))
+	}
+
+	if n == len(p.revisions)-1 {
+		return nil
+	}
+
+	revisions := p.revisions
+
+	p.entries = p.entries[:0]
+	p.trailer = nil
+	p.hasXrefStream = false
+	p.revisions = nil
+	p.visitedXRefOffsets = set.New[int64]()
+	p.xrefOffset = revisions[n].XRefOffset
+
+	if err := p.readXRefContents(r, p.xrefOffset, false); err != nil {
+		return fmt.Errorf("read revision %d: %w", n, err)
+	}
+
+	p.revisions = revisions
+	p.incUpdatesCount = n
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package podofo_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// updateDocument returns the file with the incremental update
+// adding a page to the document of the file.
+func updateDocument(t *testing.T, file []byte) []byte {
+	t.Helper()
+
+	doc, err := podofo.LoadMemDocument(bytes.NewReader(file))
+	require.NoError(t, err)
+	require.NotNil(t, doc.AddPage(podofo.PageSizeA4()))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, doc.SaveUpdate(&buf))
+
+	return buf.Bytes()
+}
+
+func TestParseRevisions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		options    []podofo.SaveOption
+		updates    int
+		linearized bool
+		numPages   []int
+	}{
+		{name: "plain", numPages: []int{2}},
+		{name: "updated", updates: 2, numPages: []int{2, 3, 4}},
+		{
+			name:       "linearized",
+			options:    []podofo.SaveOption{podofo.SaveOptionLinearize},
+			linearized: true,
+			numPages:   []int{2},
+		},
+		{
+			name:     "linearized and updated",
+			options:  []podofo.SaveOption{podofo.SaveOptionLinearize},
+			updates:  1,
+			numPages: []int{2, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := saveDocument(t, newDocument(t, 2), tt.options...)
+			for i := 0; i < tt.updates; i++ {
+				file = updateDocument(t, file)
+			}
+
+			parser, err := podofo.Parse(bytes.NewReader(file))
+			require.NoError(t, err)
+			assert.Equal(t, tt.updates, parser.NumIncrementalUpdates())
+			assert.Equal(t, tt.linearized, parser.IsLinearized())
+			assert.Equal(t, parser.XRefOffset(), parser.Revisions()[len(parser.Revisions())-1].XRefOffset)
+
+			revisions := parser.Revisions()
+			require.Len(t, revisions, len(tt.numPages))
+
+			for i, numPages := range tt.numPages {
+				assert.NotNil(t, revisions[i].Trailer.Key("Root"), "revision %d", i)
+				assert.NotEmpty(t, revisions[i].Objects, "revision %d", i)
+
+				doc, err := podofo.LoadMemDocument(bytes.NewReader(file), podofo.AtRevision(i))
+				if assert.NoError(t, err, "revision %d", i) {
+					assert.Equal(t, numPages, doc.PageCollection().Count(), "revision %d", i)
+				}
+			}
+		})
+	}
+}
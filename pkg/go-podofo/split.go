@@ -0,0 +1,251 @@
+package podofo
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	"github.com/denisss025/go-podofo/internal/set"
+)
+
+// PageRange is a range of pages, the First is the index of the first
+// page of the range.
+type PageRange struct {
+	First int
+	Count int
+}
+
+// ParsePageRanges parses the page ranges like "1-3,7,10-" of a
+// document of numPages pages. The page numbers start from 1, an
+// open range starts from the first page or ends with the last one.
+func ParsePageRanges(ranges string, numPages int) ([]PageRange, error) {
+	var result []PageRange
+
+	for _, part := range strings.Split(ranges, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("parse page ranges %q: %w", ranges, ErrInvalidPageRange)
+		}
+
+		firstText, lastText, isRange := strings.Cut(part, "-")
+		if !isRange {
+			lastText = firstText
+		}
+
+		first, err := pageNumber(strings.TrimSpace(firstText), 1)
+		if err != nil {
+			return nil, fmt.Errorf("parse page ranges %q: %w", ranges, err)
+		}
+
+		last, err := pageNumber(strings.TrimSpace(lastText), numPages)
+		if err != nil {
+			return nil, fmt.Errorf("parse page ranges %q: %w", ranges, err)
+		}
+
+		if first < 1 || last > numPages || first > last {
+			return nil, fmt.Errorf("parse page ranges %q: %s: %w", ranges, part, ErrValueOutOfRange)
+		}
+
+		result = append(result, PageRange{First: first - 1, Count: last - first + 1})
+	}
+
+	return result, nil
+}
+
+// pageNumber parses the page number, defval is returned for
+// the empty text of an open range.
+func pageNumber(text string, defval int) (int, error) {
+	if text == "" {
+		return defval, nil
+	}
+
+	number, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidPageRange, text)
+	}
+
+	return number, nil
+}
+
+// SplitByRanges returns a document for each of the page ranges like
+// "1-3,7,10-", see ParsePageRanges. A document keeps the objects its
+// pages refer to only, and the outline items and the named
+// destinations pointing at its pages.
+func (pc *PageCollection) SplitByRanges(ranges string) ([]*MemDocument, error) {
+	pageRanges, err := ParsePageRanges(ranges, pc.Count())
+	if err != nil {
+		return nil, fmt.Errorf("split document: %w", err)
+	}
+
+	docs := make([]*MemDocument, len(pageRanges))
+
+	for i, r := range pageRanges {
+		if docs[i], err = pc.splitDocument(r.First, r.Count); err != nil {
+			return nil, fmt.Errorf("split document: %w", err)
+		}
+	}
+
+	return docs, nil
+}
+
+// SplitByOutline returns a document for each top-level outline item.
+// The document of an item holds the pages from the page of the item
+// to the page of the next one, the pages preceding the first item
+// go to the first document. The items pointing at the same page make
+// one document, the items pointing at no page are skipped.
+func (pc *PageCollection) SplitByOutline() ([]*MemDocument, error) {
+	pages, _ := pc.objects.pageTreeFrom(pc.root)
+
+	indexes := make(map[Reference]int, len(pages))
+	for i, page := range pages {
+		indexes[page] = i
+	}
+
+	var starts []int
+
+	seen := make(map[int]bool)
+	names := readDestinations(pc.objects, pc.catalog)
+	outlines := objectDictionary(pc.objects.resolve(pc.catalog.Key(pdf.KeyOutlines)))
+
+	if outlines != nil {
+		visited := set.New[Reference]()
+
+		for ref, _ := outlines.Key(pdf.KeyFirst).(*Reference); ref != nil && !visited.Contains(*ref); {
+			visited.Put(*ref)
+
+			item := objectDictionary(pc.objects.GetObject(ref))
+			if item == nil {
+				break
+			}
+
+			if page := destinationPage(pc.objects, names, itemDestination(pc.objects, item)); page != nil {
+				if index, ok := indexes[*page]; ok && !seen[index] {
+					seen[index] = true
+					starts = append(starts, index)
+				}
+			}
+
+			ref, _ = item.Key(pdf.KeyNext).(*Reference)
+		}
+	}
+
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("split document by outline: %w: no outline items", ErrPageNotFound)
+	}
+
+	sort.Ints(starts)
+	starts[0] = 0
+
+	docs := make([]*MemDocument, len(starts))
+
+	for i, first := range starts {
+		last := len(pages)
+		if i+1 < len(starts) {
+			last = starts[i+1]
+		}
+
+		var err error
+		if docs[i], err = pc.splitDocument(first, last-first); err != nil {
+			return nil, fmt.Errorf("split document by outline: %w", err)
+		}
+	}
+
+	return docs, nil
+}
+
+// SplitBySize returns the documents of the consecutive pages whose
+// files saved with the options are at most limit bytes long. A page
+// that does not fit the limit on its own makes a document anyway.
+func (pc *PageCollection) SplitBySize(limit int64, options ...SaveOption) ([]*MemDocument, error) {
+	var docs []*MemDocument
+
+	for first, total := 0, pc.Count(); first < total; {
+		count, doc, err := pc.largestChunk(first, total-first, limit, options)
+		if err != nil {
+			return nil, fmt.Errorf("split document by size: %w", err)
+		}
+
+		docs = append(docs, doc)
+		first += count
+	}
+
+	return docs, nil
+}
+
+// largestChunk returns the document of the most pages starting from
+// the first one that fits the limit. The number of pages is doubled
+// until the document does not fit and then bisected, so a few
+// documents are saved per chunk.
+func (pc *PageCollection) largestChunk(first, remaining int, limit int64,
+	options []SaveOption,
+) (int, *MemDocument, error) {
+	good, bad := 0, 0
+
+	var goodDoc *MemDocument
+
+	for {
+		count := 1
+
+		switch {
+		case good == 0:
+		case bad == 0:
+			count = 2 * good
+			if count > remaining {
+				count = remaining
+			}
+		default:
+			count = (good + bad) / 2
+		}
+
+		if good > 0 && count <= good {
+			return good, goodDoc, nil
+		}
+
+		doc, err := pc.splitDocument(first, count)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		size, err := savedSize(doc, options)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch {
+		case size <= limit || count == 1:
+			good, goodDoc = count, doc
+		default:
+			bad = count
+		}
+
+		if count == 1 && size > limit {
+			return good, goodDoc, nil
+		}
+	}
+}
+
+// splitDocument creates the document of count pages starting from
+// the first one.
+func (pc *PageCollection) splitDocument(first, count int) (*MemDocument, error) {
+	doc := NewMemDocument()
+
+	if err := doc.PageCollection().insertPages(0, pc, first, count); err != nil {
+		return nil, fmt.Errorf("pages %d-%d: %w", first+1, first+count, err)
+	}
+
+	return doc, nil
+}
+
+// savedSize returns the length of the file of the document.
+func savedSize(doc *MemDocument, options []SaveOption) (int64, error) {
+	w := &countingWriter{w: io.Discard}
+
+	if err := doc.Save(w, options...); err != nil {
+		return 0, err
+	}
+
+	return w.n, nil
+}
@@ -0,0 +1,254 @@
+package podofo_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// outlineDocument loads the document of the pages 100, 200 and 300
+// points wide (objects 3-5) with the outline items and the catalog
+// entries.
+func outlineDocument(t *testing.T, catalog string, items ...string) *podofo.MemDocument {
+	t.Helper()
+
+	objects := []string{
+		"<</Type /Catalog /Pages 2 0 R /Outlines 6 0 R " + catalog + ">>",
+		"<</Type /Pages /Kids [3 0 R 4 0 R 5 0 R] /Count 3>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 100 100]>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 200 100]>>",
+		"<</Type /Page /Parent 2 0 R /MediaBox [0 0 300 100]>>",
+		"<</Type /Outlines /First 7 0 R>>",
+	}
+
+	for i, item := range items {
+		var next string
+		if i+1 < len(items) {
+			next = fmt.Sprintf(" /Next %d 0 R", 8+i)
+		}
+
+		objects = append(objects, "<</Title (item) /Parent 6 0 R "+item+next+">>")
+	}
+
+	doc, err := podofo.LoadMemDocument(bytes.NewReader(buildPDF("1.4", "/Root 1 0 R", objects...)))
+	require.NoError(t, err)
+
+	return doc
+}
+
+// splitWidths returns the page widths of the documents.
+func splitWidths(t *testing.T, docs []*podofo.MemDocument) [][]int {
+	t.Helper()
+
+	widths := make([][]int, len(docs))
+	for i, doc := range docs {
+		widths[i] = pageWidths(t, doc.PageCollection())
+	}
+
+	return widths
+}
+
+func TestParsePageRanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ranges  string
+		want    []podofo.PageRange
+		wantErr error
+	}{
+		{name: "page", ranges: "3", want: []podofo.PageRange{{First: 2, Count: 1}}},
+		{name: "range", ranges: "2-4", want: []podofo.PageRange{{First: 1, Count: 3}}},
+		{name: "open start", ranges: "-3", want: []podofo.PageRange{{First: 0, Count: 3}}},
+		{name: "open end", ranges: "8-", want: []podofo.PageRange{{First: 7, Count: 3}}},
+		{
+			name:   "list",
+			ranges: "1-3, 7 ,10-",
+			want:   []podofo.PageRange{{First: 0, Count: 3}, {First: 6, Count: 1}, {First: 9, Count: 1}},
+		},
+		{name: "empty", ranges: "", wantErr: podofo.ErrInvalidPageRange},
+		{name: "empty part", ranges: "1,,2", wantErr: podofo.ErrInvalidPageRange},
+		{name: "not a number", ranges: "a-3", wantErr: podofo.ErrInvalidPageRange},
+		{name: "zero", ranges: "0", wantErr: podofo.ErrValueOutOfRange},
+		{name: "past the end", ranges: "5-11", wantErr: podofo.ErrValueOutOfRange},
+		{name: "reversed", ranges: "4-2", wantErr: podofo.ErrValueOutOfRange},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := podofo.ParsePageRanges(tt.ranges, 10)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPageCollectionSplitByRanges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		ranges  string
+		want    [][]int
+		wantErr error
+	}{
+		{name: "pages", ranges: "1,2,3", want: [][]int{{100}, {200}, {300}}},
+		{name: "ranges", ranges: "2-,1", want: [][]int{{200, 300}, {100}}},
+		{name: "overlapping", ranges: "1-2,2-3", want: [][]int{{100, 200}, {200, 300}}},
+		{name: "out of range", ranges: "1-4", wantErr: podofo.ErrValueOutOfRange},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			docs, err := newSizedDocument(t, 100, 200, 300).PageCollection().SplitByRanges(tt.ranges)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, splitWidths(t, docs))
+		})
+	}
+}
+
+func TestPageCollectionSplitByOutline(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		catalog string
+		items   []string
+		want    [][]int
+		wantErr error
+	}{
+		{
+			name:  "explicit destinations",
+			items: []string{"/Dest [4 0 R /Fit]", "/Dest [5 0 R /Fit]"},
+			want:  [][]int{{100, 200}, {300}},
+		},
+		{
+			name:  "go-to actions",
+			items: []string{"/A <</S /GoTo /D [3 0 R /Fit]>>", "/A <</S /GoTo /D [5 0 R /Fit]>>"},
+			want:  [][]int{{100, 200}, {300}},
+		},
+		{
+			name:    "named destination",
+			catalog: "/Names <</Dests <</Names [(last) [5 0 R /Fit]]>>>>",
+			items:   []string{"/Dest [3 0 R /Fit]", "/Dest (last)"},
+			want:    [][]int{{100, 200}, {300}},
+		},
+		{
+			name:  "same page",
+			items: []string{"/Dest [4 0 R /Fit]", "/Dest [4 0 R /XYZ 0 0 0]", "/Dest [3 0 R /Fit]"},
+			want:  [][]int{{100}, {200, 300}},
+		},
+		{
+			name:  "no page",
+			items: []string{"/Dest [3 0 R /Fit]", "/Dest (missing)", "/Dest [5 0 R /Fit]"},
+			want:  [][]int{{100, 200}, {300}},
+		},
+		{
+			name:    "no items",
+			items:   []string{"/Dest (missing)"},
+			wantErr: podofo.ErrPageNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			docs, err := outlineDocument(t, tt.catalog, tt.items...).PageCollection().SplitByOutline()
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, splitWidths(t, docs))
+		})
+	}
+}
+
+func TestPageCollectionSplitBySize(t *testing.T) {
+	t.Parallel()
+
+	widths := make([]int, 20)
+	for i := range widths {
+		widths[i] = 100 + i
+	}
+
+	// splitSize returns the size of the file of the pages.
+	splitSize := func(ranges string) int64 {
+		docs, err := newSizedDocument(t, widths...).PageCollection().SplitByRanges(ranges)
+		require.NoError(t, err)
+
+		return int64(len(saveDocument(t, docs[0])))
+	}
+
+	onePage, allPages := splitSize("1"), splitSize("1-20")
+
+	tests := []struct {
+		name  string
+		limit int64
+		docs  int
+	}{
+		{name: "one document", limit: allPages, docs: 1},
+		{name: "page per document", limit: 1, docs: 20},
+		{name: "several documents", limit: (onePage + allPages) / 2},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			docs, err := newSizedDocument(t, widths...).PageCollection().SplitBySize(tt.limit)
+			require.NoError(t, err)
+
+			if tt.docs > 0 {
+				assert.Len(t, docs, tt.docs)
+			} else {
+				assert.Greater(t, len(docs), 1)
+				assert.Less(t, len(docs), len(widths))
+			}
+
+			var got []int
+
+			for _, doc := range docs {
+				got = append(got, pageWidths(t, doc.PageCollection())...)
+
+				if tt.limit >= onePage {
+					assert.LessOrEqual(t, int64(len(saveDocument(t, doc))), tt.limit)
+				}
+			}
+
+			assert.Equal(t, widths, got)
+		})
+	}
+}
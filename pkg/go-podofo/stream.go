@@ -0,0 +1,96 @@
+package podofo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/denisss025/go-podofo/internal/filter"
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// Stream is a stream object created in memory. The data
+// is kept encoded with the /Filter of the dictionary.
+type Stream struct {
+	*Dictionary
+
+	data []byte
+}
+
+// NewStream creates a stream of the data encoded with the /Filter
+// of the dictionary. A nil dictionary means the data is not encoded.
+func NewStream(dict *Dictionary, data []byte) *Stream {
+	if dict == nil {
+		dict = NewDictionary()
+	}
+
+	return &Stream{Dictionary: dict, data: data}
+}
+
+// NewFlateStream creates a stream of the data compressed with Flate.
+func NewFlateStream(dict *Dictionary, data []byte) (*Stream, error) {
+	encoded, err := flateEncode(data)
+	if err != nil {
+		return nil, fmt.Errorf("new flate stream: %w", err)
+	}
+
+	stream := NewStream(dict, encoded)
+	stream.AddKey(pdf.KeyFilter, &pdf.NameObject{Name: pdf.NameFlateDecode})
+
+	return stream, nil
+}
+
+// Data returns the encoded data of the stream.
+func (s *Stream) Data() []byte { return s.data }
+
+// streamData returns the dictionary and the raw data, i.e. decrypted,
+// but encoded, of a stream object. False is returned if the object
+// is not a stream.
+func streamData(obj Object) (*Dictionary, []byte, bool, error) {
+	switch obj := obj.(type) {
+	case *Stream:
+		return obj.Dictionary, obj.data, true, nil
+	case *ParserObject:
+		if err := obj.DelayedLoadStream(); err != nil {
+			return nil, nil, false, err
+		}
+
+		if !obj.HasStream() {
+			return nil, nil, false, nil
+		}
+
+		r, err := obj.newRawStreamReader()
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		return obj.Dictionary, data, true, nil
+	default:
+		return nil, nil, false, nil
+	}
+}
+
+// flateEncode compresses the data with Flate.
+func flateEncode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := filter.NewWriter(&buf, filter.TypeFlateDecode, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
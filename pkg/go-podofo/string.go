@@ -2,6 +2,9 @@ package podofo
 
 import (
 	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
 
 	"github.com/denisss025/go-podofo/internal/pdf"
 )
@@ -44,6 +47,14 @@ type String struct {
 	isHex bool
 }
 
+// newRawString creates a string from the bytes read from a file.
+func newRawString(data []byte, isHex bool) *String {
+	return &String{
+		data:  &stringData{State: StringStateRawBuffer, Chars: data},
+		isHex: isHex,
+	}
+}
+
 func (s *String) isValidText() (bool, error) {
 	switch s.data.State {
 	case StringStateASCII, StringStateDocEncoding, StringStateUnicode:
@@ -72,8 +83,67 @@ func (s *String) unmarshalUTF8(text []byte) error {
 	panic("not implemented") // TODO: implement me
 }
 
+// MarshalPDF writes the string encrypted with the key
+// of the indirect object being written.
 func (s *String) MarshalPDF(w *pdf.Writer) error {
-	panic("not implemented") // TODO: implement me
+	data, err := w.EncryptString(s.data.Chars)
+	if err != nil {
+		return fmt.Errorf("marshal string: %w", err)
+	}
+
+	if s.isHex {
+		return writeString(w, "<"+hex.EncodeToString(data)+">")
+	}
+
+	return writeLiteralString(w, data)
+}
+
+// writeLiteralString writes the data as a literal string with the
+// minimal escaping: the balanced parentheses are written as is, only
+// the unbalanced ones, the backslashes and the carriage returns are
+// escaped, so the binary data is read back as is.
+func writeLiteralString(w io.Writer, data []byte) error {
+	escape := unbalancedParentheses(data)
+	buf := make([]byte, 0, len(data)+len(escape)+len("()"))
+
+	buf = append(buf, '(')
+
+	for i, c := range data {
+		switch {
+		case c == '\\', escape[i]:
+			buf = append(buf, '\\', c)
+		case c == '\r':
+			buf = append(buf, '\\', 'r')
+		default:
+			buf = append(buf, c)
+		}
+	}
+
+	return write(w, append(buf, ')'))
+}
+
+// unbalancedParentheses returns the positions of the parentheses
+// that have no pair.
+func unbalancedParentheses(data []byte) map[int]bool {
+	unbalanced := make(map[int]bool)
+	open := []int{}
+
+	for i, c := range data {
+		switch {
+		case c == '(':
+			open = append(open, i)
+		case c == ')' && len(open) > 0:
+			open = open[:len(open)-1]
+		case c == ')':
+			unbalanced[i] = true
+		}
+	}
+
+	for _, i := range open {
+		unbalanced[i] = true
+	}
+
+	return unbalanced
 }
 
 func (s *String) IsHex() bool { return s.isHex }
@@ -1,5 +1,21 @@
 package podofo
 
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+// Token is a lexical token of a PDF file.
+type Token = pdf.Token
+
+// Tokenizer reads the PDF tokens and objects from a Reader. After
+// each call the position of the Reader is right after the last
+// token read, so the Reader may be used directly in between.
 type Tokenizer struct {
 	buffer []byte
 }
@@ -8,10 +24,285 @@ func NewTokenizer() *Tokenizer {
 	return &Tokenizer{buffer: make([]byte, BufferSize)}
 }
 
+// TryReadNextToken reads the next token and returns its value,
+// see Token.Value. The io.EOF error is returned at the end of r.
 func (tok *Tokenizer) TryReadNextToken(r Reader) (token []byte, err error) {
-	panic("not implemented") // TODO: implement me
+	next, err := tok.ReadNextToken(r)
+
+	return next.Value, err
+}
+
+// ReadNextToken reads the next token. The io.EOF error
+// is returned at the end of r.
+func (tok *Tokenizer) ReadNextToken(r Reader) (token Token, err error) {
+	err = tok.lex(r, func(lex *pdf.Lexer) (err error) {
+		token, err = lex.Next()
+
+		return err
+	})
+
+	return token, err
 }
 
+// PeekNextToken reads the next token without consuming it.
+func (tok *Tokenizer) PeekNextToken(r Reader) (token Token, err error) {
+	err = tok.lex(r, func(lex *pdf.Lexer) (err error) {
+		token, err = lex.Peek()
+
+		return err
+	})
+
+	return token, err
+}
+
+// ReadNextNumber reads the next token as an integer. If the token
+// is not an integer, it is not consumed and ErrNoNumber is returned.
 func (tok *Tokenizer) ReadNextNumber(r Reader) (number int64, err error) {
-	panic("not implemented") // TODO: implement me
+	err = tok.lex(r, func(lex *pdf.Lexer) error {
+		token, err := lex.Next()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+
+		if token.Kind != pdf.TokenKindInteger {
+			lex.Unread(token)
+
+			return fmt.Errorf("%w: %q", ErrNoNumber, token.Value)
+		}
+
+		number, err = token.Int64()
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrNoNumber, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("read next number: %w", err)
+	}
+
+	return number, err
+}
+
+// ReadNextObject reads the next complete object from r. Indirect
+// references are returned as *Reference and are not resolved.
+func (tok *Tokenizer) ReadNextObject(r Reader) (obj Object, err error) {
+	err = tok.lex(r, func(lex *pdf.Lexer) (err error) {
+		obj, err = readObject(lex)
+
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("read next object: %w", err)
+	}
+
+	return obj, err
+}
+
+// lex calls fn with a lexer reading r through the tokenizer buffer.
+// Then the position of r is set right after the last token consumed.
+func (tok *Tokenizer) lex(r Reader, fn func(lex *pdf.Lexer) error) error {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	lex := pdf.NewLexer(&bufferedScanner{r: r, buf: tok.buffer})
+	err = fn(lex)
+
+	if _, seekErr := r.Seek(start+lex.Offset(), io.SeekStart); seekErr != nil {
+		err = errors.Join(err, seekErr)
+	}
+
+	return err
+}
+
+// readObject reads the next object.
+func readObject(lex *pdf.Lexer) (Object, error) {
+	token, err := lex.Next()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	return readObjectFrom(lex, token)
+}
+
+// readObjectFrom reads the object starting with the token.
+func readObjectFrom(lex *pdf.Lexer, token Token) (Object, error) {
+	switch token.Kind {
+	case pdf.TokenKindDictionaryStart:
+		return readDictionary(lex)
+	case pdf.TokenKindArrayStart:
+		return readArray(lex)
+	case pdf.TokenKindName:
+		return &pdf.NameObject{Name: pdf.Name(token.Value)}, nil
+	case pdf.TokenKindString:
+		return newRawString(token.Value, false), nil
+	case pdf.TokenKindHexString:
+		return newRawString(token.Value, true), nil
+	case pdf.TokenKindReal:
+		return pdf.ParseNumber(token.Value)
+	case pdf.TokenKindInteger:
+		return readNumberOrReference(lex, token)
+	case pdf.TokenKindKeyword:
+		switch string(token.Value) {
+		case "true":
+			return Bool(true), nil
+		case "false":
+			return Bool(false), nil
+		case "null":
+			return Null{}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unexpected token %q at offset %d",
+		ErrNoObject, token.Value, token.Offset)
+}
+
+func readDictionary(lex *pdf.Lexer) (*Dictionary, error) {
+	dict := NewDictionary()
+
+	for {
+		token, err := lex.Next()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if token.Kind == pdf.TokenKindDictionaryEnd {
+			return dict, nil
+		}
+
+		if token.Kind != pdf.TokenKindName {
+			return nil, fmt.Errorf("%w: dictionary key %q is not a name", ErrInvalidDataType, token.Value)
+		}
+
+		value, err := readObject(lex)
+		if err != nil {
+			return nil, fmt.Errorf("read /%s: %w", token.Value, err)
+		}
+
+		dict.AddKey(pdf.Name(token.Value), value)
+	}
+}
+
+func readArray(lex *pdf.Lexer) (*Array, error) {
+	array := new(Array)
+
+	for {
+		token, err := lex.Next()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+
+		if token.Kind == pdf.TokenKindArrayEnd {
+			return array, nil
+		}
+
+		obj, err := readObjectFrom(lex, token)
+		if err != nil {
+			return nil, err
+		}
+
+		array.objects = append(array.objects, obj)
+	}
+}
+
+// readNumberOrReference reads either the integer
+// or the "N G R" indirect reference.
+func readNumberOrReference(lex *pdf.Lexer, token Token) (Object, error) {
+	gen, err := lex.Next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return pdf.ParseNumber(token.Value)
+		}
+
+		return nil, err
+	}
+
+	if gen.Kind == pdf.TokenKindInteger {
+		keyword, err := lex.Next()
+
+		switch {
+		case err == nil && keyword.IsKeyword("R"):
+			return newReference(token, gen)
+		case err == nil:
+			lex.Unread(keyword)
+		case !errors.Is(err, io.EOF):
+			return nil, err
+		}
+	}
+
+	lex.Unread(gen)
+
+	return pdf.ParseNumber(token.Value)
+}
+
+func newReference(objNo, gen Token) (*Reference, error) {
+	objectNo, err := objNo.Int64()
+	if err != nil || objectNo <= 0 || objectNo > math.MaxUint32 {
+		return nil, fmt.Errorf("%w: object number %q", ErrValueOutOfRange, objNo.Value)
+	}
+
+	generationNo, err := gen.Int64()
+	if err != nil || generationNo < 0 || generationNo > int64(pdf.MaxGeneration) {
+		return nil, fmt.Errorf("%w: generation number %q", ErrValueOutOfRange, gen.Value)
+	}
+
+	return pdf.NewReference(int(objectNo), Generation(generationNo)), nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// bufferedScanner reads a Reader through the tokenizer buffer,
+// so no buffer is allocated for each token.
+type bufferedScanner struct {
+	r   Reader
+	buf []byte
+	pos int
+	end int
+}
+
+var _ io.ByteScanner = (*bufferedScanner)(nil)
+
+func (s *bufferedScanner) ReadByte() (byte, error) {
+	if s.pos == s.end {
+		if err := s.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	ch := s.buf[s.pos]
+	s.pos++
+
+	return ch, nil
+}
+
+func (s *bufferedScanner) UnreadByte() error {
+	if s.pos == 0 {
+		return bufio.ErrInvalidUnreadByte
+	}
+
+	s.pos--
+
+	return nil
+}
+
+func (s *bufferedScanner) fill() error {
+	// Keep the last byte for UnreadByte.
+	keep := 0
+	if s.end > 0 {
+		s.buf[0] = s.buf[s.end-1]
+		keep = 1
+	}
+
+	n, err := io.ReadAtLeast(s.r, s.buf[keep:], 1)
+	s.pos, s.end = keep, keep+n
+
+	return err
 }
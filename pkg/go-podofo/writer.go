@@ -1,3 +1,826 @@
 package podofo
 
-type Writer struct{}
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	"github.com/denisss025/go-podofo/internal/set"
+)
+
+// binaryComment follows the header, so the applications
+// that transfer the file treat it as binary data (see
+// ISO 32000-1:2008, 7.5.2).
+const binaryComment = "%\xE2\xE3\xCF\xD3\n"
+
+// countingWriter counts the bytes written, so
+// the offsets of the objects are known.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+
+	return n, err
+}
+
+// Writer writes the indirect objects of a document to a PDF file:
+// the header, the objects, the XRef section and the trailer.
+type Writer struct {
+	objects *IndirectObjectList
+	trailer *Dictionary
+	encrypt *Encrypt
+	version PDFVersion
+	options SaveOption
+
+	out *countingWriter
+	pw  *pdf.Writer
+
+	// entries are the XRef entries of the objects written.
+	entries map[uint32]xrefEntry
+	// encryptRef is the reference of the encryption
+	// dictionary, which is never encrypted.
+	encryptRef *Reference
+
+	// objectStreamSize is the number of objects an object
+	// stream holds, 0 means the objects are not compressed.
+	objectStreamSize int
+	// reusable are the object numbers the objects created
+	// by the writer are given first, nextObjNo is the next
+	// number beyond the ones of the objects.
+	reusable  []int
+	nextObjNo uint32
+
+	useXRefStream bool
+	// update is true if the objects are appended to the
+	// original file, prevXRef is the offset of its XRef.
+	update   bool
+	prevXRef int64
+}
+
+// NewWriter creates the writer of the objects. The /Root, /Info,
+// /ID and /Encrypt keys of the trailer are written, the other
+// keys are computed.
+func NewWriter(objects *IndirectObjectList, trailer *Dictionary, version PDFVersion, options SaveOption) *Writer {
+	return &Writer{
+		objects:          objects,
+		trailer:          trailer,
+		version:          version,
+		options:          options,
+		objectStreamSize: defaultObjectStreamSize,
+		useXRefStream:    version >= pdf.Version15,
+	}
+}
+
+// SetEncrypt sets the security handler the strings and the streams
+// are encrypted with. Nil means the document is not encrypted.
+func (w *Writer) SetEncrypt(enc *Encrypt) { w.encrypt = enc }
+
+// SetObjectStreamSize sets the number of objects an object stream
+// holds. The objects are written to the object streams only if the
+// XRef stream is written, 0 means the objects are not compressed.
+func (w *Writer) SetObjectStreamSize(size int) { w.objectStreamSize = size }
+
+// UseXRefStream returns true if the XRef stream is written instead
+// of the classic XRef table, by default for PDF 1.5 and later.
+func (w *Writer) UseXRefStream() bool { return w.useXRefStream }
+
+// SetUseXRefStream sets whether the XRef stream is written instead of
+// the classic XRef table. An incremental update uses the XRef section
+// of the kind the original file has.
+func (w *Writer) SetUseXRefStream(use bool) { w.useXRefStream = use }
+
+// Write writes the document to out.
+func (w *Writer) Write(out io.Writer) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("write document: %w", err)
+		}
+	}()
+
+	if w.options&SaveOptionLinearize != 0 {
+		return w.writeLinearized(out)
+	}
+
+	return w.write(out, 0)
+}
+
+// WriteUpdate writes the incremental update of the document to out,
+// which follows the original file of size offset: the new and the
+// changed objects, the objects removed and the XRef section, which
+// refers to the one at prevXRef (see ISO 32000-1:2008, 7.5.6). The
+// original objects are kept, the security handler is not changed.
+func (w *Writer) WriteUpdate(out io.Writer, offset, prevXRef int64) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("write update: %w", err)
+		}
+	}()
+
+	w.update = true
+	w.prevXRef = prevXRef
+
+	return w.write(out, offset)
+}
+
+func (w *Writer) write(out io.Writer, offset int64) (err error) {
+	buf := bufio.NewWriter(out)
+
+	w.out = &countingWriter{w: buf, n: offset}
+	w.entries = make(map[uint32]xrefEntry)
+	w.pw = pdf.NewWriter(w.out, w.writerOptions()...)
+	w.nextObjNo = w.objects.maxObjectNo() + 1
+
+	if w.update {
+		// The object streams of the file hold the objects
+		// not changed, so their numbers are never reused.
+		if size := w.trailer.Int(pdf.KeySize, 0); size > int64(w.nextObjNo) {
+			w.nextObjNo = uint32(size)
+		}
+	} else {
+		w.reusable = w.objects.ObjectStreams()
+	}
+
+	trailer, err := w.newTrailer()
+	if err != nil {
+		return err
+	}
+
+	if !w.update {
+		if err = writeString(w.pw, "%PDF-"+string(w.version)+"\n"+binaryComment); err != nil {
+			return err
+		}
+	}
+
+	objects := w.collectObjects(trailer)
+
+	if w.UseXRefStream() && w.objectStreamSize > 0 {
+		if objects, err = w.writeObjectStreams(objects); err != nil {
+			return err
+		}
+	}
+
+	for _, obj := range objects {
+		if err = w.writeObject(obj); err != nil {
+			return err
+		}
+	}
+
+	if w.encryptRef != nil && !w.update {
+		w.pw.SetReference(Reference{})
+
+		if err = w.writeIndirect(*w.encryptRef, w.encrypt.Dictionary(), nil, false); err != nil {
+			return fmt.Errorf("encryption dictionary: %w", err)
+		}
+	}
+
+	if err = w.writeXRef(trailer); err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+func (w *Writer) writerOptions() []pdf.WriterOptionFunc {
+	var options []pdf.WriterOptionFunc
+
+	if w.options&SaveOptionClean != 0 {
+		options = append(options, pdf.WriteClean())
+	}
+
+	if w.options&SaveOptionNoFlateCompress != 0 {
+		options = append(options, pdf.WriteNoCompress())
+	}
+
+	if w.encrypt != nil {
+		options = append(options, pdf.WriteEncryptor(w.encrypt))
+	}
+
+	return options
+}
+
+// newTrailer creates the trailer with the /Root, /Info, /ID and
+// /Encrypt keys. The file key of a new security handler is
+// generated here, as it depends on the /ID.
+func (w *Writer) newTrailer() (*Dictionary, error) {
+	trailer := NewDictionary()
+
+	for _, key := range []pdf.Name{pdf.KeyRoot, pdf.KeyInfo} {
+		if value := w.trailer.Key(key); value != nil {
+			trailer.AddKey(key, value)
+		}
+	}
+
+	id, err := w.documentID()
+	if err != nil {
+		return nil, err
+	}
+
+	trailer.AddKey(pdf.KeyID, id)
+
+	if w.update {
+		trailer.AddKey(pdf.KeyPrev, pdf.NewInt(w.prevXRef))
+	}
+
+	if w.encrypt == nil {
+		return trailer, nil
+	}
+
+	if w.update {
+		// The update is encrypted with the file key of the
+		// original file, its encryption dictionary is kept.
+		switch value := w.trailer.Key(pdf.KeyEncrypt).(type) {
+		case *Reference:
+			w.encryptRef = value
+			trailer.AddKey(pdf.KeyEncrypt, value)
+		case *Dictionary:
+			trailer.AddKey(pdf.KeyEncrypt, value)
+		}
+
+		return trailer, nil
+	}
+
+	first, _ := id.objects[0].(*String)
+	if err = w.encrypt.GenerateEncryptionKey(first); err != nil {
+		return nil, err
+	}
+
+	ref, ok := w.trailer.Key(pdf.KeyEncrypt).(*Reference)
+	if !ok {
+		ref = w.newReference()
+	}
+
+	w.encryptRef = ref
+	trailer.AddKey(pdf.KeyEncrypt, ref)
+
+	return trailer, nil
+}
+
+// newReference returns the reference of an object created by the
+// writer. The numbers of the object streams of the file are reused
+// first, as these streams are never written.
+func (w *Writer) newReference() *Reference {
+	for len(w.reusable) > 0 {
+		objNo := w.reusable[0]
+		w.reusable = w.reusable[1:]
+
+		ref := pdf.NewReference(objNo, 0)
+		if obj := w.objects.GetObject(ref); obj == nil || objectType(obj) == pdf.NameObjStm {
+			return ref
+		}
+	}
+
+	w.nextObjNo++
+
+	return pdf.NewReference(int(w.nextObjNo-1), 0)
+}
+
+// documentID returns the /ID of the trailer. The first element is
+// kept, as the file key of an encrypted document depends on it, and
+// the second one is created anew (see ISO 32000-2:2020, 14.4).
+func (w *Writer) documentID() (*Array, error) {
+	id, err := NewDocumentID()
+	if err != nil {
+		return nil, err
+	}
+
+	first := id
+
+	if ids, ok := w.trailer.Key(pdf.KeyID).(*Array); ok && len(ids.objects) > 0 {
+		if s, ok := ids.objects[0].(*String); ok {
+			first = s
+		}
+	}
+
+	return &Array{objects: []Object{first, id}}, nil
+}
+
+// collectObjects returns the objects to be written sorted by their
+// references. Unless SaveOptionNoCollectGarbage is set, only the
+// objects reachable from the trailer are written. The XRef streams
+// and the object streams of the original file are never written, as
+// their objects are written anew. The incremental update holds only
+// the new and the changed objects.
+func (w *Writer) collectObjects(trailer *Dictionary) []Object {
+	var reachable *set.Set[Reference]
+
+	if w.options&SaveOptionNoCollectGarbage == 0 && !w.update {
+		reachable = w.objects.reachable(trailer)
+	}
+
+	all := w.objects.Objects()
+	objects := make([]Object, 0, len(all))
+
+	for _, obj := range all {
+		ref := indirectReference(obj)
+
+		switch typ := objectType(obj); {
+		case w.encryptRef != nil && *ref == *w.encryptRef:
+		case reachable != nil && !reachable.Contains(*ref):
+		case w.update && !isDirty(obj):
+		case typ == pdf.NameXRef || typ == pdf.NameObjStm:
+		default:
+			objects = append(objects, obj)
+		}
+	}
+
+	return objects
+}
+
+// objectDictionary returns the dictionary of an object,
+// nil is returned if the object is not a dictionary.
+func objectDictionary(obj Object) *Dictionary {
+	switch obj := obj.(type) {
+	case *Dictionary:
+		return obj
+	case *Stream:
+		return obj.Dictionary
+	case *ParserObject:
+		if err := obj.DelayedLoad(); err != nil {
+			return nil
+		}
+
+		return obj.Dictionary
+	default:
+		return nil
+	}
+}
+
+// objectType returns the /Type of a dictionary object.
+func objectType(obj Object) pdf.Name {
+	if dict := objectDictionary(obj); dict != nil {
+		if name, ok := dict.Key(pdf.KeyType).(*pdf.NameObject); ok {
+			return name.Name
+		}
+	}
+
+	return pdf.KeyNull
+}
+
+// writeObject writes the indirect object encrypted with its key.
+func (w *Writer) writeObject(obj Object) error {
+	return w.writeObjectAs(*indirectReference(obj), obj)
+}
+
+// writeObjectAs writes the object as the indirect object ref,
+// e.g. when the objects are renumbered.
+func (w *Writer) writeObjectAs(ref Reference, obj Object) error {
+	w.pw.SetReference(ref)
+
+	dict, data, isStream, err := streamData(obj)
+	if err != nil {
+		return fmt.Errorf("object %s: %w", ref, err)
+	}
+
+	value := obj
+	if parserObj, ok := obj.(*ParserObject); ok {
+		value = parserObj.Object()
+	}
+
+	if isStream {
+		if dict, data, err = w.encodeStream(dict, data); err != nil {
+			return fmt.Errorf("object %s: %w", ref, err)
+		}
+
+		value = dict
+	}
+
+	if err = w.writeIndirect(ref, value, data, isStream); err != nil {
+		return fmt.Errorf("object %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// writeObjectStreams writes the objects that can be compressed to
+// the object streams and returns the other ones, which are written
+// as usual.
+func (w *Writer) writeObjectStreams(objects []Object) ([]Object, error) {
+	var (
+		osw       *ObjectStreamWriter
+		streamRef *Reference
+	)
+
+	rest := make([]Object, 0, len(objects))
+
+	for _, obj := range objects {
+		ref := *indirectReference(obj)
+
+		value, ok := compressibleValue(obj)
+		if !ok || ref.GenerationNo != 0 {
+			rest = append(rest, obj)
+
+			continue
+		}
+
+		if osw == nil {
+			osw, streamRef = NewObjectStreamWriter(w.writerOptions()...), w.newReference()
+		}
+
+		index, err := osw.Add(ref, value)
+		if err != nil {
+			return nil, err
+		}
+
+		w.entries[ref.ObjectNo] = XRefEntryCompressed{
+			ObjectNumber: int(streamRef.ObjectNo),
+			Index:        int64(index),
+		}
+
+		if osw.Len() >= w.objectStreamSize {
+			if err = w.writeObjectStream(*streamRef, osw); err != nil {
+				return nil, err
+			}
+
+			osw = nil
+		}
+	}
+
+	if osw != nil {
+		if err := w.writeObjectStream(*streamRef, osw); err != nil {
+			return nil, err
+		}
+	}
+
+	return rest, nil
+}
+
+// compressibleValue returns the value of an object
+// that can be written to an object stream, i.e. it
+// is not a stream (see ISO 32000-1:2008, 7.5.7).
+func compressibleValue(obj Object) (Object, bool) {
+	switch obj := obj.(type) {
+	case *Stream:
+		return nil, false
+	case *ParserObject:
+		if err := obj.DelayedLoadStream(); err != nil || obj.HasStream() {
+			return nil, false
+		}
+
+		return obj.Object(), true
+	default:
+		return obj, true
+	}
+}
+
+// writeObjectStream writes the object stream encrypted with its key.
+func (w *Writer) writeObjectStream(ref Reference, osw *ObjectStreamWriter) error {
+	w.pw.SetReference(ref)
+
+	dict, data := osw.Stream()
+
+	dict, data, err := w.encodeStream(dict, data)
+	if err != nil {
+		return fmt.Errorf("object stream %s: %w", ref, err)
+	}
+
+	if err = w.writeIndirect(ref, dict, data, true); err != nil {
+		return fmt.Errorf("object stream %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// encodeStream compresses the data of a stream without filters
+// unless SaveOptionNoFlateCompress is set, and encrypts it. The
+// dictionary is copied to set the /Length of the data written.
+func (w *Writer) encodeStream(dict *Dictionary, data []byte) (*Dictionary, []byte, error) {
+	dict = dict.shallowCopy()
+
+	if w.options&SaveOptionNoFlateCompress == 0 && dict.Key(pdf.KeyFilter) == nil {
+		encoded, err := flateEncode(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		data = encoded
+		dict.AddKey(pdf.KeyFilter, &pdf.NameObject{Name: pdf.NameFlateDecode})
+	}
+
+	data, err := w.pw.EncryptStream(data, objectType(dict) == pdf.NameMetadata)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dict.AddKey(pdf.KeyLength, pdf.NewInt(int64(len(data))))
+
+	return dict, data, nil
+}
+
+// writeIndirect writes the "N G obj" header, the value and the
+// stream data, if any, and records the XRef entry of the object.
+func (w *Writer) writeIndirect(ref Reference, value Object, data []byte, isStream bool) (err error) {
+	w.entries[ref.ObjectNo] = XRefEntryInUse{Offset: w.out.n, Generation: ref.GenerationNo}
+
+	if _, err = fmt.Fprintf(w.pw, "%d %d obj\n", ref.ObjectNo, ref.GenerationNo); err != nil {
+		return err
+	}
+
+	if err = value.MarshalPDF(w.pw); err != nil {
+		return err
+	}
+
+	if isStream {
+		if err = writeString(w.pw, "\nstream\n"); err != nil {
+			return err
+		}
+
+		if err = write(w.pw, data); err != nil {
+			return err
+		}
+
+		if err = writeString(w.pw, "\nendstream"); err != nil {
+			return err
+		}
+	}
+
+	return writeString(w.pw, "\nendobj\n")
+}
+
+// xrefSection is a subsection of the XRef section,
+// the entries of the objects numbered from start.
+type xrefSection struct {
+	start   int
+	entries []xrefEntry
+}
+
+// writeXRef writes either the XRef table and the trailer or
+// the XRef stream, then the offset of the XRef section.
+func (w *Writer) writeXRef(trailer *Dictionary) (err error) {
+	offset := w.out.n
+
+	w.pw.SetReference(Reference{})
+
+	if w.UseXRefStream() {
+		err = w.writeXRefStream(trailer)
+	} else {
+		err = w.writeXRefTable(trailer)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w.pw, "startxref\n%d\n%%%%EOF\n", offset)
+
+	return err
+}
+
+// xrefSize returns the number of the XRef entries, the
+// free objects of the list are written to the XRef section
+// as well, so their generations are kept.
+func (w *Writer) xrefSize() int {
+	var maxObjNo uint32
+
+	for objNo := range w.entries {
+		if objNo > maxObjNo {
+			maxObjNo = objNo
+		}
+	}
+
+	if free := w.objects.FreeObjects(); len(free) > 0 && free[len(free)-1].ObjectNo > maxObjNo {
+		maxObjNo = free[len(free)-1].ObjectNo
+	}
+
+	size := int(maxObjNo) + 1
+
+	// The update never shrinks the XRef of the original file.
+	if prevSize := int(w.trailer.Int(pdf.KeySize, 0)); w.update && prevSize > size {
+		size = prevSize
+	}
+
+	return size
+}
+
+// xrefSections returns the subsections of the XRef section. The whole
+// file has a single one, the incremental update has the entries of
+// the objects written and of the objects removed only.
+func (w *Writer) xrefSections(size int) []xrefSection {
+	if !w.update {
+		return []xrefSection{{start: 0, entries: w.xrefEntries(size)}}
+	}
+
+	entries := make(map[uint32]xrefEntry, len(w.entries))
+	for objNo, entry := range w.entries {
+		entries[objNo] = entry
+	}
+
+	generations := make(map[uint32]Generation)
+	for _, ref := range w.objects.RemovedObjects() {
+		generations[ref.ObjectNo] = ref.GenerationNo
+	}
+
+	free := make([]int, 0, len(generations))
+
+	for objNo := range generations {
+		if _, ok := entries[objNo]; !ok {
+			free = append(free, int(objNo))
+		}
+	}
+
+	sort.Ints(free)
+
+	for i, objNo := range free {
+		next := 0
+		if i+1 < len(free) {
+			next = free[i+1]
+		}
+
+		entries[uint32(objNo)] = XRefEntryFree{ObjectNumber: next, Generation: generations[uint32(objNo)]}
+	}
+
+	objNos := make([]int, 0, len(entries))
+	for objNo := range entries {
+		objNos = append(objNos, int(objNo))
+	}
+
+	sort.Ints(objNos)
+
+	var sections []xrefSection
+
+	for i, objNo := range objNos {
+		if i == 0 || objNo != objNos[i-1]+1 {
+			sections = append(sections, xrefSection{start: objNo})
+		}
+
+		section := &sections[len(sections)-1]
+		section.entries = append(section.entries, entries[uint32(objNo)])
+	}
+
+	return sections
+}
+
+// xrefEntries returns the XRef entries of the objects written.
+// The other objects are free, they are linked in the ascending
+// order starting with the object 0 (see ISO 32000-1:2008, 7.5.4).
+func (w *Writer) xrefEntries(size int) []xrefEntry {
+	generations := w.freeGenerations()
+	entries := make([]xrefEntry, size)
+	next := 0
+
+	for objNo := size - 1; objNo >= 0; objNo-- {
+		if entry, ok := w.entries[uint32(objNo)]; ok && objNo > 0 {
+			entries[objNo] = entry
+
+			continue
+		}
+
+		gen := pdf.MaxGeneration
+		if objNo > 0 {
+			gen = generations[uint32(objNo)]
+		}
+
+		entries[objNo] = XRefEntryFree{ObjectNumber: next, Generation: gen}
+		next = objNo
+	}
+
+	return entries
+}
+
+// freeGenerations returns the generations of the free objects to
+// be used when they are reused. The generation of an object that
+// is not written, e.g. it is unreachable, is incremented.
+func (w *Writer) freeGenerations() map[uint32]Generation {
+	generations := make(map[uint32]Generation)
+
+	for ref := range w.objects.objects {
+		if _, ok := w.entries[ref.ObjectNo]; !ok && ref.GenerationNo < pdf.MaxGeneration {
+			generations[ref.ObjectNo] = ref.GenerationNo + 1
+		}
+	}
+
+	for _, ref := range w.objects.FreeObjects() {
+		generations[ref.ObjectNo] = ref.GenerationNo
+	}
+
+	return generations
+}
+
+// writeXRefTable writes the classic XRef table and the trailer.
+func (w *Writer) writeXRefTable(trailer *Dictionary) error {
+	const entryLen = 20
+
+	size := w.xrefSize()
+	sections := w.xrefSections(size)
+
+	buf := make([]byte, 0, len("xref\n")+entryLen*(size+len(sections)))
+	buf = append(buf, "xref\n"...)
+
+	for _, section := range sections {
+		buf = append(buf, fmt.Sprintf("%d %d\n", section.start, len(section.entries))...)
+
+		for _, entry := range section.entries {
+			switch entry := entry.(type) {
+			case XRefEntryInUse:
+				buf = pdf.AppendXRefEntry(buf, uint64(entry.Offset), entry.Generation, 'n')
+			case XRefEntryFree:
+				buf = pdf.AppendXRefEntry(buf, uint64(entry.ObjectNumber), entry.Generation, 'f')
+			}
+		}
+	}
+
+	if err := write(w.pw, buf); err != nil {
+		return err
+	}
+
+	trailer.AddKey(pdf.KeySize, pdf.NewInt(int64(size)))
+
+	if err := writeString(w.pw, "trailer\n"); err != nil {
+		return err
+	}
+
+	if err := trailer.MarshalPDF(w.pw); err != nil {
+		return err
+	}
+
+	return writeString(w.pw, "\n")
+}
+
+// writeXRefStream writes the XRef stream, which has an entry
+// of its own (see ISO 32000-1:2008, 7.5.8). The stream is never
+// encrypted.
+func (w *Writer) writeXRefStream(trailer *Dictionary) error {
+	ref := *w.newReference()
+
+	w.entries[ref.ObjectNo] = XRefEntryInUse{Offset: w.out.n}
+
+	size := w.xrefSize()
+	index := &Array{}
+
+	var (
+		rows      [][3]uint64
+		maxFields [3]uint64
+	)
+
+	for _, section := range w.xrefSections(size) {
+		index.objects = append(index.objects,
+			pdf.NewInt(int64(section.start)), pdf.NewInt(int64(len(section.entries))))
+
+		for _, entry := range section.entries {
+			var row [3]uint64
+
+			switch entry := entry.(type) {
+			case XRefEntryFree:
+				row = [3]uint64{0, uint64(entry.ObjectNumber), uint64(entry.Generation)}
+			case XRefEntryInUse:
+				row = [3]uint64{1, uint64(entry.Offset), uint64(entry.Generation)}
+			case XRefEntryCompressed:
+				row = [3]uint64{2, uint64(entry.ObjectNumber), uint64(entry.Index)}
+			}
+
+			for j, field := range row {
+				if field > maxFields[j] {
+					maxFields[j] = field
+				}
+			}
+
+			rows = append(rows, row)
+		}
+	}
+
+	widths := [3]int{
+		pdf.XRefStreamFieldWidth(maxFields[0]),
+		pdf.XRefStreamFieldWidth(maxFields[1]),
+		pdf.XRefStreamFieldWidth(maxFields[2]),
+	}
+
+	data := make([]byte, 0, len(rows)*(widths[0]+widths[1]+widths[2]))
+	for _, row := range rows {
+		data = pdf.AppendXRefStreamEntry(data, widths, row)
+	}
+
+	dict := trailer.shallowCopy()
+	dict.AddKey(pdf.KeyType, &pdf.NameObject{Name: pdf.NameXRef})
+	dict.AddKey(pdf.KeySize, pdf.NewInt(int64(size)))
+
+	if w.update {
+		dict.AddKey(pdf.KeyIndex, index)
+	}
+
+	dict.AddKey(pdf.KeyW, &Array{objects: []Object{
+		pdf.NewInt(int64(widths[0])), pdf.NewInt(int64(widths[1])), pdf.NewInt(int64(widths[2])),
+	}})
+
+	if w.options&SaveOptionNoFlateCompress == 0 {
+		encoded, err := flateEncode(data)
+		if err != nil {
+			return fmt.Errorf("xref stream: %w", err)
+		}
+
+		data = encoded
+		dict.AddKey(pdf.KeyFilter, &pdf.NameObject{Name: pdf.NameFlateDecode})
+	}
+
+	dict.AddKey(pdf.KeyLength, pdf.NewInt(int64(len(data))))
+
+	if err := w.writeIndirect(ref, dict, data, true); err != nil {
+		return fmt.Errorf("xref stream: %w", err)
+	}
+
+	return nil
+}
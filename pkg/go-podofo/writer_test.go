@@ -0,0 +1,249 @@
+package podofo_test
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// saveDocument returns the file of the document.
+func saveDocument(t *testing.T, doc *podofo.MemDocument, options ...podofo.SaveOption) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, doc.Save(&buf, options...))
+
+	return buf.Bytes()
+}
+
+// loadDocument loads the document of the catalog 1, the empty page
+// tree 2 and the objects referred to by the /Extra of the catalog.
+func loadDocument(t *testing.T, objects ...string) *podofo.MemDocument {
+	t.Helper()
+
+	var extra string
+	for i := range objects {
+		extra += fmt.Sprintf(" %d 0 R", i+3)
+	}
+
+	file := buildPDF("1.4", "/Root 1 0 R", append([]string{
+		"<</Type /Catalog /Pages 2 0 R /Extra [" + extra + "]>>",
+		"<</Type /Pages /Kids [] /Count 0>>",
+	}, objects...)...)
+
+	doc, err := podofo.LoadMemDocument(bytes.NewReader(file))
+	require.NoError(t, err)
+
+	return doc
+}
+
+// marshalObject returns the compact syntax of the parsed object.
+func marshalObject(t *testing.T, obj podofo.Object) string {
+	t.Helper()
+
+	parsed, ok := obj.(*podofo.ParserObject)
+	require.True(t, ok)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, parsed.Object().MarshalPDF(pdf.NewWriter(&buf)))
+
+	return buf.String()
+}
+
+func TestLoadMemDocument(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		version    podofo.PDFVersion
+		numPages   int
+		xrefStream bool
+	}{
+		{name: "xref table", version: "1.4", numPages: 3},
+		{name: "xref stream", version: "1.7", numPages: 3, xrefStream: true},
+		{name: "no pages", version: "1.4"},
+		{name: "many pages", version: "1.7", numPages: 100, xrefStream: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := newDocument(t, tt.numPages)
+			require.NoError(t, doc.SetPDFVersion(tt.version))
+
+			file := saveDocument(t, doc)
+
+			parser, err := podofo.Parse(bytes.NewReader(file))
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, parser.PDFVersion())
+			assert.Equal(t, tt.xrefStream, parser.HasXRefStream())
+
+			loaded, err := podofo.LoadMemDocument(bytes.NewReader(file))
+			require.NoError(t, err)
+			assert.Equal(t, tt.numPages, loaded.PageCollection().Count())
+
+			resaved := saveDocument(t, loaded)
+
+			reloaded, err := podofo.LoadMemDocument(bytes.NewReader(resaved))
+			require.NoError(t, err)
+			assert.Equal(t, tt.numPages, reloaded.PageCollection().Count())
+		})
+	}
+}
+
+func TestSaveLoaded(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		version    podofo.PDFVersion
+		options    []podofo.SaveOption
+		xrefStream bool
+	}{
+		{name: "xref table", version: "1.4"},
+		{name: "xref stream", version: "1.5", xrefStream: true},
+		{name: "no flate", version: "1.7", options: []podofo.SaveOption{podofo.SaveOptionNoFlateCompress}, xrefStream: true},
+		{name: "clean", version: "1.4", options: []podofo.SaveOption{podofo.SaveOptionClean}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := loadDocument(t, "<</Name (text) /Values [1 2.5 /N null true]>>", "(string)")
+			require.NoError(t, doc.SetPDFVersion(tt.version))
+
+			file := saveDocument(t, doc, tt.options...)
+
+			parser, err := podofo.Parse(bytes.NewReader(file))
+			require.NoError(t, err)
+			assert.Equal(t, tt.version, parser.PDFVersion())
+			assert.Equal(t, tt.xrefStream, parser.HasXRefStream())
+
+			loaded, err := podofo.LoadMemDocument(bytes.NewReader(file))
+			require.NoError(t, err)
+
+			list := loaded.Objects()
+			assert.Equal(t, "<</Name(text)/Values[1 2.5/N null true]>>",
+				marshalObject(t, list.GetObject(pdf.NewReference(3, 0))))
+			assert.Equal(t, "(string)", marshalObject(t, list.GetObject(pdf.NewReference(4, 0))))
+		})
+	}
+}
+
+// encryptRefPattern matches the indirect /Encrypt entry of a trailer.
+var encryptRefPattern = regexp.MustCompile(`/Encrypt (\d+) 0 R`)
+
+// directEncrypt returns the file with the encryption dictionary
+// written directly to its trailer. The file must have an XRef table.
+func directEncrypt(t *testing.T, file []byte) []byte {
+	t.Helper()
+
+	trailer := bytes.LastIndex(file, []byte("trailer"))
+	require.Positive(t, trailer)
+
+	match := encryptRefPattern.FindSubmatchIndex(file[trailer:])
+	require.NotNil(t, match)
+
+	header := fmt.Sprintf("\n%s 0 obj\n", file[trailer+match[2]:trailer+match[3]])
+	start := bytes.Index(file, []byte(header))
+	require.Positive(t, start)
+
+	start += len(header)
+	end := start + bytes.Index(file[start:], []byte("\nendobj"))
+
+	result := append([]byte{}, file[:trailer+match[0]]...)
+	result = append(result, "/Encrypt "...)
+	result = append(result, file[start:end]...)
+
+	return append(result, file[trailer+match[1]:]...)
+}
+
+func TestSaveUpdate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		version podofo.PDFVersion
+		encrypt bool
+		direct  bool
+	}{
+		{name: "xref table", version: "1.4"},
+		{name: "xref stream", version: "1.7"},
+		{name: "encrypted", version: "1.7", encrypt: true},
+		{name: "direct encrypt", version: "1.4", encrypt: true, direct: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc := newDocument(t, 2)
+			require.NoError(t, doc.SetPDFVersion(tt.version))
+
+			var options []podofo.ParserOption
+
+			if tt.encrypt {
+				enc, err := podofo.NewEncrypt("user", "owner", podofo.PermissionPrint, podofo.EncryptAlgorithmRC4V2)
+				require.NoError(t, err)
+
+				doc.SetEncrypt(enc)
+
+				options = append(options, podofo.Password("user"))
+			}
+
+			file := saveDocument(t, doc)
+			if tt.direct {
+				file = directEncrypt(t, file)
+			}
+
+			original, err := podofo.Parse(bytes.NewReader(file), options...)
+			require.NoError(t, err)
+
+			loaded, err := podofo.LoadMemDocument(bytes.NewReader(file), options...)
+			require.NoError(t, err)
+			require.NotNil(t, loaded.AddPage(podofo.PageSizeA4()))
+
+			var buf bytes.Buffer
+
+			require.NoError(t, loaded.SaveUpdate(&buf))
+			require.Greater(t, buf.Len(), len(file))
+			assert.Equal(t, file, buf.Bytes()[:len(file)])
+
+			parser, err := podofo.Parse(bytes.NewReader(buf.Bytes()), options...)
+			require.NoError(t, err)
+			assert.Equal(t, 1, parser.NumIncrementalUpdates())
+
+			revisions := parser.Revisions()
+			require.Len(t, revisions, 2)
+
+			prev, ok := revisions[1].Trailer.Key(pdf.KeyPrev).(*pdf.Number)
+			require.True(t, ok)
+			assert.EqualValues(t, original.XRefOffset(), prev.Int())
+
+			if tt.encrypt {
+				assert.Contains(t, string(buf.Bytes()[len(file):]), "/Encrypt")
+			}
+
+			updated, err := podofo.LoadMemDocument(bytes.NewReader(buf.Bytes()), options...)
+			require.NoError(t, err)
+			assert.Equal(t, 3, updated.PageCollection().Count())
+		})
+	}
+}
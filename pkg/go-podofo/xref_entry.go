@@ -1,5 +1,7 @@
 package podofo
 
+import "github.com/denisss025/go-podofo/internal/pdf"
+
 type XRefEntry struct {
 	Entry  xrefEntry
 	Parsed bool
@@ -27,3 +29,21 @@ type XRefEntryCompressed struct {
 }
 
 func (c XRefEntryCompressed) xrefEntry() {}
+
+// enlargeXRefEntries grows the XRef entries table up to size entries.
+// The table never shrinks: a section of an older revision may be
+// shorter than the current one.
+func enlargeXRefEntries(entries []XRefEntry, size int) []XRefEntry {
+	if size <= len(entries) {
+		return entries
+	}
+
+	oldSize := len(entries)
+	entries = pdf.ResizeSlice(entries, size)
+
+	for i := oldSize; i < size; i++ {
+		entries[i] = XRefEntry{}
+	}
+
+	return entries
+}
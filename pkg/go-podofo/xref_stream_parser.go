@@ -1,13 +1,244 @@
 package podofo
 
+import (
+	"fmt"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+)
+
+const (
+	// xrefStreamFieldCount is the number of fields
+	// in a XRef stream entry.
+	xrefStreamFieldCount = 3
+	// xrefStreamMaxFieldWidth is the maximum width of
+	// a XRef stream entry field in bytes.
+	xrefStreamMaxFieldWidth = 8
+)
+
+// XRefStreamParser reads a cross-reference stream
+// (PDF 1.5 and later) into the XRef entries table.
 type XRefStreamParser struct {
-	Object
+	*ParserObject
+
+	entries *[]XRefEntry
+
+	// hybrid is true for the /XRefStm stream of a hybrid-reference
+	// file. The stream then supplements the XRef table of the same
+	// revision, which marks the compressed objects as free.
+	hybrid bool
+
+	// listed records the entries listed by the stream,
+	// including the ones overridden by the newer sections.
+	listed map[int]XRefEntry
 }
 
+// NewXRefStreamParser creates a parser for the XRef stream object
+// at the current position of r.
 func NewXRefStreamParser(doc *Document, r Reader, entries *[]XRefEntry) (*XRefStreamParser, error) {
-	panic("not implemented") // TODO: implement me
+	obj, err := NewParserObject(r, -1, WithDocument(doc))
+	if err != nil {
+		return nil, fmt.Errorf("new xref stream parser: %w", err)
+	}
+
+	return &XRefStreamParser{
+		ParserObject: obj,
+		entries:      entries,
+	}, nil
+}
+
+// Parse reads the XRef stream dictionary.
+func (parser *XRefStreamParser) Parse() error {
+	if err := parser.ParserObject.Parse(); err != nil {
+		return fmt.Errorf("parse xref stream: %w", err)
+	}
+
+	if parser.Dictionary == nil || !parser.HasStream() {
+		return fmt.Errorf("parse xref stream: %w: not a stream", ErrNoXRef)
+	}
+
+	name, ok := parser.Dictionary.Key(pdf.KeyType).(*pdf.NameObject)
+	if !ok || name.Name != pdf.NameXRef {
+		return fmt.Errorf("parse xref stream: %w: /Type is not /XRef", ErrNoXRef)
+	}
+
+	return nil
+}
+
+// ParseStream decodes the stream data and fills the XRef entries
+// table. Entries that have already been parsed are left untouched,
+// i.e. streams must be read from the newest to the oldest.
+func (parser *XRefStreamParser) ParseStream() (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("parse xref stream: %w", err)
+		}
+	}()
+
+	size := parser.Dictionary.Int(pdf.KeySize, -1)
+	if size < 0 {
+		return fmt.Errorf("%w: missing /Size", ErrInvalidXRefStream)
+	}
+
+	widths, err := parser.fieldWidths()
+	if err != nil {
+		return err
+	}
+
+	indices, err := parser.indices(size)
+	if err != nil {
+		return err
+	}
+
+	// All the XRef stream dictionary entries must be direct
+	// objects, so the /Length can be read before the objects are.
+	data, err := parser.decodeStream()
+	if err != nil {
+		return err
+	}
+
+	entrySize := widths[0] + widths[1] + widths[2]
+
+	for i := 0; i < len(indices); i += 2 {
+		firstObject, count := indices[i], indices[i+1]
+
+		// The sum is checked this way, so it cannot overflow.
+		if firstObject > maxObjectCount || count > maxObjectCount-firstObject {
+			return fmt.Errorf("%w: object count exceeds %d", ErrValueOutOfRange, maxObjectCount)
+		}
+
+		if count > int64(len(data)/entrySize) {
+			return fmt.Errorf("%w: invalid count in XRef stream", ErrNoXRef)
+		}
+
+		*parser.entries = enlargeXRefEntries(*parser.entries, int(firstObject+count))
+
+		for objNo := firstObject; objNo < firstObject+count; objNo++ {
+			var listed XRefEntry
+
+			readXRefStreamEntry(&listed, data[:entrySize], widths)
+			recordRevisionEntry(parser.listed, int(objNo), listed)
+
+			entry := &(*parser.entries)[objNo]
+			if listed.Parsed && (!entry.Parsed || parser.isOverriddenByHybrid(entry)) {
+				*entry = listed
+			}
+
+			data = data[entrySize:]
+		}
+	}
+
+	return nil
 }
 
-func (parser *XRefStreamParser) ParseStream() error {
-	panic("not implemented") // TODO: implement me
+func (parser *XRefStreamParser) isOverriddenByHybrid(entry *XRefEntry) bool {
+	_, isFree := entry.Entry.(XRefEntryFree)
+
+	return parser.hybrid && isFree
+}
+
+// fieldWidths returns the /W array.
+func (parser *XRefStreamParser) fieldWidths() (widths [xrefStreamFieldCount]int, err error) {
+	array, ok := parser.Dictionary.Key(pdf.KeyW).(*Array)
+	if !ok || array.NumObjects() != xrefStreamFieldCount {
+		return widths, fmt.Errorf("%w: /W must be an array of %d integers",
+			ErrInvalidXRefStream, xrefStreamFieldCount)
+	}
+
+	for i := range widths {
+		num, ok := array.At(i).(*pdf.Number)
+		if !ok {
+			return widths, fmt.Errorf("%w: /W must contain integers only", ErrInvalidXRefStream)
+		}
+
+		widths[i] = num.Int()
+		if widths[i] < 0 || widths[i] > xrefStreamMaxFieldWidth {
+			return widths, fmt.Errorf("%w: /W field width %d", ErrInvalidXRefStream, widths[i])
+		}
+	}
+
+	if widths[1] == 0 {
+		return widths, fmt.Errorf("%w: /W second field width is zero", ErrInvalidXRefStream)
+	}
+
+	return widths, nil
+}
+
+// indices returns the /Index array, i.e. the pairs of the first
+// object number and the number of entries of each subsection.
+func (parser *XRefStreamParser) indices(size int64) ([]int64, error) {
+	index := parser.Dictionary.Key(pdf.KeyIndex)
+	if index == nil {
+		return []int64{0, size}, nil
+	}
+
+	array, ok := index.(*Array)
+	if !ok || array.NumObjects()%2 != 0 {
+		return nil, fmt.Errorf("%w: /Index must be an array of integer pairs", ErrInvalidXRefStream)
+	}
+
+	indices := make([]int64, array.NumObjects())
+
+	for i := range indices {
+		num, ok := array.At(i).(*pdf.Number)
+		if !ok {
+			return nil, fmt.Errorf("%w: /Index must contain integers only", ErrInvalidXRefStream)
+		}
+
+		indices[i] = num.Int64()
+		if indices[i] < 0 {
+			return nil, fmt.Errorf("%w: negative /Index value", ErrValueOutOfRange)
+		}
+	}
+
+	return indices, nil
+}
+
+// readXRefStreamEntry decodes a single XRef stream entry
+// (see ISO 32000-1:2008, Table 18).
+func readXRefStreamEntry(entry *XRefEntry, data []byte, widths [xrefStreamFieldCount]int) {
+	const (
+		typeFree       = 0
+		typeInUse      = 1
+		typeCompressed = 2
+	)
+
+	var fields [xrefStreamFieldCount]uint64
+
+	for i, width := range widths {
+		for _, b := range data[:width] {
+			fields[i] = fields[i]<<8 | uint64(b)
+		}
+
+		data = data[width:]
+	}
+
+	// The type field defaults to 1 if its width is zero.
+	entryType := uint64(typeInUse)
+	if widths[0] != 0 {
+		entryType = fields[0]
+	}
+
+	switch entryType {
+	case typeFree:
+		entry.Entry = XRefEntryFree{
+			ObjectNumber: int(fields[1]),
+			Generation:   Generation(fields[2]),
+		}
+	case typeInUse:
+		entry.Entry = XRefEntryInUse{
+			Offset:     int64(fields[1]),
+			Generation: Generation(fields[2]),
+		}
+	case typeCompressed:
+		entry.Entry = XRefEntryCompressed{
+			ObjectNumber: int(fields[1]),
+			Index:        int64(fields[2]),
+		}
+	default:
+		// Any other type shall be interpreted as a reference
+		// to the null object, so the entry stays unparsed.
+		return
+	}
+
+	entry.Parsed = true
 }
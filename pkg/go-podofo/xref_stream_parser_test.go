@@ -0,0 +1,67 @@
+package podofo_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/denisss025/go-podofo/internal/pdf"
+	podofo "github.com/denisss025/go-podofo/pkg/go-podofo"
+)
+
+// xrefStream returns the XRef stream object of the /Index
+// with two in-use entries of the /W [1 1 0].
+func xrefStream(index string) []byte {
+	data := []byte{1, 10, 1, 20}
+
+	return []byte(fmt.Sprintf("1 0 obj\n<</Type /XRef /Size 2 /W [1 1 0] /Index [%s] /Length %d>>\n"+
+		"stream\n%s\nendstream\nendobj\n", index, len(data), data))
+}
+
+func TestXRefStreamParserIndex(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		index   string
+		want    int
+		wantErr error
+	}{
+		{name: "valid", index: "0 2", want: 2},
+		{name: "negative first object", index: "-1 2", wantErr: podofo.ErrValueOutOfRange},
+		{name: "negative count", index: "0 -2", wantErr: podofo.ErrValueOutOfRange},
+		{name: "first object overflow", index: "9223372036854775807 1", wantErr: podofo.ErrValueOutOfRange},
+		{name: "count overflow", index: "1 9223372036854775807", wantErr: podofo.ErrValueOutOfRange},
+		{name: "count exceeds data", index: "0 1000000", wantErr: podofo.ErrNoXRef},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var entries []podofo.XRefEntry
+
+			parser, err := podofo.NewXRefStreamParser(pdf.NewDocument(),
+				bytes.NewReader(xrefStream(tt.index)), &entries)
+			require.NoError(t, err)
+			require.NoError(t, parser.Parse())
+
+			err = parser.ParseStream()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				// The entries are not enlarged for the invalid counts.
+				assert.Empty(t, entries)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, entries, tt.want)
+		})
+	}
+}